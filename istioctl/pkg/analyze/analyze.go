@@ -15,6 +15,7 @@
 package analyze
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -44,6 +45,7 @@ import (
 	"istio.io/istio/pkg/kube/multicluster"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/url"
+	"istio.io/istio/tools/bug-report/pkg/archive"
 )
 
 // AnalyzerFoundIssuesError indicates that at least one analyzer found problems.
@@ -81,6 +83,7 @@ var (
 	ignoreUnknown     bool
 	revisionSpecified string
 	remoteContexts    []string
+	bugReportDir      string
 
 	fileExtensions = []string{".json", ".yaml", ".yml"}
 )
@@ -105,6 +108,9 @@ func Analyze(ctx cli.Context) *cobra.Command {
   # Analyze yaml files without connecting to a live cluster
   istioctl analyze --use-kube=false a.yaml b.yaml my-app-config/
 
+  # Analyze the resources collected in an extracted bug report archive, without connecting to a live cluster
+  istioctl analyze --bug-report ./bug-report -A
+
   # Analyze the current live cluster and suppress PodMissingProxy for pod mypod in namespace 'testing'.
   istioctl analyze -S "IST0103=Pod mypod.testing"
 
@@ -137,6 +143,10 @@ func Analyze(ctx cli.Context) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if bugReportDir != "" {
+				// The bug report replaces the live cluster.
+				useKube = false
+			}
 			cancel := make(chan struct{})
 
 			// We use the "namespace" arg that's provided as part of root istioctl as a flag for specifying what namespace to use
@@ -211,6 +221,18 @@ func Analyze(ctx cli.Context) *cobra.Command {
 				}
 			}
 
+			parseErrors := 0
+			if bugReportDir != "" {
+				files, err := bugReportFiles(bugReportDir)
+				if err != nil {
+					return err
+				}
+				if err = sa.AddSnapshotKubeSource(files); err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Error(s) adding bug report resources: %v", err)
+					parseErrors++
+				}
+			}
+
 			// If we explicitly specify mesh config, use it.
 			// This takes precedence over default mesh config or mesh config from a running Kube instance.
 			if meshCfgFile != "" {
@@ -218,7 +240,7 @@ func Analyze(ctx cli.Context) *cobra.Command {
 			}
 
 			// If we're not using kube (files only), add defaults for some resources we expect to be provided by Istio
-			if !useKube {
+			if !useKube && bugReportDir == "" {
 				err := sa.AddDefaultResources()
 				if err != nil {
 					return err
@@ -226,7 +248,6 @@ func Analyze(ctx cli.Context) *cobra.Command {
 			}
 
 			// If files are provided, treat them (collectively) as a source.
-			parseErrors := 0
 			if len(readers) > 0 {
 				if err = sa.AddReaderKubeSource(readers); err != nil {
 					fmt.Fprintf(cmd.ErrOrStderr(), "Error(s) adding files: %v", err)
@@ -345,6 +366,8 @@ func Analyze(ctx cli.Context) *cobra.Command {
 	analysisCmd.PersistentFlags().StringArrayVar(&remoteContexts, "remote-contexts", []string{},
 		`Kubernetes configuration contexts for remote clusters to be used in multi-cluster analysis. Not to be confused with '--context'. `+
 			"If unspecified, contexts are read from the remote secrets in the cluster.")
+	analysisCmd.PersistentFlags().StringVar(&bugReportDir, "bug-report", "",
+		"Analyze the resources collected in an extracted bug report archive instead of a live cluster.")
 	return analysisCmd
 }
 
@@ -389,6 +412,27 @@ func gatherFiles(cmd *cobra.Command, args []string) ([]local.ReaderSource, error
 	return readers, nil
 }
 
+// bugReportFiles returns the files holding the cluster resources of the extracted bug report archive in dir.
+func bugReportFiles(dir string) ([]local.ReaderSource, error) {
+	a, err := archive.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := a.ClusterResourceFiles()
+	if err != nil {
+		return nil, err
+	}
+	readers := make([]local.ReaderSource, 0, len(paths))
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, local.ReaderSource{Name: p, Reader: bytes.NewReader(b)})
+	}
+	return readers, nil
+}
+
 func gatherFile(f string) (local.ReaderSource, error) {
 	r, err := os.Open(f)
 	if err != nil {
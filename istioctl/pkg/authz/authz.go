@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -27,8 +28,12 @@ import (
 	"istio.io/istio/istioctl/pkg/completion"
 	"istio.io/istio/istioctl/pkg/util"
 	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/spiffe"
 )
 
 var configDumpFile string
@@ -96,6 +101,166 @@ The command also supports reading from a standalone config dump file with flag -
 	return cmd
 }
 
+func evaluateCmd(ctx cli.Context) *cobra.Command {
+	var (
+		files              []string
+		trustDomain        string
+		sourcePrincipal    string
+		sourceNamespace    string
+		sourceSA           string
+		sourceIP           string
+		destinationNS      string
+		destinationLabels  map[string]string
+		destinationIP      string
+		destinationPort    int
+		sni                string
+		tcp                bool
+		method, host, path string
+		headers, claims    []string
+	)
+	cmd := &cobra.Command{
+		Use:     "evaluate",
+		Aliases: []string{"can"},
+		Short:   "Evaluate whether a request would be allowed by AuthorizationPolicy.",
+		Long: `Evaluate checks whether a request from a source to a destination workload would be allowed by the
+AuthorizationPolicy read from the given files, without a running cluster. The policies are converted to the same
+Envoy RBAC configuration that Istiod generates for the destination workload, and evaluated in the same order as
+the Envoy filters: CUSTOM, AUDIT, DENY and ALLOW.
+
+The command prints the decision, the policy and rule that decided it, and a trace of the evaluation.`,
+		Example: `  # Check whether the sleep service account in namespace foo can GET /headers on httpbin in namespace bar:
+  istioctl x authz evaluate -f policies.yaml --source-namespace foo --source-service-account sleep \
+    --destination-namespace bar --destination-labels app=httpbin --method GET --path /headers
+
+  # Check whether a plaintext TCP connection from 10.0.0.1 to port 3306 is allowed:
+  istioctl x authz evaluate -f policies.yaml --source-ip 10.0.0.1 --destination-labels app=mysql --port 3306 --tcp
+
+  # Check a request with JWT claims:
+  istioctl x authz evaluate -f policies.yaml --destination-labels app=httpbin \
+    --claim iss=https://issuer.example.com --claim sub=alice --claim groups=admin --claim groups=dev`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(files) == 0 {
+				return fmt.Errorf("at least one policy file must be specified with -f")
+			}
+			var configs []config.Config
+			for _, f := range files {
+				b, err := os.ReadFile(f)
+				if err != nil {
+					return err
+				}
+				c, _, err := crd.ParseInputs(string(b))
+				if err != nil {
+					return fmt.Errorf("failed to parse %s: %v", f, err)
+				}
+				configs = append(configs, c...)
+			}
+			evaluator, err := NewEvaluator(configs, ctx.IstioNamespace(), trustDomain)
+			if err != nil {
+				return err
+			}
+
+			principal := sourcePrincipal
+			if principal == "" && sourceNamespace != "" {
+				principal = spiffe.Identity{TrustDomain: trustDomain, Namespace: sourceNamespace, ServiceAccount: sourceSA}.String()
+			}
+			req := &Request{
+				SourcePrincipal: strings.TrimPrefix(principal, spiffe.URIPrefix),
+				SourceIP:        sourceIP,
+				DestinationIP:   destinationIP,
+				DestinationPort: destinationPort,
+				SNI:             sni,
+				TCP:             tcp,
+				Method:          method,
+				Host:            host,
+				Path:            path,
+				Headers:         map[string]string{},
+			}
+			for _, h := range headers {
+				k, v, ok := strings.Cut(h, "=")
+				if !ok {
+					return fmt.Errorf("invalid header %q, expected name=value", h)
+				}
+				req.Headers[strings.ToLower(k)] = v
+			}
+			if req.Claims, err = parseClaims(claims); err != nil {
+				return err
+			}
+
+			workload := Workload{
+				Namespace: ctx.NamespaceOrDefault(destinationNS),
+				Labels:    destinationLabels,
+			}
+			evaluation, err := evaluator.Evaluate(workload, req)
+			if err != nil {
+				return err
+			}
+			PrintEvaluation(cmd.OutOrStdout(), evaluation)
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVarP(&files, "file", "f", nil, "The YAML files with the AuthorizationPolicy to evaluate")
+	cmd.Flags().StringVar(&trustDomain, "trust-domain", constants.DefaultClusterLocalDomain, "The trust domain of the mesh")
+	cmd.Flags().StringVar(&sourcePrincipal, "source-principal", "",
+		"The peer identity of the request, for example cluster.local/ns/foo/sa/sleep. Leave empty for plaintext requests")
+	cmd.Flags().StringVar(&sourceNamespace, "source-namespace", "",
+		"The namespace of the source workload, used to build the peer identity if --source-principal is not set")
+	cmd.Flags().StringVar(&sourceSA, "source-service-account", "default",
+		"The service account of the source workload, used to build the peer identity if --source-principal is not set")
+	cmd.Flags().StringVar(&sourceIP, "source-ip", "", "The IP address of the source")
+	cmd.Flags().StringVar(&destinationNS, "destination-namespace", "", "The namespace of the destination workload")
+	cmd.Flags().StringToStringVar(&destinationLabels, "destination-labels", nil, "The labels of the destination workload")
+	cmd.Flags().StringVar(&destinationIP, "destination-ip", "", "The IP address of the destination")
+	cmd.Flags().IntVar(&destinationPort, "port", 0, "The destination port of the request")
+	cmd.Flags().StringVar(&sni, "sni", "", "The SNI of the request")
+	cmd.Flags().BoolVar(&tcp, "tcp", false, "Evaluate the request as a TCP connection, ignoring all HTTP attributes")
+	cmd.Flags().StringVar(&method, "method", "GET", "The HTTP method of the request")
+	cmd.Flags().StringVar(&host, "host", "", "The host of the request")
+	cmd.Flags().StringVar(&path, "path", "/", "The path of the request")
+	cmd.Flags().StringSliceVar(&headers, "header", nil, "The headers of the request, in the form name=value")
+	cmd.Flags().StringSliceVar(&claims, "claim", nil,
+		"The JWT claims of the request, in the form name=value. Repeat a claim to build a list, "+
+			"and use [a][b]=value for nested claims")
+	return cmd
+}
+
+// parseClaims parses claims in the form name=value into a JWT payload. A repeated claim is
+// converted to a list, and a name in the form [a][b] is a nested claim.
+func parseClaims(claims []string) (map[string]any, error) {
+	payload := map[string]any{}
+	for _, c := range claims {
+		k, v, ok := strings.Cut(c, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid claim %q, expected name=value", c)
+		}
+		path := []string{k}
+		if strings.HasPrefix(k, "[") && strings.HasSuffix(k, "]") {
+			path = strings.Split(strings.TrimSuffix(strings.TrimPrefix(k, "["), "]"), "][")
+		}
+		current := payload
+		for _, p := range path[:len(path)-1] {
+			next, ok := current[p].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				current[p] = next
+			}
+			current = next
+		}
+		name := path[len(path)-1]
+		switch existing := current[name].(type) {
+		case nil:
+			current[name] = v
+		case string:
+			current[name] = []any{existing, v}
+		case []any:
+			current[name] = append(existing, v)
+		default:
+			return nil, fmt.Errorf("invalid claim %q, %s is already a nested claim", c, name)
+		}
+	}
+	return payload, nil
+}
+
 func getConfigDumpFromFile(filename string) (*configdump.Wrapper, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -149,6 +314,7 @@ func AuthZ(ctx cli.Context) *cobra.Command {
 	}
 
 	cmd.AddCommand(checkCmd(ctx))
+	cmd.AddCommand(evaluateCmd(ctx))
 	cmd.Long += "\n\n" + util.ExperimentalMsg
 	return cmd
 }
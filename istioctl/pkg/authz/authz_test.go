@@ -47,3 +47,33 @@ CUSTOM   ext-authz.default           1
 		})
 	}
 }
+
+func TestEvaluateCmd(t *testing.T) {
+	cases := []testutil.TestCase{
+		{
+			Args:           []string{},
+			ExpectedOutput: "Error: at least one policy file must be specified with -f\n",
+			WantException:  true,
+		},
+		{
+			Args: strings.Split("-f testdata/policies.yaml --destination-namespace foo --destination-labels app=httpbin "+
+				"--source-namespace bar --source-service-account sleep --path /admin", " "),
+			ExpectedOutput: `DECISION: DENY
+POLICY:   deny-admin.foo
+RULE:     0
+REASON:   denied by a DENY policy
+
+TRACE:
+  - found 1 CUSTOM, 1 AUDIT, 2 DENY and 1 ALLOW policies for workload app=httpbin in namespace foo
+  - no CUSTOM policy matched
+  - matched DENY policy deny-admin.foo rule 0, the request is denied
+`,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.Args, " ")), func(t *testing.T) {
+			testutil.VerifyOutput(t, evaluateCmd(cli.NewFakeContext(&cli.NewFakeContextOption{IstioNamespace: "istio-system"})), c)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	rbachttp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	rbactcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/security/authz/builder"
+	"istio.io/istio/pilot/pkg/security/trustdomain"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/wellknown"
+)
+
+// Workload is the destination workload of an evaluated request.
+type Workload struct {
+	Namespace string
+	Labels    labels.Instance
+}
+
+// PolicyMatch is a policy rule that matched the evaluated request.
+type PolicyMatch struct {
+	Action policyAction
+	// Policy is the name of the AuthorizationPolicy, in the format of name.namespace.
+	Policy string
+	Rule   string
+	// Provider is the extension provider of a CUSTOM policy.
+	Provider string
+}
+
+func (m PolicyMatch) String() string {
+	s := fmt.Sprintf("%s policy %s rule %s", m.Action, m.Policy, m.Rule)
+	if m.Provider != "" {
+		s += fmt.Sprintf(" (provider %s)", m.Provider)
+	}
+	return s
+}
+
+// Evaluation is the result of evaluating a request against the AuthorizationPolicies applied to a workload.
+type Evaluation struct {
+	Allowed bool
+	// Decision is the policy rule that decided the request. It is nil if the request was allowed
+	// or denied without any policy matching it.
+	Decision *PolicyMatch
+	Reason   string
+	// Custom is the CUSTOM policy rules that matched. These requests are additionally checked by the
+	// extension provider, which may deny them before the ALLOW and DENY policies are evaluated.
+	Custom []PolicyMatch
+	// Audit is the AUDIT policy rules that matched.
+	Audit []PolicyMatch
+	// DryRun is the dry-run policy rules that matched. These are not enforced.
+	DryRun []PolicyMatch
+	// Trace records each step of the evaluation.
+	Trace []string
+}
+
+func (e *Evaluation) tracef(format string, args ...any) {
+	e.Trace = append(e.Trace, fmt.Sprintf(format, args...))
+}
+
+// Evaluator evaluates requests against a set of AuthorizationPolicies, using the same Envoy RBAC
+// configuration that Istiod would generate for the destination workload.
+type Evaluator struct {
+	policies    *model.AuthorizationPolicies
+	trustDomain trustdomain.Bundle
+}
+
+// NewEvaluator returns an evaluator for the AuthorizationPolicies in configs. Other configs are ignored.
+func NewEvaluator(configs []config.Config, rootNamespace, trustDomain string) (*Evaluator, error) {
+	store := memory.Make(collections.Pilot)
+	for _, cfg := range configs {
+		if cfg.GroupVersionKind != gvk.AuthorizationPolicy {
+			continue
+		}
+		if cfg.Namespace == "" {
+			cfg.Namespace = "default"
+		}
+		if _, err := store.Create(cfg); err != nil {
+			return nil, fmt.Errorf("invalid AuthorizationPolicy %s/%s: %v", cfg.Namespace, cfg.Name, err)
+		}
+	}
+	meshConfig := mesh.DefaultMeshConfig()
+	meshConfig.RootNamespace = rootNamespace
+	meshConfig.TrustDomain = trustDomain
+	env := &model.Environment{
+		ConfigStore: store,
+		Watcher:     mesh.NewFixedWatcher(meshConfig),
+	}
+	return &Evaluator{
+		policies:    model.GetAuthorizationPolicies(env),
+		trustDomain: trustdomain.NewBundle(trustDomain, nil),
+	}, nil
+}
+
+// Evaluate evaluates the request sent to the workload. The Envoy filters are evaluated in the same
+// order as on the workload: CUSTOM first, followed by AUDIT, DENY and ALLOW.
+func (e *Evaluator) Evaluate(workload Workload, req *Request) (*Evaluation, error) {
+	result := &Evaluation{}
+	policies := e.policies.ListAuthorizationPolicies(model.PolicyMatcherFor(workload.Namespace, workload.Labels, false))
+	result.tracef("found %d CUSTOM, %d AUDIT, %d DENY and %d ALLOW policies for workload %s in namespace %s",
+		len(policies.Custom), len(policies.Audit), len(policies.Deny), len(policies.Allow), workload.Labels, workload.Namespace)
+
+	if len(policies.Custom) > 0 {
+		// CUSTOM policies are built with the DENY action so that a matching rule triggers the check
+		// by the extension provider, the same way the RBAC shadow rules do for the ext_authz filter.
+		providers := map[string]string{}
+		for _, p := range policies.Custom {
+			providers[p.Name+"."+p.Namespace] = p.Spec.GetProvider().GetName()
+		}
+		custom := builder.New(e.trustDomain, nil, model.AuthorizationPoliciesResult{Deny: policies.Custom}, builder.Option{})
+		rules, err := e.build(custom, req.TCP)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			m, err := evaluateRBAC(r.GetRules(), policyActionCustom, req)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				m.Provider = providers[m.Policy]
+				result.Custom = append(result.Custom, *m)
+				result.tracef("matched %v, the request is checked by the extension provider", m)
+			}
+		}
+		if len(result.Custom) == 0 {
+			result.tracef("no CUSTOM policy matched")
+		}
+	}
+
+	local := builder.New(e.trustDomain, nil, policies, builder.Option{})
+	rules, err := e.build(local, req.TCP)
+	if err != nil {
+		return nil, err
+	}
+	hasAllow := false
+	for _, r := range rules {
+		if r.GetShadowRules() != nil {
+			action := actionForRBAC(r.GetShadowRules().GetAction())
+			m, err := evaluateRBAC(r.GetShadowRules(), action, req)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				result.DryRun = append(result.DryRun, *m)
+				result.tracef("matched dry-run %v, the policy is not enforced", m)
+			}
+		}
+		if r.GetRules() == nil {
+			continue
+		}
+		action := actionForRBAC(r.GetRules().GetAction())
+		m, err := evaluateRBAC(r.GetRules(), action, req)
+		if err != nil {
+			return nil, err
+		}
+		switch action {
+		case policyActionAudit:
+			if m != nil {
+				result.Audit = append(result.Audit, *m)
+				result.tracef("matched %v, the request is audited", m)
+			}
+		case policyActionDeny:
+			if m != nil {
+				result.Decision = m
+				result.Reason = "denied by a DENY policy"
+				result.tracef("matched %v, the request is denied", m)
+				return result, nil
+			}
+			result.tracef("no DENY policy matched")
+		case policyActionAllow:
+			hasAllow = true
+			if m != nil {
+				result.Allowed = true
+				result.Decision = m
+				result.Reason = "allowed by an ALLOW policy"
+				result.tracef("matched %v, the request is allowed", m)
+				return result, nil
+			}
+			result.tracef("no ALLOW policy matched")
+		}
+	}
+
+	if hasAllow {
+		result.Reason = "denied because ALLOW policies apply to the workload but none matched"
+		return result, nil
+	}
+	result.Allowed = true
+	result.Reason = "allowed because no ALLOW policy applies to the workload"
+	return result, nil
+}
+
+// build returns the RBAC configurations of the filters generated by the builder.
+func (e *Evaluator) build(b *builder.Builder, tcp bool) ([]*rbachttp.RBAC, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var out []*rbachttp.RBAC
+	if tcp {
+		for _, f := range b.BuildTCP() {
+			if f.GetName() != wellknown.RoleBasedAccessControl {
+				continue
+			}
+			r := &rbactcp.RBAC{}
+			if err := f.GetTypedConfig().UnmarshalTo(r); err != nil {
+				return nil, err
+			}
+			out = append(out, &rbachttp.RBAC{Rules: r.GetRules(), ShadowRules: r.GetShadowRules()})
+		}
+		return out, nil
+	}
+	for _, f := range b.BuildHTTP() {
+		if f.GetName() != wellknown.HTTPRoleBasedAccessControl {
+			continue
+		}
+		r := &rbachttp.RBAC{}
+		if err := f.GetTypedConfig().UnmarshalTo(r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// evaluateRBAC returns the first policy that matches the request. Like Envoy, policies are
+// evaluated in the order of their names.
+func evaluateRBAC(rbac *rbacpb.RBAC, action policyAction, req *Request) (*PolicyMatch, error) {
+	for _, name := range slices.Sort(maps.Keys(rbac.GetPolicies())) {
+		policy, rule := extractName(name)
+		if policy == "" {
+			continue
+		}
+		ok, err := matchPolicy(rbac.GetPolicies()[name], req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate %s: %v", name, err)
+		}
+		if ok {
+			return &PolicyMatch{Action: action, Policy: policy, Rule: rule}, nil
+		}
+	}
+	return nil, nil
+}
+
+func actionForRBAC(action rbacpb.RBAC_Action) policyAction {
+	switch action {
+	case rbacpb.RBAC_DENY:
+		return policyActionDeny
+	case rbacpb.RBAC_LOG:
+		return policyActionAudit
+	default:
+		return policyActionAllow
+	}
+}
+
+// PrintEvaluation prints the evaluation in a human-readable format.
+func PrintEvaluation(writer io.Writer, e *Evaluation) {
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 1, ' ', 0)
+	decision := "DENY"
+	if e.Allowed {
+		decision = "ALLOW"
+	}
+	_, _ = fmt.Fprintf(w, "DECISION:\t%s\n", decision)
+	if e.Decision != nil {
+		_, _ = fmt.Fprintf(w, "POLICY:\t%s\n", e.Decision.Policy)
+		_, _ = fmt.Fprintf(w, "RULE:\t%s\n", e.Decision.Rule)
+	}
+	_, _ = fmt.Fprintf(w, "REASON:\t%s\n", e.Reason)
+	for _, m := range e.Custom {
+		_, _ = fmt.Fprintf(w, "CUSTOM:\t%v\n", m)
+	}
+	for _, m := range e.Audit {
+		_, _ = fmt.Fprintf(w, "AUDIT:\t%v\n", m)
+	}
+	for _, m := range e.DryRun {
+		_, _ = fmt.Fprintf(w, "DRY-RUN:\t%v\n", m)
+	}
+	_ = w.Flush()
+	if len(e.Trace) > 0 {
+		_, _ = fmt.Fprintf(writer, "\nTRACE:\n  - %s\n", strings.Join(e.Trace, "\n  - "))
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"testing"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	configs, _, err := crd.ParseInputs(string(util.ReadFile(t, "testdata/policies.yaml")))
+	assert.NoError(t, err)
+	evaluator, err := NewEvaluator(configs, "istio-system", "cluster.local")
+	assert.NoError(t, err)
+
+	httpbin := Workload{Namespace: "foo", Labels: map[string]string{"app": "httpbin"}}
+	cases := []struct {
+		name     string
+		workload Workload
+		req      *Request
+		allowed  bool
+		decision *PolicyMatch
+		custom   []PolicyMatch
+		audit    []PolicyMatch
+		dryRun   []PolicyMatch
+	}{
+		{
+			name:     "allowed by principal",
+			workload: httpbin,
+			req:      &Request{SourcePrincipal: "cluster.local/ns/bar/sa/sleep", Method: "GET", Path: "/headers"},
+			allowed:  true,
+			decision: &PolicyMatch{Action: policyActionAllow, Policy: "allow-sleep.foo", Rule: "0"},
+		},
+		{
+			name:     "denied by path",
+			workload: httpbin,
+			req:      &Request{SourcePrincipal: "cluster.local/ns/bar/sa/sleep", Method: "GET", Path: "/admin/users"},
+			allowed:  false,
+			decision: &PolicyMatch{Action: policyActionDeny, Policy: "deny-admin.foo", Rule: "0"},
+		},
+		{
+			name:     "no allow matched",
+			workload: httpbin,
+			req:      &Request{SourcePrincipal: "cluster.local/ns/bar/sa/other", Method: "GET", Path: "/headers"},
+			allowed:  false,
+		},
+		{
+			name:     "plaintext",
+			workload: httpbin,
+			req:      &Request{Method: "GET", Path: "/headers"},
+			allowed:  false,
+		},
+		{
+			name:     "allowed by JWT claims",
+			workload: httpbin,
+			req: &Request{Method: "POST", Path: "/headers", Claims: map[string]any{
+				"iss": "https://issuer.example.com", "sub": "alice", "groups": []any{"dev", "admin"},
+			}},
+			allowed:  true,
+			decision: &PolicyMatch{Action: policyActionAllow, Policy: "allow-sleep.foo", Rule: "1"},
+			audit:    []PolicyMatch{{Action: policyActionAudit, Policy: "audit-all.istio-system", Rule: "0"}},
+		},
+		{
+			name:     "wrong JWT claim",
+			workload: httpbin,
+			req: &Request{Method: "GET", Path: "/headers", Claims: map[string]any{
+				"iss": "https://issuer.example.com", "sub": "alice", "groups": "dev",
+			}},
+			allowed: false,
+		},
+		{
+			name:     "custom and dry-run",
+			workload: httpbin,
+			req:      &Request{SourcePrincipal: "cluster.local/ns/bar/sa/sleep", SourceIP: "10.1.2.3", Method: "GET", Path: "/ext/foo"},
+			allowed:  true,
+			decision: &PolicyMatch{Action: policyActionAllow, Policy: "allow-sleep.foo", Rule: "0"},
+			custom:   []PolicyMatch{{Action: policyActionCustom, Policy: "ext-authz.foo", Rule: "0", Provider: "my-ext-authz"}},
+			dryRun:   []PolicyMatch{{Action: policyActionDeny, Policy: "deny-ip.foo", Rule: "0"}},
+		},
+		{
+			name:     "tcp ignores http only attributes",
+			workload: httpbin,
+			req:      &Request{SourcePrincipal: "cluster.local/ns/bar/sa/sleep", TCP: true},
+			allowed:  false,
+			// HTTP only attributes of rules other than ALLOW are dropped on TCP, so the rules match all connections.
+			decision: &PolicyMatch{Action: policyActionDeny, Policy: "deny-admin.foo", Rule: "0"},
+			custom:   []PolicyMatch{{Action: policyActionCustom, Policy: "ext-authz.foo", Rule: "0", Provider: "my-ext-authz"}},
+			audit:    []PolicyMatch{{Action: policyActionAudit, Policy: "audit-all.istio-system", Rule: "0"}},
+		},
+		{
+			name:     "no policy",
+			workload: Workload{Namespace: "bar", Labels: map[string]string{"app": "sleep"}},
+			req:      &Request{Method: "GET", Path: "/"},
+			allowed:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evaluator.Evaluate(tt.workload, tt.req)
+			assert.NoError(t, err)
+			if got.Allowed != tt.allowed {
+				t.Fatalf("expected allowed=%v, got %v: %v\n%v", tt.allowed, got.Allowed, got.Reason, got.Trace)
+			}
+			assert.Equal(t, got.Decision, tt.decision)
+			assert.Equal(t, got.Custom, tt.custom)
+			assert.Equal(t, got.Audit, tt.audit)
+			assert.Equal(t, got.DryRun, tt.dryRun)
+		})
+	}
+}
+
+func TestParseClaims(t *testing.T) {
+	got, err := parseClaims([]string{"sub=alice", "groups=a", "groups=b", "[nested][key]=v"})
+	assert.NoError(t, err)
+	assert.Equal(t, got, map[string]any{
+		"sub":    "alice",
+		"groups": []any{"a", "b"},
+		"nested": map[string]any{"key": "v"},
+	})
+}
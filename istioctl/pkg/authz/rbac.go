@@ -0,0 +1,359 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	routepb "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	uri_template "github.com/envoyproxy/go-control-plane/envoy/extensions/path/match/uri_template/v3"
+	matcherpb "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"istio.io/istio/pilot/pkg/xds/filters"
+	"istio.io/istio/pkg/spiffe"
+)
+
+// Request is a request evaluated against the AuthorizationPolicies applied to a workload.
+type Request struct {
+	// SourcePrincipal is the identity of the peer, for example "cluster.local/ns/default/sa/sleep".
+	// Empty if the request is not sent over mTLS.
+	SourcePrincipal string
+	SourceIP        string
+
+	DestinationIP   string
+	DestinationPort int
+	SNI             string
+
+	// TCP evaluates the request as a TCP connection, ignoring all HTTP attributes.
+	TCP     bool
+	Method  string
+	Host    string
+	Path    string
+	Headers map[string]string
+	// Claims is the payload of the JWT of the request, if any.
+	Claims map[string]any
+}
+
+func (r *Request) header(name string) (string, bool) {
+	switch name {
+	case ":method":
+		return r.Method, r.Method != ""
+	case ":authority", "host":
+		return r.Host, r.Host != ""
+	case ":path":
+		return r.Path, r.Path != ""
+	}
+	v, ok := r.Headers[strings.ToLower(name)]
+	return v, ok
+}
+
+// metadata returns the dynamic metadata of the request, as populated by the JWT filter.
+func (r *Request) metadata(filter string) (*structpb.Value, error) {
+	if filter != filters.EnvoyJwtFilterName || len(r.Claims) == 0 {
+		return nil, nil
+	}
+	return structpb.NewValue(map[string]any{filters.EnvoyJwtFilterPayload: r.Claims})
+}
+
+// matchPolicy mirrors the evaluation of an Envoy RBAC policy: it matches if any of the
+// permissions and any of the principals match.
+func matchPolicy(p *rbacpb.Policy, r *Request) (bool, error) {
+	permission, err := matchAnyPermission(p.GetPermissions(), r)
+	if err != nil || !permission {
+		return false, err
+	}
+	return matchAnyPrincipal(p.GetPrincipals(), r)
+}
+
+func matchAnyPermission(permissions []*rbacpb.Permission, r *Request) (bool, error) {
+	for _, p := range permissions {
+		if ok, err := matchPermission(p, r); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func matchAllPermissions(permissions []*rbacpb.Permission, r *Request) (bool, error) {
+	for _, p := range permissions {
+		if ok, err := matchPermission(p, r); err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchPermission(p *rbacpb.Permission, r *Request) (bool, error) {
+	switch rule := p.GetRule().(type) {
+	case *rbacpb.Permission_Any:
+		return rule.Any, nil
+	case *rbacpb.Permission_AndRules:
+		return matchAllPermissions(rule.AndRules.GetRules(), r)
+	case *rbacpb.Permission_OrRules:
+		return matchAnyPermission(rule.OrRules.GetRules(), r)
+	case *rbacpb.Permission_NotRule:
+		ok, err := matchPermission(rule.NotRule, r)
+		return !ok, err
+	case *rbacpb.Permission_Header:
+		return matchHeader(rule.Header, r)
+	case *rbacpb.Permission_UrlPath:
+		return matchString(rule.UrlPath.GetPath(), pathWithoutQuery(r.Path))
+	case *rbacpb.Permission_UriTemplate:
+		tmpl := &uri_template.UriTemplateMatchConfig{}
+		if err := rule.UriTemplate.GetTypedConfig().UnmarshalTo(tmpl); err != nil {
+			return false, err
+		}
+		return matchURITemplate(tmpl.GetPathTemplate(), pathWithoutQuery(r.Path)), nil
+	case *rbacpb.Permission_DestinationIp:
+		return matchCIDR(rule.DestinationIp, r.DestinationIP)
+	case *rbacpb.Permission_DestinationPort:
+		return int(rule.DestinationPort) == r.DestinationPort, nil
+	case *rbacpb.Permission_DestinationPortRange:
+		return int32(r.DestinationPort) >= rule.DestinationPortRange.GetStart() &&
+			int32(r.DestinationPort) < rule.DestinationPortRange.GetEnd(), nil
+	case *rbacpb.Permission_RequestedServerName:
+		return matchString(rule.RequestedServerName, r.SNI)
+	case *rbacpb.Permission_Metadata:
+		return matchMetadata(rule.Metadata, r)
+	default:
+		return false, fmt.Errorf("unsupported permission %T", rule)
+	}
+}
+
+func matchAnyPrincipal(principals []*rbacpb.Principal, r *Request) (bool, error) {
+	for _, p := range principals {
+		if ok, err := matchPrincipal(p, r); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func matchAllPrincipals(principals []*rbacpb.Principal, r *Request) (bool, error) {
+	for _, p := range principals {
+		if ok, err := matchPrincipal(p, r); err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchPrincipal(p *rbacpb.Principal, r *Request) (bool, error) {
+	switch id := p.GetIdentifier().(type) {
+	case *rbacpb.Principal_Any:
+		return id.Any, nil
+	case *rbacpb.Principal_AndIds:
+		return matchAllPrincipals(id.AndIds.GetIds(), r)
+	case *rbacpb.Principal_OrIds:
+		return matchAnyPrincipal(id.OrIds.GetIds(), r)
+	case *rbacpb.Principal_NotId:
+		ok, err := matchPrincipal(id.NotId, r)
+		return !ok, err
+	case *rbacpb.Principal_Authenticated_:
+		// Without a peer certificate, the authenticated principal never matches.
+		if r.SourcePrincipal == "" {
+			return false, nil
+		}
+		if id.Authenticated.GetPrincipalName() == nil {
+			return true, nil
+		}
+		return matchString(id.Authenticated.GetPrincipalName(), spiffe.URIPrefix+r.SourcePrincipal)
+	case *rbacpb.Principal_FilterState:
+		if id.FilterState.GetKey() != "io.istio.peer_principal" || r.SourcePrincipal == "" {
+			return false, nil
+		}
+		return matchString(id.FilterState.GetStringMatch(), spiffe.URIPrefix+r.SourcePrincipal)
+	case *rbacpb.Principal_DirectRemoteIp:
+		return matchCIDR(id.DirectRemoteIp, r.SourceIP)
+	case *rbacpb.Principal_RemoteIp:
+		return matchCIDR(id.RemoteIp, r.SourceIP)
+	case *rbacpb.Principal_SourceIp:
+		return matchCIDR(id.SourceIp, r.SourceIP)
+	case *rbacpb.Principal_Header:
+		return matchHeader(id.Header, r)
+	case *rbacpb.Principal_Metadata:
+		return matchMetadata(id.Metadata, r)
+	default:
+		return false, fmt.Errorf("unsupported principal %T", id)
+	}
+}
+
+func matchHeader(m *routepb.HeaderMatcher, r *Request) (bool, error) {
+	value, found := r.header(m.GetName())
+	if !found && m.GetTreatMissingHeaderAsEmpty() {
+		value, found = "", true
+	}
+	var match bool
+	var err error
+	switch spec := m.GetHeaderMatchSpecifier().(type) {
+	case *routepb.HeaderMatcher_PresentMatch:
+		// A missing header matches a present_match of false.
+		return found == spec.PresentMatch != m.GetInvertMatch(), nil
+	case *routepb.HeaderMatcher_StringMatch:
+		match, err = matchString(spec.StringMatch, value)
+	case *routepb.HeaderMatcher_ExactMatch:
+		match = value == spec.ExactMatch
+	case *routepb.HeaderMatcher_PrefixMatch:
+		match = strings.HasPrefix(value, spec.PrefixMatch)
+	case *routepb.HeaderMatcher_SuffixMatch:
+		match = strings.HasSuffix(value, spec.SuffixMatch)
+	case *routepb.HeaderMatcher_ContainsMatch:
+		match = strings.Contains(value, spec.ContainsMatch)
+	case *routepb.HeaderMatcher_SafeRegexMatch:
+		match, err = matchRegex(spec.SafeRegexMatch.GetRegex(), value)
+	default:
+		return false, fmt.Errorf("unsupported header matcher %T", spec)
+	}
+	if err != nil || !found {
+		return false, err
+	}
+	return match != m.GetInvertMatch(), nil
+}
+
+func matchString(m *matcherpb.StringMatcher, v string) (bool, error) {
+	if m.GetIgnoreCase() {
+		v = strings.ToLower(v)
+	}
+	lower := func(s string) string {
+		if m.GetIgnoreCase() {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	switch p := m.GetMatchPattern().(type) {
+	case *matcherpb.StringMatcher_Exact:
+		return v == lower(p.Exact), nil
+	case *matcherpb.StringMatcher_Prefix:
+		return strings.HasPrefix(v, lower(p.Prefix)), nil
+	case *matcherpb.StringMatcher_Suffix:
+		return strings.HasSuffix(v, lower(p.Suffix)), nil
+	case *matcherpb.StringMatcher_Contains:
+		return strings.Contains(v, lower(p.Contains)), nil
+	case *matcherpb.StringMatcher_SafeRegex:
+		return matchRegex(p.SafeRegex.GetRegex(), v)
+	default:
+		return false, fmt.Errorf("unsupported string matcher %T", p)
+	}
+}
+
+func matchRegex(regex, v string) (bool, error) {
+	// Envoy requires the regex to match the full string.
+	re, err := regexp.Compile("^(?:" + regex + ")$")
+	if err != nil {
+		return false, fmt.Errorf("invalid regex %q: %v", regex, err)
+	}
+	return re.MatchString(v), nil
+}
+
+func matchCIDR(cidr *core.CidrRange, ip string) (bool, error) {
+	if ip == "" {
+		return false, nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, fmt.Errorf("invalid IP address %q: %v", ip, err)
+	}
+	prefix, err := netip.ParsePrefix(fmt.Sprintf("%s/%d", cidr.GetAddressPrefix(), cidr.GetPrefixLen().GetValue()))
+	if err != nil {
+		return false, fmt.Errorf("invalid CIDR range: %v", err)
+	}
+	return prefix.Contains(addr), nil
+}
+
+func matchMetadata(m *matcherpb.MetadataMatcher, r *Request) (bool, error) {
+	value, err := r.metadata(m.GetFilter())
+	if err != nil {
+		return false, err
+	}
+	for _, segment := range m.GetPath() {
+		value = value.GetStructValue().GetFields()[segment.GetKey()]
+	}
+	match, err := matchValue(m.GetValue(), value)
+	if err != nil {
+		return false, err
+	}
+	return match != m.GetInvert(), nil
+}
+
+func matchValue(m *matcherpb.ValueMatcher, v *structpb.Value) (bool, error) {
+	switch p := m.GetMatchPattern().(type) {
+	case *matcherpb.ValueMatcher_PresentMatch:
+		return (v != nil) == p.PresentMatch, nil
+	case *matcherpb.ValueMatcher_NullMatch_:
+		_, ok := v.GetKind().(*structpb.Value_NullValue)
+		return ok, nil
+	case *matcherpb.ValueMatcher_BoolMatch:
+		b, ok := v.GetKind().(*structpb.Value_BoolValue)
+		return ok && b.BoolValue == p.BoolMatch, nil
+	case *matcherpb.ValueMatcher_StringMatch:
+		s, ok := v.GetKind().(*structpb.Value_StringValue)
+		if !ok {
+			return false, nil
+		}
+		return matchString(p.StringMatch, s.StringValue)
+	case *matcherpb.ValueMatcher_ListMatch:
+		for _, item := range v.GetListValue().GetValues() {
+			if ok, err := matchValue(p.ListMatch.GetOneOf(), item); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case *matcherpb.ValueMatcher_OrMatch:
+		for _, vm := range p.OrMatch.GetValueMatchers() {
+			if ok, err := matchValue(vm, v); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported value matcher %T", p)
+	}
+}
+
+// matchURITemplate matches the path against an Envoy URI template, where "*" matches a single
+// path segment and "**" matches the remaining path segments.
+func matchURITemplate(template, path string) bool {
+	want := strings.Split(strings.TrimPrefix(template, "/"), "/")
+	got := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range want {
+		if segment == "**" {
+			return true
+		}
+		if i >= len(got) {
+			return false
+		}
+		if segment == "*" {
+			if got[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != got[i] {
+			return false
+		}
+	}
+	return len(want) == len(got)
+}
+
+func pathWithoutQuery(path string) string {
+	p, _, _ := strings.Cut(path, "?")
+	return p
+}
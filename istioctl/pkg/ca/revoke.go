@@ -0,0 +1,207 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/slices"
+	istioca "istio.io/istio/security/pkg/pki/ca"
+)
+
+// Cmd returns the commands managing the certificates issued by the Istio CA.
+func Cmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ca",
+		Short: "Manage the workload certificates issued by the Istio CA",
+	}
+	cmd.AddCommand(revokeCmd(ctx))
+	cmd.AddCommand(revokedCmd(ctx))
+	return cmd
+}
+
+func revokeCmd(ctx cli.Context) *cobra.Command {
+	var filter istioca.RevocationFilter
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke workload certificates issued by the Istio CA",
+		Long: fmt.Sprintf(`Revoke the workload certificates issued by the Istio CA with a serial number, for an identity, or to the
+workloads of a node. The revocation request is added to the %s ConfigMap of the Istio namespace. Each
+istiod revokes the matching certificates issued by the istiod replicas, including the ones issued before they
+restarted, and the CRL of the revoked certificates is distributed to the proxies, which then reject them. The
+certificates issued after the revocation are not revoked.
+
+This requires istiod to run with ENABLE_CA_CRL=true. The certificates are revoked by identity or node only if they
+are still tracked by istiod, see CA_ISSUED_CERTS_CAPACITY.`, istioca.RevocationsConfigMap),
+		Example: `  # Revoke a certificate by serial number
+  istioctl x ca revoke --serial 4f:1c:32:8a:9d
+
+  # Revoke the certificates of an identity
+  istioctl x ca revoke --identity spiffe://cluster.local/ns/default/sa/reviews
+
+  # Revoke the certificates of the workloads of a compromised node
+  istioctl x ca revoke --node worker-3`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			filter.RevokedAt = time.Now().Truncate(time.Second)
+			if err := filter.Validate(); err != nil {
+				return err
+			}
+			client, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			if err := addRevocationRequest(client, ctx.IstioNamespace(), filter); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(c.OutOrStdout(), "Revocation requested in %s/%s; the CRL is distributed to the proxies within a few minutes\n",
+				ctx.IstioNamespace(), istioca.RevocationsConfigMap)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&filter.Serial, "serial", "", "Hexadecimal serial number of the certificate to revoke")
+	cmd.Flags().StringVar(&filter.SubjectID, "identity", "", "SPIFFE identity whose certificates are revoked")
+	cmd.Flags().StringVar(&filter.Node, "node", "", "Node whose workload certificates are revoked")
+	return cmd
+}
+
+// addRevocationRequest adds a revocation request to the revocations ConfigMap, creating it if needed.
+func addRevocationRequest(client kube.CLIClient, namespace string, filter istioca.RevocationFilter) error {
+	configmaps := client.Kube().CoreV1().ConfigMaps(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configmaps.Get(context.TODO(), istioca.RevocationsConfigMap, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			requests, err := json.Marshal([]istioca.RevocationFilter{filter})
+			if err != nil {
+				return err
+			}
+			_, err = configmaps.Create(context.TODO(), &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: istioca.RevocationsConfigMap, Namespace: namespace},
+				Data:       map[string]string{istioca.RevocationRequestsKey: string(requests)},
+			}, metav1.CreateOptions{})
+			if kerrors.IsAlreadyExists(err) {
+				return kerrors.NewConflict(v1.Resource("configmaps"), istioca.RevocationsConfigMap, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		var requests []istioca.RevocationFilter
+		if data := strings.TrimSpace(cm.Data[istioca.RevocationRequestsKey]); data != "" {
+			if err := json.Unmarshal([]byte(data), &requests); err != nil {
+				return fmt.Errorf("invalid %s in %s/%s: %v", istioca.RevocationRequestsKey, namespace, istioca.RevocationsConfigMap, err)
+			}
+		}
+		b, err := json.Marshal(append(requests, filter))
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[istioca.RevocationRequestsKey] = string(b)
+		_, err = configmaps.Update(context.TODO(), cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func revokedCmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revoked",
+		Short: "List the workload certificates revoked by the Istio CA",
+		Long: `List the unexpired workload certificates revoked by the Istio CA, as resolved by the istiod replicas from the
+revocation requests.`,
+		Example: `  istioctl x ca revoked`,
+		Args:    cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			revoked, err := listRevoked(client, ctx.IstioNamespace())
+			if err != nil {
+				return err
+			}
+			writeRevoked(c.OutOrStdout(), revoked)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// listRevoked returns the unexpired certificates revoked by all the istiod replicas.
+func listRevoked(client kube.CLIClient, namespace string) ([]istioca.RevokedCert, error) {
+	cm, err := client.Kube().CoreV1().ConfigMaps(namespace).Get(context.TODO(), istioca.RevocationsConfigMap, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	revoked := map[string]istioca.RevokedCert{}
+	for k, v := range cm.Data {
+		if !strings.HasPrefix(k, istioca.RevokedKeyPrefix) {
+			continue
+		}
+		var list []istioca.RevokedCert
+		if err := json.Unmarshal([]byte(v), &list); err != nil {
+			return nil, fmt.Errorf("invalid %s in %s/%s: %v", k, namespace, istioca.RevocationsConfigMap, err)
+		}
+		for _, r := range list {
+			if r.NotAfter.After(time.Now()) {
+				revoked[r.Serial] = r
+			}
+		}
+	}
+	out := make([]istioca.RevokedCert, 0, len(revoked))
+	for _, r := range revoked {
+		out = append(out, r)
+	}
+	return slices.SortBy(out, func(r istioca.RevokedCert) string {
+		return r.Serial
+	}), nil
+}
+
+func writeRevoked(w io.Writer, revoked []istioca.RevokedCert) {
+	tw := tabwriter.NewWriter(w, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "SERIAL\tIDENTITIES\tNODE\tREVOKED AT\tEXPIRES")
+	for _, r := range revoked {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Serial, valueOrUnknown(strings.Join(r.SubjectIDs, ",")), valueOrUnknown(r.Node),
+			r.RevokedAt.UTC().Format(time.RFC3339), r.NotAfter.UTC().Format(time.RFC3339))
+	}
+	_ = tw.Flush()
+}
+
+func valueOrUnknown(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/util/assert"
+	istioca "istio.io/istio/security/pkg/pki/ca"
+)
+
+func TestAddRevocationRequest(t *testing.T) {
+	client := kube.NewFakeClient()
+	revokedAt := time.Now().Truncate(time.Second)
+	first := istioca.RevocationFilter{Node: "node-1", RevokedAt: revokedAt}
+	second := istioca.RevocationFilter{Serial: "4f1c", RevokedAt: revokedAt}
+	assert.NoError(t, addRevocationRequest(client, "istio-system", first))
+	assert.NoError(t, addRevocationRequest(client, "istio-system", second))
+
+	cm, err := client.Kube().CoreV1().ConfigMaps("istio-system").Get(context.Background(), istioca.RevocationsConfigMap, metav1.GetOptions{})
+	assert.NoError(t, err)
+	var requests []istioca.RevocationFilter
+	assert.NoError(t, json.Unmarshal([]byte(cm.Data[istioca.RevocationRequestsKey]), &requests))
+	assert.Equal(t, len(requests), 2)
+	assert.Equal(t, requests[0].Node, "node-1")
+	assert.Equal(t, requests[1].Serial, "4f1c")
+	assert.Equal(t, requests[1].RevokedAt.Equal(revokedAt), true)
+}
+
+func TestListRevoked(t *testing.T) {
+	revoked, err := listRevoked(kube.NewFakeClient(), "istio-system")
+	assert.NoError(t, err)
+	assert.Equal(t, len(revoked), 0)
+
+	revokedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	notAfter := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	marshal := func(revoked ...istioca.RevokedCert) string {
+		b, err := json.Marshal(revoked)
+		assert.NoError(t, err)
+		return string(b)
+	}
+	client := kube.NewFakeClient(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: istioca.RevocationsConfigMap, Namespace: "istio-system"},
+		Data: map[string]string{
+			istioca.RevocationRequestsKey: `[{"node":"node-1"}]`,
+			istioca.RevokedKeyPrefix + "istiod-a.json": marshal(
+				istioca.RevokedCert{Serial: "b2", SubjectIDs: []string{"spiffe://cluster.local/ns/default/sa/a"}, Node: "node-1",
+					NotAfter: notAfter, RevokedAt: revokedAt},
+				istioca.RevokedCert{Serial: "c3", NotAfter: time.Now().Add(-time.Hour), RevokedAt: revokedAt},
+			),
+			istioca.RevokedKeyPrefix + "istiod-b.json": marshal(
+				istioca.RevokedCert{Serial: "a1", NotAfter: notAfter, RevokedAt: revokedAt},
+			),
+		},
+	})
+	revoked, err = listRevoked(client, "istio-system")
+	assert.NoError(t, err)
+	var out bytes.Buffer
+	writeRevoked(&out, revoked)
+	expires := notAfter.Format(time.RFC3339)
+	assert.Equal(t, out.String(), `SERIAL     IDENTITIES                                 NODE       REVOKED AT               EXPIRES
+a1         -                                          -          2026-01-02T03:04:05Z     `+expires+`
+b2         spiffe://cluster.local/ns/default/sa/a     node-1     2026-01-02T03:04:05Z     `+expires+`
+`)
+}
@@ -0,0 +1,538 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package canary implements canary upgrades of the control plane: a new revision is installed next to the
+// existing ones, and the namespaces are moved to it in waves. The namespaces using a revision tag are moved
+// with their tag.
+package canary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/api/annotation"
+	"istio.io/api/label"
+	"istio.io/istio/istioctl/pkg/tag"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/inject"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// restartedAtAnnotation is the annotation set on pod templates by `kubectl rollout restart`.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// injectionLabels are the namespace labels selecting the revision injecting the sidecars.
+var injectionLabels = []string{util.InjectionLabelName, label.IoIstioRev.Name}
+
+// Upgrader moves the namespaces of the mesh to a new revision, in waves.
+type Upgrader struct {
+	Client         kube.CLIClient
+	IstioNamespace string
+	// Revision is the revision to upgrade to.
+	Revision string
+	// Tags are the revision tags moved to the revision, each in its own wave, after the waves of namespaces.
+	Tags []string
+
+	// Install installs the new revision.
+	Install func() error
+	// MoveTag points the revision tag to the revision, like `istioctl tag set --overwrite`.
+	MoveTag func(tag, revision string) error
+	// ProxiesOnRevision returns the proxies connected to the revision, as `<pod>.<namespace>`.
+	ProxiesOnRevision func(revision string) (sets.String, error)
+	// ErrorRate returns the ratio of the requests to the workloads of the namespaces failing with a 5xx
+	// error, over the window. It is only called when MaxErrorRate is set.
+	ErrorRate func(namespaces []string, window time.Duration) (float64, error)
+
+	// ProxyTimeout is the maximum time to wait for the proxies of a wave to move to the revision.
+	ProxyTimeout time.Duration
+	// MaxErrorRate is the maximum error rate of the namespaces of a wave once migrated. 0 disables the check.
+	MaxErrorRate float64
+	// ErrorRateWindow is the time to observe the error rate after the migration of a wave.
+	ErrorRateWindow time.Duration
+	// RollbackOnFailure rolls back the wave which failed to migrate or to pass the error rate check.
+	RollbackOnFailure bool
+	// PollInterval is the interval between the checks of the proxies.
+	PollInterval time.Duration
+
+	Writer io.Writer
+}
+
+// Run installs the revision, migrates the waves of namespaces, then moves the tags. If an upgrade to the revision
+// was interrupted, it is resumed: the waves and tags must then be the same, or empty to use the ones of the
+// interrupted upgrade.
+func (u *Upgrader) Run(waves [][]string) error {
+	s, err := u.LoadState()
+	if err != nil {
+		return err
+	}
+	switch {
+	case s == nil:
+		s = newState(u.Revision, waves, u.Tags)
+	case len(waves) == 0 && len(u.Tags) == 0:
+		u.printf("Resuming the upgrade to revision %q\n", u.Revision)
+	case !s.sameWaves(waves, u.Tags):
+		return fmt.Errorf("an upgrade to revision %q with different waves is in progress; "+
+			"run it without waves to resume it", u.Revision)
+	default:
+		u.printf("Resuming the upgrade to revision %q\n", u.Revision)
+	}
+	if err := u.saveState(s); err != nil {
+		return err
+	}
+
+	if !s.Installed {
+		u.printf("Installing revision %q\n", u.Revision)
+		if err := u.Install(); err != nil {
+			return fmt.Errorf("failed to install revision %q: %v", u.Revision, err)
+		}
+		s.Installed = true
+		if err := u.saveState(s); err != nil {
+			return err
+		}
+	}
+
+	for i := range s.Waves {
+		if s.Waves[i].Status == WaveCompleted {
+			continue
+		}
+		if err := u.migrateWave(s, i); err != nil {
+			return err
+		}
+	}
+	u.printf("All the waves were migrated to revision %q\n", u.Revision)
+	return nil
+}
+
+func (u *Upgrader) migrateWave(s *State, i int) error {
+	w := &s.Waves[i]
+	u.printf("Migrating wave %d: %s\n", i+1, w)
+	var err error
+	if w.Tag != "" {
+		err = u.prepareTagWave(w)
+	} else {
+		err = u.prepareNamespaceWave(w)
+	}
+	if err != nil {
+		return err
+	}
+	w.Status = WaveMigrating
+	if err := u.saveState(s); err != nil {
+		return err
+	}
+
+	err = u.migrate(w)
+	if err != nil && u.RollbackOnFailure {
+		u.printf("Wave %d failed: %v\nRolling back wave %d\n", i+1, err, i+1)
+		if rerr := u.rollbackWave(s, i); rerr != nil {
+			return fmt.Errorf("wave %d failed: %v; and failed to roll back: %v", i+1, err, rerr)
+		}
+		return fmt.Errorf("wave %d failed and was rolled back: %v", i+1, err)
+	}
+	if err != nil {
+		return fmt.Errorf("wave %d failed: %v", i+1, err)
+	}
+	w.Status = WaveCompleted
+	if err := u.saveState(s); err != nil {
+		return err
+	}
+	u.printf("Wave %d migrated to revision %q\n", i+1, u.Revision)
+	return nil
+}
+
+// prepareNamespaceWave records the injection labels of the namespaces of the wave. The namespaces using a revision
+// tag are left alone: they move with their tag.
+func (u *Upgrader) prepareNamespaceWave(w *Wave) error {
+	if w.PreviousLabels == nil {
+		w.PreviousLabels = map[string]map[string]string{}
+	}
+	for _, ns := range w.Namespaces {
+		if _, f := w.PreviousLabels[ns]; f {
+			// The wave was interrupted; keep the labels of the namespace before the first attempt.
+			continue
+		}
+		labels, err := u.injectionLabels(ns)
+		if err != nil {
+			return err
+		}
+		if rev := labels[label.IoIstioRev.Name]; rev != "" {
+			isTag, err := u.isTag(rev)
+			if err != nil {
+				return err
+			}
+			if isTag {
+				u.printf("Skipping namespace %s, which uses the revision tag %q: it moves with the tag\n", ns, rev)
+				continue
+			}
+		}
+		w.PreviousLabels[ns] = labels
+	}
+	return nil
+}
+
+// prepareTagWave records the revision of the tag of the wave, and the namespaces using it.
+func (u *Upgrader) prepareTagWave(w *Wave) error {
+	if w.PreviousRevision == "" {
+		webhooks, err := tag.GetWebhooksWithTag(context.TODO(), u.Client.Kube(), w.Tag)
+		if err != nil {
+			return fmt.Errorf("failed to get revision tag %q: %v", w.Tag, err)
+		}
+		if len(webhooks) == 0 {
+			return fmt.Errorf("revision tag %q not found", w.Tag)
+		}
+		revision, err := tag.GetWebhookRevision(webhooks[0])
+		if err != nil {
+			return err
+		}
+		w.PreviousRevision = revision
+	}
+	namespaces, err := u.taggedNamespaces(w.Tag)
+	if err != nil {
+		return err
+	}
+	w.Namespaces = namespaces
+	return nil
+}
+
+func (u *Upgrader) migrate(w *Wave) error {
+	if w.Tag != "" {
+		if err := u.moveTag(w, u.Revision); err != nil {
+			return err
+		}
+	} else {
+		for _, ns := range w.targets() {
+			if err := u.setInjectionLabels(ns, map[string]string{label.IoIstioRev.Name: u.Revision}); err != nil {
+				return err
+			}
+			if err := u.restartWorkloads(ns); err != nil {
+				return err
+			}
+		}
+	}
+	namespaces := w.targets()
+	if err := u.waitForProxies(namespaces); err != nil {
+		return err
+	}
+	if u.MaxErrorRate <= 0 || len(namespaces) == 0 {
+		return nil
+	}
+	u.printf("Observing the error rate for %v\n", u.ErrorRateWindow)
+	time.Sleep(u.ErrorRateWindow)
+	rate, err := u.ErrorRate(namespaces, u.ErrorRateWindow)
+	if err != nil {
+		return fmt.Errorf("failed to check the error rate: %v", err)
+	}
+	if rate > u.MaxErrorRate {
+		return fmt.Errorf("error rate %.4f is above the maximum %.4f", rate, u.MaxErrorRate)
+	}
+	u.printf("Error rate %.4f is below the maximum %.4f\n", rate, u.MaxErrorRate)
+	return nil
+}
+
+// Rollback moves the namespaces of the wave, numbered from 1, back to the revisions they used before the upgrade.
+// 0 rolls back the last wave migrated. The waves migrated after it must be rolled back first.
+func (u *Upgrader) Rollback(wave int) error {
+	s, err := u.LoadState()
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return fmt.Errorf("no upgrade to revision %q found", u.Revision)
+	}
+	if wave == 0 {
+		for i := len(s.Waves) - 1; i >= 0; i-- {
+			if migrated(s.Waves[i]) {
+				wave = i + 1
+				break
+			}
+		}
+		if wave == 0 {
+			return fmt.Errorf("no wave of the upgrade to revision %q was migrated", u.Revision)
+		}
+	}
+	if wave < 1 || wave > len(s.Waves) {
+		return fmt.Errorf("invalid wave %d, the upgrade has %d waves", wave, len(s.Waves))
+	}
+	if !migrated(s.Waves[wave-1]) {
+		return fmt.Errorf("wave %d was not migrated", wave)
+	}
+	for i := wave; i < len(s.Waves); i++ {
+		if migrated(s.Waves[i]) {
+			return fmt.Errorf("wave %d must be rolled back before wave %d", i+1, wave)
+		}
+	}
+	u.printf("Rolling back wave %d: %s\n", wave, s.Waves[wave-1])
+	if err := u.rollbackWave(s, wave-1); err != nil {
+		return err
+	}
+	u.printf("Wave %d rolled back\n", wave)
+	return nil
+}
+
+func migrated(w Wave) bool {
+	return w.Status == WaveMigrating || w.Status == WaveCompleted
+}
+
+func (u *Upgrader) rollbackWave(s *State, i int) error {
+	w := &s.Waves[i]
+	if w.Tag != "" {
+		if err := u.moveTag(w, w.PreviousRevision); err != nil {
+			return err
+		}
+	} else {
+		for _, ns := range w.targets() {
+			if err := u.setInjectionLabels(ns, w.PreviousLabels[ns]); err != nil {
+				return err
+			}
+			if err := u.restartWorkloads(ns); err != nil {
+				return err
+			}
+		}
+	}
+	if err := u.waitForRollback(w.targets()); err != nil {
+		return err
+	}
+	w.Status = WaveRolledBack
+	return u.saveState(s)
+}
+
+// moveTag points the tag of the wave to the revision, and restarts the workloads of the namespaces using it.
+func (u *Upgrader) moveTag(w *Wave, revision string) error {
+	u.printf("Moving revision tag %q to revision %q\n", w.Tag, revision)
+	if err := u.MoveTag(w.Tag, revision); err != nil {
+		return fmt.Errorf("failed to move revision tag %q to revision %q: %v", w.Tag, revision, err)
+	}
+	for _, ns := range w.Namespaces {
+		if err := u.restartWorkloads(ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTag checks whether the revision label value is a revision tag.
+func (u *Upgrader) isTag(rev string) (bool, error) {
+	webhooks, err := tag.GetWebhooksWithTag(context.TODO(), u.Client.Kube(), rev)
+	if err != nil {
+		return false, fmt.Errorf("failed to get revision tag %q: %v", rev, err)
+	}
+	return len(webhooks) > 0, nil
+}
+
+// taggedNamespaces returns the namespaces using the revision tag. The namespaces with the `istio-injection=enabled`
+// label use the default tag.
+func (u *Upgrader) taggedNamespaces(tagName string) ([]string, error) {
+	namespaces, err := tag.GetNamespacesWithTag(context.TODO(), u.Client.Kube(), tagName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the namespaces using revision tag %q: %v", tagName, err)
+	}
+	if tagName != tag.DefaultRevisionName {
+		return namespaces, nil
+	}
+	injected, err := u.Client.Kube().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=enabled,!%s", util.InjectionLabelName, label.IoIstioRev.Name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the namespaces using revision tag %q: %v", tagName, err)
+	}
+	for _, ns := range injected.Items {
+		namespaces = append(namespaces, ns.Name)
+	}
+	return namespaces, nil
+}
+
+// injectionLabels returns the injection labels of the namespace.
+func (u *Upgrader) injectionLabels(namespace string) (map[string]string, error) {
+	ns, err := u.Client.Kube().CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %v", namespace, err)
+	}
+	labels := map[string]string{}
+	for _, l := range injectionLabels {
+		if v, f := ns.Labels[l]; f {
+			labels[l] = v
+		}
+	}
+	return labels, nil
+}
+
+// setInjectionLabels replaces the injection labels of the namespace.
+func (u *Upgrader) setInjectionLabels(namespace string, labels map[string]string) error {
+	patch := map[string]any{}
+	for _, l := range injectionLabels {
+		if v, f := labels[l]; f {
+			patch[l] = v
+		} else {
+			// Null removes the label.
+			patch[l] = nil
+		}
+	}
+	b, err := json.Marshal(map[string]any{"metadata": map[string]any{"labels": patch}})
+	if err != nil {
+		return err
+	}
+	if _, err := u.Client.Kube().CoreV1().Namespaces().Patch(context.TODO(), namespace, types.MergePatchType, b, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to label namespace %s: %v", namespace, err)
+	}
+	return nil
+}
+
+// restartWorkloads restarts the deployments, stateful sets and daemon sets of the namespace, like
+// `kubectl rollout restart`, so that their pods are injected again.
+func (u *Upgrader) restartWorkloads(namespace string) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		restartedAtAnnotation, time.Now().Format(time.RFC3339)))
+	apps := u.Client.Kube().AppsV1()
+	ctx := context.TODO()
+
+	deployments, err := apps.Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, d := range deployments.Items {
+		if !injectionEnabled(d.Spec.Template.ObjectMeta) {
+			continue
+		}
+		if _, err := apps.Deployments(namespace).Patch(ctx, d.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to restart deployment %s/%s: %v", namespace, d.Name, err)
+		}
+	}
+	statefulSets, err := apps.StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, s := range statefulSets.Items {
+		if !injectionEnabled(s.Spec.Template.ObjectMeta) {
+			continue
+		}
+		if _, err := apps.StatefulSets(namespace).Patch(ctx, s.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to restart stateful set %s/%s: %v", namespace, s.Name, err)
+		}
+	}
+	daemonSets, err := apps.DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, d := range daemonSets.Items {
+		if !injectionEnabled(d.Spec.Template.ObjectMeta) {
+			continue
+		}
+		if _, err := apps.DaemonSets(namespace).Patch(ctx, d.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to restart daemon set %s/%s: %v", namespace, d.Name, err)
+		}
+	}
+	return nil
+}
+
+// injectionEnabled checks whether the pod template does not opt out of the injection.
+func injectionEnabled(meta metav1.ObjectMeta) bool {
+	return meta.Labels[label.SidecarInject.Name] != "false" && meta.Annotations[annotation.SidecarInject.Name] != "false"
+}
+
+// waitForProxies waits for the proxies of the namespaces to be injected by the revision, and connected to it.
+func (u *Upgrader) waitForProxies(namespaces []string) error {
+	return u.poll(func() ([]string, error) {
+		proxies, err := u.ProxiesOnRevision(u.Revision)
+		if err != nil {
+			return nil, err
+		}
+		return u.pendingPods(namespaces, func(pod corev1.Pod, revision string) bool {
+			return revision == u.Revision && proxies.Contains(pod.Name+"."+pod.Namespace)
+		})
+	})
+}
+
+// waitForRollback waits for the proxies of the namespaces to be injected by another revision.
+func (u *Upgrader) waitForRollback(namespaces []string) error {
+	return u.poll(func() ([]string, error) {
+		return u.pendingPods(namespaces, func(_ corev1.Pod, revision string) bool {
+			return revision != u.Revision
+		})
+	})
+}
+
+// poll calls check until it returns no pending pod, or ProxyTimeout is reached.
+func (u *Upgrader) poll(check func() ([]string, error)) error {
+	deadline := time.Now().Add(u.ProxyTimeout)
+	for {
+		pending, err := check()
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %d proxies: %s", len(pending), strings.Join(pending, ", "))
+		}
+		u.printf("Waiting for %d proxies\n", len(pending))
+		time.Sleep(u.PollInterval)
+	}
+}
+
+// pendingPods returns the running pods in the namespaces which are not done. The pods which are not restarted, like
+// the pods of jobs, and the pods opting out of the injection are ignored. The other pods without a sidecar are pending,
+// as the injector failed open when they were created.
+func (u *Upgrader) pendingPods(namespaces []string, done func(pod corev1.Pod, revision string) bool) ([]string, error) {
+	var pending []string
+	for _, ns := range namespaces {
+		pods, err := u.Client.Kube().CoreV1().Pods(ns).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning || !restartable(pod) {
+				continue
+			}
+			if !injectionEnabled(pod.ObjectMeta) || pod.Spec.HostNetwork {
+				continue
+			}
+			injection := inject.SidecarInjectionStatus{}
+			status, f := pod.Annotations[annotation.SidecarStatus.Name]
+			if !f || json.Unmarshal([]byte(status), &injection) != nil {
+				pending = append(pending, pod.Name+"."+pod.Namespace+" (no sidecar)")
+				continue
+			}
+			if !done(pod, injection.Revision) {
+				pending = append(pending, pod.Name+"."+pod.Namespace)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// restartable checks whether the pod is controlled by a workload restarted by restartWorkloads.
+func restartable(pod corev1.Pod) bool {
+	owner := metav1.GetControllerOf(&pod)
+	if owner == nil {
+		return false
+	}
+	switch owner.Kind {
+	case "ReplicaSet", "StatefulSet", "DaemonSet":
+		return true
+	}
+	return false
+}
+
+func (u *Upgrader) printf(format string, a ...any) {
+	_, _ = fmt.Fprintf(u.Writer, format, a...)
+}
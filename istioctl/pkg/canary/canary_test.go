@@ -0,0 +1,325 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	admitv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"istio.io/api/annotation"
+	"istio.io/api/label"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	istioNamespace = "istio-system"
+	oldRevision    = "1-24"
+	newRevision    = "1-25"
+)
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func deployment(ns string) *appsv1.Deployment {
+	return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: ns}}
+}
+
+func pod(ns, revision string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app-1",
+			Namespace: ns,
+			Annotations: map[string]string{
+				annotation.SidecarStatus.Name: fmt.Sprintf(`{"revision":%q}`, revision),
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       "app-1",
+				Controller: ptr.Of(true),
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func tagWebhook(tagName, revision string) *admitv1.MutatingWebhookConfiguration {
+	return &admitv1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{
+		Name:   "istio-revision-tag-" + tagName,
+		Labels: map[string]string{label.IoIstioTag.Name: tagName, label.IoIstioRev.Name: revision},
+	}}
+}
+
+// newTestClient returns a client whose pods are injected again, by the revision of their namespace, when their
+// deployment is restarted.
+func newTestClient(t *testing.T, objects ...runtime.Object) kube.CLIClient {
+	client := kube.NewFakeClient(objects...)
+	cs := client.Kube().(*fake.Clientset)
+	cs.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		ns := action.GetNamespace()
+		obj, err := cs.Tracker().Get(corev1.SchemeGroupVersion.WithResource("namespaces"), "", ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels := obj.(*corev1.Namespace).Labels
+		revision := labels[label.IoIstioRev.Name]
+		if revision == "" && labels["istio-injection"] == "enabled" {
+			revision = "default"
+		}
+		// Resolve the revision tags to their revision.
+		if wh, err := cs.Tracker().Get(admitv1.SchemeGroupVersion.WithResource("mutatingwebhookconfigurations"), "",
+			"istio-revision-tag-"+revision); err == nil {
+			revision = wh.(*admitv1.MutatingWebhookConfiguration).Labels[label.IoIstioRev.Name]
+		}
+		if err := cs.Tracker().Update(corev1.SchemeGroupVersion.WithResource("pods"), pod(ns, revision), ns); err != nil {
+			t.Fatal(err)
+		}
+		return false, nil, nil
+	})
+	return client
+}
+
+func newTestUpgrader(client kube.CLIClient) *Upgrader {
+	return &Upgrader{
+		Client:         client,
+		IstioNamespace: istioNamespace,
+		Revision:       newRevision,
+		Install:        func() error { return nil },
+		MoveTag: func(tagName, revision string) error {
+			_, err := client.Kube().AdmissionregistrationV1().MutatingWebhookConfigurations().Update(context.TODO(),
+				tagWebhook(tagName, revision), metav1.UpdateOptions{})
+			return err
+		},
+		ProxiesOnRevision: func(revision string) (sets.String, error) {
+			return sets.New("app-1.ns-a", "app-1.ns-b", "app-1.ns-c"), nil
+		},
+		ErrorRate:         func([]string, time.Duration) (float64, error) { return 0, nil },
+		ProxyTimeout:      time.Second,
+		RollbackOnFailure: true,
+		PollInterval:      time.Millisecond,
+		Writer:            io.Discard,
+	}
+}
+
+func testObjects() []runtime.Object {
+	return []runtime.Object{
+		namespace("ns-a", map[string]string{label.IoIstioRev.Name: oldRevision}),
+		namespace("ns-b", map[string]string{"istio-injection": "enabled"}),
+		namespace("ns-c", map[string]string{label.IoIstioRev.Name: oldRevision}),
+		deployment("ns-a"), deployment("ns-b"), deployment("ns-c"),
+		pod("ns-a", oldRevision), pod("ns-b", "default"), pod("ns-c", oldRevision),
+	}
+}
+
+func namespaceLabels(t *testing.T, client kube.CLIClient, name string) map[string]string {
+	ns, err := client.Kube().CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return ns.Labels
+}
+
+func waveStatuses(t *testing.T, u *Upgrader) []WaveStatus {
+	s, err := u.LoadState()
+	assert.NoError(t, err)
+	var statuses []WaveStatus
+	for _, w := range s.Waves {
+		statuses = append(statuses, w.Status)
+	}
+	return statuses
+}
+
+func TestRun(t *testing.T) {
+	client := newTestClient(t, testObjects()...)
+	u := newTestUpgrader(client)
+	installs := 0
+	u.Install = func() error {
+		installs++
+		return nil
+	}
+
+	assert.NoError(t, u.Run([][]string{{"ns-a", "ns-b"}, {"ns-c"}}))
+	assert.Equal(t, installs, 1)
+	assert.Equal(t, waveStatuses(t, u), []WaveStatus{WaveCompleted, WaveCompleted})
+	for _, ns := range []string{"ns-a", "ns-b", "ns-c"} {
+		assert.Equal(t, namespaceLabels(t, client, ns), map[string]string{label.IoIstioRev.Name: newRevision})
+	}
+
+	// Running it again resumes the completed upgrade without installing the revision again.
+	assert.NoError(t, u.Run(nil))
+	assert.Equal(t, installs, 1)
+	// Different waves are rejected.
+	if err := u.Run([][]string{{"ns-c"}}); err == nil {
+		t.Fatal("expected an error for different waves")
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	client := newTestClient(t, testObjects()...)
+	u := newTestUpgrader(client)
+	// The proxies of ns-c never connect to the revision.
+	u.ProxiesOnRevision = func(string) (sets.String, error) {
+		return sets.New("app-1.ns-a", "app-1.ns-b"), nil
+	}
+	u.RollbackOnFailure = false
+	waves := [][]string{{"ns-a", "ns-b"}, {"ns-c"}}
+	err := u.Run(waves)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	assert.Equal(t, waveStatuses(t, u), []WaveStatus{WaveCompleted, WaveMigrating})
+
+	u = newTestUpgrader(client)
+	u.Install = func() error {
+		t.Fatal("the revision must not be installed again")
+		return nil
+	}
+	assert.NoError(t, u.Run(nil))
+	assert.Equal(t, waveStatuses(t, u), []WaveStatus{WaveCompleted, WaveCompleted})
+}
+
+func TestRunInjectionFailOpen(t *testing.T) {
+	client := newTestClient(t, testObjects()...)
+	cs := client.Kube().(*fake.Clientset)
+	// The injector fails open for ns-c: its restarted pod has no sidecar.
+	cs.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() != "ns-c" {
+			return false, nil, nil
+		}
+		p := pod("ns-c", "")
+		p.Annotations = nil
+		if err := cs.Tracker().Update(corev1.SchemeGroupVersion.WithResource("pods"), p, "ns-c"); err != nil {
+			t.Fatal(err)
+		}
+		return true, nil, nil
+	})
+	u := newTestUpgrader(client)
+	u.RollbackOnFailure = false
+	err := u.Run([][]string{{"ns-a", "ns-b"}, {"ns-c"}})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	assert.Equal(t, waveStatuses(t, u), []WaveStatus{WaveCompleted, WaveMigrating})
+}
+
+func TestRunErrorRateRollback(t *testing.T) {
+	client := newTestClient(t, testObjects()...)
+	u := newTestUpgrader(client)
+	u.MaxErrorRate = 0.05
+	u.ErrorRate = func(namespaces []string, _ time.Duration) (float64, error) {
+		if namespaces[0] == "ns-c" {
+			return 0.5, nil
+		}
+		return 0.01, nil
+	}
+	err := u.Run([][]string{{"ns-a", "ns-b"}, {"ns-c"}})
+	if err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected a rollback, got %v", err)
+	}
+	assert.Equal(t, waveStatuses(t, u), []WaveStatus{WaveCompleted, WaveRolledBack})
+	assert.Equal(t, namespaceLabels(t, client, "ns-a"), map[string]string{label.IoIstioRev.Name: newRevision})
+	assert.Equal(t, namespaceLabels(t, client, "ns-c"), map[string]string{label.IoIstioRev.Name: oldRevision})
+}
+
+func TestRollback(t *testing.T) {
+	client := newTestClient(t, testObjects()...)
+	u := newTestUpgrader(client)
+	if err := u.Rollback(0); err == nil {
+		t.Fatal("expected an error without upgrade")
+	}
+	assert.NoError(t, u.Run([][]string{{"ns-a", "ns-b"}, {"ns-c"}}))
+
+	// The last wave must be rolled back first.
+	if err := u.Rollback(1); err == nil {
+		t.Fatal("expected an error rolling back the first wave")
+	}
+	assert.NoError(t, u.Rollback(0))
+	assert.Equal(t, waveStatuses(t, u), []WaveStatus{WaveCompleted, WaveRolledBack})
+	assert.NoError(t, u.Rollback(0))
+	assert.Equal(t, waveStatuses(t, u), []WaveStatus{WaveRolledBack, WaveRolledBack})
+
+	assert.Equal(t, namespaceLabels(t, client, "ns-a"), map[string]string{label.IoIstioRev.Name: oldRevision})
+	assert.Equal(t, namespaceLabels(t, client, "ns-b"), map[string]string{"istio-injection": "enabled"})
+	assert.Equal(t, namespaceLabels(t, client, "ns-c"), map[string]string{label.IoIstioRev.Name: oldRevision})
+	if err := u.Rollback(0); err == nil {
+		t.Fatal("expected an error without migrated wave")
+	}
+}
+
+func TestRunTags(t *testing.T) {
+	objects := append(testObjects(),
+		tagWebhook("prod", oldRevision),
+		namespace("ns-tagged", map[string]string{label.IoIstioRev.Name: "prod"}),
+		deployment("ns-tagged"),
+		pod("ns-tagged", oldRevision))
+	client := newTestClient(t, objects...)
+	u := newTestUpgrader(client)
+	u.Tags = []string{"prod"}
+	u.ProxiesOnRevision = func(revision string) (sets.String, error) {
+		return sets.New("app-1.ns-a", "app-1.ns-tagged"), nil
+	}
+
+	// The namespace using the tag is left alone by the waves of namespaces, and moves with the tag.
+	assert.NoError(t, u.Run([][]string{{"ns-a", "ns-tagged"}}))
+	assert.Equal(t, waveStatuses(t, u), []WaveStatus{WaveCompleted, WaveCompleted})
+	assert.Equal(t, namespaceLabels(t, client, "ns-a"), map[string]string{label.IoIstioRev.Name: newRevision})
+	assert.Equal(t, namespaceLabels(t, client, "ns-tagged"), map[string]string{label.IoIstioRev.Name: "prod"})
+	wh, err := client.Kube().AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.TODO(),
+		"istio-revision-tag-prod", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, wh.Labels[label.IoIstioRev.Name], newRevision)
+	s, err := u.LoadState()
+	assert.NoError(t, err)
+	assert.Equal(t, s.Waves[1].Namespaces, []string{"ns-tagged"})
+	assert.Equal(t, s.Waves[1].PreviousRevision, oldRevision)
+
+	// Rolling back the tag wave moves the tag back to its previous revision.
+	assert.NoError(t, u.Rollback(0))
+	wh, err = client.Kube().AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.TODO(),
+		"istio-revision-tag-prod", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, wh.Labels[label.IoIstioRev.Name], oldRevision)
+	assert.Equal(t, namespaceLabels(t, client, "ns-tagged"), map[string]string{label.IoIstioRev.Name: "prod"})
+
+	// A missing tag fails its wave.
+	u = newTestUpgrader(newTestClient(t, testObjects()...))
+	u.Tags = []string{"missing"}
+	if err := u.Run(nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a missing tag error, got %v", err)
+	}
+}
+
+func TestSameWaves(t *testing.T) {
+	s := newState(newRevision, [][]string{{"a", "b"}, {"c"}}, []string{"prod"})
+	assert.Equal(t, s.sameWaves([][]string{{"a", "b"}, {"c"}}, []string{"prod"}), true)
+	assert.Equal(t, s.sameWaves([][]string{{"a", "b"}, {"c"}}, nil), false)
+	assert.Equal(t, s.sameWaves([][]string{{"a", "b"}, {"c"}}, []string{"canary"}), false)
+	assert.Equal(t, s.sameWaves([][]string{{"a"}, {"b", "c"}}, []string{"prod"}), false)
+	assert.Equal(t, s.sameWaves([][]string{{"a", "b"}}, []string{"prod"}), false)
+}
@@ -0,0 +1,136 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	xdsstatus "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	prommodel "github.com/prometheus/common/model"
+
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/pilot/pkg/model"
+	pilotxds "istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// ProxiesOnRevision returns the proxies connected to the Istiods of the revision, and injected by it, as reported
+// by `istioctl proxy-status`. The client must target the revision.
+func ProxiesOnRevision(client kube.CLIClient, istioNamespace, revision string) (sets.String, error) {
+	dr := &discovery.DiscoveryRequest{
+		TypeUrl: pilotxds.TypeDebugSyncronization,
+	}
+	responses, err := multixds.AllRequestAndProcessXds(dr, clioptions.CentralControlPlaneOptions{}, istioNamespace, "", "", client,
+		multixds.Options{MessageWriter: io.Discard})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the status of the proxies of revision %q: %v", revision, err)
+	}
+	proxies := sets.New[string]()
+	for _, dr := range responses {
+		for _, resource := range dr.Resources {
+			clientConfig := xdsstatus.ClientConfig{}
+			if err := resource.UnmarshalTo(&clientConfig); err != nil {
+				return nil, fmt.Errorf("could not unmarshal ClientConfig: %w", err)
+			}
+			meta, err := model.ParseMetadata(clientConfig.GetNode().GetMetadata())
+			if err != nil {
+				return nil, fmt.Errorf("could not parse node metadata: %w", err)
+			}
+			if meta.IstioRevision != revision {
+				continue
+			}
+			proxy, err := model.ParseServiceNodeWithMetadata(clientConfig.GetNode().GetId(), meta)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse node id: %w", err)
+			}
+			// The ID of a proxy is `<pod>.<namespace>`.
+			proxies.Insert(proxy.ID)
+		}
+	}
+	return proxies, nil
+}
+
+// PrometheusErrorRate returns the ratio of the requests to the workloads of the namespaces failing with a 5xx
+// error over the window, according to the standard Istio metrics. If address is empty, the Prometheus pod of the
+// Istio namespace is port forwarded.
+func PrometheusErrorRate(client kube.CLIClient, istioNamespace, address string, namespaces []string, window time.Duration) (float64, error) {
+	if address == "" {
+		pl, err := client.PodsForSelector(context.TODO(), istioNamespace, "app.kubernetes.io/name=prometheus")
+		if err != nil {
+			return 0, fmt.Errorf("not able to locate Prometheus pod: %v", err)
+		}
+		if len(pl.Items) < 1 {
+			return 0, errors.New("no Prometheus pods found")
+		}
+		fw, err := client.NewPortForwarder(pl.Items[0].Name, istioNamespace, "", 0, 9090)
+		if err != nil {
+			return 0, fmt.Errorf("could not build port forwarder for prometheus: %v", err)
+		}
+		if err := fw.Start(); err != nil {
+			return 0, fmt.Errorf("failure running port forward process: %v", err)
+		}
+		defer fw.Close()
+		address = "http://" + fw.Address()
+	}
+	promClient, err := api.NewClient(api.Config{Address: address})
+	if err != nil {
+		return 0, fmt.Errorf("could not build prometheus client: %v", err)
+	}
+	return errorRate(promv1.NewAPI(promClient), namespaces, window)
+}
+
+func errorRate(promAPI promv1.API, namespaces []string, window time.Duration) (float64, error) {
+	selector := fmt.Sprintf(`reporter="destination",destination_workload_namespace=~"%s"`, strings.Join(namespaces, "|"))
+	total, err := vectorValue(promAPI, fmt.Sprintf(`sum(rate(istio_requests_total{%s}[%s]))`,
+		selector, prommodel.Duration(window)))
+	if err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	errs, err := vectorValue(promAPI, fmt.Sprintf(`sum(rate(istio_requests_total{%s,response_code=~"5.."}[%s]))`,
+		selector, prommodel.Duration(window)))
+	if err != nil {
+		return 0, err
+	}
+	return errs / total, nil
+}
+
+func vectorValue(promAPI promv1.API, query string) (float64, error) {
+	val, _, err := promAPI.Query(context.Background(), query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("query() failure for '%s': %v", query, err)
+	}
+	switch v := val.(type) {
+	case prommodel.Vector:
+		if v.Len() < 1 {
+			return 0, nil
+		}
+		return float64(v[0].Value), nil
+	default:
+		return 0, errors.New("bad metric value type returned for query")
+	}
+}
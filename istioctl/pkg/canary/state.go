@@ -0,0 +1,166 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/slices"
+)
+
+const (
+	stateConfigMapPrefix = "istio-canary-upgrade-"
+	stateKey             = "state"
+)
+
+// WaveStatus is the progress of the migration of the namespaces of a wave.
+type WaveStatus string
+
+const (
+	// WavePending is a wave not migrated yet.
+	WavePending WaveStatus = "Pending"
+	// WaveMigrating is a wave whose namespaces are being migrated, or whose migration was interrupted.
+	WaveMigrating WaveStatus = "Migrating"
+	// WaveCompleted is a wave whose proxies all moved to the new revision, and which passed the gates.
+	WaveCompleted WaveStatus = "Completed"
+	// WaveRolledBack is a wave moved back to the revisions it used before the upgrade.
+	WaveRolledBack WaveStatus = "RolledBack"
+)
+
+// State is the progress of a canary upgrade. It is stored in the cluster, so that an interrupted upgrade
+// can be resumed, and its waves rolled back.
+type State struct {
+	Revision string `json:"revision"`
+	// Installed is set once the new revision is installed.
+	Installed bool   `json:"installed"`
+	Waves     []Wave `json:"waves"`
+}
+
+// Wave is a group of namespaces migrated together, or a revision tag moved to the new revision.
+type Wave struct {
+	// Namespaces are the namespaces of the wave. For the wave of a revision tag, they are the namespaces using
+	// the tag when it was moved.
+	Namespaces []string   `json:"namespaces"`
+	Status     WaveStatus `json:"status"`
+	// PreviousLabels are the injection labels of the namespaces before their migration, to roll back the wave.
+	// The namespaces using a revision tag are not migrated by the waves of namespaces, and have no labels.
+	PreviousLabels map[string]map[string]string `json:"previousLabels,omitempty"`
+	// Tag is the revision tag moved by the wave.
+	Tag string `json:"tag,omitempty"`
+	// PreviousRevision is the revision of the tag before it was moved, to roll back the wave.
+	PreviousRevision string `json:"previousRevision,omitempty"`
+}
+
+// targets returns the namespaces moved to the new revision by the wave.
+func (w Wave) targets() []string {
+	if w.Tag != "" {
+		return w.Namespaces
+	}
+	return slices.Filter(w.Namespaces, func(ns string) bool {
+		_, f := w.PreviousLabels[ns]
+		return f
+	})
+}
+
+// String describes the wave.
+func (w Wave) String() string {
+	if w.Tag != "" {
+		return fmt.Sprintf("revision tag %q", w.Tag)
+	}
+	return strings.Join(w.Namespaces, ", ")
+}
+
+// newState returns the state of an upgrade migrating the waves of namespaces, then moving each of the tags
+// in its own wave.
+func newState(revision string, waves [][]string, tags []string) *State {
+	s := &State{Revision: revision}
+	for _, namespaces := range waves {
+		s.Waves = append(s.Waves, Wave{Namespaces: namespaces, Status: WavePending})
+	}
+	for _, tag := range tags {
+		s.Waves = append(s.Waves, Wave{Tag: tag, Status: WavePending})
+	}
+	return s
+}
+
+// sameWaves checks whether the waves of the state are the given waves of namespaces and tags.
+func (s *State) sameWaves(waves [][]string, tags []string) bool {
+	if len(s.Waves) != len(waves)+len(tags) {
+		return false
+	}
+	for i, w := range s.Waves {
+		if i < len(waves) {
+			if w.Tag != "" || !slices.Equal(w.Namespaces, waves[i]) {
+				return false
+			}
+		} else if w.Tag != tags[i-len(waves)] {
+			return false
+		}
+	}
+	return true
+}
+
+func stateConfigMapName(revision string) string {
+	return stateConfigMapPrefix + revision
+}
+
+// LoadState returns the state of the canary upgrade to the revision, or nil if there is none.
+func (u *Upgrader) LoadState() (*State, error) {
+	cm, err := u.Client.Kube().CoreV1().ConfigMaps(u.IstioNamespace).Get(context.TODO(), stateConfigMapName(u.Revision), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the state of the upgrade: %v", err)
+	}
+	s := &State{}
+	if err := json.Unmarshal([]byte(cm.Data[stateKey]), s); err != nil {
+		return nil, fmt.Errorf("invalid state in config map %s/%s: %v", u.IstioNamespace, cm.Name, err)
+	}
+	return s, nil
+}
+
+func (u *Upgrader) saveState(s *State) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	cms := u.Client.Kube().CoreV1().ConfigMaps(u.IstioNamespace)
+	cm, err := cms.Get(context.TODO(), stateConfigMapName(s.Revision), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		_, err = cms.Create(context.TODO(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      stateConfigMapName(s.Revision),
+				Namespace: u.IstioNamespace,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "istioctl"},
+			},
+			Data: map[string]string{stateKey: string(b)},
+		}, metav1.CreateOptions{})
+	} else if err == nil {
+		cm.Data = map[string]string{stateKey: string(b)}
+		_, err = cms.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save the state of the upgrade: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package describe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/api/label"
+	istioclient "istio.io/client-go/pkg/clientset/versioned"
+	"istio.io/istio/istioctl/pkg/util/ambient"
+	ztunnelDump "istio.io/istio/istioctl/pkg/writer/ztunnel/configdump"
+	"istio.io/istio/istioctl/pkg/ztunnelconfig"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	ztunnelAdminPort = 15000
+	hboneProtocol    = "HBONE"
+	healthyStatus    = "Healthy"
+)
+
+// isAmbient returns true if the pod is in the mesh through ztunnel rather than a sidecar.
+func isAmbient(pod *corev1.Pod) bool {
+	return !isMeshed(pod) && ambient.InAmbient(pod)
+}
+
+// getZtunnelConfigDump returns the config dump of the ztunnel on the node of the pod, which enforces
+// the L4 policies on the traffic to the pod, and the name of the ztunnel.
+func getZtunnelConfigDump(kubeClient kube.CLIClient, pod *corev1.Pod, istioNamespace string) (*ztunnelDump.ZtunnelDump, string, error) {
+	ztunnel, err := ztunnelconfig.PodOnNodeFromDaemonset(pod.Spec.NodeName, "ztunnel", istioNamespace, kubeClient)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to find the ztunnel of node %q: %v", pod.Spec.NodeName, err)
+	}
+	ztunnelName := ztunnel.Name + "." + ztunnel.Namespace
+	byConfigDump, err := kubeClient.EnvoyDoWithPort(context.TODO(), ztunnel.Name, ztunnel.Namespace, "GET", "config_dump", ztunnelAdminPort)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to execute command on ztunnel %s: %v", ztunnelName, err)
+	}
+	dump, err := ztunnelDump.ParseZtunnelDump(byConfigDump)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't parse config_dump of ztunnel %s: %v", ztunnelName, err)
+	}
+	return dump, ztunnelName, nil
+}
+
+// describeAmbientPod describes how ztunnel captures the pod and the policies it enforces, then the waypoints
+// of the services of the pod and the policies they enforce.
+func describeAmbientPod(
+	writer io.Writer,
+	kubeClient kube.CLIClient,
+	configClient istioclient.Interface,
+	pod *corev1.Pod,
+	matchingServices []corev1.Service,
+	istioNamespace string,
+) error {
+	dump, ztunnelName, err := getZtunnelConfigDump(kubeClient, pod, istioNamespace)
+	if err != nil {
+		if ignoreUnmeshed {
+			return nil
+		}
+		return err
+	}
+	printZtunnelWorkload(writer, kubeClient, dump, ztunnelName, pod)
+	for _, svc := range matchingServices {
+		fmt.Fprintf(writer, "--------------------\n")
+		if err := describeAmbientService(writer, kubeClient, configClient, dump, svc, pod, istioNamespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describeAmbientServices describes the waypoints of the services and the policies they enforce, then how
+// ztunnel captures the pod, one of the pods of the services, and the policies it enforces.
+func describeAmbientServices(
+	writer io.Writer,
+	kubeClient kube.CLIClient,
+	configClient istioclient.Interface,
+	pod *corev1.Pod,
+	svcs []corev1.Service,
+	istioNamespace string,
+) error {
+	dump, ztunnelName, err := getZtunnelConfigDump(kubeClient, pod, istioNamespace)
+	if err != nil {
+		if ignoreUnmeshed {
+			return nil
+		}
+		return err
+	}
+	for _, svc := range svcs {
+		if err := describeAmbientService(writer, kubeClient, configClient, dump, svc, pod, istioNamespace); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "--------------------\n")
+	}
+	fmt.Fprintf(writer, "Pod: %s\n", kname(pod.ObjectMeta))
+	printZtunnelWorkload(writer, kubeClient, dump, ztunnelName, pod)
+	return nil
+}
+
+// printZtunnelWorkload prints the ztunnel view of the pod: whether it is captured, its waypoint, and the
+// L4 policies ztunnel enforces on its inbound traffic.
+func printZtunnelWorkload(writer io.Writer, kubeClient kube.CLIClient, dump *ztunnelDump.ZtunnelDump, ztunnelName string, pod *corev1.Pod) {
+	fmt.Fprintf(writer, "Ztunnel: %s\n", ztunnelName)
+	wl := dump.Workload(pod.Namespace, pod.Name)
+	if wl == nil {
+		fmt.Fprintf(writer, "   WARNING: ztunnel has no workload for pod %s; its traffic is not captured\n", kname(pod.ObjectMeta))
+		return
+	}
+	fmt.Fprintf(writer, "   Workload Protocol: %s\n", wl.Protocol)
+	if wl.Protocol != hboneProtocol {
+		fmt.Fprintf(writer, "   WARNING: the traffic of the pod is not captured by ztunnel; it is not secured with mTLS\n")
+	}
+	if wl.Status != healthyStatus {
+		fmt.Fprintf(writer, "   WARNING: workload status is %s\n", wl.Status)
+	}
+	fmt.Fprintf(writer, "   Workload Waypoint: %s\n", waypointDescription(kubeClient, dump, wl.Waypoint, pod.ObjectMeta))
+
+	pols := dump.WorkloadPolicies(wl)
+	if len(pols) == 0 {
+		fmt.Fprintf(writer, "   No L4 AuthorizationPolicies enforced by ztunnel\n")
+		return
+	}
+	fmt.Fprintf(writer, "   L4 AuthorizationPolicies enforced by ztunnel:\n")
+	for _, pol := range pols {
+		fmt.Fprintf(writer, "      %s %s.%s (%s)\n", strings.ToUpper(pol.Action), pol.Name, pol.Namespace, pol.Scope)
+	}
+}
+
+// describeAmbientService prints the waypoint of the service, and the L7 policies and routes it applies to
+// the traffic to the service.
+func describeAmbientService(
+	writer io.Writer,
+	kubeClient kube.CLIClient,
+	configClient istioclient.Interface,
+	dump *ztunnelDump.ZtunnelDump,
+	svc corev1.Service,
+	pod *corev1.Pod,
+	rootNamespace string,
+) error {
+	printService(writer, svc, pod)
+	zsvc := dump.Service(svc.Namespace, svc.Name)
+	if zsvc == nil {
+		fmt.Fprintf(writer, "   WARNING: ztunnel has no configuration for service %s\n", kname(svc.ObjectMeta))
+		return nil
+	}
+	fmt.Fprintf(writer, "   Waypoint: %s\n", waypointDescription(kubeClient, dump, zsvc.Waypoint, svc.ObjectMeta))
+	waypoint := dump.WaypointService(zsvc.Waypoint)
+	if waypoint == nil {
+		fmt.Fprintf(writer, "   No waypoint; only L4 policies are enforced\n")
+		return nil
+	}
+
+	policies, err := getWaypointPolicies(configClient, svc, waypoint, rootNamespace)
+	if err != nil {
+		return err
+	}
+	if len(policies) > 0 {
+		fmt.Fprintf(writer, "   L7 AuthorizationPolicies enforced by waypoint: %s\n", strings.Join(policies, ", "))
+	} else {
+		fmt.Fprintf(writer, "   No L7 AuthorizationPolicies enforced by waypoint\n")
+	}
+
+	routes, err := getServiceRoutes(kubeClient, svc)
+	if err != nil {
+		return err
+	}
+	if len(routes) > 0 {
+		fmt.Fprintf(writer, "   HTTPRoutes applied by waypoint: %s\n", strings.Join(routes, ", "))
+	}
+	return nil
+}
+
+// waypointDescription returns the waypoint at the address, and which istio.io/use-waypoint label binds it.
+func waypointDescription(kubeClient kube.CLIClient, dump *ztunnelDump.ZtunnelDump, gw *ztunnelDump.GatewayAddress, meta metav1.ObjectMeta) string {
+	if gw == nil {
+		return "None"
+	}
+	waypoint := dump.WaypointService(gw)
+	if waypoint == nil {
+		return fmt.Sprintf("%s (unknown to ztunnel)", gw.Destination)
+	}
+	name := waypoint.Name + "." + waypoint.Namespace
+	if _, f := meta.Labels[label.IoIstioUseWaypoint.Name]; f {
+		return fmt.Sprintf("%s (bound by %s label)", name, label.IoIstioUseWaypoint.Name)
+	}
+	ns, err := kubeClient.Kube().CoreV1().Namespaces().Get(context.TODO(), meta.Namespace, metav1.GetOptions{})
+	if err == nil {
+		if _, f := ns.Labels[label.IoIstioUseWaypoint.Name]; f {
+			return fmt.Sprintf("%s (bound by %s label of namespace %s)", name, label.IoIstioUseWaypoint.Name, meta.Namespace)
+		}
+	}
+	return name
+}
+
+// getWaypointPolicies returns the AuthorizationPolicies the waypoint enforces on the traffic to the service:
+// the ones targeting the service, the waypoint, or all the waypoints from the root namespace.
+func getWaypointPolicies(
+	configClient istioclient.Interface,
+	svc corev1.Service,
+	waypoint *ztunnelDump.ZtunnelService,
+	rootNamespace string,
+) ([]string, error) {
+	type target struct {
+		namespace, kind, name string
+	}
+	targets := []target{
+		{svc.Namespace, gvk.Service.Kind, svc.Name},
+		{waypoint.Namespace, gvk.KubernetesGateway.Kind, waypoint.Name},
+		{rootNamespace, gvk.GatewayClass.Kind, constants.WaypointGatewayClassName},
+	}
+	namespaces := sets.New(svc.Namespace, waypoint.Namespace, rootNamespace)
+
+	var out []string
+	for _, ns := range sets.SortedList(namespaces) {
+		pols, err := configClient.SecurityV1().AuthorizationPolicies(ns).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch AuthorizationPolicies of namespace %s: %v", ns, err)
+		}
+		for _, pol := range pols.Items {
+			for _, ref := range model.GetTargetRefs(&pol.Spec) {
+				refNamespace := ref.GetNamespace()
+				if refNamespace == "" {
+					refNamespace = pol.Namespace
+				}
+				matched := false
+				for _, t := range targets {
+					if ref.GetKind() == t.kind && ref.GetName() == t.name && t.namespace == refNamespace {
+						matched = true
+						break
+					}
+				}
+				if matched {
+					out = append(out, fmt.Sprintf("%s.%s (%s)", pol.Name, pol.Namespace, pol.Spec.GetAction()))
+					break
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// getServiceRoutes returns the HTTPRoutes attached to the service, which its waypoint applies.
+func getServiceRoutes(kubeClient kube.CLIClient, svc corev1.Service) ([]string, error) {
+	routes, err := kubeClient.GatewayAPI().GatewayV1().HTTPRoutes(svc.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch HTTPRoutes of namespace %s: %v", svc.Namespace, err)
+	}
+	var out []string
+	for _, route := range routes.Items {
+		for _, ref := range route.Spec.ParentRefs {
+			if ref.Kind == nil || string(*ref.Kind) != gvk.Service.Kind || string(ref.Name) != svc.Name {
+				continue
+			}
+			if ref.Namespace != nil && string(*ref.Namespace) != svc.Namespace {
+				continue
+			}
+			out = append(out, route.Name+"."+route.Namespace)
+			break
+		}
+	}
+	return out, nil
+}
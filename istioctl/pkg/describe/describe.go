@@ -106,7 +106,10 @@ func podDescribeCmd(ctx cli.Context) *cobra.Command {
 		Aliases: []string{"po"},
 		Short:   "Describe pods and their Istio configuration [kube-only]",
 		Long: `Analyzes pod, its Services, DestinationRules, and VirtualServices and reports
-the configuration objects that affect that pod.`,
+the configuration objects that affect that pod.
+
+For pods in ambient mode, reports how the ztunnel of their node captures them, the L4 AuthorizationPolicies
+it enforces, and the waypoints of their Services with the L7 AuthorizationPolicies and HTTPRoutes they apply.`,
 		Example: `  istioctl experimental describe pod productpage-v1-c7765c886-7zzd4`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			describeNamespace = ctx.NamespaceOrDefault(ctx.Namespace())
@@ -164,7 +167,11 @@ the configuration objects that affect that pod.`,
 
 			podsLabels := []klabels.Set{klabels.Set(pod.ObjectMeta.Labels)}
 			fmt.Fprintf(writer, "--------------------\n")
-			err = describePodServices(writer, kubeClient, configClient, pod, matchingServices, podsLabels)
+			if isAmbient(pod) {
+				err = describeAmbientPod(writer, kubeClient, configClient, pod, matchingServices, ctx.IstioNamespace())
+			} else {
+				err = describePodServices(writer, kubeClient, configClient, pod, matchingServices, podsLabels)
+			}
 			if err != nil {
 				return err
 			}
@@ -530,13 +537,13 @@ func printPod(writer io.Writer, pod *corev1.Pod, revision string) {
 		return
 	}
 
-	if !isMeshed(pod) {
+	if isAmbient(pod) {
+		fmt.Fprintf(writer, "   Pod is in ambient mode; its traffic is captured by ztunnel\n")
+	} else if !isMeshed(pod) {
 		fmt.Fprintf(writer, "WARNING: %s is not part of mesh; no Istio sidecar\n", kname(pod.ObjectMeta))
 		return
-	}
-
-	// Ref: https://istio.io/latest/docs/ops/deployment/requirements/#pod-requirements
-	if pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.RunAsUser != nil {
+	} else if pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.RunAsUser != nil {
+		// Ref: https://istio.io/latest/docs/ops/deployment/requirements/#pod-requirements
 		if *pod.Spec.SecurityContext.RunAsUser == UserID {
 			fmt.Fprintf(writer, "   WARNING: User ID (UID) 1337 is reserved for the sidecar proxy.\n")
 		}
@@ -1201,7 +1208,10 @@ func svcDescribeCmd(ctx cli.Context) *cobra.Command {
 		Aliases: []string{"svc"},
 		Short:   "Describe services and their Istio configuration [kube-only]",
 		Long: `Analyzes service, pods, DestinationRules, and VirtualServices and reports
-the configuration objects that affect that service.`,
+the configuration objects that affect that service.
+
+For services of pods in ambient mode, reports the waypoint of the service with the L7 AuthorizationPolicies
+and HTTPRoutes it applies, and the L4 AuthorizationPolicies the ztunnel of the first pod enforces.`,
 		Example: `  istioctl experimental describe service productpage`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
@@ -1246,6 +1256,10 @@ the configuration objects that affect that service.`,
 						continue
 					}
 
+					if isAmbient(&pod) {
+						matchingPods = append(matchingPods, pod)
+						continue
+					}
 					ready, err := containerReady(&pod, inject.ProxyContainerName)
 					if err != nil {
 						fmt.Fprintf(writer, "Pod %s: %s\n", kname(pod.ObjectMeta), err)
@@ -1289,7 +1303,11 @@ the configuration objects that affect that service.`,
 			// Only consider the service invoked with this command, not other services that might select the pod
 			svcs := []corev1.Service{*svc}
 
-			err = describePodServices(writer, kubeClient, configClient, &pod, svcs, podsLabels)
+			if isAmbient(&pod) {
+				err = describeAmbientServices(writer, kubeClient, configClient, &pod, svcs, ctx.IstioNamespace())
+			} else {
+				err = describePodServices(writer, kubeClient, configClient, &pod, svcs, podsLabels)
+			}
 			if err != nil {
 				return err
 			}
@@ -23,18 +23,24 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	klabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	apiannotation "istio.io/api/annotation"
 	networking "istio.io/api/networking/v1alpha3"
+	security "istio.io/api/security/v1beta1"
+	typev1beta1 "istio.io/api/type/v1beta1"
 	clientnetworking "istio.io/client-go/pkg/apis/networking/v1"
+	clientsecurity "istio.io/client-go/pkg/apis/security/v1"
 	"istio.io/istio/istioctl/pkg/cli"
 	"istio.io/istio/istioctl/pkg/util/configdump"
 	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/ptr"
 	"istio.io/istio/pkg/test/util/assert"
 )
 
@@ -62,6 +68,11 @@ func TestDescribe(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to read %s: %v", productPageConfigPath, err)
 	}
+	ztunnelConfigPath := "testdata/describe/ztunnel_config.json"
+	ztunnelConfig, err := os.ReadFile(ztunnelConfigPath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", ztunnelConfigPath, err)
+	}
 	cases := []execAndK8sConfigTestCase{
 		{ // case 0
 			args:           []string{},
@@ -956,6 +967,163 @@ VirtualService: bookinfo
    Route to host "productpage2" with weight 20%
    Route to host "productpage3" with weight 50%
    Match: /prefix*
+`,
+		},
+		// ambient service with a waypoint
+		{
+			k8sConfigs: []runtime.Object{
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "reviews",
+						Namespace: "default",
+						Labels: map[string]string{
+							"istio.io/use-waypoint": "waypoint",
+						},
+					},
+					Spec: corev1.ServiceSpec{
+						Selector: map[string]string{
+							"app": "reviews",
+						},
+						Ports: []corev1.ServicePort{
+							{
+								Name:       "http",
+								Port:       9080,
+								Protocol:   corev1.ProtocolTCP,
+								TargetPort: intstr.FromInt32(9080),
+							},
+						},
+					},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "reviews-v1-1234567890",
+						Namespace: "default",
+						Labels: map[string]string{
+							"app": "reviews",
+						},
+						Annotations: map[string]string{
+							apiannotation.AmbientRedirection.Name: "enabled",
+						},
+					},
+					Spec: corev1.PodSpec{
+						NodeName: "node-1",
+						Containers: []corev1.Container{
+							{
+								Name: "reviews",
+								Ports: []corev1.ContainerPort{
+									{
+										Name:          "http",
+										ContainerPort: 9080,
+									},
+								},
+							},
+						},
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+					},
+				},
+				&appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "ztunnel",
+						Namespace: "istio-system",
+					},
+					Spec: appsv1.DaemonSetSpec{
+						Selector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"app": "ztunnel"},
+						},
+					},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "ztunnel-abcde",
+						Namespace: "istio-system",
+						Labels: map[string]string{
+							"app": "ztunnel",
+						},
+					},
+					Spec: corev1.PodSpec{
+						NodeName: "node-1",
+					},
+					Status: corev1.PodStatus{
+						Phase: corev1.PodRunning,
+					},
+				},
+			},
+			istioConfigs: []runtime.Object{
+				&clientsecurity.AuthorizationPolicy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "allow-get",
+						Namespace: "default",
+					},
+					Spec: security.AuthorizationPolicy{
+						TargetRefs: []*typev1beta1.PolicyTargetReference{
+							{
+								Group: "gateway.networking.k8s.io",
+								Kind:  "Gateway",
+								Name:  "waypoint",
+							},
+						},
+						Rules: []*security.Rule{
+							{
+								To: []*security.Rule_To{{Operation: &security.Operation{Methods: []string{"GET"}}}},
+							},
+						},
+					},
+				},
+				&clientsecurity.AuthorizationPolicy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "deny-ratings",
+						Namespace: "default",
+					},
+					Spec: security.AuthorizationPolicy{
+						TargetRefs: []*typev1beta1.PolicyTargetReference{
+							{
+								Kind: "Service",
+								Name: "ratings",
+							},
+						},
+						Action: security.AuthorizationPolicy_DENY,
+					},
+				},
+				&gatewayv1.HTTPRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "reviews",
+						Namespace: "default",
+					},
+					Spec: gatewayv1.HTTPRouteSpec{
+						CommonRouteSpec: gatewayv1.CommonRouteSpec{
+							ParentRefs: []gatewayv1.ParentReference{
+								{
+									Group: ptr.Of(gatewayv1.Group("")),
+									Kind:  ptr.Of(gatewayv1.Kind("Service")),
+									Name:  "reviews",
+								},
+							},
+						},
+					},
+				},
+			},
+			configDumps: map[string][]byte{
+				"ztunnel-abcde": ztunnelConfig,
+			},
+			namespace:      "default",
+			istioNamespace: "istio-system",
+			args:           strings.Split("service reviews", " "),
+			expectedOutput: `Service: reviews
+   Port: http 9080/HTTP targets pod port 9080
+   Waypoint: waypoint.default (bound by istio.io/use-waypoint label)
+   L7 AuthorizationPolicies enforced by waypoint: allow-get.default (ALLOW)
+   HTTPRoutes applied by waypoint: reviews.default
+--------------------
+Pod: reviews-v1-1234567890
+Ztunnel: ztunnel-abcde.istio-system
+   Workload Protocol: HBONE
+   Workload Waypoint: None
+   L4 AuthorizationPolicies enforced by ztunnel:
+      DENY deny-external.istio-system (Global)
+      ALLOW allow-productpage.default (WorkloadSelector)
+Skipping Gateway information (no ingress gateway pods)
 `,
 		},
 	}
@@ -1096,6 +1264,10 @@ func verifyExecAndK8sConfigTestCaseTestOutput(t *testing.T, c execAndK8sConfigTe
 			client.Istio().NetworkingV1().Gateways(t.Namespace).Create(context.TODO(), t, metav1.CreateOptions{})
 		case *clientnetworking.VirtualService:
 			client.Istio().NetworkingV1().VirtualServices(t.Namespace).Create(context.TODO(), t, metav1.CreateOptions{})
+		case *clientsecurity.AuthorizationPolicy:
+			client.Istio().SecurityV1().AuthorizationPolicies(t.Namespace).Create(context.TODO(), t, metav1.CreateOptions{})
+		case *gatewayv1.HTTPRoute:
+			client.GatewayAPI().GatewayV1().HTTPRoutes(t.Namespace).Create(context.TODO(), t, metav1.CreateOptions{})
 		}
 	}
 	for i := range c.k8sConfigs {
@@ -1104,6 +1276,8 @@ func verifyExecAndK8sConfigTestCaseTestOutput(t *testing.T, c execAndK8sConfigTe
 			client.Kube().CoreV1().Services(t.Namespace).Create(context.TODO(), t, metav1.CreateOptions{})
 		case *corev1.Pod:
 			client.Kube().CoreV1().Pods(t.Namespace).Create(context.TODO(), t, metav1.CreateOptions{})
+		case *appsv1.DaemonSet:
+			client.Kube().AppsV1().DaemonSets(t.Namespace).Create(context.TODO(), t, metav1.CreateOptions{})
 		}
 	}
 
@@ -33,12 +33,14 @@ import (
 	"istio.io/istio/istioctl/pkg/clioptions"
 	"istio.io/istio/istioctl/pkg/completion"
 	"istio.io/istio/istioctl/pkg/dashboard"
+	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/log"
 )
 
 var (
 	metricsOpts     clioptions.ControlPlaneOptions
 	metricsDuration time.Duration
+	metricsSource   string
 )
 
 const (
@@ -65,6 +67,13 @@ All metrics returned are from server-side reports. This means that latencies
 and error rates are from the perspective of the service itself and not of an
 individual client (or aggregate set of clients). Rates and latencies are
 calculated over a time interval of 1 minute.
+
+With --source proxy, Prometheus is not needed: the stats of the sidecars
+of the workload pods are scraped at the start and the end of the interval,
+and the metrics are computed from their deltas. For ambient pods, the
+ztunnels of their nodes are scraped instead. ztunnel only reports TCP
+connections, so their rate is reported as the total RPS and the error rate
+and latencies are not available.
 `,
 		Example: `  # Retrieve workload metrics for productpage-v1 workload
   istioctl experimental metrics productpage-v1
@@ -73,7 +82,10 @@ calculated over a time interval of 1 minute.
   istioctl experimental metrics productpage-v1 -d 2m
 
   # Retrieve workload metrics for various services in the different namespaces
-  istioctl experimental metrics productpage-v1.foo reviews-v1.bar ratings-v1.baz`,
+  istioctl experimental metrics productpage-v1.foo reviews-v1.bar ratings-v1.baz
+
+  # Retrieve workload metrics from the stats of its proxies over 30 seconds, without Prometheus
+  istioctl experimental metrics productpage-v1 --source proxy -d 30s`,
 		// nolint: goimports
 		Aliases: []string{"m"},
 		Args: func(cmd *cobra.Command, args []string) error {
@@ -91,6 +103,8 @@ calculated over a time interval of 1 minute.
 	}
 
 	cmd.PersistentFlags().DurationVarP(&metricsDuration, "duration", "d", time.Minute, "Duration of query metrics, default value is 1m.")
+	cmd.PersistentFlags().StringVar(&metricsSource, "source", prometheusSource,
+		"Source of the metrics: prometheus, or proxy to scrape the stats of the proxies of the workloads directly")
 
 	return cmd
 }
@@ -109,6 +123,14 @@ func run(c *cobra.Command, ctx cli.Context, args []string) error {
 		return fmt.Errorf("failed to create k8s client: %v", err)
 	}
 
+	switch metricsSource {
+	case prometheusSource:
+	case proxySource:
+		return runProxies(c, ctx, client, args)
+	default:
+		return fmt.Errorf("unknown metrics source %q, expected %s or %s", metricsSource, prometheusSource, proxySource)
+	}
+
 	pl, err := client.PodsForSelector(context.TODO(), ctx.IstioNamespace(), "app.kubernetes.io/name=prometheus")
 	if err != nil {
 		return fmt.Errorf("not able to locate Prometheus pod: %v", err)
@@ -154,6 +176,29 @@ func run(c *cobra.Command, ctx cli.Context, args []string) error {
 	return nil
 }
 
+func runProxies(c *cobra.Command, ctx cli.Context, client kube.CLIClient, workloads []string) error {
+	if metricsDuration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	var targets []workloadTargets
+	for _, workload := range workloads {
+		wt, err := proxyTargets(client, workload, ctx.NamespaceOrDefault(ctx.Namespace()), ctx.IstioNamespace())
+		if err != nil {
+			return fmt.Errorf("could not find the proxies of workload '%s': %v", workload, err)
+		}
+		targets = append(targets, wt)
+	}
+	ms, err := newProxyScraper(client).metrics(targets, metricsDuration)
+	if err != nil {
+		return err
+	}
+	printHeader(c.OutOrStdout())
+	for _, sm := range ms {
+		printMetrics(c.OutOrStdout(), sm)
+	}
+	return nil
+}
+
 func prometheusAPI(address string) (promv1.API, error) {
 	promClient, err := api.NewClient(api.Config{Address: address})
 	if err != nil {
@@ -0,0 +1,336 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/istioctl/pkg/util/ambient"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/inject"
+	"istio.io/istio/pkg/log"
+)
+
+const (
+	prometheusSource = "prometheus"
+	proxySource      = "proxy"
+
+	tcpOpened = "istio_tcp_connections_opened_total"
+
+	// The Envoy admin endpoint serving the stats of a sidecar, including the Istio metrics.
+	envoyAdminPort    = 15000
+	envoyStatsPath    = "stats/prometheus"
+	ztunnelStatsPort  = 15020
+	ztunnelStatsPath  = "metrics"
+	ztunnelPodLabel   = "app=ztunnel"
+	destinationReport = "destination"
+)
+
+var errorCode = regexp.MustCompile(`^[45][0-9]{2}$`)
+
+// scrapeTarget is a proxy whose stats are scraped.
+type scrapeTarget struct {
+	pod, namespace string
+	port           int
+	path           string
+	// ztunnel is true for the ztunnel of the node of the workload, which only reports TCP connections.
+	ztunnel bool
+}
+
+func (t scrapeTarget) String() string {
+	return t.pod + "." + t.namespace
+}
+
+// workloadTargets are the proxies reporting the server side metrics of a workload.
+type workloadTargets struct {
+	workload        string
+	name, namespace string
+	targets         []scrapeTarget
+}
+
+// proxyStats are the cumulative stats of the requests to a workload reported by a proxy.
+type proxyStats struct {
+	requests, errors float64
+	// buckets are the cumulative counts of the request duration histogram, by upper bound in milliseconds.
+	buckets map[float64]float64
+}
+
+// sub returns the stats since before. Proxies restarting reset their stats.
+func (s proxyStats) sub(before proxyStats) proxyStats {
+	if s.requests < before.requests {
+		return s
+	}
+	out := proxyStats{requests: s.requests - before.requests, errors: s.errors - before.errors, buckets: map[float64]float64{}}
+	for le, count := range s.buckets {
+		out.buckets[le] = count - before.buckets[le]
+	}
+	return out
+}
+
+func (s *proxyStats) add(other proxyStats) {
+	s.requests += other.requests
+	s.errors += other.errors
+	if s.buckets == nil {
+		s.buckets = map[float64]float64{}
+	}
+	for le, count := range other.buckets {
+		s.buckets[le] += count
+	}
+}
+
+// proxyScraper computes the metrics of workloads from the stats of their proxies, instead of querying Prometheus.
+type proxyScraper struct {
+	scrape func(t scrapeTarget) ([]byte, error)
+	sleep  func(d time.Duration)
+}
+
+func newProxyScraper(client kube.CLIClient) *proxyScraper {
+	return &proxyScraper{
+		scrape: func(t scrapeTarget) ([]byte, error) {
+			return client.EnvoyDoWithPort(context.Background(), t.pod, t.namespace, "GET", t.path, t.port)
+		},
+		sleep: time.Sleep,
+	}
+}
+
+// metrics returns the metrics of the workloads over the window, from the deltas of the stats of their proxies
+// scraped at the start and the end of the window.
+func (p *proxyScraper) metrics(workloads []workloadTargets, window time.Duration) ([]workloadMetrics, error) {
+	targets := map[scrapeTarget]bool{}
+	for _, w := range workloads {
+		for _, t := range w.targets {
+			targets[t] = true
+		}
+	}
+	before, err := p.scrapeAll(targets)
+	if err != nil {
+		return nil, err
+	}
+	p.sleep(window)
+	after, err := p.scrapeAll(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []workloadMetrics
+	for _, w := range workloads {
+		total := proxyStats{}
+		for _, t := range w.targets {
+			total.add(workloadStats(after[t], w.name, w.namespace, t.ztunnel).sub(workloadStats(before[t], w.name, w.namespace, t.ztunnel)))
+		}
+		seconds := window.Seconds()
+		out = append(out, workloadMetrics{
+			workload:   w.workload,
+			totalRPS:   total.requests / seconds,
+			errorRPS:   total.errors / seconds,
+			p50Latency: convertLatencyToDuration(histogramQuantile(0.5, total.buckets)),
+			p90Latency: convertLatencyToDuration(histogramQuantile(0.9, total.buckets)),
+			p99Latency: convertLatencyToDuration(histogramQuantile(0.99, total.buckets)),
+		})
+	}
+	return out, nil
+}
+
+func (p *proxyScraper) scrapeAll(targets map[scrapeTarget]bool) (map[scrapeTarget]map[string]*dto.MetricFamily, error) {
+	out := map[scrapeTarget]map[string]*dto.MetricFamily{}
+	for t := range targets {
+		b, err := p.scrape(t)
+		if err != nil {
+			return nil, fmt.Errorf("could not scrape the stats of %s: %v", t, err)
+		}
+		parser := expfmt.TextParser{}
+		families, err := parser.TextToMetricFamilies(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the stats of %s: %v", t, err)
+		}
+		out[t] = families
+	}
+	return out, nil
+}
+
+// workloadStats returns the stats of the requests to the workload reported by a proxy. Like the Prometheus queries,
+// the workload name and namespace are prefixes. ztunnel only reports TCP connections, which are counted as requests.
+func workloadStats(families map[string]*dto.MetricFamily, name, namespace string, ztunnel bool) proxyStats {
+	out := proxyStats{buckets: map[float64]float64{}}
+	matches := func(m *dto.Metric) bool {
+		labels := map[string]string{}
+		for _, l := range m.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+		return labels["reporter"] == destinationReport &&
+			strings.HasPrefix(labels[destWorkloadLabel], name) &&
+			strings.HasPrefix(labels[destWorkloadNamespaceLabel], namespace)
+	}
+	if ztunnel {
+		for _, m := range families[tcpOpened].GetMetric() {
+			if matches(m) {
+				out.requests += counterValue(m)
+			}
+		}
+		return out
+	}
+	for _, m := range families[reqTot].GetMetric() {
+		if !matches(m) {
+			continue
+		}
+		out.requests += counterValue(m)
+		for _, l := range m.GetLabel() {
+			if l.GetName() == "response_code" && errorCode.MatchString(l.GetValue()) {
+				out.errors += counterValue(m)
+			}
+		}
+	}
+	for _, m := range families[reqDur].GetMetric() {
+		if !matches(m) {
+			continue
+		}
+		inf := false
+		for _, b := range m.GetHistogram().GetBucket() {
+			out.buckets[b.GetUpperBound()] += float64(b.GetCumulativeCount())
+			inf = inf || math.IsInf(b.GetUpperBound(), 1)
+		}
+		if !inf {
+			out.buckets[math.Inf(1)] += float64(m.GetHistogram().GetSampleCount())
+		}
+	}
+	return out
+}
+
+// counterValue returns the value of a counter. The counters of the OpenMetrics format of ztunnel are untyped for
+// the Prometheus text parser, as their samples have a _total suffix unlike their family.
+func counterValue(m *dto.Metric) float64 {
+	if m.GetCounter() != nil {
+		return m.GetCounter().GetValue()
+	}
+	return m.GetUntyped().GetValue()
+}
+
+// histogramQuantile estimates the quantile from cumulative histogram buckets the way Prometheus histogram_quantile
+// does, interpolating linearly within the bucket of the quantile.
+func histogramQuantile(q float64, buckets map[float64]float64) float64 {
+	if len(buckets) == 0 {
+		return 0
+	}
+	bounds := make([]float64, 0, len(buckets))
+	for le := range buckets {
+		bounds = append(bounds, le)
+	}
+	sort.Float64s(bounds)
+	total := buckets[bounds[len(bounds)-1]]
+	if total == 0 {
+		return 0
+	}
+	rank := q * total
+	for i, le := range bounds {
+		count := buckets[le]
+		if count < rank {
+			continue
+		}
+		if math.IsInf(le, 1) {
+			// The quantile is above the highest finite bucket, which is the best estimation.
+			if i == 0 {
+				return 0
+			}
+			return bounds[i-1]
+		}
+		lower, lowerCount := 0.0, 0.0
+		if i > 0 {
+			lower, lowerCount = bounds[i-1], buckets[bounds[i-1]]
+		}
+		if count == lowerCount {
+			return le
+		}
+		return lower + (le-lower)*(rank-lowerCount)/(count-lowerCount)
+	}
+	return bounds[len(bounds)-1]
+}
+
+// proxyTargets returns the proxies reporting the server side metrics of the workload, `<name>[.<namespace>]`: the
+// sidecars of its pods, or the ztunnels of their nodes for ambient pods.
+func proxyTargets(client kube.CLIClient, workload, defaultNamespace, istioNamespace string) (workloadTargets, error) {
+	name, namespace, _ := strings.Cut(workload, ".")
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	wt := workloadTargets{workload: workload, name: name, namespace: namespace}
+	pods, err := client.Kube().CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return wt, err
+	}
+	var ztunnels map[string]string
+	seen := map[scrapeTarget]bool{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if deployMeta, _ := kube.GetDeployMetaFromPod(pod); !strings.HasPrefix(deployMeta.Name, name) {
+			continue
+		}
+		var t scrapeTarget
+		switch {
+		case inject.FindSidecar(pod) != nil:
+			t = scrapeTarget{pod: pod.Name, namespace: pod.Namespace, port: envoyAdminPort, path: envoyStatsPath}
+		case ambient.InAmbient(pod):
+			if ztunnels == nil {
+				if ztunnels, err = ztunnelsByNode(client, istioNamespace); err != nil {
+					return wt, err
+				}
+			}
+			ztunnel, f := ztunnels[pod.Spec.NodeName]
+			if !f {
+				return wt, fmt.Errorf("no ztunnel found on node %s of pod %s.%s", pod.Spec.NodeName, pod.Name, pod.Namespace)
+			}
+			t = scrapeTarget{pod: ztunnel, namespace: istioNamespace, port: ztunnelStatsPort, path: ztunnelStatsPath, ztunnel: true}
+		default:
+			log.Debugf("skipping pod %s.%s without proxy", pod.Name, pod.Namespace)
+			continue
+		}
+		if !seen[t] {
+			seen[t] = true
+			wt.targets = append(wt.targets, t)
+		}
+	}
+	if len(wt.targets) == 0 {
+		return wt, fmt.Errorf("no running pods with a proxy found for workload %s in namespace %s", name, namespace)
+	}
+	return wt, nil
+}
+
+func ztunnelsByNode(client kube.CLIClient, istioNamespace string) (map[string]string, error) {
+	pods, err := client.Kube().CoreV1().Pods(istioNamespace).List(context.Background(), metav1.ListOptions{LabelSelector: ztunnelPodLabel})
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			out[pod.Spec.NodeName] = pod.Name
+		}
+	}
+	return out, nil
+}
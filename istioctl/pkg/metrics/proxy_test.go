@@ -0,0 +1,189 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"istio.io/api/annotation"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets := map[float64]float64{1: 10, 5: 50, 10: 90, 25: 100, math.Inf(1): 100}
+	assert.Equal(t, histogramQuantile(0.5, buckets), 5.0)
+	assert.Equal(t, histogramQuantile(0.75, buckets), 8.125)
+	assert.Equal(t, histogramQuantile(0.1, buckets), 1.0)
+	assert.Equal(t, histogramQuantile(0.05, buckets), 0.5)
+	assert.Equal(t, histogramQuantile(0.99, buckets), 23.5)
+	// Above the highest finite bucket.
+	assert.Equal(t, histogramQuantile(0.99, map[float64]float64{1: 10, math.Inf(1): 20}), 1.0)
+	assert.Equal(t, histogramQuantile(0.5, map[float64]float64{}), 0.0)
+	assert.Equal(t, histogramQuantile(0.5, map[float64]float64{1: 0, math.Inf(1): 0}), 0.0)
+}
+
+// envoyStats returns the stats of a sidecar, as served by Envoy, with the requests to details and a request to
+// another workload.
+func envoyStats(ok, failed int, buckets [3]int) string {
+	labels := `reporter="destination",destination_workload="details-v1",destination_workload_namespace="default"`
+	return strings.Join([]string{
+		"# TYPE istio_requests_total counter",
+		fmt.Sprintf(`istio_requests_total{response_code="200",%s} %d`, labels, ok),
+		fmt.Sprintf(`istio_requests_total{response_code="503",%s} %d`, labels, failed),
+		`istio_requests_total{response_code="200",reporter="destination",destination_workload="ratings-v1",destination_workload_namespace="default"} 1000`,
+		`istio_requests_total{response_code="200",reporter="source",destination_workload="details-v1",destination_workload_namespace="default"} 1000`,
+		"# TYPE istio_request_duration_milliseconds histogram",
+		fmt.Sprintf(`istio_request_duration_milliseconds_bucket{%s,le="5"} %d`, labels, buckets[0]),
+		fmt.Sprintf(`istio_request_duration_milliseconds_bucket{%s,le="10"} %d`, labels, buckets[1]),
+		fmt.Sprintf(`istio_request_duration_milliseconds_bucket{%s,le="+Inf"} %d`, labels, buckets[2]),
+		fmt.Sprintf(`istio_request_duration_milliseconds_sum{%s} 0`, labels),
+		fmt.Sprintf(`istio_request_duration_milliseconds_count{%s} %d`, labels, buckets[2]),
+		"# TYPE envoy_server_uptime gauge",
+		"envoy_server_uptime{} 100",
+		"",
+	}, "\n")
+}
+
+// ztunnelStats returns the stats of a ztunnel, in the OpenMetrics format it serves.
+func ztunnelStats(opened int) string {
+	return strings.Join([]string{
+		"# HELP istio_tcp_connections_opened The total number of TCP connections opened.",
+		"# TYPE istio_tcp_connections_opened counter",
+		fmt.Sprintf(`istio_tcp_connections_opened_total{reporter="destination",destination_workload="reviews-v1",`+
+			`destination_workload_namespace="default"} %d`, opened),
+		"# EOF",
+		"",
+	}, "\n")
+}
+
+func TestProxyScraperMetrics(t *testing.T) {
+	sidecar := scrapeTarget{pod: "details-v1-1", namespace: "default", port: envoyAdminPort, path: envoyStatsPath}
+	ztunnel := scrapeTarget{pod: "ztunnel-1", namespace: "istio-system", port: ztunnelStatsPort, path: ztunnelStatsPath, ztunnel: true}
+	stats := map[scrapeTarget]string{
+		sidecar: envoyStats(100, 10, [3]int{50, 100, 110}),
+		ztunnel: ztunnelStats(5),
+	}
+	slept := time.Duration(0)
+	p := &proxyScraper{
+		scrape: func(t scrapeTarget) ([]byte, error) {
+			return []byte(stats[t]), nil
+		},
+		sleep: func(d time.Duration) {
+			slept = d
+			stats[sidecar] = envoyStats(280, 30, [3]int{150, 280, 310})
+			stats[ztunnel] = ztunnelStats(65)
+		},
+	}
+	ms, err := p.metrics([]workloadTargets{
+		{workload: "details", name: "details", namespace: "default", targets: []scrapeTarget{sidecar}},
+		{workload: "reviews", name: "reviews", namespace: "default", targets: []scrapeTarget{ztunnel}},
+	}, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, slept, time.Minute)
+
+	var out bytes.Buffer
+	printHeader(&out)
+	for _, sm := range ms {
+		printMetrics(&out, sm)
+	}
+	// 200 requests in 1m, 20 of them failed. Of their durations, 100 are below 5ms and 180 below 10ms.
+	expectedOutput := `                                  WORKLOAD    TOTAL RPS    ERROR RPS  P50 LATENCY  P90 LATENCY  P99 LATENCY
+                                   details        3.333        0.333          5ms         10ms         10ms
+                                   reviews        1.000        0.000           0s           0s           0s
+`
+	assert.Equal(t, out.String(), expectedOutput)
+
+	p.scrape = func(t scrapeTarget) ([]byte, error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+	_, err = p.metrics([]workloadTargets{{workload: "details", targets: []scrapeTarget{sidecar}}}, time.Minute)
+	assert.Error(t, err)
+}
+
+func workloadPod(name, node string, sidecar, ambient bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:         name + "-5f8d7c6b9d-x2x7q",
+			GenerateName: name + "-5f8d7c6b9d-",
+			Namespace:    "default",
+			Labels:       map[string]string{"pod-template-hash": "5f8d7c6b9d"},
+			Annotations:  map[string]string{},
+			OwnerReferences: []metav1.OwnerReference{{
+				Kind:       "ReplicaSet",
+				Name:       name + "-5f8d7c6b9d",
+				Controller: ptr.Of(true),
+			}},
+		},
+		Spec:   corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Name: "app"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if sidecar {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "istio-proxy"})
+	}
+	if ambient {
+		pod.Annotations[annotation.AmbientRedirection.Name] = "enabled"
+	}
+	return pod
+}
+
+func assertTargets(t *testing.T, got []scrapeTarget, want ...scrapeTarget) {
+	t.Helper()
+	if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", want) {
+		t.Fatalf("unexpected targets: got %+v, want %+v", got, want)
+	}
+}
+
+func TestProxyTargets(t *testing.T) {
+	ztunnel := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ztunnel-abcde", Namespace: "istio-system", Labels: map[string]string{"app": "ztunnel"}},
+		Spec:       corev1.PodSpec{NodeName: "node-1"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	client := kube.NewFakeClient([]runtime.Object{
+		ztunnel,
+		workloadPod("details-v1", "node-1", true, false),
+		workloadPod("reviews-v1", "node-1", false, true),
+		workloadPod("reviews-v2", "node-2", false, true),
+		workloadPod("ratings-v1", "node-1", false, false),
+	}...)
+
+	wt, err := proxyTargets(client, "details-v1", "default", "istio-system")
+	assert.NoError(t, err)
+	assertTargets(t, wt.targets, scrapeTarget{pod: "details-v1-5f8d7c6b9d-x2x7q", namespace: "default", port: envoyAdminPort, path: envoyStatsPath})
+
+	wt, err = proxyTargets(client, "reviews-v1.default", "other", "istio-system")
+	assert.NoError(t, err)
+	assert.Equal(t, wt.name, "reviews-v1")
+	assert.Equal(t, wt.namespace, "default")
+	assertTargets(t, wt.targets, scrapeTarget{pod: "ztunnel-abcde", namespace: "istio-system", port: ztunnelStatsPort, path: ztunnelStatsPath, ztunnel: true})
+
+	// There is no ztunnel on node-2.
+	_, err = proxyTargets(client, "reviews", "default", "istio-system")
+	assert.Error(t, err)
+	// ratings has no proxy.
+	_, err = proxyTargets(client, "ratings-v1", "default", "istio-system")
+	assert.Error(t, err)
+}
@@ -627,6 +627,24 @@ func (o *RemoteSecretOptions) prepare(ctx cli.Context) error {
 	return nil
 }
 
+// applySecretTypeDefaults defaults the service account for the type of the secret, and returns the name of the secret.
+func (o *RemoteSecretOptions) applySecretTypeDefaults() (string, error) {
+	switch o.Type {
+	case SecretTypeRemote:
+		if o.ServiceAccountName == "" {
+			o.ServiceAccountName = constants.DefaultServiceAccountName
+		}
+		return remoteSecretNameFromClusterName(o.ClusterName), nil
+	case SecretTypeConfig:
+		if o.ServiceAccountName == "" {
+			o.ServiceAccountName = constants.DefaultConfigServiceAccountName
+		}
+		return configSecretName, nil
+	default:
+		return "", fmt.Errorf("unsupported type: %v", o.Type)
+	}
+}
+
 type Warning error
 
 func createRemoteSecret(opt RemoteSecretOptions, client kube.CLIClient) (*v1.Secret, Warning, error) {
@@ -639,20 +657,9 @@ func createRemoteSecret(opt RemoteSecretOptions, client kube.CLIClient) (*v1.Sec
 		opt.ClusterName = string(uid)
 	}
 
-	var secretName string
-	switch opt.Type {
-	case SecretTypeRemote:
-		secretName = remoteSecretNameFromClusterName(opt.ClusterName)
-		if opt.ServiceAccountName == "" {
-			opt.ServiceAccountName = constants.DefaultServiceAccountName
-		}
-	case SecretTypeConfig:
-		secretName = configSecretName
-		if opt.ServiceAccountName == "" {
-			opt.ServiceAccountName = constants.DefaultConfigServiceAccountName
-		}
-	default:
-		return nil, nil, fmt.Errorf("unsupported type: %v", opt.Type)
+	secretName, err := opt.applySecretTypeDefaults()
+	if err != nil {
+		return nil, nil, err
 	}
 	tokenSecret, err := getServiceAccountSecret(client, opt)
 	if err != nil {
@@ -0,0 +1,367 @@
+// Copyright Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// clusterSyncPollInterval is the interval between the checks of the sync status of the rotated cluster in istiod.
+var clusterSyncPollInterval = time.Second
+
+func rotateRemoteSecretCmd(ctx cli.Context) *cobra.Command {
+	opts := RemoteSecretOptions{
+		AuthType:         RemoteSecretAuthTypeBearerToken,
+		AuthPluginConfig: make(map[string]string),
+		Type:             SecretTypeRemote,
+	}
+	var primaryContexts []string
+	var deleteOldTokens bool
+	var syncTimeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate the token of the remote secret of the current cluster in primary clusters",
+		Long: `Rotate the credentials Istio uses to access the API server of the cluster of the current context. A new
+service account token is issued, verified against the API server, and the remote secret of the cluster is replaced with
+it in all the primary clusters. If the replacement fails in a primary cluster, the secrets already replaced are
+restored. The clusters each istiod of the primary clusters reads from are then reported, from its /debug/clusterz
+endpoint. With --delete-old-tokens, the token secrets of the replaced remote secrets are deleted once every istiod of
+the primary clusters has synced the cluster with the new credentials.`,
+		Example: `  # Rotate the remote secret of cluster c0 in the primary clusters of contexts c1 and c2.
+  istioctl --context=c0 x remote-secret rotate --name c0 --primary-contexts c1,c2
+
+  # Rotate the remote secret, and revoke the previous tokens once it is replaced.
+  istioctl --context=c0 x remote-secret rotate --name c0 --primary-contexts c1,c2 --delete-old-tokens`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(primaryContexts) == 0 {
+				return fmt.Errorf("--primary-contexts is required")
+			}
+			if err := opts.prepare(ctx); err != nil {
+				return err
+			}
+			client, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			primaries, err := ctx.CLIClientsForContexts(primaryContexts)
+			if err != nil {
+				return err
+			}
+			result, warn, err := rotateRemoteSecret(client, primaries, opts)
+			if warn != nil {
+				_, _ = fmt.Fprintf(c.OutOrStderr(), "warn: %v\n", warn)
+			}
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(c.OutOrStdout(), "Rotated remote secret %s of cluster %s with the token of secret %s in %s\n",
+				result.secret, result.cluster, result.tokenSecret, strings.Join(primaryContexts, ", "))
+
+			clusters := waitForClusterSync(primaries, ctx.IstioNamespace(), result.cluster, syncTimeout)
+			writeIstiodClusters(c.OutOrStdout(), primaries, clusters)
+			synced := true
+			for i, primary := range primaries {
+				if !clusterSynced(clusters[i], result.cluster) {
+					synced = false
+					_, _ = fmt.Fprintf(c.OutOrStderr(), "warn: istiod of %s has not synced cluster %s yet\n", primaryName(primary), result.cluster)
+				}
+			}
+			if !deleteOldTokens || len(result.replacedTokens) == 0 {
+				return nil
+			}
+			if !synced {
+				_, _ = fmt.Fprintf(c.OutOrStderr(), "warn: not deleting the previous token secrets %s, as cluster %s is not synced "+
+					"in every primary cluster; delete them once it is\n", strings.Join(result.replacedTokens, ", "), result.cluster)
+				return nil
+			}
+			deleted, err := deleteTokenSecrets(client, opts.Namespace, result.replacedTokens)
+			for _, name := range deleted {
+				_, _ = fmt.Fprintf(c.OutOrStdout(), "Deleted previous token secret %s\n", name)
+			}
+			return err
+		},
+	}
+	opts.addFlags(cmd.PersistentFlags())
+	cmd.PersistentFlags().StringSliceVar(&primaryContexts, "primary-contexts", nil,
+		"The kubeconfig contexts of the primary clusters in which the remote secret is replaced.")
+	cmd.PersistentFlags().BoolVar(&deleteOldTokens, "delete-old-tokens", false,
+		"Delete the token secrets of the replaced remote secrets, which revokes them, once istiod synced the cluster in "+
+			"every primary cluster.")
+	cmd.PersistentFlags().DurationVar(&syncTimeout, "sync-timeout", 30*time.Second,
+		"How long to wait for istiod to sync the cluster with the new credentials.")
+	return cmd
+}
+
+// rotation is the result of the rotation of a remote secret.
+type rotation struct {
+	cluster     string
+	secret      string
+	tokenSecret string
+	// replacedTokens are the token secrets of the service account referenced by the replaced remote secrets.
+	replacedTokens []string
+}
+
+// rotateRemoteSecret issues a new token for the service account of the remote secret, verifies it, and replaces the
+// remote secret with it in all the primary clusters, or none of them.
+func rotateRemoteSecret(client kube.CLIClient, primaries []kube.CLIClient, opt RemoteSecretOptions) (*rotation, Warning, error) {
+	if opt.AuthType != RemoteSecretAuthTypeBearerToken {
+		return nil, nil, fmt.Errorf("only remote secrets with --auth-type=%v can be rotated", RemoteSecretAuthTypeBearerToken)
+	}
+	if opt.SecretName != "" {
+		return nil, nil, fmt.Errorf("--secret-name is not supported, a new token secret is created")
+	}
+	if !kube.IsAtLeastVersion(client, 24) {
+		return nil, nil, fmt.Errorf("rotating remote secrets requires Kubernetes 1.24 or later")
+	}
+	if opt.ClusterName == "" {
+		uid, err := clusterUID(client.Kube())
+		if err != nil {
+			return nil, nil, err
+		}
+		opt.ClusterName = string(uid)
+	}
+	if _, err := opt.applySecretTypeDefaults(); err != nil {
+		return nil, nil, err
+	}
+	serviceAccount, err := getOrCreateServiceAccount(client, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secrets := client.Kube().CoreV1().Secrets(opt.Namespace)
+	log.Infof("Creating token secret for service account %q", serviceAccount.Name)
+	tokenSecret, err := secrets.Create(context.TODO(), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: tokenSecretName(serviceAccount.Name) + "-",
+			Annotations:  map[string]string{v1.ServiceAccountNameKey: serviceAccount.Name},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed creating token secret for service account %s: %v", serviceAccount.Name, err)
+	}
+	// The new token is revoked if it does not replace the remote secret.
+	cleanup := func() {
+		if err := secrets.Delete(context.TODO(), tokenSecret.Name, metav1.DeleteOptions{}); err != nil {
+			log.Warnf("failed deleting token secret %s: %v", tokenSecret.Name, err)
+		}
+	}
+
+	opt.SecretName = tokenSecret.Name
+	remoteSecret, warn, err := createRemoteSecret(opt, client)
+	if err != nil {
+		cleanup()
+		return nil, warn, err
+	}
+	for clusterID, kubeconfig := range remoteSecret.Data {
+		if s := verifyKubeconfig(kubeconfig); s.Problem != "" {
+			cleanup()
+			return nil, warn, fmt.Errorf("new credentials of cluster %s failed verification: %s", clusterID, s.Problem)
+		}
+	}
+	previous, err := replaceRemoteSecret(primaries, remoteSecret)
+	if err != nil {
+		cleanup()
+		return nil, warn, err
+	}
+
+	result := &rotation{
+		cluster:     opt.ClusterName,
+		secret:      remoteSecret.Name,
+		tokenSecret: tokenSecret.Name,
+	}
+	replacedTokens := sets.New[string]()
+	for _, secret := range previous {
+		for _, kubeconfig := range secret.Data {
+			if token := kubeconfigToken(kubeconfig); token != "" {
+				replacedTokens.Insert(token)
+			}
+		}
+	}
+	if replacedTokens.IsEmpty() {
+		return result, warn, nil
+	}
+	all, err := secrets.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return result, warn, fmt.Errorf("failed listing secrets in %s: %v", opt.Namespace, err)
+	}
+	for i := range all.Items {
+		old := &all.Items[i]
+		if old.Name == tokenSecret.Name || secretReferencesServiceAccount(serviceAccount, old) != nil {
+			continue
+		}
+		if replacedTokens.Contains(string(old.Data[v1.ServiceAccountTokenKey])) {
+			result.replacedTokens = append(result.replacedTokens, old.Name)
+		}
+	}
+	return result, warn, nil
+}
+
+// kubeconfigToken returns the bearer token of the current context of the kubeconfig.
+func kubeconfigToken(kubeconfig []byte) string {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return ""
+	}
+	kc, f := config.Contexts[config.CurrentContext]
+	if !f {
+		return ""
+	}
+	auth, f := config.AuthInfos[kc.AuthInfo]
+	if !f {
+		return ""
+	}
+	return auth.Token
+}
+
+// deleteTokenSecrets deletes the token secrets, which revokes their tokens, and returns the deleted ones.
+func deleteTokenSecrets(client kube.CLIClient, namespace string, names []string) ([]string, error) {
+	var deleted []string
+	for _, name := range names {
+		err := client.Kube().CoreV1().Secrets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return deleted, fmt.Errorf("failed deleting previous token secret %s: %v", name, err)
+		}
+		deleted = append(deleted, name)
+	}
+	return deleted, nil
+}
+
+// replaceRemoteSecret creates or updates the remote secret in all the primary clusters, and returns the secrets it
+// replaced. If it fails in a primary cluster, the secrets of the previous ones are restored.
+func replaceRemoteSecret(primaries []kube.CLIClient, secret *v1.Secret) ([]*v1.Secret, error) {
+	type replaced struct {
+		client   kube.CLIClient
+		previous *v1.Secret
+	}
+	var done []replaced
+	rollback := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			secrets := done[i].client.Kube().CoreV1().Secrets(secret.Namespace)
+			var err error
+			if done[i].previous == nil {
+				err = secrets.Delete(context.TODO(), secret.Name, metav1.DeleteOptions{})
+			} else {
+				previous := done[i].previous.DeepCopy()
+				previous.ResourceVersion = ""
+				_, err = secrets.Update(context.TODO(), previous, metav1.UpdateOptions{})
+			}
+			if err != nil {
+				log.Errorf("failed restoring remote secret %s/%s in %s: %v", secret.Namespace, secret.Name, primaryName(done[i].client), err)
+			}
+		}
+	}
+
+	for _, primary := range primaries {
+		secrets := primary.Kube().CoreV1().Secrets(secret.Namespace)
+		existing, err := secrets.Get(context.TODO(), secret.Name, metav1.GetOptions{})
+		switch {
+		case kerrors.IsNotFound(err):
+			_, err = secrets.Create(context.TODO(), secret.DeepCopy(), metav1.CreateOptions{})
+			existing = nil
+		case err == nil:
+			updated := existing.DeepCopy()
+			updated.Data = secret.Data
+			updated.StringData = nil
+			updated.Annotations = maps.MergeCopy(updated.Annotations, secret.Annotations)
+			updated.Labels = maps.MergeCopy(updated.Labels, secret.Labels)
+			_, err = secrets.Update(context.TODO(), updated, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("failed replacing remote secret %s/%s in %s, restored the previous secrets: %v",
+				secret.Namespace, secret.Name, primaryName(primary), err)
+		}
+		done = append(done, replaced{client: primary, previous: existing})
+	}
+	var previous []*v1.Secret
+	for _, r := range done {
+		if r.previous != nil {
+			previous = append(previous, r.previous)
+		}
+	}
+	return previous, nil
+}
+
+// waitForClusterSync waits for the istiods of the primary clusters to sync the cluster, and returns the clusters they
+// read from, by primary cluster.
+func waitForClusterSync(primaries []kube.CLIClient, istioNamespace, clusterID string, timeout time.Duration) []istiodClusters {
+	deadline := time.Now().Add(timeout)
+	for {
+		out := make([]istiodClusters, 0, len(primaries))
+		synced := true
+		for _, primary := range primaries {
+			clusters := getIstiodClusters(primary, istioNamespace)
+			synced = synced && clusterSynced(clusters, clusterID)
+			out = append(out, clusters)
+		}
+		if synced || time.Now().After(deadline) {
+			return out
+		}
+		time.Sleep(clusterSyncPollInterval)
+	}
+}
+
+// clusterSynced returns true if all the istiods have synced the cluster.
+func clusterSynced(clusters istiodClusters, clusterID string) bool {
+	if len(clusters) == 0 {
+		return false
+	}
+	for _, cs := range clusters {
+		found := false
+		for _, c := range cs {
+			if c.ID.String() == clusterID {
+				found = c.SyncStatus == "synced"
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func writeIstiodClusters(out io.Writer, primaries []kube.CLIClient, clusters []istiodClusters) {
+	w := new(tabwriter.Writer).Init(out, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PRIMARY\tISTIOD\tCLUSTER\tSECRET\tSTATUS")
+	for i, primary := range primaries {
+		for _, istiod := range slices.Sort(maps.Keys(clusters[i])) {
+			for _, c := range clusters[i][istiod] {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", primaryName(primary), istiod, c.ID, c.SecretName, c.SyncStatus)
+			}
+		}
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,159 @@
+// Copyright Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+// newRemoteCluster returns the fake client of a remote cluster whose token controller populates the token secrets.
+func newRemoteCluster(objects ...runtime.Object) kube.CLIClient {
+	client := kube.NewFakeClientWithVersion("30", objects...)
+	tokens := 0
+	client.Kube().(*fake.Clientset).PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		secret := action.(k8stesting.CreateAction).GetObject().(*v1.Secret)
+		if secret.Type == v1.SecretTypeServiceAccountToken {
+			tokens++
+			secret.Data = map[string][]byte{
+				v1.ServiceAccountRootCAKey: []byte("caData"),
+				v1.ServiceAccountTokenKey:  []byte(fmt.Sprintf("new-token-%d", tokens)),
+			}
+		}
+		return false, nil, nil
+	})
+	return client
+}
+
+func getSecret(t *testing.T, client kube.CLIClient, name string) *v1.Secret {
+	t.Helper()
+	secret, err := client.Kube().CoreV1().Secrets(testNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	assert.NoError(t, err)
+	return secret
+}
+
+func listTokenSecrets(t *testing.T, client kube.CLIClient) []string {
+	t.Helper()
+	secrets, err := client.Kube().CoreV1().Secrets(testNamespace).List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	var out []string
+	for _, s := range secrets.Items {
+		if s.Type == v1.SecretTypeServiceAccountToken {
+			out = append(out, s.Name)
+		}
+	}
+	return out
+}
+
+func TestRotateRemoteSecret(t *testing.T) {
+	fakeRemoteClusters(t, "", "")
+	opts := RemoteSecretOptions{
+		KubeOptions:        KubeOptions{Namespace: testNamespace},
+		ClusterName:        "c0",
+		ServiceAccountName: testServiceAccountName,
+		AuthType:           RemoteSecretAuthTypeBearerToken,
+		Type:               SecretTypeRemote,
+		ServerOverride:     "https://c0",
+	}
+	oldSecret := makeRemoteSecret(t, "c0", "https://c0", "old-token")
+	// other-token is another token of the service account, used by another remote secret.
+	remote := newRemoteCluster(kubeSystemNamespace, makeServiceAccount(),
+		makeSecret("old-token", "caData", "old-token"), makeSecret("other-token", "caData", "other-token"))
+	primary1 := kube.NewFakeClient(oldSecret)
+	primary2 := kube.NewFakeClient()
+
+	result, _, err := rotateRemoteSecret(remote, []kube.CLIClient{primary1, primary2}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, result.cluster, "c0")
+	assert.Equal(t, result.secret, "istio-remote-secret-c0")
+	// Only the token of the replaced remote secret is reported, and it is not deleted before the cluster is synced.
+	assert.Equal(t, result.replacedTokens, []string{"old-token"})
+	assert.Equal(t, listTokenSecrets(t, remote), []string{"old-token", "other-token", result.tokenSecret})
+	deleted, err := deleteTokenSecrets(remote, testNamespace, result.replacedTokens)
+	assert.NoError(t, err)
+	assert.Equal(t, deleted, []string{"old-token"})
+	assert.Equal(t, listTokenSecrets(t, remote), []string{"other-token", result.tokenSecret})
+	assert.Equal(t, strings.HasPrefix(result.tokenSecret, tokenSecretName(testServiceAccountName)+"-"), true)
+
+	for _, primary := range []kube.CLIClient{primary1, primary2} {
+		secret := getSecret(t, primary, "istio-remote-secret-c0")
+		assert.Equal(t, strings.Contains(string(secret.Data["c0"]), "new-token-1"), true)
+		assert.Equal(t, secret.Annotations[clusterNameAnnotationKey], "c0")
+	}
+
+	// If the secret cannot be replaced in a primary cluster, the ones already replaced are restored and the new
+	// token is deleted.
+	primary1 = kube.NewFakeClient(oldSecret)
+	primary2 = kube.NewFakeClient()
+	primary2.Kube().(*fake.Clientset).PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, kerrors.NewForbidden(v1.Resource("secrets"), "istio-remote-secret-c0", fmt.Errorf("denied"))
+	})
+	remote = newRemoteCluster(kubeSystemNamespace, makeServiceAccount(), makeSecret("old-token", "caData", "old-token"))
+	_, _, err = rotateRemoteSecret(remote, []kube.CLIClient{primary1, primary2}, opts)
+	assert.Error(t, err)
+	assert.Equal(t, getSecret(t, primary1, "istio-remote-secret-c0").Data, oldSecret.Data)
+	assert.Equal(t, getSecret(t, primary2, "istio-remote-secret-c0") == nil, true)
+	assert.Equal(t, listTokenSecrets(t, remote), []string{"old-token"})
+
+	// The new credentials are verified before replacing the secrets.
+	fakeRemoteClusters(t, "https://c0", "")
+	primary1 = kube.NewFakeClient(oldSecret)
+	_, _, err = rotateRemoteSecret(remote, []kube.CLIClient{primary1}, opts)
+	assert.Error(t, err)
+	assert.Equal(t, getSecret(t, primary1, "istio-remote-secret-c0").Data, oldSecret.Data)
+	assert.Equal(t, listTokenSecrets(t, remote), []string{"old-token"})
+
+	opts.AuthType = RemoteSecretAuthTypePlugin
+	_, _, err = rotateRemoteSecret(remote, []kube.CLIClient{primary1}, opts)
+	assert.Error(t, err)
+}
+
+func TestWaitForClusterSync(t *testing.T) {
+	synced := cli.MockClient{
+		CLIClient: kube.NewFakeClient(),
+		Results: map[string][]byte{
+			"istiod-1": []byte(`[{"id":"c0","secretName":"istio-system/istio-remote-secret-c0","syncStatus":"synced"}]`),
+			"istiod-2": []byte(`[{"id":"c0","secretName":"istio-system/istio-remote-secret-c0","syncStatus":"synced"}]`),
+		},
+	}
+	syncing := cli.MockClient{
+		CLIClient: kube.NewFakeClient(),
+		Results: map[string][]byte{
+			"istiod-1": []byte(`[{"id":"c0","secretName":"istio-system/istio-remote-secret-c0","syncStatus":"synced"}]`),
+			"istiod-2": []byte(`[{"id":"c0","secretName":"istio-system/istio-remote-secret-c0","syncStatus":"syncing"}]`),
+		},
+	}
+	clusters := waitForClusterSync([]kube.CLIClient{synced, syncing}, "istio-system", "c0", 0)
+	assert.Equal(t, len(clusters), 2)
+	assert.Equal(t, clusterSynced(clusters[0], "c0"), true)
+	assert.Equal(t, clusterSynced(clusters[1], "c0"), false)
+	assert.Equal(t, clusterSynced(clusters[0], "c1"), false)
+}
@@ -0,0 +1,316 @@
+// Copyright Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/multicluster"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/security/pkg/util"
+)
+
+// remoteClusterTimeout bounds the requests to the API servers of the remote clusters.
+const remoteClusterTimeout = 10 * time.Second
+
+// newRemoteKubeClient returns a client for the remote cluster of a kubeconfig read from a remote secret.
+var newRemoteKubeClient = func(kubeconfig []byte) (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	restConfig.Timeout = remoteClusterTimeout
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// RemoteSecretCmd returns the commands managing the lifecycle of remote secrets.
+func RemoteSecretCmd(ctx cli.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remote-secret",
+		Short: "Verify and rotate the remote secrets allowing Istio to access remote Kubernetes apiservers",
+	}
+	cmd.AddCommand(verifyRemoteSecretsCmd(ctx))
+	cmd.AddCommand(rotateRemoteSecretCmd(ctx))
+	return cmd
+}
+
+// remoteSecretStatus is the result of the verification of the kubeconfig of a remote cluster in a remote secret.
+type remoteSecretStatus struct {
+	Primary string `json:"primary"`
+	Cluster string `json:"cluster"`
+	Secret  string `json:"secret"`
+	Server  string `json:"server,omitempty"`
+	// Problem is empty if the kubeconfig authenticates to the API server of the remote cluster.
+	Problem string `json:"problem,omitempty"`
+	// TokenExpiry is the expiration time of the bearer token, if any.
+	TokenExpiry *time.Time `json:"tokenExpiry,omitempty"`
+	// Istiod is the sync status of the cluster reported by each istiod of the primary cluster.
+	Istiod map[string]string `json:"istiod,omitempty"`
+}
+
+func verifyRemoteSecretsCmd(ctx cli.Context) *cobra.Command {
+	var contexts []string
+	var minTokenValidity time.Duration
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the remote secrets of primary clusters",
+		Long: `Verify the remote secrets installed in the Istio namespace of primary clusters. For each remote cluster,
+the command checks that the API server in the kubeconfig of the secret is reachable, that the credentials still
+authenticate, and that the bearer token does not expire soon. It also reports the sync status of the cluster in each
+istiod of the primary cluster, from its /debug/clusterz endpoint.`,
+		Example: `  # Verify the remote secrets of the cluster of the current context
+  istioctl x remote-secret verify
+
+  # Verify the remote secrets of several primary clusters, failing if a token expires within a week
+  istioctl x remote-secret verify --contexts primary-1,primary-2 --min-token-validity 168h`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			clients, err := primaryClients(ctx, contexts)
+			if err != nil {
+				return err
+			}
+			var statuses []remoteSecretStatus
+			for _, client := range clients {
+				s, err := verifyRemoteSecrets(client, ctx.IstioNamespace())
+				if err != nil {
+					return err
+				}
+				statuses = append(statuses, s...)
+			}
+			failed := 0
+			for i := range statuses {
+				s := &statuses[i]
+				if s.Problem == "" && s.TokenExpiry != nil && time.Until(*s.TokenExpiry) < minTokenValidity {
+					s.Problem = fmt.Sprintf("token expires in less than %v", minTokenValidity)
+				}
+				if s.Problem != "" {
+					failed++
+				}
+			}
+			switch outputFormat {
+			case jsonOutput:
+				b, err := json.MarshalIndent(statuses, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintln(c.OutOrStdout(), string(b))
+			case summaryOutput:
+				writeRemoteSecretStatuses(c.OutOrStdout(), statuses)
+			default:
+				return fmt.Errorf("unknown output format %q, expected %s or %s", outputFormat, summaryOutput, jsonOutput)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d remote clusters failed verification", failed, len(statuses))
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringSliceVar(&contexts, "contexts", nil,
+		"The kubeconfig contexts of the primary clusters whose remote secrets are verified. Defaults to the current context.")
+	cmd.PersistentFlags().DurationVar(&minTokenValidity, "min-token-validity", 24*time.Hour,
+		"Report the bearer tokens expiring within this duration.")
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput,
+		fmt.Sprintf("Output format: one of %s|%s", summaryOutput, jsonOutput))
+	return cmd
+}
+
+const (
+	summaryOutput = "short"
+	jsonOutput    = "json"
+)
+
+// primaryClients returns the clients of the primary clusters of the contexts, or of the current context.
+func primaryClients(ctx cli.Context, contexts []string) ([]kube.CLIClient, error) {
+	if len(contexts) > 0 {
+		return ctx.CLIClientsForContexts(contexts)
+	}
+	client, err := ctx.CLIClient()
+	if err != nil {
+		return nil, err
+	}
+	return []kube.CLIClient{client}, nil
+}
+
+// verifyRemoteSecrets verifies the kubeconfigs of the remote secrets of the primary cluster.
+func verifyRemoteSecrets(client kube.CLIClient, istioNamespace string) ([]remoteSecretStatus, error) {
+	secrets, err := client.Kube().CoreV1().Secrets(istioNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: multicluster.MultiClusterSecretLabel + "=true",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing remote secrets in %s: %v", istioNamespace, err)
+	}
+	istiodStatuses := istiodClusterStatuses(client, istioNamespace)
+
+	var out []remoteSecretStatus
+	for _, secret := range secrets.Items {
+		for clusterID, kubeconfig := range secret.Data {
+			s := verifyKubeconfig(kubeconfig)
+			s.Primary = primaryName(client)
+			s.Cluster = clusterID
+			s.Secret = secret.Name
+			s.Istiod = istiodStatuses[clusterID]
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Secret != out[j].Secret {
+			return out[i].Secret < out[j].Secret
+		}
+		return out[i].Cluster < out[j].Cluster
+	})
+	return out, nil
+}
+
+func primaryName(client kube.CLIClient) string {
+	if id := client.ClusterID(); id != "" {
+		return id.String()
+	}
+	return "current-context"
+}
+
+// verifyKubeconfig checks that the API server of the kubeconfig is reachable and that its credentials authenticate,
+// by reading the kube-system namespace like istiod does to identify the cluster.
+func verifyKubeconfig(kubeconfig []byte) remoteSecretStatus {
+	s := remoteSecretStatus{}
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		s.Problem = fmt.Sprintf("invalid kubeconfig: %v", err)
+		return s
+	}
+	if kc, f := config.Contexts[config.CurrentContext]; f {
+		if c, f := config.Clusters[kc.Cluster]; f {
+			s.Server = c.Server
+		}
+		if auth, f := config.AuthInfos[kc.AuthInfo]; f {
+			token := auth.Token
+			if token == "" && auth.TokenFile != "" {
+				if b, err := os.ReadFile(auth.TokenFile); err == nil {
+					token = strings.TrimSpace(string(b))
+				}
+			}
+			if token != "" {
+				if exp, err := util.GetExp(token); err == nil && !exp.IsZero() {
+					s.TokenExpiry = &exp
+				}
+			}
+		}
+	}
+
+	remote, err := newRemoteKubeClient(kubeconfig)
+	if err != nil {
+		s.Problem = fmt.Sprintf("invalid kubeconfig: %v", err)
+		return s
+	}
+	_, err = remote.CoreV1().Namespaces().Get(context.TODO(), "kube-system", metav1.GetOptions{})
+	switch {
+	case err == nil:
+	case kerrors.IsUnauthorized(err):
+		s.Problem = fmt.Sprintf("credentials do not authenticate: %v", err)
+	case kerrors.IsForbidden(err):
+		s.Problem = fmt.Sprintf("credentials are not authorized to read namespaces: %v", err)
+	default:
+		s.Problem = fmt.Sprintf("API server is not reachable: %v", err)
+	}
+	if s.Problem == "" && s.TokenExpiry != nil && time.Now().After(*s.TokenExpiry) {
+		s.Problem = "token is expired"
+	}
+	return s
+}
+
+// istiodClusters are the clusters each istiod reads from, by istiod.
+type istiodClusters map[string][]cluster.DebugInfo
+
+// getIstiodClusters returns the clusters each istiod of the primary cluster reads from, from its /debug/clusterz
+// endpoint. Failures to reach istiod are not fatal, as the remote secrets can be verified without it.
+func getIstiodClusters(client kube.CLIClient, istioNamespace string) istiodClusters {
+	out := istiodClusters{}
+	res, err := client.AllDiscoveryDo(context.TODO(), istioNamespace, "debug/clusterz")
+	if err != nil {
+		log.Warnf("failed to get the remote clusters of istiod in %s: %v", primaryName(client), err)
+		return out
+	}
+	for istiod, b := range res {
+		var clusters []cluster.DebugInfo
+		if err := json.Unmarshal(b, &clusters); err != nil {
+			log.Warnf("failed to parse the remote clusters of istiod %s: %v", istiod, err)
+			continue
+		}
+		out[istiod] = clusters
+	}
+	return out
+}
+
+// istiodClusterStatuses returns the sync status of each cluster reported by each istiod of the primary cluster.
+func istiodClusterStatuses(client kube.CLIClient, istioNamespace string) map[string]map[string]string {
+	out := map[string]map[string]string{}
+	for istiod, clusters := range getIstiodClusters(client, istioNamespace) {
+		for _, c := range clusters {
+			if out[c.ID.String()] == nil {
+				out[c.ID.String()] = map[string]string{}
+			}
+			out[c.ID.String()][istiod] = c.SyncStatus
+		}
+	}
+	return out
+}
+
+func writeRemoteSecretStatuses(out io.Writer, statuses []remoteSecretStatus) {
+	w := new(tabwriter.Writer).Init(out, 0, 8, 5, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PRIMARY\tCLUSTER\tSECRET\tSERVER\tTOKEN EXPIRY\tISTIOD\tSTATUS")
+	for _, s := range statuses {
+		expiry := "never"
+		if s.TokenExpiry != nil {
+			expiry = s.TokenExpiry.UTC().Format(time.RFC3339)
+		}
+		status := "OK"
+		if s.Problem != "" {
+			status = s.Problem
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.Primary, s.Cluster, s.Secret, s.Server, expiry, istiodStatus(s.Istiod), status)
+	}
+	_ = w.Flush()
+}
+
+// istiodStatus summarizes the sync status of a cluster in the istiods of a primary cluster.
+func istiodStatus(statuses map[string]string) string {
+	if len(statuses) == 0 {
+		return "not loaded"
+	}
+	var out []string
+	for _, istiod := range slices.Sort(maps.Keys(statuses)) {
+		out = append(out, istiod+"="+statuses[istiod])
+	}
+	return strings.Join(out, ",")
+}
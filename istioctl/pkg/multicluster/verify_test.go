@@ -0,0 +1,123 @@
+// Copyright Istio Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicluster
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+// testJWT returns an unsigned JWT expiring at the time.
+func testJWT(exp time.Time) string {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return "eyJhbGciOiJub25lIn0." + claims + ".c2ln"
+}
+
+func makeRemoteSecret(t *testing.T, clusterName, server, token string) *v1.Secret {
+	t.Helper()
+	kubeconfig := createBearerTokenKubeconfig([]byte("caData"), []byte(token), clusterName, server)
+	secret, err := createRemoteServiceAccountSecret(kubeconfig, clusterName, remoteSecretNameFromClusterName(clusterName))
+	assert.NoError(t, err)
+	secret.Namespace = testNamespace
+	return secret
+}
+
+// fakeRemoteClusters makes the remote clusters fakes. The API servers of the unauthorized servers reject the
+// credentials, and the ones of the unreachable servers do not respond.
+func fakeRemoteClusters(t *testing.T, unauthorized, unreachable string) {
+	prev := newRemoteKubeClient
+	t.Cleanup(func() { newRemoteKubeClient = prev })
+	newRemoteKubeClient = func(kubeconfig []byte) (kubernetes.Interface, error) {
+		restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		c := fake.NewClientset(kubeSystemNamespace)
+		c.PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			switch restConfig.Host {
+			case unauthorized:
+				return true, nil, kerrors.NewUnauthorized("invalid bearer token")
+			case unreachable:
+				return true, nil, fmt.Errorf("dial tcp: i/o timeout")
+			}
+			return false, nil, nil
+		})
+		return c, nil
+	}
+}
+
+func TestVerifyRemoteSecrets(t *testing.T) {
+	fakeRemoteClusters(t, "https://c2", "https://c3")
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	client := cli.MockClient{
+		CLIClient: kube.NewFakeClient(
+			makeRemoteSecret(t, "c0", "https://c0", "token"),
+			makeRemoteSecret(t, "c1", "https://c1", testJWT(expiry)),
+			makeRemoteSecret(t, "c2", "https://c2", "token"),
+			makeRemoteSecret(t, "c3", "https://c3", "token"),
+			makeRemoteSecret(t, "c4", "https://c4", testJWT(time.Now().Add(-time.Hour))),
+		),
+		Results: map[string][]byte{
+			"istiod-1": []byte(`[{"id":"primary","syncStatus":"synced"},{"id":"c0","secretName":"istio-system-test/istio-remote-secret-c0","syncStatus":"synced"},` +
+				`{"id":"c2","secretName":"istio-system-test/istio-remote-secret-c2","syncStatus":"timeout"}]`),
+		},
+	}
+
+	statuses, err := verifyRemoteSecrets(client, testNamespace)
+	assert.NoError(t, err)
+	assert.Equal(t, len(statuses), 5)
+
+	c0 := statuses[0]
+	assert.Equal(t, c0.Cluster, "c0")
+	assert.Equal(t, c0.Secret, "istio-remote-secret-c0")
+	assert.Equal(t, c0.Server, "https://c0")
+	assert.Equal(t, c0.Problem, "")
+	assert.Equal(t, c0.TokenExpiry == nil, true)
+	assert.Equal(t, c0.Istiod, map[string]string{"istiod-1": "synced"})
+
+	c1 := statuses[1]
+	assert.Equal(t, c1.Problem, "")
+	assert.Equal(t, *c1.TokenExpiry, expiry)
+	assert.Equal(t, len(c1.Istiod), 0)
+
+	assert.Equal(t, strings.HasPrefix(statuses[2].Problem, "credentials do not authenticate"), true)
+	assert.Equal(t, statuses[2].Istiod, map[string]string{"istiod-1": "timeout"})
+	assert.Equal(t, strings.HasPrefix(statuses[3].Problem, "API server is not reachable"), true)
+	assert.Equal(t, statuses[4].Problem, "token is expired")
+
+	var out bytes.Buffer
+	writeRemoteSecretStatuses(&out, statuses[:2])
+	want := fmt.Sprintf(`PRIMARY     CLUSTER     SECRET                     SERVER         TOKEN EXPIRY             ISTIOD              STATUS
+fake        c0          istio-remote-secret-c0     https://c0     never                    istiod-1=synced     OK
+fake        c1          istio-remote-secret-c1     https://c1     %s     not loaded          OK
+`, expiry.UTC().Format(time.RFC3339))
+	assert.Equal(t, out.String(), want)
+}
@@ -0,0 +1,398 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package precheck
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis/diag"
+	legacykube "istio.io/istio/pkg/config/analysis/legacy/source/kube"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	sresource "istio.io/istio/pkg/config/schema/resource"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/util/sets"
+)
+
+//go:embed compatibility.yaml
+var defaultCompatibilityRules []byte
+
+// compatibilityRule describes configuration whose behavior changes, or which is removed, in a release. It is
+// reported when upgrading from a version before the release to the release or a later one.
+type compatibilityRule struct {
+	Release     string `json:"release"`
+	Removed     bool   `json:"removed,omitempty"`
+	Info        string `json:"info"`
+	Remediation string `json:"remediation"`
+
+	// Exactly one of the following matches the configuration.
+	Field       *fieldMatch       `json:"field,omitempty"`
+	Annotation  string            `json:"annotation,omitempty"`
+	EnvoyFilter *envoyFilterMatch `json:"envoyFilter,omitempty"`
+	FeatureFlag *featureFlagMatch `json:"featureFlag,omitempty"`
+
+	minor int
+}
+
+type fieldMatch struct {
+	// Kind is the kind of the Istio custom resources, like DestinationRule.
+	Kind string `json:"kind"`
+	// Path is the dot separated path of the field. Lists along the path are traversed.
+	Path string `json:"path"`
+	// Value only matches the fields with this value, if set.
+	Value string `json:"value,omitempty"`
+}
+
+type envoyFilterMatch struct {
+	ApplyTo string `json:"applyTo,omitempty"`
+	// Contains matches the patches whose JSON contains it, like the name or the type of a filter.
+	Contains string `json:"contains,omitempty"`
+}
+
+type featureFlagMatch struct {
+	Name string `json:"name"`
+	// Value only matches the flags with this value, if set.
+	Value string `json:"value,omitempty"`
+	// Unset matches the istiod deployments which do not set the flag, and get its new default.
+	Unset bool `json:"unset,omitempty"`
+}
+
+func (r *compatibilityRule) message(res *resource.Instance, configType, name string) diag.Message {
+	if r.Removed {
+		return msg.NewUpgradeRemovedConfig(res, configType, name, r.Release, r.Info, r.Remediation)
+	}
+	return msg.NewUpgradeBehaviorChange(res, configType, name, r.Release, r.Info, r.Remediation)
+}
+
+// loadCompatibilityRules returns the built-in compatibility rules, and the ones of the file if set.
+func loadCompatibilityRules(file string) ([]*compatibilityRule, error) {
+	rules, err := parseCompatibilityRules(defaultCompatibilityRules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid built-in compatibility rules: %v", err)
+	}
+	if file == "" {
+		return rules, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := parseCompatibilityRules(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compatibility rules in %s: %v", file, err)
+	}
+	return append(rules, extra...), nil
+}
+
+func parseCompatibilityRules(b []byte) ([]*compatibilityRule, error) {
+	var rules []*compatibilityRule
+	if err := yaml.UnmarshalStrict(b, &rules); err != nil {
+		return nil, err
+	}
+	for i, r := range rules {
+		minor, err := parseMinorVersion(r.Release)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i, err)
+		}
+		r.minor = minor
+		matchers := 0
+		for _, set := range []bool{r.Field != nil, r.Annotation != "", r.EnvoyFilter != nil, r.FeatureFlag != nil} {
+			if set {
+				matchers++
+			}
+		}
+		if matchers != 1 {
+			return nil, fmt.Errorf("rule %d: expected exactly one of field, annotation, envoyFilter or featureFlag", i)
+		}
+		if r.Field != nil {
+			if _, f := istioSchema(r.Field.Kind); !f {
+				return nil, fmt.Errorf("rule %d: unknown Istio kind %q", i, r.Field.Kind)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// istioSchema returns the schema of the Istio custom resources of the kind.
+func istioSchema(kind string) (sresource.Schema, bool) {
+	for _, s := range collections.Pilot.All() {
+		if s.Kind() == kind && strings.HasSuffix(s.Group(), "istio.io") {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// checkCompatibilityRules reports the configuration of the cluster matching the rules of the releases after
+// fromMinor, up to toMinor unless it is negative. EnvoyFilter patches only applying to the proxies of the
+// from version are also reported.
+func checkCompatibilityRules(
+	cli kube.CLIClient,
+	istioNamespace string,
+	rules []*compatibilityRule,
+	fromMinor, toMinor int,
+	messages *diag.Messages,
+) error {
+	var applicable []*compatibilityRule
+	for _, r := range rules {
+		if r.minor > fromMinor && (toMinor < 0 || r.minor <= toMinor) {
+			applicable = append(applicable, r)
+		}
+	}
+
+	var fields, envoyFilters, annotations, flags []*compatibilityRule
+	for _, r := range applicable {
+		switch {
+		case r.Field != nil:
+			fields = append(fields, r)
+		case r.EnvoyFilter != nil:
+			envoyFilters = append(envoyFilters, r)
+		case r.Annotation != "":
+			annotations = append(annotations, r)
+		case r.FeatureFlag != nil:
+			flags = append(flags, r)
+		}
+	}
+	if err := checkFieldRules(cli, fields, messages); err != nil {
+		return err
+	}
+	if err := checkEnvoyFilterRules(cli, envoyFilters, fromMinor, toMinor, messages); err != nil {
+		return err
+	}
+	if err := checkAnnotationRules(cli, annotations, messages); err != nil {
+		return err
+	}
+	return checkFeatureFlagRules(cli, istioNamespace, flags, messages)
+}
+
+func listIstioResources(cli kube.CLIClient, kind string) ([]unstructured.Unstructured, error) {
+	s, f := istioSchema(kind)
+	if !f {
+		return nil, fmt.Errorf("unknown Istio kind %q", kind)
+	}
+	list, err := cli.Dynamic().Resource(s.GroupVersionResource()).Namespace(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %v", kind, err)
+	}
+	return list.Items, nil
+}
+
+func unstructuredToInstance(u *unstructured.Unstructured) *resource.Instance {
+	return instance(config.FromKubernetesGVK(u.GroupVersionKind()), u.GetNamespace(), u.GetName())
+}
+
+func instance(typ config.GroupVersionKind, namespace, name string) *resource.Instance {
+	return &resource.Instance{
+		Origin: &legacykube.Origin{
+			Type: typ,
+			FullName: resource.FullName{
+				Namespace: resource.Namespace(namespace),
+				Name:      resource.LocalName(name),
+			},
+		},
+	}
+}
+
+func checkFieldRules(cli kube.CLIClient, rules []*compatibilityRule, messages *diag.Messages) error {
+	resources := map[string][]unstructured.Unstructured{}
+	for _, r := range rules {
+		items, f := resources[r.Field.Kind]
+		if !f {
+			var err error
+			if items, err = listIstioResources(cli, r.Field.Kind); err != nil {
+				return err
+			}
+			resources[r.Field.Kind] = items
+		}
+		for i := range items {
+			if fieldMatches(items[i].Object, strings.Split(r.Field.Path, "."), r.Field.Value) {
+				messages.Add(r.message(unstructuredToInstance(&items[i]), "field", r.Field.Kind+"."+r.Field.Path))
+			}
+		}
+	}
+	return nil
+}
+
+// fieldMatches returns true if the object has the field at the path, with the value if set. Lists along the path
+// match if any of their elements does.
+func fieldMatches(obj any, path []string, value string) bool {
+	switch o := obj.(type) {
+	case []any:
+		for _, e := range o {
+			if fieldMatches(e, path, value) {
+				return true
+			}
+		}
+		return false
+	case map[string]any:
+		if len(path) == 0 {
+			return value == ""
+		}
+		child, f := o[path[0]]
+		if !f {
+			return false
+		}
+		return fieldMatches(child, path[1:], value)
+	default:
+		if len(path) != 0 {
+			return false
+		}
+		return value == "" || fmt.Sprint(o) == value
+	}
+}
+
+func checkEnvoyFilterRules(cli kube.CLIClient, rules []*compatibilityRule, fromMinor, toMinor int, messages *diag.Messages) error {
+	if len(rules) == 0 && toMinor < 0 {
+		return nil
+	}
+	envoyFilters, err := listIstioResources(cli, gvk.EnvoyFilter.Kind)
+	if err != nil {
+		return err
+	}
+	for i := range envoyFilters {
+		ef := &envoyFilters[i]
+		patches, _, _ := unstructured.NestedSlice(ef.Object, "spec", "configPatches")
+		for index, p := range patches {
+			patch, ok := p.(map[string]any)
+			if !ok {
+				continue
+			}
+			name := fmt.Sprintf("configPatches[%d]", index)
+			for _, r := range rules {
+				if envoyFilterPatchMatches(patch, r.EnvoyFilter) {
+					messages.Add(r.message(unstructuredToInstance(ef), "EnvoyFilter patch", name))
+				}
+			}
+			if toMinor < 0 {
+				continue
+			}
+			proxyVersion, _, _ := unstructured.NestedString(patch, "match", "proxy", "proxyVersion")
+			if proxyVersion == "" {
+				continue
+			}
+			re, err := regexp.Compile(proxyVersion)
+			if err != nil {
+				continue
+			}
+			from, to := fmt.Sprintf("1.%d.0", fromMinor), fmt.Sprintf("1.%d.0", toMinor)
+			if re.MatchString(from) && !re.MatchString(to) {
+				release := fmt.Sprintf("1.%d", toMinor)
+				messages.Add(msg.NewUpgradeBehaviorChange(unstructuredToInstance(ef), "EnvoyFilter patch", name, release,
+					fmt.Sprintf("the proxyVersion %q of the patch does not match the proxies of the release, so it is no longer applied", proxyVersion),
+					"update the proxyVersion of the patch, after checking it still applies to the configuration generated by the release"))
+			}
+		}
+	}
+	return nil
+}
+
+func envoyFilterPatchMatches(patch map[string]any, m *envoyFilterMatch) bool {
+	if m.ApplyTo != "" {
+		if applyTo, _, _ := unstructured.NestedString(patch, "applyTo"); applyTo != m.ApplyTo {
+			return false
+		}
+	}
+	if m.Contains != "" {
+		b, err := json.Marshal(patch)
+		if err != nil || !strings.Contains(string(b), m.Contains) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAnnotationRules reports the workloads whose pods have the annotations of the rules, once per workload.
+func checkAnnotationRules(cli kube.CLIClient, rules []*compatibilityRule, messages *diag.Messages) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	pods, err := cli.Kube().CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	reported := sets.New[string]()
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		for _, r := range rules {
+			if _, f := pod.Annotations[r.Annotation]; !f {
+				continue
+			}
+			deployMeta, typeMeta := kube.GetDeployMetaFromPod(pod)
+			key := strings.Join([]string{typeMeta.Kind, deployMeta.Namespace, deployMeta.Name, r.Annotation}, "/")
+			if reported.InsertContains(key) {
+				continue
+			}
+			res := instance(config.FromKubernetesGVK(typeMeta.GroupVersionKind()), deployMeta.Namespace, deployMeta.Name)
+			messages.Add(r.message(res, "annotation", r.Annotation))
+		}
+	}
+	return nil
+}
+
+// checkFeatureFlagRules reports the istiod deployments setting the feature flags of the rules, or not setting
+// them for the rules of flags whose default changes.
+func checkFeatureFlagRules(cli kube.CLIClient, istioNamespace string, rules []*compatibilityRule, messages *diag.Messages) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	deployments, err := cli.Kube().AppsV1().Deployments(istioNamespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: "app=istiod",
+	})
+	if err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		env := istiodEnv(deployment)
+		for _, r := range rules {
+			value, set := env[r.FeatureFlag.Name]
+			matched := set && (r.FeatureFlag.Value == "" || value == r.FeatureFlag.Value)
+			if r.FeatureFlag.Unset {
+				matched = !set
+			}
+			if matched {
+				messages.Add(r.message(ObjectToInstance(deployment), "feature flag", r.FeatureFlag.Name))
+			}
+		}
+	}
+	return nil
+}
+
+func istiodEnv(deployment *appsv1.Deployment) map[string]string {
+	env := map[string]string{}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name != "discovery" {
+			continue
+		}
+		for _, e := range container.Env {
+			env[e.Name] = e.Value
+		}
+	}
+	return env
+}
@@ -0,0 +1,231 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package precheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"istio.io/istio/pkg/config/analysis/diag"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/ptr"
+)
+
+func TestParseCompatibilityRules(t *testing.T) {
+	rules, err := loadCompatibilityRules("")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, rules)
+
+	cases := []struct {
+		name  string
+		rules string
+		err   bool
+	}{
+		{
+			name: "valid",
+			rules: `
+- release: "1.24"
+  field: {kind: DestinationRule, path: spec.exportTo}
+  info: info
+  remediation: remediation`,
+		},
+		{
+			name: "no matcher",
+			rules: `
+- release: "1.24"
+  info: info`,
+			err: true,
+		},
+		{
+			name: "several matchers",
+			rules: `
+- release: "1.24"
+  annotation: foo
+  featureFlag: {name: FOO}`,
+			err: true,
+		},
+		{
+			name: "unknown kind",
+			rules: `
+- release: "1.24"
+  field: {kind: Deployment, path: spec.replicas}`,
+			err: true,
+		},
+		{
+			name: "invalid release",
+			rules: `
+- release: "latest"
+  annotation: foo`,
+			err: true,
+		},
+		{
+			name: "unknown field",
+			rules: `
+- release: "1.24"
+  annotations: foo`,
+			err: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := parseCompatibilityRules([]byte(c.rules))
+			if c.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFieldMatches(t *testing.T) {
+	obj := map[string]any{
+		"spec": map[string]any{
+			"exportTo": []any{"."},
+			"subsets": []any{
+				map[string]any{"name": "v1"},
+				map[string]any{"name": "v2", "trafficPolicy": map[string]any{"tls": map[string]any{"mode": "SIMPLE"}}},
+			},
+		},
+	}
+	assert.True(t, fieldMatches(obj, []string{"spec", "exportTo"}, ""))
+	assert.True(t, fieldMatches(obj, []string{"spec", "exportTo"}, "."))
+	assert.False(t, fieldMatches(obj, []string{"spec", "exportTo"}, "*"))
+	assert.True(t, fieldMatches(obj, []string{"spec", "subsets", "trafficPolicy", "tls", "mode"}, "SIMPLE"))
+	assert.False(t, fieldMatches(obj, []string{"spec", "trafficPolicy"}, ""))
+	assert.False(t, fieldMatches(obj, []string{"spec", "exportTo", "foo"}, ""))
+}
+
+func messageCodes(messages diag.Messages) []string {
+	var out []string
+	for _, m := range messages {
+		out = append(out, m.Type.Code()+" "+m.Resource.Origin.FriendlyName()+" "+m.Parameters[1].(string))
+	}
+	return out
+}
+
+func TestCheckCompatibilityRules(t *testing.T) {
+	rules, err := parseCompatibilityRules([]byte(`
+- release: "1.23"
+  featureFlag: {name: NEW_DEFAULT, unset: true}
+  info: info
+  remediation: remediation
+- release: "1.24"
+  featureFlag: {name: OLD_FLAG}
+  removed: true
+  info: info
+  remediation: remediation
+- release: "1.24"
+  annotation: example.com/deprecated
+  info: info
+  remediation: remediation
+- release: "1.24"
+  field: {kind: DestinationRule, path: spec.exportTo}
+  info: info
+  remediation: remediation
+- release: "1.25"
+  envoyFilter: {applyTo: HTTP_FILTER, contains: envoy.filters.http.lua}
+  info: info
+  remediation: remediation
+`))
+	assert.NoError(t, err)
+
+	istiod := func(name string, env ...corev1.EnvVar) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "istio-system", Labels: map[string]string{"app": "istiod"}},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "discovery", Env: env}},
+			}}},
+		}
+	}
+	pod := func(name, rs string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:         name,
+			GenerateName: rs + "-",
+			Namespace:    "default",
+			Labels:       map[string]string{"pod-template-hash": "5f8d7c6b9d"},
+			Annotations:  map[string]string{"example.com/deprecated": "true"},
+			OwnerReferences: []metav1.OwnerReference{{
+				Kind:       "ReplicaSet",
+				Name:       rs,
+				Controller: ptr.Of(true),
+			}},
+		}}
+	}
+	cli := kube.NewFakeClient(
+		istiod("istiod"),
+		istiod("istiod-canary", corev1.EnvVar{Name: "NEW_DEFAULT", Value: "false"}, corev1.EnvVar{Name: "OLD_FLAG", Value: "true"}),
+		pod("reviews-5f8d7c6b9d-a", "reviews-5f8d7c6b9d"),
+		pod("reviews-5f8d7c6b9d-b", "reviews-5f8d7c6b9d"),
+	)
+	create := func(u map[string]any) {
+		obj := &unstructured.Unstructured{Object: u}
+		s, _ := istioSchema(obj.GetKind())
+		_, err := cli.Dynamic().Resource(s.GroupVersionResource()).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	create(map[string]any{
+		"apiVersion": gvk.DestinationRule.GroupVersion(),
+		"kind":       gvk.DestinationRule.Kind,
+		"metadata":   map[string]any{"name": "reviews", "namespace": "default"},
+		"spec":       map[string]any{"host": "reviews", "exportTo": []any{"."}},
+	})
+	create(map[string]any{
+		"apiVersion": gvk.DestinationRule.GroupVersion(),
+		"kind":       gvk.DestinationRule.Kind,
+		"metadata":   map[string]any{"name": "ratings", "namespace": "default"},
+		"spec":       map[string]any{"host": "ratings"},
+	})
+	create(map[string]any{
+		"apiVersion": gvk.EnvoyFilter.GroupVersion(),
+		"kind":       gvk.EnvoyFilter.Kind,
+		"metadata":   map[string]any{"name": "lua", "namespace": "default"},
+		"spec": map[string]any{"configPatches": []any{
+			map[string]any{
+				"applyTo": "HTTP_FILTER",
+				"match":   map[string]any{"proxy": map[string]any{"proxyVersion": `^1\.22.*`}},
+				"patch":   map[string]any{"value": map[string]any{"name": "envoy.filters.http.lua"}},
+			},
+			map[string]any{
+				"applyTo": "CLUSTER",
+				"match":   map[string]any{"proxy": map[string]any{"proxyVersion": `^1\.(22|25).*`}},
+			},
+		}},
+	})
+
+	messages := diag.Messages{}
+	assert.NoError(t, checkCompatibilityRules(cli, "istio-system", rules, 22, 25, &messages))
+	assert.Equal(t, []string{
+		msg.UpgradeBehaviorChange.Code() + " DestinationRule default/reviews DestinationRule.spec.exportTo",
+		msg.UpgradeBehaviorChange.Code() + " EnvoyFilter default/lua configPatches[0]",
+		msg.UpgradeBehaviorChange.Code() + " EnvoyFilter default/lua configPatches[0]",
+		msg.UpgradeBehaviorChange.Code() + " Deployment default/reviews example.com/deprecated",
+		msg.UpgradeBehaviorChange.Code() + " Deployment istio-system/istiod NEW_DEFAULT",
+		msg.UpgradeRemovedConfig.Code() + " Deployment istio-system/istiod-canary OLD_FLAG",
+	}, messageCodes(messages))
+
+	// Only the rules of the releases after the from version and up to the to version apply.
+	messages = diag.Messages{}
+	assert.NoError(t, checkCompatibilityRules(cli, "istio-system", rules, 23, 23, &messages))
+	assert.Empty(t, messages)
+}
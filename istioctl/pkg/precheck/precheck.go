@@ -50,6 +50,7 @@ import (
 	"istio.io/istio/pkg/kube/controllers"
 	"istio.io/istio/pkg/url"
 	"istio.io/istio/pkg/util/sets"
+	istioversion "istio.io/istio/pkg/version"
 )
 
 func Cmd(ctx cli.Context) *cobra.Command {
@@ -58,6 +59,8 @@ func Cmd(ctx cli.Context) *cobra.Command {
 	outputThreshold := formatting.MessageThreshold{Level: diag.Warning}
 	var msgOutputFormat string
 	var fromCompatibilityVersion string
+	var toCompatibilityVersion string
+	var compatibilityRulesFile string
 	// cmd represents the upgradeCheck command
 	cmd := &cobra.Command{
 		Use:   "precheck",
@@ -70,7 +73,11 @@ func Cmd(ctx cli.Context) *cobra.Command {
   istioctl x precheck --namespace default
 
   # Check for behavioral changes since a specific version
-  istioctl x precheck --from-version 1.10`,
+  istioctl x precheck --from-version 1.10
+
+  # Check for behavioral changes and removed configuration when upgrading from 1.22 to 1.24,
+  # with additional compatibility rules
+  istioctl x precheck --from-version 1.22 --to-version 1.24 --compatibility-rules rules.yaml`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			msgs := diag.Messages{}
 			if !skipControlPlane {
@@ -81,7 +88,7 @@ func Cmd(ctx cli.Context) *cobra.Command {
 			}
 
 			if fromCompatibilityVersion != "" {
-				m, err := checkFromVersion(ctx, opts.Revision, fromCompatibilityVersion)
+				m, err := checkFromVersion(ctx, opts.Revision, fromCompatibilityVersion, toCompatibilityVersion, compatibilityRulesFile)
 				if err != nil {
 					return err
 				}
@@ -125,25 +132,36 @@ See %s for more information about causes and resolutions.`, url.ConfigAnalysis)
 		fmt.Sprintf("Output format: one of %v", formatting.MsgOutputFormatKeys))
 	cmd.PersistentFlags().StringVarP(&fromCompatibilityVersion, "from-version", "f", "",
 		"check changes since the provided version")
+	cmd.PersistentFlags().StringVar(&toCompatibilityVersion, "to-version", "",
+		"with --from-version, check changes up to the provided version instead of the version of istioctl")
+	cmd.PersistentFlags().StringVar(&compatibilityRulesFile, "compatibility-rules", "",
+		"with --from-version, a file of compatibility rules to check in addition to the built-in ones")
 	opts.AttachControlPlaneFlags(cmd)
 	return cmd
 }
 
-func checkFromVersion(ctx cli.Context, revision, version string) (diag.Messages, error) {
+// checkFromVersion reports the changes of behavior when upgrading from the version to toVersion, or to the
+// version of istioctl if it is empty, including the ones of the compatibility rules and of the rules file.
+func checkFromVersion(ctx cli.Context, revision, version, toVersion, rulesFile string) (diag.Messages, error) {
 	cli, err := ctx.CLIClientWithRevision(revision)
 	if err != nil {
 		return nil, err
 	}
-	major, minors, ok := strings.Cut(version, ".")
-	if !ok {
-		return nil, fmt.Errorf("invalid version %v, expected format like '1.0'", version)
+	minor, err := parseMinorVersion(version)
+	if err != nil {
+		return nil, err
 	}
-	if major != "1" {
-		return nil, fmt.Errorf("expected major version 1, got %v", version)
+	toMinor := -1
+	if toVersion != "" {
+		if toMinor, err = parseMinorVersion(toVersion); err != nil {
+			return nil, err
+		}
+	} else if m, err := parseMinorVersion(istioversion.Info.Version); err == nil {
+		toMinor = m
 	}
-	minor, err := strconv.Atoi(minors)
+	rules, err := loadCompatibilityRules(rulesFile)
 	if err != nil {
-		return nil, fmt.Errorf("minor version is not a number: %v", minors)
+		return nil, err
 	}
 
 	var messages diag.Messages = make([]diag.Message, 0)
@@ -162,9 +180,29 @@ func checkFromVersion(ctx cli.Context, revision, version string) (diag.Messages,
 			return nil, err
 		}
 	}
+	if err := checkCompatibilityRules(cli, ctx.IstioNamespace(), rules, minor, toMinor, &messages); err != nil {
+		return nil, err
+	}
 	return messages, nil
 }
 
+// parseMinorVersion returns the minor version of a version like 1.22, or 1.22.1.
+func parseMinorVersion(version string) (int, error) {
+	major, minors, ok := strings.Cut(version, ".")
+	if !ok {
+		return 0, fmt.Errorf("invalid version %v, expected format like '1.0'", version)
+	}
+	if major != "1" {
+		return 0, fmt.Errorf("expected major version 1, got %v", version)
+	}
+	minors, _, _ = strings.Cut(minors, ".")
+	minor, err := strconv.Atoi(minors)
+	if err != nil {
+		return 0, fmt.Errorf("minor version is not a number: %v", minors)
+	}
+	return minor, nil
+}
+
 func checkTracing(cli kube.CLIClient, messages *diag.Messages) error {
 	// In 1.22, we remove the default tracing config which points to zipkin.istio-system
 	// This has no effect for users, unless they have this service.
@@ -81,7 +81,7 @@ func Test_checkFromVersion(t *testing.T) {
 			ctx := cli.NewFakeContext(&cli.NewFakeContextOption{
 				IstioNamespace: "istio-system",
 			})
-			output, err := checkFromVersion(ctx, c.revision, c.version)
+			output, err := checkFromVersion(ctx, c.revision, c.version, "", "")
 
 			assert.Equal(t, output, c.expectedOutput)
 
@@ -0,0 +1,54 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/tools/bug-report/pkg/archive"
+)
+
+// bugReportDir is an extracted bug report archive to read the config dumps from, instead of the proxies.
+var bugReportDir string
+
+// errNotInBugReport is returned by the commands which need data not collected by bug reports.
+var errNotInBugReport = fmt.Errorf("this command is not supported with --bug-report, " +
+	"the data it needs is not collected by bug reports")
+
+// bugReportPodName returns the name and namespace of the pod of the bug report named by podflag,
+// in the form <name>[.<namespace>] or pod/<name>[.<namespace>].
+func bugReportPodName(ctx cli.Context, podflag, ns string) (string, string, error) {
+	name, namespace := handlers.InferPodInfo(podflag, ctx.NamespaceOrDefault(ns))
+	if strings.Contains(name, "/") {
+		resource, podName, _ := strings.Cut(name, "/")
+		if resource != "pod" && resource != "pods" && resource != "po" {
+			return "", "", fmt.Errorf("only pods can be used with --bug-report, got %q", podflag)
+		}
+		name = podName
+	}
+	return name, namespace, nil
+}
+
+// bugReportConfigDump returns the config dump of the pod from the bug report. It includes the endpoints.
+func bugReportConfigDump(podName, podNamespace string) ([]byte, error) {
+	a, err := archive.Open(bugReportDir)
+	if err != nil {
+		return nil, err
+	}
+	return a.ProxyConfigDump(podNamespace, podName)
+}
@@ -0,0 +1,158 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxyconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/completion"
+	dumputil "istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/istioctl/pkg/writer/compare"
+)
+
+const diffOutput = "diff"
+
+// configDumpSource is a config dump to diff, and how to label it.
+type configDumpSource struct {
+	name string
+	dump []byte
+}
+
+func diffConfigCmd(ctx cli.Context) *cobra.Command {
+	var files []string
+	var diffContext int
+
+	diffCmd := &cobra.Command{
+		Use:   "diff [<type>/]<name-1>[.<namespace-1>] [<type>/]<name-2>[.<namespace-2>]",
+		Short: "Diffs the configuration of two Envoys, or of an Envoy and a saved config dump",
+		Long: `Diff the listeners, routes, clusters, endpoints and secrets of two Envoy config dumps, each one either
+retrieved from the Envoy instance in a pod or read from a file. The resources are compared by name, ignoring
+volatile fields like version_info and last_updated, and the order of the endpoints.
+
+The config dumps from files are the first ones.`,
+		Example: `  # Diff the configuration of a working and a broken replica.
+  istioctl proxy-config diff productpage-v1-7bd5bd857c-shr9z.default productpage-v1-7bd5bd857c-xc72z.default
+
+  # Diff a config dump saved earlier with the current configuration of the same pod.
+  istioctl proxy-config diff --file saved-config-dump.json productpage-v1-7bd5bd857c-shr9z.default
+
+  # Diff two saved config dumps, printing the changes of each resource.
+  istioctl proxy-config diff --file before.json --file after.json -o diff`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args)+len(files) != 2 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("diff requires two config dumps, from pod names or --file parameters")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var sources []configDumpSource
+			for _, f := range files {
+				dump, err := readFile(f)
+				if err != nil {
+					return err
+				}
+				sources = append(sources, configDumpSource{name: f, dump: dump})
+			}
+			if len(args) > 0 {
+				kubeClient, err := ctx.CLIClient()
+				if err != nil {
+					return err
+				}
+				for _, arg := range args {
+					podName, podNamespace, err := getPodName(ctx, arg)
+					if err != nil {
+						return err
+					}
+					dump, err := extractConfigDump(kubeClient, podName, podNamespace, edsPath)
+					if err != nil {
+						return err
+					}
+					sources = append(sources, configDumpSource{name: podName + "." + podNamespace, dump: dump})
+				}
+			}
+			var wrappers []*dumputil.Wrapper
+			for _, s := range sources {
+				w := &dumputil.Wrapper{}
+				if err := json.Unmarshal(s.dump, w); err != nil {
+					return fmt.Errorf("could not parse config dump of %s: %v", s.name, err)
+				}
+				wrappers = append(wrappers, w)
+			}
+			diffs, err := compare.DiffConfigDumps(wrappers[0], wrappers[1], sources[0].name, sources[1].name, diffContext)
+			if err != nil {
+				return err
+			}
+			return printConfigDumpDiffs(c.OutOrStdout(), diffs, outputFormat)
+		},
+		ValidArgsFunction: completion.ValidPodsNameArgs(ctx),
+	}
+
+	diffCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short|diff")
+	diffCmd.PersistentFlags().StringArrayVarP(&files, "file", "f", nil,
+		"Envoy config dump JSON file, can be repeated")
+	diffCmd.PersistentFlags().IntVar(&diffContext, "context", 3,
+		"Number of lines of context around the changes with -o diff")
+	return diffCmd
+}
+
+func printConfigDumpDiffs(out io.Writer, diffs []compare.ResourceDiff, format string) error {
+	switch format {
+	case summaryOutput:
+		if len(diffs) == 0 {
+			_, _ = fmt.Fprintln(out, "Config dumps match")
+			return nil
+		}
+		w := new(tabwriter.Writer).Init(out, 0, 8, 5, ' ', 0)
+		_, _ = fmt.Fprintln(w, "TYPE\tNAME\tCHANGE")
+		for _, d := range diffs {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", d.Type, d.Name, d.Change)
+		}
+		return w.Flush()
+	case diffOutput:
+		if len(diffs) == 0 {
+			_, _ = fmt.Fprintln(out, "Config dumps match")
+			return nil
+		}
+		for _, d := range diffs {
+			_, _ = fmt.Fprintln(out, d.Diff)
+		}
+		return nil
+	case jsonOutput, yamlOutput:
+		if diffs == nil {
+			diffs = []compare.ResourceDiff{}
+		}
+		b, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return err
+		}
+		if format == yamlOutput {
+			if b, err = yaml.JSONToYAML(b); err != nil {
+				return err
+			}
+		}
+		_, _ = fmt.Fprintln(out, string(b))
+		return nil
+	default:
+		return fmt.Errorf("output format %q not supported", format)
+	}
+}
@@ -136,6 +136,9 @@ var (
 )
 
 func extractConfigDump(kubeClient kube.CLIClient, podName, podNamespace string, addtionPath string) ([]byte, error) {
+	if bugReportDir != "" {
+		return bugReportConfigDump(podName, podNamespace)
+	}
 	path := "config_dump" + addtionPath
 	debug, err := kubeClient.EnvoyDoWithPort(context.TODO(), podName, podNamespace, "GET", path, proxyAdminPort)
 	if err != nil {
@@ -309,6 +312,9 @@ func getLogLevelFromConfigMap(ctx cli.Context) (string, error) {
 }
 
 func setupPodClustersWriter(kubeClient kube.CLIClient, podName, podNamespace string, out io.Writer) (*clusters.ConfigWriter, error) {
+	if bugReportDir != "" {
+		return nil, fmt.Errorf("the endpoint health is not collected by bug reports, use `istioctl proxy-config eds` instead")
+	}
 	path := "clusters?format=json"
 	debug, err := kubeClient.EnvoyDoWithPort(context.TODO(), podName, podNamespace, "GET", path, proxyAdminPort)
 	if err != nil {
@@ -1302,12 +1308,14 @@ func ProxyConfig(ctx cli.Context) *cobra.Command {
 		Short: "Retrieve information about proxy configuration from Envoy [kube only]",
 		Long:  `A group of commands used to retrieve information about proxy configuration from the Envoy config dump`,
 		Example: `  # Retrieve information about proxy configuration from an Envoy instance.
-  istioctl proxy-config <clusters|listeners|routes|endpoints|ecds|bootstrap|log|secret> <pod-name[.namespace]>`,
+  istioctl proxy-config <clusters|listeners|routes|endpoints|ecds|bootstrap|log|secret|diff> <pod-name[.namespace]>`,
 		Aliases: []string{"pc"},
 	}
 
 	configCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|yaml|short")
 	configCmd.PersistentFlags().IntVar(&proxyAdminPort, "proxy-admin-port", defaultProxyAdminPort, "Envoy proxy admin port")
+	configCmd.PersistentFlags().StringVar(&bugReportDir, "bug-report", "",
+		"Read the config dumps of the proxies from an extracted bug report archive instead of the cluster.")
 
 	configCmd.AddCommand(clusterConfigCmd(ctx))
 	configCmd.AddCommand(allConfigCmd(ctx))
@@ -1320,11 +1328,15 @@ func ProxyConfig(ctx cli.Context) *cobra.Command {
 	configCmd.AddCommand(secretConfigCmd(ctx))
 	configCmd.AddCommand(rootCACompareConfigCmd(ctx))
 	configCmd.AddCommand(ecdsConfigCmd(ctx))
+	configCmd.AddCommand(diffConfigCmd(ctx))
 
 	return configCmd
 }
 
 func getPodNames(ctx cli.Context, podflag, ns string) ([]string, string, error) {
+	if bugReportDir != "" {
+		return nil, "", errNotInBugReport
+	}
 	podNames, ns, err := ctx.InferPodsFromTypedResource(podflag, ns)
 	if err != nil {
 		log.Errorf("pods lookup failed")
@@ -1338,6 +1350,9 @@ func getPodName(ctx cli.Context, podflag string) (string, string, error) {
 }
 
 func getPodNameWithNamespace(ctx cli.Context, podflag, ns string) (string, string, error) {
+	if bugReportDir != "" {
+		return bugReportPodName(ctx, podflag, ns)
+	}
 	var podName, podNamespace string
 	podName, podNamespace, err := ctx.InferPodInfoFromTypedResource(podflag, ns)
 	if err != nil {
@@ -1347,6 +1362,9 @@ func getPodNameWithNamespace(ctx cli.Context, podflag, ns string) (string, strin
 }
 
 func getPodNameBySelector(ctx cli.Context, kubeClient kube.CLIClient, labelSelector string) ([]string, string, error) {
+	if bugReportDir != "" {
+		return nil, "", errNotInBugReport
+	}
 	var (
 		podNames []string
 		ns       string
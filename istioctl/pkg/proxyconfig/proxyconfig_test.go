@@ -18,6 +18,8 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -225,6 +227,95 @@ func TestPrintProxyConfigSummary(t *testing.T) {
 	}
 }
 
+func TestProxyConfigBugReport(t *testing.T) {
+	dir := t.TempDir()
+	proxyDir := filepath.Join(dir, "bug-report", "proxies", "default", "httpbin-794b576b6c-qx6pf")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "bug-report", "cluster"), 0o755))
+	assert.NoError(t, os.MkdirAll(proxyDir, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(proxyDir, "config_dump?include_eds"), util.ReadFile(t, "testdata/config_dump.json"), 0o644))
+
+	cases := []execTestCase{
+		{
+			args:           []string{"all", "httpbin-794b576b6c-qx6pf", "--bug-report", dir},
+			expectedOutput: string(util.ReadFile(t, "testdata/config_dump_summary.txt")),
+		},
+		{
+			args:           []string{"clusters", "pod/httpbin-794b576b6c-qx6pf.default", "--bug-report", filepath.Join(dir, "bug-report")},
+			expectedString: "httpbin.default.svc.cluster.local",
+		},
+		{
+			args:           []string{"clusters", "invalid", "--bug-report", dir},
+			expectedString: "proxy invalid.default not found in the bug report",
+			wantException:  true,
+		},
+		{
+			args:           []string{"clusters", "deployment/httpbin", "--bug-report", dir},
+			expectedString: "only pods can be used with --bug-report",
+			wantException:  true,
+		},
+		{
+			args:           []string{"log", "httpbin-794b576b6c-qx6pf", "--bug-report", dir},
+			expectedString: "not supported with --bug-report",
+			wantException:  true,
+		},
+		{
+			args:           []string{"clusters", "httpbin-794b576b6c-qx6pf", "--bug-report", t.TempDir()},
+			expectedString: "is not an extracted bug report archive",
+			wantException:  true,
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyExecTestOutput(t, ProxyConfig(cli.NewFakeContext(&cli.NewFakeContextOption{
+				Namespace: "default",
+			})), c)
+		})
+	}
+}
+
+func TestProxyConfigDiff(t *testing.T) {
+	dump := util.ReadFile(t, "testdata/config_dump.json")
+	changed := filepath.Join(t.TempDir(), "config_dump.json")
+	// The version of the listeners is not significant, unlike the type of the dynamic cluster.
+	modified := strings.Replace(string(dump), `"version_info": "2023-12-26T05:57:39Z/1"`, `"version_info": "2023-12-26T06:00:00Z/2"`, 1)
+	modified = strings.Replace(modified, `"type": "EDS"`, `"type": "STRICT_DNS"`, 1)
+	assert.NoError(t, os.WriteFile(changed, []byte(modified), 0o644))
+
+	dir := t.TempDir()
+	proxyDir := filepath.Join(dir, "bug-report", "proxies", "default", "httpbin-794b576b6c-qx6pf")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "bug-report", "cluster"), 0o755))
+	assert.NoError(t, os.MkdirAll(proxyDir, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(proxyDir, "config_dump?include_eds"), dump, 0o644))
+
+	cases := []execTestCase{
+		{
+			args:           []string{"diff", "-f", "testdata/config_dump.json", "-f", "testdata/config_dump.json"},
+			expectedOutput: "Config dumps match\n",
+		},
+		{
+			args: []string{"diff", "-f", changed, "httpbin-794b576b6c-qx6pf", "--bug-report", dir},
+			expectedOutput: "TYPE        NAME                                                        CHANGE\n" +
+				"Cluster     inbound-vip|8000|http|httpbin.default.svc.cluster.local     Modified\n",
+		},
+		{
+			args:           []string{"diff", "-f", changed, "httpbin-794b576b6c-qx6pf", "--bug-report", dir, "-o", "diff"},
+			expectedString: "-    \"type\": \"STRICT_DNS\",\n+    \"type\": \"EDS\",",
+		},
+		{
+			args:           []string{"diff", "-f", changed},
+			expectedString: "diff requires two config dumps",
+			wantException:  true,
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyExecTestOutput(t, ProxyConfig(cli.NewFakeContext(&cli.NewFakeContextOption{
+				Namespace: "default",
+			})), c)
+		})
+	}
+}
+
 func init() {
 	cli.MakeKubeFactory = func(k kube.CLIClient) cmdutil.Factory {
 		tf := cmdtesting.NewTestFactory()
@@ -19,6 +19,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/spf13/cobra"
@@ -31,6 +34,7 @@ import (
 	"istio.io/istio/istioctl/pkg/writer/compare"
 	"istio.io/istio/istioctl/pkg/writer/pilot"
 	pilotxds "istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/log"
 )
 
@@ -78,6 +82,7 @@ func XdsStatusCommand(ctx cli.Context) *cobra.Command {
 	var opts clioptions.ControlPlaneOptions
 	var centralOpts clioptions.CentralControlPlaneOptions
 	var multiXdsOpts multixds.Options
+	var wOpts watchOptions
 
 	statusCmd := &cobra.Command{
 		Use:   "proxy-status [<type>/]<name>[.<namespace>]",
@@ -94,6 +99,13 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
   # Retrieve sync diff for a single Envoy and Istiod
   istioctl proxy-status istio-egressgateway-59585c5b9c-ndc59.istio-system
 
+  # Watch the Envoys in a specific namespace, and print a JSON line event for each one that is STALE
+  # or whose config differs from Istiod for more than 2 minutes
+  istioctl proxy-status --namespace foo --watch --drift-threshold 2m
+
+  # Watch specific Envoys
+  istioctl proxy-status --watch productpage-v1-7bd5bd857c-shr9z.default deployment/reviews-v1.default
+
   # SECURITY OPTIONS
 
   # Retrieve proxy status information directly from the control plane, using token security
@@ -120,6 +132,18 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
 			}
 			multiXdsOpts.MessageWriter = c.OutOrStdout()
 
+			if wOpts.watch {
+				// Keep the output to the events.
+				multiXdsOpts.MessageWriter = c.ErrOrStderr()
+				w, err := newWatcher(ctx, kubeClient, c.OutOrStdout(), args, wOpts, centralOpts, multiXdsOpts)
+				if err != nil {
+					return err
+				}
+				sigCtx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer cancel()
+				return w.Run(sigCtx)
+			}
+
 			if len(args) > 0 {
 				podName, ns, err := ctx.InferPodInfoFromTypedResource(args[0], ctx.Namespace())
 				if err != nil {
@@ -133,23 +157,11 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
 				var envoyDump []byte
 				if configDumpFile != "" {
 					envoyDump, err = readConfigFile(configDumpFile)
-				} else {
-					path := "config_dump"
-					envoyDump, err = kubeClient.EnvoyDo(context.TODO(), podName, ns, "GET", path)
-				}
-				if err != nil {
-					return fmt.Errorf("could not contact sidecar: %w", err)
-				}
-
-				xdsRequest := discovery.DiscoveryRequest{
-					ResourceNames: []string{fmt.Sprintf("%s.%s", podName, ns)},
-					TypeUrl:       pilotxds.TypeDebugConfigDump,
-				}
-				xdsResponses, err := multixds.FirstRequestAndProcessXds(&xdsRequest, centralOpts, ctx.IstioNamespace(), "", "", kubeClient, multiXdsOpts)
-				if err != nil {
-					return err
+					if err != nil {
+						return fmt.Errorf("could not contact sidecar: %w", err)
+					}
 				}
-				c, err := compare.NewXdsComparator(c.OutOrStdout(), xdsResponses, envoyDump)
+				c, err := newComparator(ctx, kubeClient, c.OutOrStdout(), podName, ns, envoyDump, centralOpts, multiXdsOpts)
 				if err != nil {
 					return err
 				}
@@ -175,6 +187,13 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
 	centralOpts.AttachControlPlaneFlags(statusCmd)
 	statusCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
 		"Envoy config dump JSON file")
+	statusCmd.PersistentFlags().BoolVarP(&wOpts.watch, "watch", "w", false,
+		"Continuously sample the proxies, and print a JSON line event for each one that is out of sync with Istiod "+
+			"for longer than --drift-threshold")
+	statusCmd.PersistentFlags().DurationVar(&wOpts.interval, "watch-interval", 30*time.Second,
+		"Time between two samples of the proxies with --watch")
+	statusCmd.PersistentFlags().DurationVar(&wOpts.threshold, "drift-threshold", time.Minute,
+		"How long a proxy may be STALE or have a config different from Istiod before an event is printed with --watch")
 	statusCmd.PersistentFlags().BoolVar(&multiXdsOpts.XdsViaAgents, "xds-via-agents", false,
 		"Access Istiod via the tap service of each agent")
 	statusCmd.PersistentFlags().IntVar(&multiXdsOpts.XdsViaAgentsLimit, "xds-via-agents-limit", 100,
@@ -183,3 +202,26 @@ Retrieves last sent and last acknowledged xDS sync from Istiod to each Envoy in
 
 	return statusCmd
 }
+
+// newComparator returns a comparator of the config of the proxy of the pod, from its config dump or from the
+// proxy if it is empty, with the config Istiod generated for it.
+func newComparator(ctx cli.Context, kubeClient kube.CLIClient, w io.Writer, podName, ns string, envoyDump []byte,
+	centralOpts clioptions.CentralControlPlaneOptions, multiXdsOpts multixds.Options,
+) (*compare.Comparator, error) {
+	if envoyDump == nil {
+		var err error
+		envoyDump, err = kubeClient.EnvoyDo(context.TODO(), podName, ns, "GET", "config_dump")
+		if err != nil {
+			return nil, fmt.Errorf("could not contact sidecar: %w", err)
+		}
+	}
+	xdsRequest := discovery.DiscoveryRequest{
+		ResourceNames: []string{fmt.Sprintf("%s.%s", podName, ns)},
+		TypeUrl:       pilotxds.TypeDebugConfigDump,
+	}
+	xdsResponses, err := multixds.FirstRequestAndProcessXds(&xdsRequest, centralOpts, ctx.IstioNamespace(), "", "", kubeClient, multiXdsOpts)
+	if err != nil {
+		return nil, err
+	}
+	return compare.NewXdsComparator(w, xdsResponses, envoyDump)
+}
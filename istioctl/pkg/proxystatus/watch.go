@@ -0,0 +1,282 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxystatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/istioctl/pkg/writer/compare"
+	"istio.io/istio/istioctl/pkg/writer/pilot"
+	pilotxds "istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+type watchOptions struct {
+	watch     bool
+	interval  time.Duration
+	threshold time.Duration
+}
+
+// EventType is the type of a drift event.
+type EventType string
+
+const (
+	// EventDrift is emitted when a proxy has been STALE or diverged from Istiod for longer than the threshold, and
+	// again each time the way it is out of sync changes.
+	EventDrift EventType = "Drift"
+	// EventRecovered is emitted when a proxy that drifted is in sync with Istiod again, or has disconnected.
+	EventRecovered EventType = "Recovered"
+	// EventError is emitted when a proxy could not be sampled.
+	EventError EventType = "Error"
+)
+
+// Event is a drift event, emitted as a JSON line by `proxy-status --watch`.
+type Event struct {
+	Time  time.Time `json:"time"`
+	Type  EventType `json:"type"`
+	Proxy string    `json:"proxy,omitempty"`
+	// Istiod is the Istiod the proxy is connected to.
+	Istiod string `json:"istiod,omitempty"`
+	// Since is when the proxy was first seen out of sync.
+	Since *time.Time `json:"since,omitempty"`
+	// Stale are the xDS types Istiod sent to the proxy which were not acknowledged yet.
+	Stale []string `json:"stale,omitempty"`
+	// Diff is the resources whose config differs between the proxy and Istiod.
+	Diff  *compare.Drift `json:"diff,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// proxyDrift is how a watched proxy is out of sync. diff is nil if the configs match.
+type proxyDrift struct {
+	since    time.Time
+	stale    []string
+	diff     *compare.Drift
+	reported bool
+}
+
+func (d *proxyDrift) inSync() bool {
+	return len(d.stale) == 0 && d.diff == nil
+}
+
+func (d *proxyDrift) same(other *proxyDrift) bool {
+	return slices.Equal(d.stale, other.stale) && d.diff.Equal(other.diff)
+}
+
+// watcher samples the sync status and the config of proxies, and emits events for the ones out of sync for too long.
+type watcher struct {
+	// Proxies are the IDs of the watched proxies. All the proxies connected to Istiod are watched if empty.
+	Proxies []string
+	// Threshold is how long a proxy may be out of sync before an event is emitted.
+	Threshold time.Duration
+	// Interval is the time between samples.
+	Interval time.Duration
+	// SyncStatus returns the sync status of the proxies connected to Istiod.
+	SyncStatus func() ([]pilot.ProxyStatus, error)
+	// ConfigDrift returns the resources whose config differs between the proxy and Istiod.
+	ConfigDrift func(proxyID string) (*compare.Drift, error)
+	Writer      io.Writer
+
+	drifts map[string]*proxyDrift
+}
+
+// Run samples the proxies every interval until the context is done.
+func (w *watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.sample(time.Now()); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample samples the proxies once. It only fails if the events cannot be written; failures to sample are
+// reported as events.
+func (w *watcher) sample(now time.Time) error {
+	if w.drifts == nil {
+		w.drifts = map[string]*proxyDrift{}
+	}
+	statuses, err := w.SyncStatus()
+	if err != nil {
+		return w.emit(Event{Time: now, Type: EventError, Error: err.Error()})
+	}
+	connected := sets.New[string]()
+	watched := sets.New(w.Proxies...)
+	for _, status := range statuses {
+		if len(w.Proxies) > 0 && !watched.Contains(status.ProxyID) {
+			continue
+		}
+		connected.Insert(status.ProxyID)
+		current := &proxyDrift{stale: status.Stale()}
+		// Only Envoy proxies have a config to compare, ztunnel does not subscribe to clusters.
+		if _, f := status.Statuses["CDS"]; f {
+			current.diff, err = w.ConfigDrift(status.ProxyID)
+			if err != nil {
+				if err := w.emit(Event{Time: now, Type: EventError, Proxy: status.ProxyID, Istiod: status.IstiodID, Error: err.Error()}); err != nil {
+					return err
+				}
+				continue
+			}
+			if current.diff.Empty() {
+				current.diff = nil
+			}
+		}
+		if err := w.update(now, status, current); err != nil {
+			return err
+		}
+	}
+	for _, proxy := range sets.SortedList(watched.Difference(connected)) {
+		if err := w.emit(Event{Time: now, Type: EventError, Proxy: proxy, Error: "proxy is not connected to Istiod"}); err != nil {
+			return err
+		}
+	}
+	// Forget the proxies which disconnected, for example because their pod was deleted.
+	for _, proxy := range sets.SortedList(sets.New(maps.Keys(w.drifts)...).Difference(connected)) {
+		d := w.drifts[proxy]
+		delete(w.drifts, proxy)
+		if d.reported {
+			if err := w.emit(Event{Time: now, Type: EventRecovered, Proxy: proxy, Since: &d.since}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// update tracks the drift of a proxy, and emits the events once it has been out of sync for the threshold.
+func (w *watcher) update(now time.Time, status pilot.ProxyStatus, current *proxyDrift) error {
+	previous := w.drifts[status.ProxyID]
+	if current.inSync() {
+		delete(w.drifts, status.ProxyID)
+		if previous != nil && previous.reported {
+			return w.emit(Event{Time: now, Type: EventRecovered, Proxy: status.ProxyID, Istiod: status.IstiodID, Since: &previous.since})
+		}
+		return nil
+	}
+	current.since = now
+	if previous != nil {
+		current.since = previous.since
+		current.reported = previous.reported
+		if previous.reported && previous.same(current) {
+			w.drifts[status.ProxyID] = current
+			return nil
+		}
+	}
+	w.drifts[status.ProxyID] = current
+	if now.Sub(current.since) < w.Threshold {
+		return nil
+	}
+	current.reported = true
+	e := Event{
+		Time:   now,
+		Type:   EventDrift,
+		Proxy:  status.ProxyID,
+		Istiod: status.IstiodID,
+		Since:  &current.since,
+		Stale:  current.stale,
+		Diff:   current.diff,
+	}
+	return w.emit(e)
+}
+
+func (w *watcher) emit(e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w.Writer, string(b))
+	return err
+}
+
+// newWatcher returns a watcher of the proxies of the pods named by args, or of all the proxies of the namespace
+// if there are none.
+func newWatcher(ctx cli.Context, kubeClient kube.CLIClient, out io.Writer, args []string, opts watchOptions,
+	centralOpts clioptions.CentralControlPlaneOptions, multiXdsOpts multixds.Options,
+) (*watcher, error) {
+	if configDumpFile != "" {
+		return nil, fmt.Errorf("--file cannot be used with --watch")
+	}
+	if opts.interval <= 0 {
+		return nil, fmt.Errorf("--watch-interval must be positive")
+	}
+	var proxies []string
+	for _, arg := range args {
+		podName, ns, err := ctx.InferPodInfoFromTypedResource(arg, ctx.Namespace())
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, podName+"."+ns)
+	}
+	// The proxies named by args may be in any namespace.
+	namespace := ctx.Namespace()
+	if len(proxies) > 0 {
+		namespace = ""
+	}
+	return &watcher{
+		Proxies:   proxies,
+		Threshold: opts.threshold,
+		Interval:  opts.interval,
+		SyncStatus: func() ([]pilot.ProxyStatus, error) {
+			xdsRequest := discovery.DiscoveryRequest{
+				TypeUrl: pilotxds.TypeDebugSyncronization,
+			}
+			xdsResponses, err := multixds.AllRequestAndProcessXds(&xdsRequest, centralOpts, ctx.IstioNamespace(), "", "", kubeClient, multiXdsOpts)
+			if err != nil {
+				return nil, err
+			}
+			return pilot.ProxyStatuses(xdsResponses, namespace)
+		},
+		ConfigDrift: func(proxyID string) (*compare.Drift, error) {
+			podName, ns, err := splitProxyID(proxyID)
+			if err != nil {
+				return nil, err
+			}
+			c, err := newComparator(ctx, kubeClient, io.Discard, podName, ns, nil, centralOpts, multiXdsOpts)
+			if err != nil {
+				return nil, err
+			}
+			return c.Drift()
+		},
+		Writer: out,
+	}, nil
+}
+
+// splitProxyID returns the pod name and namespace of a proxy ID, `<pod>.<namespace>`. Namespaces cannot contain
+// dots, unlike pod names.
+func splitProxyID(proxyID string) (string, string, error) {
+	i := strings.LastIndex(proxyID, ".")
+	if i <= 0 || i == len(proxyID)-1 {
+		return "", "", fmt.Errorf("invalid proxy ID %q, expected <pod>.<namespace>", proxyID)
+	}
+	return proxyID[:i], proxyID[i+1:], nil
+}
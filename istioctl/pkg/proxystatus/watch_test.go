@@ -0,0 +1,164 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxystatus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	xdsstatus "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
+
+	"istio.io/istio/istioctl/pkg/writer/compare"
+	"istio.io/istio/istioctl/pkg/writer/pilot"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func syncStatus(proxyID string, cds xdsstatus.ConfigStatus) pilot.ProxyStatus {
+	return pilot.ProxyStatus{
+		ProxyID:  proxyID,
+		IstiodID: "istiod-1",
+		Statuses: map[string]xdsstatus.ConfigStatus{
+			"CDS": cds,
+			"LDS": xdsstatus.ConfigStatus_SYNCED,
+		},
+	}
+}
+
+func events(t *testing.T, out *bytes.Buffer) []Event {
+	var events []Event
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		e := Event{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &e))
+		events = append(events, e)
+	}
+	out.Reset()
+	return events
+}
+
+func TestWatcher(t *testing.T) {
+	statuses := []pilot.ProxyStatus{
+		syncStatus("a.default", xdsstatus.ConfigStatus_SYNCED),
+		syncStatus("b.default", xdsstatus.ConfigStatus_STALE),
+	}
+	drifts := map[string]*compare.Drift{}
+	out := &bytes.Buffer{}
+	w := &watcher{
+		Threshold: time.Minute,
+		SyncStatus: func() ([]pilot.ProxyStatus, error) {
+			return statuses, nil
+		},
+		ConfigDrift: func(proxyID string) (*compare.Drift, error) {
+			if d, f := drifts[proxyID]; f {
+				return d, nil
+			}
+			return &compare.Drift{}, nil
+		},
+		Writer: out,
+	}
+	start := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+
+	// Out of sync for less than the threshold.
+	assert.NoError(t, w.sample(start))
+	assert.Equal(t, len(events(t, out)), 0)
+
+	drifts["a.default"] = &compare.Drift{Clusters: []string{"outbound|80||b.default.svc.cluster.local"}}
+	assert.NoError(t, w.sample(start.Add(30*time.Second)))
+	assert.Equal(t, len(events(t, out)), 0)
+
+	// b has been STALE for the threshold.
+	assert.NoError(t, w.sample(start.Add(time.Minute)))
+	got := events(t, out)
+	assert.Equal(t, len(got), 1)
+	assert.Equal(t, got[0].Type, EventDrift)
+	assert.Equal(t, got[0].Proxy, "b.default")
+	assert.Equal(t, got[0].Stale, []string{"CDS"})
+	assert.Equal(t, got[0].Since.Equal(start), true)
+
+	// a has diverged for the threshold, b is still STALE the same way and is not reported again.
+	assert.NoError(t, w.sample(start.Add(90*time.Second)))
+	got = events(t, out)
+	assert.Equal(t, len(got), 1)
+	assert.Equal(t, got[0].Proxy, "a.default")
+	assert.Equal(t, got[0].Diff.Clusters, []string{"outbound|80||b.default.svc.cluster.local"})
+	assert.Equal(t, got[0].Stale, nil)
+
+	// a diverges differently, b recovers.
+	drifts["a.default"] = &compare.Drift{Routes: []string{"80"}}
+	statuses[1] = syncStatus("b.default", xdsstatus.ConfigStatus_SYNCED)
+	assert.NoError(t, w.sample(start.Add(2*time.Minute)))
+	got = events(t, out)
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[0].Type, EventDrift)
+	assert.Equal(t, got[0].Diff.Routes, []string{"80"})
+	assert.Equal(t, got[1].Type, EventRecovered)
+	assert.Equal(t, got[1].Proxy, "b.default")
+
+	// a disconnects.
+	statuses = statuses[1:]
+	assert.NoError(t, w.sample(start.Add(3*time.Minute)))
+	got = events(t, out)
+	assert.Equal(t, len(got), 1)
+	assert.Equal(t, got[0].Type, EventRecovered)
+	assert.Equal(t, got[0].Proxy, "a.default")
+}
+
+func TestWatcherSelectedProxies(t *testing.T) {
+	out := &bytes.Buffer{}
+	compared := []string{}
+	w := &watcher{
+		Proxies: []string{"a.default", "c.default"},
+		SyncStatus: func() ([]pilot.ProxyStatus, error) {
+			return []pilot.ProxyStatus{
+				syncStatus("a.default", xdsstatus.ConfigStatus_SYNCED),
+				syncStatus("b.default", xdsstatus.ConfigStatus_STALE),
+				// ztunnel has no config to compare.
+				{ProxyID: "ztunnel.istio-system", Statuses: map[string]xdsstatus.ConfigStatus{"WDS": xdsstatus.ConfigStatus_SYNCED}},
+			}, nil
+		},
+		ConfigDrift: func(proxyID string) (*compare.Drift, error) {
+			compared = append(compared, proxyID)
+			return nil, fmt.Errorf("could not contact sidecar")
+		},
+		Writer: out,
+	}
+	assert.NoError(t, w.sample(time.Now()))
+	assert.Equal(t, compared, []string{"a.default"})
+	got := events(t, out)
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[0].Type, EventError)
+	assert.Equal(t, got[0].Proxy, "a.default")
+	assert.Equal(t, got[0].Error, "could not contact sidecar")
+	assert.Equal(t, got[1].Type, EventError)
+	assert.Equal(t, got[1].Proxy, "c.default")
+}
+
+func TestSplitProxyID(t *testing.T) {
+	pod, ns, err := splitProxyID("my.pod.default")
+	assert.NoError(t, err)
+	assert.Equal(t, pod, "my.pod")
+	assert.Equal(t, ns, "default")
+	for _, id := range []string{"pod", ".default", "pod."} {
+		if _, _, err := splitProxyID(id); err == nil {
+			t.Errorf("expected an error for %q", id)
+		}
+	}
+}
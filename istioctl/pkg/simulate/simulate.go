@@ -0,0 +1,404 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/completion"
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	"istio.io/istio/pilot/pkg/simulation"
+	istiocluster "istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh"
+)
+
+const (
+	summaryOutput = "short"
+	jsonOutput    = "json"
+)
+
+type options struct {
+	configDumpFile string
+	configFiles    []string
+	outputFormat   string
+
+	proxyType      string
+	proxyNamespace string
+	proxyLabels    map[string]string
+	proxyIP        string
+
+	address  string
+	port     int
+	protocol string
+	tls      string
+	host     string
+	path     string
+	sni      string
+	alpn     string
+	headers  []string
+	callMode string
+}
+
+func Cmd(ctx cli.Context) *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "simulate [<type>/]<name>[.<namespace>]",
+		Short: "Simulate a request against the configuration of a proxy",
+		Long: `Simulate a request against the Envoy configuration of a proxy, reporting which listener, filter chain,
+route, and cluster the request would hit, and why.
+
+The configuration can be retrieved from a running pod, read from an Envoy config dump file, or generated offline
+from a set of Istio configuration and Kubernetes Service YAML files.
+
+THIS COMMAND IS UNDER ACTIVE DEVELOPMENT AND NOT READY FOR PRODUCTION USE.
+`,
+		Example: `  # Simulate an HTTP request from a pod to reviews:9080
+  istioctl x simulate productpage-v1-7d8f5b6f5c-9q2xk --port 9080 --host reviews:9080 --path /reviews/0
+
+  # Simulate an mTLS request received by a pod on port 9080
+  istioctl x simulate deployment/reviews-v1 --mode inbound --port 9080 --tls mtls
+
+  # Simulate a request using an Envoy config dump
+  istioctl x simulate --file envoy-config.json --port 80 --host httpbin.example.com
+
+  # Simulate a request through an ingress gateway, generating its configuration from config files
+  istioctl x simulate --config gateway.yaml --config services.yaml --proxy-type router \
+    --proxy-namespace istio-system --proxy-labels istio=ingressgateway --mode gateway --port 80 --host httpbin.example.com
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			sources := len(args)
+			if o.configDumpFile != "" {
+				sources++
+			}
+			if len(o.configFiles) > 0 {
+				sources++
+			}
+			if len(args) > 1 || sources != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("simulate requires exactly one of a pod name, --file, or --config")
+			}
+			if o.port == 0 {
+				return fmt.Errorf("--port must be set")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			call, err := o.call()
+			if err != nil {
+				return err
+			}
+			var sim *simulation.Simulation
+			switch {
+			case len(args) == 1:
+				sim, err = o.simulationFromPod(ctx, args[0])
+			case o.configDumpFile != "":
+				sim, err = o.simulationFromConfigDumpFile()
+			default:
+				sim, err = o.simulationFromConfigFiles()
+			}
+			if err != nil {
+				return err
+			}
+			return printResult(cmd.OutOrStdout(), sim.Run(call), o.outputFormat)
+		},
+		ValidArgsFunction: completion.ValidPodsNameArgs(ctx),
+	}
+
+	cmd.Flags().StringVarP(&o.configDumpFile, "file", "f", "", "Envoy config dump JSON file")
+	cmd.Flags().StringSliceVar(&o.configFiles, "config", nil,
+		"Istio configuration and Kubernetes Service YAML files to generate the proxy configuration from")
+	cmd.Flags().StringVarP(&o.outputFormat, "output", "o", summaryOutput, "Output format: one of json|short")
+
+	cmd.Flags().StringVar(&o.proxyType, "proxy-type", string(model.SidecarProxy),
+		"Type of the proxy to generate configuration for, when using --config: one of sidecar|router")
+	cmd.Flags().StringVar(&o.proxyNamespace, "proxy-namespace", "default",
+		"Namespace of the proxy to generate configuration for, when using --config")
+	cmd.Flags().StringToStringVar(&o.proxyLabels, "proxy-labels", nil,
+		"Labels of the proxy to generate configuration for, when using --config")
+	cmd.Flags().StringVar(&o.proxyIP, "proxy-ip", "1.1.1.1", "IP address of the proxy to generate configuration for, when using --config")
+
+	cmd.Flags().StringVar(&o.address, "address", "", "Destination IP address of the request")
+	cmd.Flags().IntVar(&o.port, "port", 0, "Destination port of the request")
+	cmd.Flags().StringVar(&o.protocol, "protocol", string(simulation.HTTP), "Protocol of the request: one of http|http2|tcp")
+	cmd.Flags().StringVar(&o.tls, "tls", string(simulation.Plaintext), "TLS mode of the request: one of plaintext|tls|mtls")
+	cmd.Flags().StringVar(&o.host, "host", "", "Host header of the request")
+	cmd.Flags().StringVar(&o.path, "path", "/", "Path of the request")
+	cmd.Flags().StringVar(&o.sni, "sni", "", "SNI of the request. Defaults to the host for TLS requests")
+	cmd.Flags().StringVar(&o.alpn, "alpn", "", "ALPN of the request. Defaults based on the protocol for TLS requests")
+	cmd.Flags().StringSliceVar(&o.headers, "header", nil, "Additional headers of the request, in the form name=value")
+	cmd.Flags().StringVar(&o.callMode, "mode", "",
+		"How the request reaches the proxy: one of outbound|inbound|gateway. "+
+			"Defaults to gateway for --proxy-type=router, and outbound otherwise")
+
+	return cmd
+}
+
+func (o *options) call() (simulation.Call, error) {
+	call := simulation.Call{
+		Address:    o.address,
+		Port:       o.port,
+		Path:       o.path,
+		Protocol:   simulation.Protocol(o.protocol),
+		TLS:        simulation.TLSMode(o.tls),
+		Alpn:       o.alpn,
+		HostHeader: o.host,
+		Sni:        o.sni,
+		CallMode:   simulation.CallMode(o.callMode),
+		Headers:    http.Header{},
+	}
+	if call.CallMode == "" {
+		call.CallMode = simulation.CallModeOutbound
+		if len(o.configFiles) > 0 && model.NodeType(o.proxyType) == model.Router {
+			call.CallMode = simulation.CallModeGateway
+		}
+	}
+	switch call.Protocol {
+	case simulation.HTTP, simulation.HTTP2, simulation.TCP:
+	default:
+		return call, fmt.Errorf("unknown protocol %q", o.protocol)
+	}
+	switch call.TLS {
+	case simulation.Plaintext, simulation.TLS, simulation.MTLS:
+	default:
+		return call, fmt.Errorf("unknown TLS mode %q", o.tls)
+	}
+	switch call.CallMode {
+	case simulation.CallModeOutbound, simulation.CallModeInbound, simulation.CallModeGateway:
+	default:
+		return call, fmt.Errorf("unknown mode %q", o.callMode)
+	}
+	for _, h := range o.headers {
+		k, v, ok := strings.Cut(h, "=")
+		if !ok {
+			return call, fmt.Errorf("invalid header %q, expected name=value", h)
+		}
+		call.Headers.Add(k, v)
+	}
+	return call, nil
+}
+
+func (o *options) simulationFromPod(ctx cli.Context, pod string) (*simulation.Simulation, error) {
+	kubeClient, err := ctx.CLIClient()
+	if err != nil {
+		return nil, err
+	}
+	podName, podNamespace, err := ctx.InferPodInfoFromTypedResource(pod, ctx.Namespace())
+	if err != nil {
+		return nil, err
+	}
+	dump, err := kubeClient.EnvoyDo(context.TODO(), podName, podNamespace, "GET", "config_dump")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve config dump from %s.%s: %v", podName, podNamespace, err)
+	}
+	return simulationFromConfigDump(dump)
+}
+
+func (o *options) simulationFromConfigDumpFile() (*simulation.Simulation, error) {
+	dump, err := os.ReadFile(o.configDumpFile)
+	if err != nil {
+		return nil, err
+	}
+	return simulationFromConfigDump(dump)
+}
+
+// simulationFromConfigDump builds a simulation from the listeners, routes, and clusters of an Envoy config dump.
+func simulationFromConfigDump(dump []byte) (*simulation.Simulation, error) {
+	cd := &configdump.Wrapper{}
+	if err := cd.UnmarshalJSON(dump); err != nil {
+		return nil, fmt.Errorf("failed to parse config dump: %v", err)
+	}
+	sim := &simulation.Simulation{}
+
+	listeners, err := cd.GetDynamicListenerDump(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range listeners.DynamicListeners {
+		res := &listener.Listener{}
+		if err := l.ActiveState.Listener.UnmarshalTo(res); err != nil {
+			return nil, err
+		}
+		sim.Listeners = append(sim.Listeners, res)
+	}
+
+	routes, err := cd.GetDynamicRouteDump(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range routes.DynamicRouteConfigs {
+		res := &route.RouteConfiguration{}
+		if err := r.RouteConfig.UnmarshalTo(res); err != nil {
+			return nil, err
+		}
+		sim.Routes = append(sim.Routes, res)
+	}
+
+	clusters, err := cd.GetDynamicClusterDump(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clusters.DynamicActiveClusters {
+		res := &cluster.Cluster{}
+		if err := c.Cluster.UnmarshalTo(res); err != nil {
+			return nil, err
+		}
+		sim.Clusters = append(sim.Clusters, res)
+	}
+	return sim, nil
+}
+
+// simulationFromConfigFiles generates the configuration of the proxy from Istio configuration and
+// Kubernetes Services, as istiod would.
+func (o *options) simulationFromConfigFiles() (*simulation.Simulation, error) {
+	configs, services, err := readConfigFiles(o.configFiles)
+	if err != nil {
+		return nil, err
+	}
+	proxyType := model.NodeType(o.proxyType)
+	if proxyType != model.SidecarProxy && proxyType != model.Router {
+		return nil, fmt.Errorf("unknown proxy type %q", o.proxyType)
+	}
+	sim, err := simulation.NewSimulationFromConfigs(configs, services, nil, &model.Proxy{
+		Type:            proxyType,
+		ConfigNamespace: o.proxyNamespace,
+		Labels:          o.proxyLabels,
+		IPAddresses:     []string{o.proxyIP},
+		Metadata: &model.NodeMetadata{
+			Labels: o.proxyLabels,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proxy configuration: %v", err)
+	}
+	return sim, nil
+}
+
+// readConfigFiles reads the Istio configuration and Kubernetes Services from the files.
+// Other Kubernetes resources are ignored.
+func readConfigFiles(files []string) ([]config.Config, []*model.Service, error) {
+	var configs []config.Config
+	var services []*model.Service
+	meshConfig := mesh.DefaultMeshConfig()
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfgs, others, err := crd.ParseInputs(string(b))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %v: %v", f, err)
+		}
+		configs = append(configs, cfgs...)
+		for _, obj := range others {
+			if obj.Kind != "Service" || obj.APIVersion != "v1" {
+				continue
+			}
+			svc := corev1.Service{ObjectMeta: obj.ObjectMeta}
+			if err := json.Unmarshal(obj.Spec, &svc.Spec); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse Service %v: %v", obj.Name, err)
+			}
+			if svc.Namespace == "" {
+				svc.Namespace = "default"
+			}
+			services = append(services, kube.ConvertService(svc, constants.DefaultClusterLocalDomain, istiocluster.ID(constants.DefaultClusterName), meshConfig))
+		}
+	}
+	return configs, services, nil
+}
+
+func printResult(w io.Writer, r simulation.Result, outputFormat string) error {
+	switch outputFormat {
+	case jsonOutput:
+		out := struct {
+			Error              string   `json:"error,omitempty"`
+			ListenerMatched    string   `json:"listener,omitempty"`
+			FilterChainMatched string   `json:"filterChain,omitempty"`
+			MTLSRequired       bool     `json:"mtlsRequired"`
+			RouteConfigMatched string   `json:"routeConfig,omitempty"`
+			VirtualHostMatched string   `json:"virtualHost,omitempty"`
+			RouteMatched       string   `json:"route,omitempty"`
+			ClusterMatched     string   `json:"cluster,omitempty"`
+			Trace              []string `json:"trace,omitempty"`
+		}{
+			ListenerMatched:    r.ListenerMatched,
+			FilterChainMatched: r.FilterChainMatched,
+			MTLSRequired:       r.MTLSRequired,
+			RouteConfigMatched: r.RouteConfigMatched,
+			VirtualHostMatched: r.VirtualHostMatched,
+			RouteMatched:       r.RouteMatched,
+			ClusterMatched:     r.ClusterMatched,
+			Trace:              r.Trace,
+		}
+		if r.Error != nil {
+			out.Error = r.Error.Error()
+		}
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case summaryOutput:
+		tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+		row := func(name, value string) {
+			if value != "" {
+				_, _ = fmt.Fprintf(tw, "%s:\t%s\n", name, value)
+			}
+		}
+		row("LISTENER", r.ListenerMatched)
+		row("FILTER CHAIN", r.FilterChainMatched)
+		if r.ListenerMatched != "" {
+			row("MTLS REQUIRED", fmt.Sprint(r.MTLSRequired))
+		}
+		row("ROUTE CONFIG", r.RouteConfigMatched)
+		row("VIRTUAL HOST", r.VirtualHostMatched)
+		row("ROUTE", r.RouteMatched)
+		row("CLUSTER", r.ClusterMatched)
+		if r.Error != nil {
+			row("ERROR", r.Error.Error())
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if len(r.Trace) > 0 {
+			_, _ = fmt.Fprintln(w, "\nTRACE:")
+			for _, t := range r.Trace {
+				_, _ = fmt.Fprintf(w, "  - %s\n", t)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("output format %q not supported", outputFormat)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	gateway := "--config testdata/gateway.yaml --proxy-type router --proxy-namespace istio-system --proxy-labels istio=ingressgateway --port 80"
+	cases := []struct {
+		name          string
+		args          string
+		expected      []string
+		wantException bool
+	}{
+		{
+			name: "gateway route",
+			args: gateway + " --host httpbin.example.com --path /headers",
+			expected: []string{
+				"LISTENER: 0.0.0.0_80",
+				"ROUTE CONFIG: http.80",
+				"VIRTUAL HOST: httpbin.example.com:80",
+				"ROUTE: headers",
+				"CLUSTER: outbound|8000||httpbin.default.svc.cluster.local",
+				"matched route \"headers\"",
+			},
+		},
+		{
+			name: "gateway no route",
+			args: gateway + " --host httpbin.example.com --path /status",
+			expected: []string{
+				"ERROR: no route matched",
+			},
+		},
+		{
+			name:     "json",
+			args:     gateway + " --host httpbin.example.com --path /headers -o json",
+			expected: []string{`"cluster": "outbound|8000||httpbin.default.svc.cluster.local"`},
+		},
+		{
+			name:          "no source",
+			args:          "--port 80",
+			wantException: true,
+		},
+		{
+			name:          "invalid protocol",
+			args:          gateway + " --protocol udp",
+			wantException: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := Cmd(cli.NewFakeContext(nil))
+			var out bytes.Buffer
+			cmd.SetArgs(strings.Fields(tt.args))
+			cmd.SetOut(&out)
+			cmd.SetErr(&out)
+			cmd.SilenceUsage = true
+			err := cmd.Execute()
+			if tt.wantException {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			// Ignore the column alignment of the output
+			got := strings.Join(strings.Fields(out.String()), " ")
+			for _, want := range tt.expected {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
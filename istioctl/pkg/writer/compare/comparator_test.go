@@ -16,6 +16,7 @@ package compare
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"testing"
 )
@@ -73,3 +74,49 @@ func TestComparatorMismatchedConfigs(t *testing.T) {
 		}
 	}
 }
+
+func TestComparatorDrift(t *testing.T) {
+	cfg, err := os.ReadFile("testdata/configdump.json")
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+	diffCfg, err := os.ReadFile("testdata/configdump_diff.json")
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+
+	comparator, err := NewComparator(io.Discard, map[string][]byte{"default": cfg}, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Comparator: %v", err)
+	}
+	drift, err := comparator.Drift()
+	if err != nil {
+		t.Fatalf("Unexpected error during drift: %v", err)
+	}
+	if !drift.Empty() {
+		t.Errorf("Expected no drift, got %+v", drift)
+	}
+
+	comparator, err = NewComparator(io.Discard, map[string][]byte{"default": cfg}, diffCfg)
+	if err != nil {
+		t.Fatalf("Failed to create Comparator: %v", err)
+	}
+	drift, err = comparator.Drift()
+	if err != nil {
+		t.Fatalf("Unexpected error during drift: %v", err)
+	}
+	want := &Drift{
+		Clusters: []string{
+			"inbound-vip|9080|http|ratings.default.svc.cluster.local",
+			"inbound-vip|9999|http|ratings.default.svc.cluster.local",
+		},
+		Listeners: []string{"connect_terminate", "main_internal"},
+		Routes: []string{
+			"inbound-vip|9080|http|reviews-v3.default.svc.cluster.local",
+			"inbound-vip|9999|http|reviews-v3.default.svc.cluster.local",
+		},
+	}
+	if !drift.Equal(want) {
+		t.Errorf("Expected drift %+v, got %+v", want, drift)
+	}
+}
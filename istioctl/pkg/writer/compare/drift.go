@@ -0,0 +1,166 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compare
+
+import (
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// Drift is the names of the resources whose config differs between Istiod and Envoy, including the ones
+// only one of them has.
+type Drift struct {
+	Clusters  []string `json:"clusters,omitempty"`
+	Listeners []string `json:"listeners,omitempty"`
+	Routes    []string `json:"routes,omitempty"`
+}
+
+// Empty returns true if the configs of Istiod and Envoy match.
+func (d *Drift) Empty() bool {
+	return len(d.Clusters) == 0 && len(d.Listeners) == 0 && len(d.Routes) == 0
+}
+
+// Equal returns true if both drifts have the same resources.
+func (d *Drift) Equal(other *Drift) bool {
+	if d == nil || other == nil {
+		return d == other
+	}
+	return slices.Equal(d.Clusters, other.Clusters) &&
+		slices.Equal(d.Listeners, other.Listeners) &&
+		slices.Equal(d.Routes, other.Routes)
+}
+
+// Drift returns the resources whose config differs between Istiod and Envoy. Unlike Diff, it only reports which
+// resources differ, not how.
+func (c *Comparator) Drift() (*Drift, error) {
+	d := &Drift{}
+	var err error
+	if d.Clusters, err = driftedResources(c.istiod, c.envoy, clusterResources); err != nil {
+		return nil, err
+	}
+	if d.Listeners, err = driftedResources(c.istiod, c.envoy, listenerResources); err != nil {
+		return nil, err
+	}
+	if d.Routes, err = driftedResources(c.istiod, c.envoy, routeResources); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// resourcesFunc returns the dynamic resources of a type of a config dump, by name.
+type resourcesFunc func(w *configdump.Wrapper) (map[string]*anypb.Any, error)
+
+func driftedResources(istiod, envoy *configdump.Wrapper, resources resourcesFunc) ([]string, error) {
+	istiodResources, err := resources(istiod)
+	if err != nil {
+		return nil, err
+	}
+	envoyResources, err := resources(envoy)
+	if err != nil {
+		return nil, err
+	}
+	drifted := sets.New[string]()
+	for name, r := range istiodResources {
+		other, f := envoyResources[name]
+		if !f {
+			drifted.Insert(name)
+			continue
+		}
+		equal, err := sameJSON(r, other)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			drifted.Insert(name)
+		}
+	}
+	for name := range envoyResources {
+		if _, f := istiodResources[name]; !f {
+			drifted.Insert(name)
+		}
+	}
+	return sets.SortedList(drifted), nil
+}
+
+// sameJSON compares resources the way Diff does, by their JSON.
+func sameJSON(a, b *anypb.Any) (bool, error) {
+	aj, err := protomarshal.ToJSONWithAnyResolver(a, "", &envoyResolver)
+	if err != nil {
+		return false, err
+	}
+	bj, err := protomarshal.ToJSONWithAnyResolver(b, "", &envoyResolver)
+	if err != nil {
+		return false, err
+	}
+	return aj == bj, nil
+}
+
+// clusterResources returns the dynamic clusters of the config dump. A config dump without clusters section has
+// no clusters, rather than being invalid, so that it drifts from one with clusters. It is the same for the
+// listeners and routes.
+func clusterResources(w *configdump.Wrapper) (map[string]*anypb.Any, error) {
+	out := map[string]*anypb.Any{}
+	dump, err := w.GetDynamicClusterDump(true)
+	if err != nil {
+		return out, nil
+	}
+	for _, dc := range dump.DynamicActiveClusters {
+		c := &cluster.Cluster{}
+		if err := dc.Cluster.UnmarshalTo(c); err != nil {
+			return nil, err
+		}
+		out[c.Name] = dc.Cluster
+	}
+	return out, nil
+}
+
+func listenerResources(w *configdump.Wrapper) (map[string]*anypb.Any, error) {
+	out := map[string]*anypb.Any{}
+	dump, err := w.GetDynamicListenerDump(true)
+	if err != nil {
+		return out, nil
+	}
+	for _, dl := range dump.DynamicListeners {
+		l := &listener.Listener{}
+		if err := dl.ActiveState.Listener.UnmarshalTo(l); err != nil {
+			return nil, err
+		}
+		out[l.Name] = dl.ActiveState.Listener
+	}
+	return out, nil
+}
+
+func routeResources(w *configdump.Wrapper) (map[string]*anypb.Any, error) {
+	out := map[string]*anypb.Any{}
+	dump, err := w.GetDynamicRouteDump(true)
+	if err != nil {
+		return out, nil
+	}
+	for _, drc := range dump.DynamicRouteConfigs {
+		r := &route.RouteConfiguration{}
+		if err := drc.RouteConfig.UnmarshalTo(r); err != nil {
+			return nil, err
+		}
+		out[r.Name] = drc.RouteConfig
+	}
+	return out, nil
+}
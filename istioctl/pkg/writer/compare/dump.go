@@ -0,0 +1,194 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compare
+
+import (
+	"fmt"
+	"sort"
+
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	"github.com/pmezard/go-difflib/difflib"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// Change is how a resource changed between two config dumps.
+type Change string
+
+const (
+	Added    Change = "Added"
+	Removed  Change = "Removed"
+	Modified Change = "Modified"
+)
+
+// ResourceDiff is the difference of a resource between two config dumps.
+type ResourceDiff struct {
+	// Type is the type of the resource, one of Cluster, Listener, Route, Endpoint or Secret.
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Change Change `json:"change"`
+	// Diff is the unified diff of the resource in JSON.
+	Diff string `json:"diff"`
+}
+
+// dumpResourceTypes are the types of resources DiffConfigDumps compares, in the order they are reported.
+var dumpResourceTypes = []struct {
+	name      string
+	resources resourcesFunc
+}{
+	{"Cluster", clusterResources},
+	{"Listener", listenerResources},
+	{"Route", routeResources},
+	{"Endpoint", endpointResources},
+	{"Secret", secretResources},
+}
+
+// DiffConfigDumps returns the differences of the dynamic resources between two Envoy config dumps, sorted by type
+// and name. Volatile fields, like version_info and last_updated, are ignored. fromName and toName label the
+// dumps in the diffs.
+func DiffConfigDumps(from, to *configdump.Wrapper, fromName, toName string, context int) ([]ResourceDiff, error) {
+	var out []ResourceDiff
+	for _, t := range dumpResourceTypes {
+		fromResources, err := t.resources(from)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fromName, err)
+		}
+		toResources, err := t.resources(to)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", toName, err)
+		}
+		names := sets.New[string]()
+		for name := range fromResources {
+			names.Insert(name)
+		}
+		for name := range toResources {
+			names.Insert(name)
+		}
+		for _, name := range sets.SortedList(names) {
+			fromJSON, err := resourceJSON(fromResources[name])
+			if err != nil {
+				return nil, err
+			}
+			toJSON, err := resourceJSON(toResources[name])
+			if err != nil {
+				return nil, err
+			}
+			if fromJSON == toJSON {
+				continue
+			}
+			change := Modified
+			if _, f := fromResources[name]; !f {
+				change = Added
+			} else if _, f := toResources[name]; !f {
+				change = Removed
+			}
+			text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				FromFile: fmt.Sprintf("%s %s %s", fromName, t.name, name),
+				A:        splitLines(fromJSON),
+				ToFile:   fmt.Sprintf("%s %s %s", toName, t.name, name),
+				B:        splitLines(toJSON),
+				Context:  context,
+			})
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, ResourceDiff{Type: t.name, Name: name, Change: change, Diff: text})
+		}
+	}
+	return out, nil
+}
+
+// resourceJSON returns the JSON of a resource as Diff prints it, or an empty string if it is nil.
+func resourceJSON(r *anypb.Any) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	j, err := protomarshal.ToJSONWithAnyResolver(r, "    ", &envoyResolver)
+	if err != nil {
+		return "", err
+	}
+	return j + "\n", nil
+}
+
+// splitLines splits s in lines, without any for an empty string so that added and removed resources do not
+// have an empty line diff.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return difflib.SplitLines(s)
+}
+
+// endpointResources returns the dynamic endpoints of the config dump, by cluster. The endpoints of each cluster are
+// sorted, as their order is not significant and differs between proxies.
+func endpointResources(w *configdump.Wrapper) (map[string]*anypb.Any, error) {
+	out := map[string]*anypb.Any{}
+	dump, err := w.GetEndpointsConfigDump()
+	if err != nil || dump == nil {
+		return out, err
+	}
+	for _, dec := range dump.DynamicEndpointConfigs {
+		cla := &endpoint.ClusterLoadAssignment{}
+		if err := dec.EndpointConfig.UnmarshalTo(cla); err != nil {
+			return nil, err
+		}
+		sort.SliceStable(cla.Endpoints, func(i, j int) bool {
+			return localityKey(cla.Endpoints[i]) < localityKey(cla.Endpoints[j])
+		})
+		for _, le := range cla.Endpoints {
+			sort.SliceStable(le.LbEndpoints, func(i, j int) bool {
+				return addressKey(le.LbEndpoints[i]) < addressKey(le.LbEndpoints[j])
+			})
+		}
+		out[cla.ClusterName] = protoconv.MessageToAny(cla)
+	}
+	return out, nil
+}
+
+func localityKey(le *endpoint.LocalityLbEndpoints) string {
+	l := le.GetLocality()
+	return fmt.Sprintf("%s/%s/%s/%d", l.GetRegion(), l.GetZone(), l.GetSubZone(), le.GetPriority())
+}
+
+func addressKey(lb *endpoint.LbEndpoint) string {
+	address := lb.GetEndpoint().GetAddress()
+	if pipe := address.GetPipe(); pipe != nil {
+		return pipe.GetPath()
+	}
+	if internal := address.GetEnvoyInternalAddress(); internal != nil {
+		return internal.GetServerListenerName() + "/" + internal.GetEndpointId()
+	}
+	sa := address.GetSocketAddress()
+	return fmt.Sprintf("%s:%d", sa.GetAddress(), sa.GetPortValue())
+}
+
+// secretResources returns the active dynamic secrets of the config dump.
+func secretResources(w *configdump.Wrapper) (map[string]*anypb.Any, error) {
+	out := map[string]*anypb.Any{}
+	dump, err := w.GetSecretConfigDump()
+	if err != nil {
+		return out, nil
+	}
+	for _, ds := range dump.DynamicActiveSecrets {
+		if ds.Secret != nil {
+			out[ds.Name] = ds.Secret
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func readConfigDump(t *testing.T, path string) *configdump.Wrapper {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+	w := &configdump.Wrapper{}
+	if err := json.Unmarshal(b, w); err != nil {
+		t.Fatalf("Failed to parse test data: %v", err)
+	}
+	return w
+}
+
+func TestDiffConfigDumps(t *testing.T) {
+	diffs, err := DiffConfigDumps(readConfigDump(t, "testdata/configdump.json"), readConfigDump(t, "testdata/configdump.json"), "a", "b", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("Expected no differences, got %v", diffs)
+	}
+
+	diffs, err = DiffConfigDumps(readConfigDump(t, "testdata/configdump.json"), readConfigDump(t, "testdata/configdump_diff.json"), "a", "b", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, fmt.Sprintf("%s %s %s", d.Type, d.Name, d.Change))
+	}
+	assert.Equal(t, got, []string{
+		"Cluster inbound-vip|9080|http|ratings.default.svc.cluster.local Removed",
+		"Cluster inbound-vip|9999|http|ratings.default.svc.cluster.local Added",
+		"Listener connect_terminate Modified",
+		"Listener main_internal Modified",
+		"Route inbound-vip|9080|http|reviews-v3.default.svc.cluster.local Removed",
+		"Route inbound-vip|9999|http|reviews-v3.default.svc.cluster.local Added",
+	})
+	if !strings.Contains(diffs[3].Diff, `+                        "statPrefix": "inbound_0.0.0.0_9999",`) {
+		t.Errorf("Unexpected diff:\n%s", diffs[3].Diff)
+	}
+	if !strings.HasPrefix(diffs[1].Diff, "--- a Cluster inbound-vip|9999|http|ratings.default.svc.cluster.local\n"+
+		"+++ b Cluster inbound-vip|9999|http|ratings.default.svc.cluster.local\n@@ -0,0 +1,44 @@\n") {
+		t.Errorf("Unexpected diff:\n%s", diffs[1].Diff)
+	}
+}
+
+func endpointsAndSecrets(version string, addresses []string, rootCA string) *configdump.Wrapper {
+	cla := &endpoint.ClusterLoadAssignment{
+		ClusterName: "outbound|80||b.default.svc.cluster.local",
+		Endpoints:   []*endpoint.LocalityLbEndpoints{{}},
+	}
+	for _, a := range addresses {
+		cla.Endpoints[0].LbEndpoints = append(cla.Endpoints[0].LbEndpoints, &endpoint.LbEndpoint{
+			HostIdentifier: &endpoint.LbEndpoint_Endpoint{Endpoint: &endpoint.Endpoint{
+				Address: &core.Address{Address: &core.Address_SocketAddress{SocketAddress: &core.SocketAddress{
+					Address:       a,
+					PortSpecifier: &core.SocketAddress_PortValue{PortValue: 80},
+				}}},
+			}},
+		})
+	}
+	secret := func(name, ca string) *admin.SecretsConfigDump_DynamicSecret {
+		return &admin.SecretsConfigDump_DynamicSecret{
+			Name:        name,
+			VersionInfo: version,
+			LastUpdated: timestamppb.Now(),
+			Secret: protoconv.MessageToAny(&tls.Secret{
+				Name: name,
+				Type: &tls.Secret_ValidationContext{ValidationContext: &tls.CertificateValidationContext{
+					TrustedCa: &core.DataSource{Specifier: &core.DataSource_InlineString{InlineString: ca}},
+				}},
+			}),
+		}
+	}
+	return &configdump.Wrapper{ConfigDump: &admin.ConfigDump{Configs: []*anypb.Any{
+		protoconv.MessageToAny(&admin.EndpointsConfigDump{
+			DynamicEndpointConfigs: []*admin.EndpointsConfigDump_DynamicEndpointConfig{{
+				VersionInfo:    version,
+				EndpointConfig: protoconv.MessageToAny(cla),
+			}},
+		}),
+		protoconv.MessageToAny(&admin.SecretsConfigDump{
+			DynamicActiveSecrets: []*admin.SecretsConfigDump_DynamicSecret{secret("default", "cert"), secret("ROOTCA", rootCA)},
+		}),
+	}}}
+}
+
+func TestDiffConfigDumpsEndpointsAndSecrets(t *testing.T) {
+	// The order of the endpoints and the versions are not significant.
+	diffs, err := DiffConfigDumps(
+		endpointsAndSecrets("1", []string{"10.0.0.1", "10.0.0.2"}, "root-1"),
+		endpointsAndSecrets("2", []string{"10.0.0.2", "10.0.0.1"}, "root-1"),
+		"a", "b", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, len(diffs), 0)
+
+	diffs, err = DiffConfigDumps(
+		endpointsAndSecrets("1", []string{"10.0.0.1", "10.0.0.2"}, "root-1"),
+		endpointsAndSecrets("1", []string{"10.0.0.3", "10.0.0.1"}, "root-2"),
+		"a", "b", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, len(diffs), 2)
+	assert.Equal(t, diffs[0].Type, "Endpoint")
+	assert.Equal(t, diffs[0].Change, Modified)
+	assert.Equal(t, diffs[1].Type, "Secret")
+	assert.Equal(t, diffs[1].Name, "ROOTCA")
+	assert.Equal(t, diffs[1].Change, Modified)
+}
@@ -124,6 +124,63 @@ func (s *XdsStatusWriter) setupStatusPrint(drs map[string]*discovery.DiscoveryRe
 	return w, fullStatus, nil
 }
 
+// ProxyStatus is the sync status of a proxy connected to Istiod.
+type ProxyStatus struct {
+	ProxyID  string
+	IstiodID string
+	// Statuses are the statuses of the xDS types the proxy subscribed to, by short type, for example CDS.
+	Statuses map[string]xdsstatus.ConfigStatus
+}
+
+// Stale returns the short xDS types Istiod sent to the proxy which were not acknowledged yet.
+func (p ProxyStatus) Stale() []string {
+	var stale []string
+	for t, s := range p.Statuses {
+		if s == xdsstatus.ConfigStatus_STALE {
+			stale = append(stale, t)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// ProxyStatuses returns the sync statuses of the proxies of the namespace from Istiod syncz responses, sorted by
+// proxy ID. All the proxies are returned if namespace is empty.
+func ProxyStatuses(drs map[string]*discovery.DiscoveryResponse, namespace string) ([]ProxyStatus, error) {
+	var out []ProxyStatus
+	for _, dr := range drs {
+		cp := multixds.CpInfo(dr)
+		for _, resource := range dr.Resources {
+			clientConfig := xdsstatus.ClientConfig{}
+			if err := resource.UnmarshalTo(&clientConfig); err != nil {
+				return nil, fmt.Errorf("could not unmarshal ClientConfig: %w", err)
+			}
+			meta, err := model.ParseMetadata(clientConfig.GetNode().GetMetadata())
+			if err != nil {
+				return nil, fmt.Errorf("could not parse node metadata: %w", err)
+			}
+			if namespace != "" && meta.Namespace != namespace {
+				continue
+			}
+			statuses := map[string]xdsstatus.ConfigStatus{}
+			for _, config := range handleAndGetXdsConfigs(&clientConfig) {
+				if config.GetConfigStatus() != xdsstatus.ConfigStatus_UNKNOWN {
+					statuses[xdsresource.GetShortType(config.GetTypeUrl())] = config.GetConfigStatus()
+				}
+			}
+			out = append(out, ProxyStatus{
+				ProxyID:  clientConfig.GetNode().GetId(),
+				IstiodID: cp.ID,
+				Statuses: statuses,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ProxyID < out[j].ProxyID
+	})
+	return out, nil
+}
+
 func xdsStatusPrintln(w io.Writer, status *xdsWriterStatus) error {
 	_, err := fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
 		status.proxyID, status.clusterID,
@@ -148,6 +148,44 @@ func TestXdsStatusWriter_PrintAll(t *testing.T) {
 	}
 }
 
+func TestProxyStatuses(t *testing.T) {
+	input := map[string]*discovery.DiscoveryResponse{
+		"istiod1": xdsResponseInput("istiod1", []clientConfigInput{
+			{
+				proxyID:        "proxy2",
+				cdsSyncStatus:  status.ConfigStatus_STALE,
+				ldsSyncStatus:  status.ConfigStatus_SYNCED,
+				rdsSyncStatus:  status.ConfigStatus_NOT_SENT,
+				edsSyncStatus:  status.ConfigStatus_STALE,
+				ecdsSyncStatus: status.ConfigStatus_UNKNOWN,
+			},
+		}),
+		"istiod2": xdsResponseInput("istiod2", []clientConfigInput{
+			{
+				proxyID:        "proxy1",
+				cdsSyncStatus:  status.ConfigStatus_SYNCED,
+				ldsSyncStatus:  status.ConfigStatus_SYNCED,
+				rdsSyncStatus:  status.ConfigStatus_SYNCED,
+				edsSyncStatus:  status.ConfigStatus_SYNCED,
+				ecdsSyncStatus: status.ConfigStatus_SYNCED,
+			},
+		}),
+	}
+	statuses, err := ProxyStatuses(input, "")
+	assert.NoError(t, err)
+	assert.Equal(t, len(statuses), 2)
+	assert.Equal(t, statuses[0].ProxyID, "proxy1")
+	assert.Equal(t, statuses[0].IstiodID, "istiod2")
+	assert.Equal(t, statuses[0].Stale(), nil)
+	assert.Equal(t, statuses[1].ProxyID, "proxy2")
+	assert.Equal(t, statuses[1].Stale(), []string{"CDS", "EDS"})
+	assert.Equal(t, len(statuses[1].Statuses), 4)
+
+	statuses, err = ProxyStatuses(input, "other")
+	assert.NoError(t, err)
+	assert.Equal(t, len(statuses), 0)
+}
+
 const clientConfigType = "type.googleapis.com/envoy.service.status.v3.ClientConfig"
 
 type clientConfigInput struct {
@@ -42,30 +42,39 @@ type rawDump struct {
 
 // Prime loads the config dump into the writer ready for printing
 func (c *ConfigWriter) Prime(b []byte) error {
+	zDump, err := ParseZtunnelDump(b)
+	if err != nil {
+		return err
+	}
+	c.ztunnelDump = zDump
+	return nil
+}
+
+// ParseZtunnelDump parses the response of the Ztunnel Admin config_dump endpoint
+func ParseZtunnelDump(b []byte) (*ZtunnelDump, error) {
 	zDump := &ZtunnelDump{}
 	rawDump := &rawDump{}
 	// TODO(fisherxu): migrate this to jsonpb when issue fixed in golang
 	// Issue to track -> https://github.com/golang/protobuf/issues/632
 	err := json.Unmarshal(b, rawDump)
 	if err != nil {
-		return fmt.Errorf("error unmarshalling config dump response from ztunnel: %v", err)
+		return nil, fmt.Errorf("error unmarshalling config dump response from ztunnel: %v", err)
 	}
 	// ensure that data gets unmarshalled into the right data type
 	if err := unmarshalListOrMap(rawDump.Services, &zDump.Services); err != nil {
-		return err
+		return nil, err
 	}
 	if err := unmarshalListOrMap(rawDump.Workloads, &zDump.Workloads); err != nil {
-		return err
+		return nil, err
 	}
 	if err := unmarshalListOrMap(rawDump.Certificates, &zDump.Certificates); err != nil {
-		return err
+		return nil, err
 	}
 	if err := unmarshalListOrMap(rawDump.Policies, &zDump.Policies); err != nil {
-		return err
+		return nil, err
 	}
 	zDump.WorkloadState = rawDump.WorkloadState
-	c.ztunnelDump = zDump
-	return nil
+	return zDump, nil
 }
 
 func unmarshalListOrMap[T any](input json.RawMessage, i *[]T) error {
@@ -127,3 +127,35 @@ func TestConfigWriter_PrintSecretSummary(t *testing.T) {
 		})
 	}
 }
+
+func TestZtunnelDumpLookups(t *testing.T) {
+	dump, err := ParseZtunnelDump([]byte(`{
+  "workloads": [{"name": "reviews-v1", "namespace": "default", "authorizationPolicies": ["default/selected"],
+    "waypoint": {"destination": "/10.96.0.10"}}],
+  "services": [{"name": "waypoint", "namespace": "default", "hostname": "waypoint.default.svc.cluster.local", "vips": ["/10.96.0.10"]}],
+  "policies": [
+    {"name": "selected", "namespace": "default", "scope": "WorkloadSelector", "action": "Allow"},
+    {"name": "not-selected", "namespace": "default", "scope": "WorkloadSelector", "action": "Allow"},
+    {"name": "namespace", "namespace": "default", "scope": "Namespace", "action": "Allow"},
+    {"name": "other-namespace", "namespace": "other", "scope": "Namespace", "action": "Deny"},
+    {"name": "global", "namespace": "istio-system", "scope": "Global", "action": "Deny"}
+  ]
+}`))
+	assert.NoError(t, err)
+
+	wl := dump.Workload("default", "reviews-v1")
+	if wl == nil {
+		t.Fatal("workload not found")
+	}
+	assert.Equal(t, dump.Workload("other", "reviews-v1") == nil, true)
+	assert.Equal(t, dump.WaypointService(wl.Waypoint).Name, "waypoint")
+	assert.Equal(t, dump.WaypointService(&GatewayAddress{Destination: "default/waypoint.default.svc.cluster.local"}).Name, "waypoint")
+	assert.Equal(t, dump.WaypointService(nil) == nil, true)
+	assert.Equal(t, dump.Service("default", "waypoint").Hostname, "waypoint.default.svc.cluster.local")
+
+	var names []string
+	for _, pol := range dump.WorkloadPolicies(wl) {
+		names = append(names, pol.Namespace+"/"+pol.Name)
+	}
+	assert.Equal(t, names, []string{"istio-system/global", "default/namespace", "default/selected"})
+}
@@ -23,6 +23,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
 )
 
 // PolicyFilter is used to pass filter information into service based config writer print functions
@@ -84,3 +85,36 @@ func (c *ConfigWriter) PrintPolicyDump(filter PolicyFilter, outputFormat string)
 	fmt.Fprintln(c.Stdout, string(out))
 	return nil
 }
+
+// WorkloadPolicies returns the policies ztunnel enforces on the traffic to the workload: the global policies,
+// the policies of its namespace, and the ones selecting it. DENY policies are first, as they are evaluated first.
+func (d *ZtunnelDump) WorkloadPolicies(wl *ZtunnelWorkload) []*ZtunnelPolicy {
+	selected := sets.New(wl.AuthorizationPolicies...)
+	pols := slices.Filter(d.Policies, func(pol *ZtunnelPolicy) bool {
+		switch {
+		case strings.EqualFold(pol.Scope, "Global"):
+			return true
+		case strings.EqualFold(pol.Scope, "Namespace"):
+			return pol.Namespace == wl.Namespace
+		default:
+			return selected.Contains(pol.Namespace + "/" + pol.Name)
+		}
+	})
+	slices.SortFunc(pols, func(a, b *ZtunnelPolicy) int {
+		if r := cmp.Compare(actionOrder(a.Action), actionOrder(b.Action)); r != 0 {
+			return r
+		}
+		if r := cmp.Compare(a.Namespace, b.Namespace); r != 0 {
+			return r
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return pols
+}
+
+func actionOrder(action string) int {
+	if strings.EqualFold(action, "Deny") {
+		return 0
+	}
+	return 1
+}
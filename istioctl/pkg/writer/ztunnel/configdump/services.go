@@ -113,3 +113,13 @@ func (c *ConfigWriter) PrintServiceDump(filter ServiceFilter, outputFormat strin
 	fmt.Fprintln(c.Stdout, string(out))
 	return nil
 }
+
+// Service returns the service, or nil if the ztunnel doesn't know about it
+func (d *ZtunnelDump) Service(namespace, name string) *ZtunnelService {
+	for _, svc := range d.Services {
+		if svc.Namespace == namespace && svc.Name == name {
+			return svc
+		}
+	}
+	return nil
+}
@@ -125,18 +125,9 @@ func waypointName(wl *ZtunnelWorkload, services []*ZtunnelService) string {
 	if wl.Waypoint == nil {
 		return "None"
 	}
-
-	for _, svc := range services {
-		if fmt.Sprintf("%s/%s", svc.Namespace, svc.Hostname) == wl.Waypoint.Destination {
-			return svc.Name
-		}
-		for _, addr := range svc.Addresses {
-			if addr == wl.Waypoint.Destination {
-				return svc.Name
-			}
-		}
+	if svc := waypointService(wl.Waypoint, services); svc != nil {
+		return svc.Name
 	}
-
 	return "NA" // Shouldn't normally reach here
 }
 
@@ -144,17 +135,42 @@ func serviceWaypointName(svc *ZtunnelService, services []*ZtunnelService) string
 	if svc.Waypoint == nil {
 		return "None"
 	}
+	if service := waypointService(svc.Waypoint, services); service != nil {
+		return service.Name
+	}
+	return "NA" // Shouldn't normally reach here
+}
 
-	for _, service := range services {
-		if fmt.Sprintf("%s/%s", service.Namespace, service.Hostname) == svc.Waypoint.Destination {
-			return service.Name
+// waypointService returns the service of the waypoint at the gateway address, which is either
+// <namespace>/<hostname> or <network>/<address>.
+func waypointService(gw *GatewayAddress, services []*ZtunnelService) *ZtunnelService {
+	for _, svc := range services {
+		if fmt.Sprintf("%s/%s", svc.Namespace, svc.Hostname) == gw.Destination {
+			return svc
 		}
-		for _, addr := range service.Addresses {
-			if addr == svc.Waypoint.Destination {
-				return service.Name
+		for _, addr := range svc.Addresses {
+			if addr == gw.Destination {
+				return svc
 			}
 		}
 	}
+	return nil
+}
 
-	return "NA" // Shouldn't normally reach here
+// Workload returns the workload of the pod, or nil if the ztunnel doesn't know about it
+func (d *ZtunnelDump) Workload(namespace, name string) *ZtunnelWorkload {
+	for _, wl := range d.Workloads {
+		if wl.Namespace == namespace && wl.Name == name {
+			return wl
+		}
+	}
+	return nil
+}
+
+// WaypointService returns the service of the waypoint at the gateway address, or nil if it is unknown
+func (d *ZtunnelDump) WaypointService(gw *GatewayAddress) *ZtunnelService {
+	if gw == nil {
+		return nil
+	}
+	return waypointService(gw, d.Services)
 }
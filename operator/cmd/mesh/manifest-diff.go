@@ -0,0 +1,200 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mesh
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/operator/pkg/install"
+	"istio.io/istio/operator/pkg/render"
+	"istio.io/istio/operator/pkg/util/clog"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/slices"
+)
+
+const (
+	diffSummaryOutput = "summary"
+	diffJSONOutput    = "json"
+)
+
+type ManifestDiffArgs struct {
+	// InFilenames is an array of paths to the input IstioOperator CR files.
+	InFilenames []string
+	// Set is a string with element format "path=value" where path is an IstioOperator path and the value is a
+	// value to set the node at that path to.
+	Set []string
+	// Force proceeds even if there are validation errors
+	Force bool
+	// ManifestsPath is a path to a charts and profiles directory in the local filesystem with a release tgz.
+	ManifestsPath string
+	// Revision is the Istio control plane revision the command targets.
+	Revision string
+	// Output is the output format, one of summary|json.
+	Output string
+}
+
+func (a *ManifestDiffArgs) String() string {
+	var b strings.Builder
+	b.WriteString("InFilenames:   " + fmt.Sprint(a.InFilenames) + "\n")
+	b.WriteString("Set:           " + fmt.Sprint(a.Set) + "\n")
+	b.WriteString("Force:         " + fmt.Sprint(a.Force) + "\n")
+	b.WriteString("ManifestsPath: " + a.ManifestsPath + "\n")
+	b.WriteString("Revision:      " + a.Revision + "\n")
+	b.WriteString("Output:        " + a.Output + "\n")
+	return b.String()
+}
+
+func addManifestDiffFlags(cmd *cobra.Command, args *ManifestDiffArgs) {
+	cmd.PersistentFlags().StringSliceVarP(&args.InFilenames, "filename", "f", nil, filenameFlagHelpStr)
+	cmd.PersistentFlags().StringArrayVarP(&args.Set, "set", "s", nil, setFlagHelpStr)
+	cmd.PersistentFlags().BoolVar(&args.Force, "force", false, ForceFlagHelpStr)
+	cmd.PersistentFlags().StringVarP(&args.ManifestsPath, "manifests", "d", "", ManifestsFlagHelpStr)
+	cmd.PersistentFlags().StringVarP(&args.Revision, "revision", "r", "", revisionFlagHelpStr)
+	cmd.PersistentFlags().StringVarP(&args.Output, "output", "o", diffSummaryOutput, "Output format: one of summary|json")
+}
+
+func ManifestDiffCmd(ctx cli.Context, mdArgs *ManifestDiffArgs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff",
+		Short: "Compares an Istio install manifest to the cluster",
+		Long: "The diff subcommand compares the manifest `istioctl install` would apply to the objects in the cluster, " +
+			"reporting the objects and fields which would be added, changed or removed. The objects are compared to the " +
+			"result of a server-side apply dry run of the manifest, so defaulted and normalized fields are not reported.",
+		Example: `  # Show the changes installing the default profile would make
+  istioctl manifest diff
+
+  # Show the changes of enabling tracing, in JSON
+  istioctl manifest diff --set meshConfig.enableTracing=true -o json
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("diff accepts no positional arguments, got %#v", args)
+			}
+			if mdArgs.Output != diffSummaryOutput && mdArgs.Output != diffJSONOutput {
+				return fmt.Errorf("unknown output format %q, expected one of summary|json", mdArgs.Output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			l := clog.NewConsoleLogger(cmd.OutOrStdout(), cmd.ErrOrStderr(), installerScope)
+			return ManifestDiff(kubeClient, mdArgs, cmd.OutOrStdout(), l)
+		},
+	}
+}
+
+// ManifestDiff renders the manifest as `istioctl install` does, and writes its differences with the cluster.
+func ManifestDiff(kubeClient kube.CLIClient, mdArgs *ManifestDiffArgs, w io.Writer, l clog.Logger) error {
+	setFlags := applyFlagAliases(mdArgs.Set, mdArgs.ManifestsPath, mdArgs.Revision)
+	manifests, vals, err := render.GenerateManifest(mdArgs.InFilenames, setFlags, mdArgs.Force, kubeClient, l)
+	if err != nil {
+		return fmt.Errorf("generate config: %v", err)
+	}
+	i := install.Installer{
+		Force:  mdArgs.Force,
+		DryRun: true,
+		Kube:   kubeClient,
+		Logger: l,
+		Values: vals,
+	}
+	diffs, err := i.Diff(manifests)
+	if err != nil {
+		return err
+	}
+	if mdArgs.Output == diffJSONOutput {
+		if diffs == nil {
+			diffs = []install.ObjectDiff{}
+		}
+		b, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(b)); err != nil {
+			return err
+		}
+	} else {
+		printManifestDiff(w, diffs)
+	}
+	// The objects which could not be compared are reported with the others, and fail the command.
+	failed := slices.Filter(diffs, func(d install.ObjectDiff) bool {
+		return d.Status == install.DiffError
+	})
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to compare %d objects with the cluster", len(failed))
+	}
+	return nil
+}
+
+var diffMarkers = map[install.DiffStatus]string{
+	install.DiffAdded:   "+",
+	install.DiffRemoved: "-",
+	install.DiffChanged: "~",
+	install.DiffError:   "!",
+}
+
+func printManifestDiff(w io.Writer, diffs []install.ObjectDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+	counts := map[install.DiffStatus]int{}
+	for _, d := range diffs {
+		counts[d.Status]++
+		name := d.Name
+		if d.Namespace != "" {
+			name = d.Namespace + "/" + d.Name
+		}
+		component := ""
+		if d.Component != "" {
+			component = " (" + d.Component + ")"
+		}
+		fmt.Fprintf(w, "%s %s %s%s\n", diffMarkers[d.Status], d.Kind, name, component)
+		if d.Error != "" {
+			fmt.Fprintf(w, "    error: %s\n", d.Error)
+		}
+		for _, f := range d.Fields {
+			switch f.Status {
+			case install.DiffAdded:
+				fmt.Fprintf(w, "    + %s: %s\n", f.Path, diffValue(f.Rendered))
+			case install.DiffRemoved:
+				fmt.Fprintf(w, "    - %s: %s\n", f.Path, diffValue(f.Live))
+			default:
+				fmt.Fprintf(w, "    ~ %s: %s -> %s\n", f.Path, diffValue(f.Live), diffValue(f.Rendered))
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d added, %d changed, %d removed", counts[install.DiffAdded], counts[install.DiffChanged], counts[install.DiffRemoved])
+	if counts[install.DiffError] > 0 {
+		fmt.Fprintf(w, ", %d failed to compare", counts[install.DiffError])
+	}
+	fmt.Fprintln(w, ".")
+}
+
+func diffValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
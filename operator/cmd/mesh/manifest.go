@@ -30,11 +30,13 @@ func ManifestCmd(ctx cli.Context) *cobra.Command {
 
 	mgcArgs := &ManifestGenerateArgs{}
 	mtcArgs := &ManifestTranslateArgs{}
+	mdcArgs := &ManifestDiffArgs{}
 
 	args := &RootArgs{}
 
 	mgc := ManifestGenerateCmd(ctx, args, mgcArgs)
 	mtc := ManifestTranslateCmd(ctx, mtcArgs)
+	mdc := ManifestDiffCmd(ctx, mdcArgs)
 	ic := InstallCmd(ctx)
 
 	addFlags(mc, args)
@@ -42,10 +44,12 @@ func ManifestCmd(ctx cli.Context) *cobra.Command {
 
 	addManifestGenerateFlags(mgc, mgcArgs)
 	addManifestTranslateFlags(mtc, mtcArgs)
+	addManifestDiffFlags(mdc, mdcArgs)
 
 	mc.AddCommand(mgc)
 	mc.AddCommand(ic)
 	mc.AddCommand(mtc)
+	mc.AddCommand(mdc)
 
 	return mc
 }
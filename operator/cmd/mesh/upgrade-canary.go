@@ -0,0 +1,322 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mesh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/canary"
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/tag"
+	"istio.io/istio/operator/pkg/util/clog"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/util/sets"
+)
+
+type canaryArgs struct {
+	// Waves are the comma separated namespaces migrated together, in order.
+	Waves []string
+	// Tags are the revision tags moved to the revision after the waves, each in its own wave.
+	Tags []string
+	// ProxyTimeout is the maximum time to wait for the proxies of a wave to move to the revision.
+	ProxyTimeout time.Duration
+	// MaxErrorRate is the maximum ratio of requests failing with a 5xx error for a wave. 0 disables the check.
+	MaxErrorRate float64
+	// ErrorRateWindow is the time to observe the error rate after migrating a wave.
+	ErrorRateWindow time.Duration
+	// PrometheusAddress is the address of Prometheus. If empty, the Prometheus pod of the Istio namespace is used.
+	PrometheusAddress string
+	// RollbackOnFailure rolls back the wave which fails.
+	RollbackOnFailure bool
+	// Wave is the wave to roll back, numbered from 1.
+	Wave int
+}
+
+func addCanaryFlags(cmd *cobra.Command, args *canaryArgs) {
+	cmd.Flags().StringArrayVar(&args.Waves, "wave", nil,
+		"Comma separated namespaces to move to the revision together. This flag can be specified multiple times, "+
+			"the waves are migrated in order. The namespaces using a revision tag are skipped: they move with the tag.")
+	cmd.Flags().StringArrayVar(&args.Tags, "tag", nil,
+		"Revision tag to move to the revision after the waves of namespaces, like `istioctl tag set --overwrite`, "+
+			"restarting the workloads of the namespaces using it. This flag can be specified multiple times, "+
+			"each tag is moved in its own wave.")
+	cmd.Flags().DurationVar(&args.ProxyTimeout, "proxy-timeout", 10*time.Minute,
+		"Maximum time to wait for the proxies of a wave to be restarted and connected to the revision.")
+	cmd.Flags().Float64Var(&args.MaxErrorRate, "max-error-rate", 0,
+		"Maximum ratio of the requests to the workloads of a wave failing with a 5xx error, once migrated. "+
+			"The error rate is checked with Prometheus. If set to 0, the error rate is not checked.")
+	cmd.Flags().DurationVar(&args.ErrorRateWindow, "error-rate-window", 2*time.Minute,
+		"Time to observe the error rate after migrating a wave.")
+	cmd.Flags().StringVar(&args.PrometheusAddress, "prometheus-address", "",
+		"Address of Prometheus, like http://prometheus.istio-system:9090. "+
+			"If empty, the Prometheus pod of the Istio namespace is port forwarded.")
+	cmd.Flags().BoolVar(&args.RollbackOnFailure, "rollback-on-failure", true,
+		"Roll back the wave which fails to migrate, or whose error rate is too high.")
+}
+
+// canaryCmd installs a revision, and moves namespaces to it in waves. It uses the install flags of the upgrade command.
+func canaryCmd(ctx cli.Context, rootArgs *RootArgs, iArgs *InstallArgs) *cobra.Command {
+	cArgs := &canaryArgs{}
+	cmd := &cobra.Command{
+		Use:   "canary",
+		Short: "Upgrade Istio with a canary revision, moving namespaces to it in waves",
+		Long: `The canary command installs a new revision of the control plane next to the existing ones, then moves the
+namespaces to it in waves. For each wave, the namespaces are labeled with the revision, their workloads are restarted,
+and the command waits for their proxies to be connected to the new revision. The namespaces using a revision tag are
+left alone; the tags set with --tag are moved to the new revision after the waves of namespaces, each in its own wave.
+The error rate of the wave can then be checked with Prometheus, and the wave rolled back if it is too high.
+
+The progress of the upgrade is stored in the cluster: if the command is interrupted, run it again to resume it.`,
+		Example: `  # Install the revision 1-25-0 and move the namespaces to it in two waves
+  istioctl upgrade canary --revision 1-25-0 --wave test,staging --wave prod
+
+  # Move the namespaces of the test wave, then the namespaces using the revision tag prod-stable
+  istioctl upgrade canary --revision 1-25-0 --wave test --tag prod-stable
+
+  # Check that less than 1% of the requests fail after each wave
+  istioctl upgrade canary --revision 1-25-0 --wave test --wave prod --max-error-rate 0.01
+
+  # Resume an interrupted upgrade
+  istioctl upgrade canary --revision 1-25-0
+
+  # Roll back the last wave moved to the revision
+  istioctl upgrade canary rollback --revision 1-25-0`,
+		Args: cobra.ExactArgs(0),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateCanaryArgs(rootArgs, iArgs); err != nil {
+				return err
+			}
+			for _, t := range cArgs.Tags {
+				if t == iArgs.Revision {
+					return fmt.Errorf("revision tag %q has the name of the revision", t)
+				}
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			waves, err := parseWaves(cArgs.Waves)
+			if err != nil {
+				return err
+			}
+			if !iArgs.SkipConfirmation {
+				prompt := fmt.Sprintf("This will install the Istio revision %q and move %d waves of namespaces and %d revision tags to it. "+
+					"Proceed? (y/N)", iArgs.Revision, len(waves), len(cArgs.Tags))
+				if len(waves) == 0 && len(cArgs.Tags) == 0 {
+					prompt = fmt.Sprintf("This will resume the upgrade to the Istio revision %q. Proceed? (y/N)", iArgs.Revision)
+				}
+				if !Confirm(prompt, cmd.OutOrStdout()) {
+					p := NewPrinterForWriter(cmd.OutOrStderr())
+					p.Println("Cancelled.")
+					os.Exit(1)
+				}
+			}
+			u := newUpgrader(ctx, kubeClient, rootArgs, iArgs, cArgs, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			if err := u.Run(waves); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "To make %q the default revision, run: istioctl tag set default --revision %s --overwrite\n",
+				iArgs.Revision, iArgs.Revision)
+			return nil
+		},
+	}
+	addCanaryFlags(cmd, cArgs)
+	cmd.AddCommand(canaryRollbackCmd(ctx, rootArgs, iArgs, cArgs))
+	cmd.AddCommand(canaryStatusCmd(ctx, rootArgs, iArgs, cArgs))
+	return cmd
+}
+
+func canaryRollbackCmd(ctx cli.Context, rootArgs *RootArgs, iArgs *InstallArgs, cArgs *canaryArgs) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Move a wave of a canary upgrade back to the revisions it used before",
+		Example: `  # Roll back the last wave moved to the revision 1-25-0
+  istioctl upgrade canary rollback --revision 1-25-0
+
+  # Roll back the second wave
+  istioctl upgrade canary rollback --revision 1-25-0 --wave 2`,
+		Args: cobra.ExactArgs(0),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateCanaryArgs(rootArgs, iArgs)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			u := newUpgrader(ctx, kubeClient, rootArgs, iArgs, cArgs, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			return u.Rollback(cArgs.Wave)
+		},
+	}
+	cmd.Flags().IntVar(&cArgs.Wave, "wave", 0, "Wave to roll back, numbered from 1. Defaults to the last wave moved to the revision.")
+	cmd.Flags().DurationVar(&cArgs.ProxyTimeout, "proxy-timeout", 10*time.Minute,
+		"Maximum time to wait for the proxies of the wave to be restarted.")
+	return cmd
+}
+
+func canaryStatusCmd(ctx cli.Context, rootArgs *RootArgs, iArgs *InstallArgs, cArgs *canaryArgs) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the progress of a canary upgrade",
+		Example: `  # Show the progress of the upgrade to the revision 1-25-0
+  istioctl upgrade canary status --revision 1-25-0`,
+		Args: cobra.ExactArgs(0),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateCanaryArgs(rootArgs, iArgs)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			u := newUpgrader(ctx, kubeClient, rootArgs, iArgs, cArgs, cmd.OutOrStdout(), cmd.ErrOrStderr())
+			s, err := u.LoadState()
+			if err != nil {
+				return err
+			}
+			if s == nil {
+				return fmt.Errorf("no upgrade to revision %q found", iArgs.Revision)
+			}
+			printCanaryState(cmd.OutOrStdout(), s)
+			return nil
+		},
+	}
+}
+
+func validateCanaryArgs(rootArgs *RootArgs, iArgs *InstallArgs) error {
+	if rootArgs.DryRun {
+		return fmt.Errorf("--dry-run is not supported by canary upgrades")
+	}
+	if iArgs.Revision == "" || iArgs.Revision == tag.DefaultRevisionName {
+		return fmt.Errorf("a canary upgrade requires a revision other than %q, set with --revision", tag.DefaultRevisionName)
+	}
+	if !labels.IsDNS1123Label(iArgs.Revision) {
+		return fmt.Errorf("invalid revision specified: %v", iArgs.Revision)
+	}
+	return nil
+}
+
+// parseWaves parses the comma separated namespaces of the waves. A namespace can only be in one wave.
+func parseWaves(in []string) ([][]string, error) {
+	seen := sets.New[string]()
+	var waves [][]string
+	for _, w := range in {
+		var namespaces []string
+		for _, ns := range strings.Split(w, ",") {
+			ns = strings.TrimSpace(ns)
+			if ns == "" {
+				continue
+			}
+			if seen.InsertContains(ns) {
+				return nil, fmt.Errorf("namespace %s is in several waves", ns)
+			}
+			namespaces = append(namespaces, ns)
+		}
+		if len(namespaces) == 0 {
+			return nil, fmt.Errorf("wave %d has no namespace", len(waves)+1)
+		}
+		waves = append(waves, namespaces)
+	}
+	return waves, nil
+}
+
+func newUpgrader(ctx cli.Context, kubeClient kube.CLIClient, rootArgs *RootArgs, iArgs *InstallArgs, cArgs *canaryArgs,
+	stdOut, stdErr io.Writer,
+) *canary.Upgrader {
+	istioNamespace := ctx.IstioNamespace()
+	return &canary.Upgrader{
+		Client:         kubeClient,
+		IstioNamespace: istioNamespace,
+		Revision:       iArgs.Revision,
+		Tags:           cArgs.Tags,
+		Install: func() error {
+			args := *iArgs
+			args.SkipConfirmation = true
+			l := clog.NewConsoleLogger(stdOut, stdErr, installerScope)
+			if err := Install(kubeClient, rootArgs, &args, stdOut, l, NewPrinterForWriter(stdErr)); err != nil {
+				return err
+			}
+			webhooks, err := tag.GetWebhooksWithRevision(context.Background(), kubeClient.Kube(), iArgs.Revision)
+			if err != nil {
+				return err
+			}
+			if len(webhooks) == 0 {
+				return fmt.Errorf("no injector found for revision %q", iArgs.Revision)
+			}
+			return nil
+		},
+		MoveTag: func(tagName, revision string) error {
+			manifests, err := tag.Generate(context.Background(), kubeClient, &tag.GenerateOptions{
+				Tag:         tagName,
+				Revision:    revision,
+				Overwrite:   true,
+				UserManaged: true,
+			}, istioNamespace)
+			if err != nil {
+				return err
+			}
+			return tag.Create(kubeClient, manifests, istioNamespace)
+		},
+		ProxiesOnRevision: func(revision string) (sets.String, error) {
+			client, err := ctx.CLIClientWithRevision(revision)
+			if err != nil {
+				return nil, err
+			}
+			return canary.ProxiesOnRevision(client, istioNamespace, revision)
+		},
+		ErrorRate: func(namespaces []string, window time.Duration) (float64, error) {
+			return canary.PrometheusErrorRate(kubeClient, istioNamespace, cArgs.PrometheusAddress, namespaces, window)
+		},
+		ProxyTimeout:      cArgs.ProxyTimeout,
+		MaxErrorRate:      cArgs.MaxErrorRate,
+		ErrorRateWindow:   cArgs.ErrorRateWindow,
+		RollbackOnFailure: cArgs.RollbackOnFailure,
+		PollInterval:      5 * time.Second,
+		Writer:            stdOut,
+	}
+}
+
+func printCanaryState(w io.Writer, s *canary.State) {
+	installed := "no"
+	if s.Installed {
+		installed = "yes"
+	}
+	_, _ = fmt.Fprintf(w, "Revision: %s\nInstalled: %s\n\n", s.Revision, installed)
+	tw := new(tabwriter.Writer).Init(w, 0, 8, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "WAVE\tSTATUS\tTAG\tNAMESPACES")
+	for i, wave := range s.Waves {
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", i+1, wave.Status, renderTag(wave.Tag), strings.Join(wave.Namespaces, ","))
+	}
+	_ = tw.Flush()
+}
+
+func renderTag(t string) string {
+	if t == "" {
+		return "-"
+	}
+	return t
+}
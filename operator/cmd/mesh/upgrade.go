@@ -47,5 +47,6 @@ func UpgradeCmd(ctx cli.Context) *cobra.Command {
 	}
 	addFlags(cmd, rootArgs)
 	addInstallFlags(cmd, upgradeArgs.InstallArgs)
+	cmd.AddCommand(canaryCmd(ctx, rootArgs, upgradeArgs.InstallArgs))
 	return cmd
 }
@@ -0,0 +1,318 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/istio/operator/pkg/manifest"
+	"istio.io/istio/operator/pkg/webhook"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/slices"
+)
+
+// fieldOwnerOperator is the field manager of the objects applied by the installer.
+const fieldOwnerOperator = "istio-operator"
+
+// DiffStatus is the change applying an object would make to the cluster.
+type DiffStatus string
+
+const (
+	// DiffAdded is an object or field which would be created.
+	DiffAdded DiffStatus = "added"
+	// DiffRemoved is an object or field which would be deleted.
+	DiffRemoved DiffStatus = "removed"
+	// DiffChanged is an object or field which would be updated.
+	DiffChanged DiffStatus = "changed"
+	// DiffError is an object which could not be compared, for example because its server-side apply dry run was
+	// rejected. The error is reported in ObjectDiff.Error.
+	DiffError DiffStatus = "error"
+)
+
+// ObjectDiff is the change applying the rendered manifests would make to an object.
+type ObjectDiff struct {
+	Component  string      `json:"component,omitempty"`
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name"`
+	Status     DiffStatus  `json:"status"`
+	Fields     []FieldDiff `json:"fields,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// FieldDiff is the change of a field of a changed object.
+type FieldDiff struct {
+	// Path is the path of the field, like `spec.template.spec.containers[name=discovery].image`.
+	Path   string     `json:"path"`
+	Status DiffStatus `json:"status"`
+	Live   any        `json:"live,omitempty"`
+	// Rendered is the value of the field after applying the rendered manifests.
+	Rendered any `json:"rendered,omitempty"`
+}
+
+// ignoredFields are the fields set by the API server or the controllers, which are never applied.
+var ignoredFields = []string{
+	"status",
+	"metadata.managedFields",
+	"metadata.resourceVersion",
+	"metadata.uid",
+	"metadata.generation",
+	"metadata.creationTimestamp",
+	"metadata.selfLink",
+	"metadata.deletionTimestamp",
+	"metadata.deletionGracePeriodSeconds",
+	"metadata.ownerReferences",
+	"metadata.annotations.kubectl\\.kubernetes\\.io/last-applied-configuration",
+	"metadata.annotations.deployment\\.kubernetes\\.io/revision",
+	"metadata.annotations.deprecated\\.daemonset\\.template\\.generation",
+}
+
+// Diff compares the rendered manifests, as they would be applied by InstallManifests, to the objects in the cluster.
+// The objects are compared to the result of a server-side apply dry run of the manifests, so the fields are compared
+// as they would be stored by the API server: with their defaults, normalized and merged with the fields of the other
+// field managers. The objects which would be pruned are reported as removed, and the objects which could not be
+// compared are reported with their error, without failing the comparison of the other objects.
+func (i Installer) Diff(manifests []manifest.ManifestSet) ([]ObjectDiff, error) {
+	var diffs []ObjectDiff
+	addDiff := func(obj manifest.Manifest, component string) {
+		d, err := i.diffObject(obj, component)
+		if err != nil {
+			d = ptr.Of(newObjectDiff(obj.Unstructured, component, DiffError))
+			d.Error = err.Error()
+		}
+		if d != nil {
+			diffs = append(diffs, *d)
+		}
+	}
+	for _, mfs := range manifests {
+		for _, m := range mfs.Manifests {
+			obj, err := i.applyLabelsAndAnnotations(m, string(mfs.Component))
+			if err != nil {
+				return nil, err
+			}
+			addDiff(obj, string(mfs.Component))
+		}
+	}
+
+	webhooks, err := webhook.WebhooksToDeploy(i.Values, i.Kube, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed generating webhooks: %v", err)
+	}
+	for _, wh := range webhooks {
+		addDiff(wh, "")
+	}
+
+	pruned, err := i.prunedResources(manifests)
+	if err != nil {
+		return nil, fmt.Errorf("pruning: %v", err)
+	}
+	for _, obj := range pruned {
+		diffs = append(diffs, newObjectDiff(obj, obj.GetLabels()[manifest.IstioComponentLabel], DiffRemoved))
+	}
+
+	slices.SortStableFunc(diffs, func(a, b ObjectDiff) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	return diffs, nil
+}
+
+// diffObject compares the object in the cluster to the result of applying the rendered object, with a server-side
+// apply dry run as the installer field manager. It returns nil if they do not differ.
+func (i Installer) diffObject(obj manifest.Manifest, component string) (*ObjectDiff, error) {
+	dc, err := i.Kube.DynamicClientFor(obj.GroupVersionKind(), obj.Unstructured, "")
+	if err != nil {
+		return nil, err
+	}
+	objectStr := fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+	live, err := dc.Get(context.Background(), obj.GetName(), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		d := newObjectDiff(obj.Unstructured, component, DiffAdded)
+		return &d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %v", objectStr, err)
+	}
+	applied, err := dc.Patch(context.Background(), obj.GetName(), types.ApplyPatchType, []byte(obj.Content), metav1.PatchOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		Force:        ptr.Of(true),
+		FieldManager: fieldOwnerOperator,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to dry run server-side apply for %s: %v", objectStr, err)
+	}
+	fields := DiffFields(live, applied)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	d := newObjectDiff(obj.Unstructured, component, DiffChanged)
+	d.Fields = fields
+	return &d, nil
+}
+
+func newObjectDiff(obj *unstructured.Unstructured, component string, status DiffStatus) ObjectDiff {
+	return ObjectDiff{
+		Component:  component,
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		Status:     status,
+	}
+}
+
+// DiffFields returns the changes between the live object and the object as it would be after applying the rendered
+// manifest, ignoring the fields managed by the server. Both objects are expected to be returned by the API server,
+// so their values have the same types and formats.
+func DiffFields(live, applied *unstructured.Unstructured) []FieldDiff {
+	d := &fieldDiffer{}
+	d.diff("", live.Object, applied.Object)
+	return d.fields
+}
+
+type fieldDiffer struct {
+	fields []FieldDiff
+}
+
+func (d *fieldDiffer) diff(path string, live, applied any) {
+	if slices.Contains(ignoredFields, path) {
+		return
+	}
+	switch a := applied.(type) {
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok {
+			break
+		}
+		for _, k := range slices.Sort(maps.Keys(a)) {
+			p := joinPath(path, k)
+			lv, f := l[k]
+			if !f {
+				if !slices.Contains(ignoredFields, p) && a[k] != nil {
+					d.add(p, DiffAdded, nil, a[k])
+				}
+				continue
+			}
+			d.diff(p, lv, a[k])
+		}
+		for _, k := range slices.Sort(maps.Keys(l)) {
+			if _, f := a[k]; f {
+				continue
+			}
+			p := joinPath(path, k)
+			if !slices.Contains(ignoredFields, p) && l[k] != nil {
+				d.add(p, DiffRemoved, l[k], nil)
+			}
+		}
+		return
+	case []any:
+		l, ok := live.([]any)
+		if !ok {
+			break
+		}
+		d.diffList(path, l, a)
+		return
+	}
+	if !reflect.DeepEqual(live, applied) {
+		d.add(path, DiffChanged, live, applied)
+	}
+}
+
+// diffList compares the items of lists. The items of lists of named objects, like containers, are compared by name;
+// the others are compared by index.
+func (d *fieldDiffer) diffList(path string, live, applied []any) {
+	if names, ok := itemNames(applied); ok {
+		if liveNames, ok := itemNames(live); ok {
+			liveIndex := map[string]int{}
+			for i, n := range liveNames {
+				liveIndex[n] = i
+			}
+			for i, n := range names {
+				p := fmt.Sprintf("%s[name=%s]", path, n)
+				if li, f := liveIndex[n]; f {
+					d.diff(p, live[li], applied[i])
+					delete(liveIndex, n)
+				} else {
+					d.add(p, DiffAdded, nil, applied[i])
+				}
+			}
+			for i, n := range liveNames {
+				if _, f := liveIndex[n]; f {
+					d.add(fmt.Sprintf("%s[name=%s]", path, n), DiffRemoved, live[i], nil)
+				}
+			}
+			return
+		}
+	}
+	for i := range max(len(live), len(applied)) {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(live):
+			d.add(p, DiffAdded, nil, applied[i])
+		case i >= len(applied):
+			d.add(p, DiffRemoved, live[i], nil)
+		default:
+			d.diff(p, live[i], applied[i])
+		}
+	}
+}
+
+func (d *fieldDiffer) add(path string, status DiffStatus, live, applied any) {
+	d.fields = append(d.fields, FieldDiff{Path: path, Status: status, Live: live, Rendered: applied})
+}
+
+// itemNames returns the names of the items of a list, if they all are objects with a unique name.
+func itemNames(items []any) ([]string, bool) {
+	if len(items) == 0 {
+		return nil, false
+	}
+	names := make([]string, 0, len(items))
+	seen := map[string]struct{}{}
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		name, ok := obj["name"].(string)
+		if !ok {
+			return nil, false
+		}
+		if _, f := seen[name]; f {
+			return nil, false
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names, true
+}
+
+// joinPath appends a key to a path, escaping the dots of the key.
+func joinPath(path, key string) string {
+	key = strings.ReplaceAll(key, ".", "\\.")
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,163 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package install
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/operator/pkg/component"
+	"istio.io/istio/operator/pkg/manifest"
+	"istio.io/istio/operator/pkg/values"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestDiffFields(t *testing.T) {
+	live := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: istiod
+  namespace: istio-system
+  resourceVersion: "12"
+  uid: 5f8c8a2e
+  generation: 3
+  annotations:
+    deployment.kubernetes.io/revision: "2"
+  labels:
+    app: istiod
+    old: label
+    user: label
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: discovery
+        image: istiod:1.0
+        args: ["discovery", "--monitoringAddr=:15014"]
+        resources:
+          requests:
+            cpu: 500m
+            memory: 2Gi
+        terminationMessagePath: /dev/termination-log
+      - name: old
+        image: old:1.0
+        terminationMessagePath: /dev/termination-log
+status:
+  replicas: 1
+`
+	// applied is the result of the server-side apply dry run of the rendered manifest: the defaults, the normalized
+	// quantities and the fields of the other field managers are kept, and the fields only applied by the previous
+	// version of the manifest are removed.
+	applied := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: istiod
+  namespace: istio-system
+  resourceVersion: "12"
+  uid: 5f8c8a2e
+  generation: 4
+  annotations:
+    deployment.kubernetes.io/revision: "2"
+  labels:
+    app: istiod
+    new: label
+    user: label
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: discovery
+        image: istiod:2.0
+        args: ["discovery", "--monitoringAddr=:15014"]
+        resources:
+          requests:
+            cpu: 500m
+            memory: 2Gi
+        terminationMessagePath: /dev/termination-log
+status:
+  replicas: 1
+`
+	parse := func(s string) *unstructured.Unstructured {
+		t.Helper()
+		us := &unstructured.Unstructured{}
+		assert.NoError(t, yaml.Unmarshal([]byte(s), us))
+		return us
+	}
+
+	assert.Equal(t, DiffFields(parse(live), parse(applied)), []FieldDiff{
+		{Path: "metadata.labels.new", Status: DiffAdded, Rendered: "label"},
+		{Path: "metadata.labels.old", Status: DiffRemoved, Live: "label"},
+		{Path: "spec.template.spec.containers[name=discovery].image", Status: DiffChanged, Live: "istiod:1.0", Rendered: "istiod:2.0"},
+		{
+			Path:   "spec.template.spec.containers[name=old]",
+			Status: DiffRemoved,
+			Live:   map[string]any{"name": "old", "image": "old:1.0", "terminationMessagePath": "/dev/termination-log"},
+		},
+	})
+	assert.Equal(t, len(DiffFields(parse(live), parse(live))), 0)
+}
+
+func TestDiffReportsErrors(t *testing.T) {
+	added, err := manifest.FromYaml([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: added
+  namespace: istio-system
+`))
+	assert.NoError(t, err)
+	rejected, err := manifest.FromYaml([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: rejected
+  namespace: istio-system
+`))
+	assert.NoError(t, err)
+
+	client := kube.NewFakeClient()
+	_, err = client.Dynamic().Resource(corev1.SchemeGroupVersion.WithResource("configmaps")).Namespace("istio-system").
+		Create(context.Background(), rejected.Unstructured, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	// The admission rejects the dry run of the object, which does not prevent the comparison of the others.
+	client.Dynamic().(*dynamicfake.FakeDynamicClient).PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, kerrors.NewForbidden(corev1.Resource("configmaps"), "rejected", errors.New("denied by admission"))
+	})
+	i := Installer{Kube: client, Values: values.Map{}}
+	diffs, err := i.Diff([]manifest.ManifestSet{{Component: component.PilotComponentName, Manifests: []manifest.Manifest{rejected, added}}})
+	assert.NoError(t, err)
+	assert.Equal(t, len(diffs), 2)
+	assert.Equal(t, diffs[0].Name, "added")
+	assert.Equal(t, diffs[0].Status, DiffAdded)
+	assert.Equal(t, diffs[1].Name, "rejected")
+	assert.Equal(t, diffs[1].Status, DiffError)
+	assert.Equal(t, strings.Contains(diffs[1].Error, "denied by admission"), true)
+}
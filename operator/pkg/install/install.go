@@ -22,6 +22,7 @@ import (
 
 	"github.com/hashicorp/go-multierror"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	klabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
@@ -192,7 +193,6 @@ func (i Installer) applyManifestSet(manifestSet manifest.ManifestSet) error {
 
 // serverSideApply creates or updates an object in the API server depending on whether it already exists.
 func (i Installer) serverSideApply(obj manifest.Manifest) error {
-	const fieldOwnerOperator = "istio-operator"
 	dc, err := i.Kube.DynamicClientFor(obj.GroupVersionKind(), obj.Unstructured, "")
 	if err != nil {
 		return err
@@ -231,6 +231,22 @@ func (i Installer) prune(manifests []manifest.ManifestSet) error {
 	}
 	i.ProgressLogger.SetState(progress.StatePruning)
 
+	objs, err := i.prunedResources(manifests)
+	if err != nil {
+		return err
+	}
+	var errs util.Errors
+	for _, obj := range objs {
+		if err := uninstall.DeleteResource(i.Kube, i.DryRun, i.Logger, obj); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs.ToError()
+}
+
+// prunedResources returns the resources that are in the cluster, but not a part of the set of objects, in the
+// order they should be deleted.
+func (i Installer) prunedResources(manifests []manifest.ManifestSet) ([]*unstructured.Unstructured, error) {
 	// Build up a map of component->resources, so we know what to keep around
 	excluded := map[component.Name]sets.String{}
 	// Include all components in case we disabled some.
@@ -247,21 +263,21 @@ func (i Installer) prune(manifests []manifest.ManifestSet) error {
 	selector := klabels.Set(coreLabels).AsSelectorPreValidated()
 	componentRequirement, err := klabels.NewRequirement(manifest.IstioComponentLabel, selection.Exists, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	selector = selector.Add(*componentRequirement)
 
-	var errs util.Errors
+	var pruned []*unstructured.Unstructured
 	resources := uninstall.PrunedResourcesSchemas()
 	for _, gvk := range resources {
 		dc, err := i.Kube.DynamicClientFor(gvk, nil, "")
 		if err != nil {
-			return err
+			return nil, err
 		}
 		objs, err := dc.List(context.Background(), metav1.ListOptions{LabelSelector: selector.String()})
 		if err := controllers.IgnoreNotFound(err); err != nil {
 			// Cluster may not even have these resources; ignore these errors
-			return err
+			return nil, err
 		}
 		if objs == nil {
 			continue
@@ -281,13 +297,11 @@ func (i Installer) prune(manifests []manifest.ManifestSet) error {
 					continue
 				}
 
-				if err := uninstall.DeleteResource(i.Kube, i.DryRun, i.Logger, &obj); err != nil {
-					errs = append(errs, err)
-				}
+				pruned = append(pruned, &obj)
 			}
 		}
 	}
-	return errs.ToError()
+	return pruned, nil
 }
 
 var componentDependencies = map[component.Name][]component.Name{
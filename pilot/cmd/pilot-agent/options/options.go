@@ -92,9 +92,26 @@ var (
 	eccCurvEnv          = env.Register("ECC_CURVE", "P256", "The elliptic curve to use when ECC_SIGNATURE_ALGORITHM is set to ECDSA").Get()
 	fileMountedCertsEnv = env.Register("FILE_MOUNTED_CERTS", false, "").Get()
 	credFetcherTypeEnv  = env.Register("CREDENTIAL_FETCHER_TYPE", security.JWT,
-		"The type of the credential fetcher. Currently supported types include GoogleComputeEngine").Get()
+		"The type of the credential fetcher. Currently supported types include GoogleComputeEngine, JWT, "+
+			"MetadataToken and OIDCTokenExchange").Get()
 	credIdentityProvider = env.Register("CREDENTIAL_IDENTITY_PROVIDER", "GoogleComputeEngine",
 		"The identity provider for credential. Currently default supported identity provider is GoogleComputeEngine").Get()
+	credFetcherTokenURL = env.Register("CREDENTIAL_FETCHER_TOKEN_URL", "",
+		"The token URL of the instance metadata service for the MetadataToken credential fetcher, or the OAuth 2.0 "+
+			"token exchange endpoint for the OIDCTokenExchange credential fetcher.").Get()
+	credFetcherHeaderScheme = env.Register("CREDENTIAL_FETCHER_HEADER_SCHEME", "",
+		"The header scheme of the instance metadata service for the MetadataToken credential fetcher: gce, azure, "+
+			"aws-imdsv2, or empty to only send the CREDENTIAL_FETCHER_HEADERS.").Get()
+	credFetcherHeaders = env.Register("CREDENTIAL_FETCHER_HEADERS", "",
+		"A comma separated list of name=value headers sent to CREDENTIAL_FETCHER_TOKEN_URL.").Get()
+	credFetcherSubjectTokenPath = env.Register("CREDENTIAL_FETCHER_SUBJECT_TOKEN_PATH", "",
+		"The OIDC token file exchanged by the OIDCTokenExchange credential fetcher, such as a workload identity federation token.").Get()
+	credFetcherAudience = env.Register("CREDENTIAL_FETCHER_AUDIENCE", "",
+		"The audience of the token requested by the OIDCTokenExchange credential fetcher. Defaults to the trust domain.").Get()
+	credFetcherClientID = env.Register("CREDENTIAL_FETCHER_CLIENT_ID", "",
+		"The client ID sent by the OIDCTokenExchange credential fetcher, if any.").Get()
+	credFetcherScope = env.Register("CREDENTIAL_FETCHER_SCOPE", "",
+		"The scope requested by the OIDCTokenExchange credential fetcher, if any.").Get()
 	proxyXDSDebugViaAgent = env.Register("PROXY_XDS_DEBUG_VIA_AGENT", true,
 		"If set to true, the agent will listen on tap port and offer pilot's XDS istio.io/debug debug API there.").Get()
 	proxyXDSDebugViaAgentPort = env.Register("PROXY_XDS_DEBUG_VIA_AGENT_PORT", 15004,
@@ -25,6 +25,7 @@ import (
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/credentialfetcher"
+	"istio.io/istio/security/pkg/credentialfetcher/plugin"
 	"istio.io/istio/security/pkg/nodeagent/cafile"
 )
 
@@ -90,7 +91,11 @@ func SetupSecurityOptions(proxyConfig *meshconfig.ProxyConfig, secOpt *security.
 	}
 
 	o.CredIdentityProvider = credIdentityProvider
-	credFetcher, err := credentialfetcher.NewCredFetcher(credFetcherTypeEnv, o.TrustDomain, jwtPath, o.CredIdentityProvider)
+	httpOpts, err := credFetcherHTTPOptions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential fetcher: %v", err)
+	}
+	credFetcher, err := credentialfetcher.NewCredFetcher(credFetcherTypeEnv, o.TrustDomain, jwtPath, o.CredIdentityProvider, httpOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create credential fetcher: %v", err)
 	}
@@ -124,3 +129,29 @@ func SetupSecurityOptions(proxyConfig *meshconfig.ProxyConfig, secOpt *security.
 	}
 	return o, nil
 }
+
+// credFetcherHTTPOptions returns the options of the credential fetchers fetching the tokens from an HTTP endpoint.
+func credFetcherHTTPOptions() (plugin.HTTPTokenOptions, error) {
+	opts := plugin.HTTPTokenOptions{
+		URL:              credFetcherTokenURL,
+		HeaderScheme:     credFetcherHeaderScheme,
+		SubjectTokenPath: credFetcherSubjectTokenPath,
+		Audience:         credFetcherAudience,
+		ClientID:         credFetcherClientID,
+		Scope:            credFetcherScope,
+	}
+	for _, h := range strings.Split(credFetcherHeaders, ",") {
+		if strings.TrimSpace(h) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(h, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return opts, fmt.Errorf("invalid credential fetcher header %q, expected name=value", h)
+		}
+		if opts.Headers == nil {
+			opts.Headers = map[string]string{}
+		}
+		opts.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return opts, nil
+}
@@ -19,6 +19,8 @@ import (
 	"testing"
 
 	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
 )
 
 func TestCheckGkeWorkloadCertificate(t *testing.T) {
@@ -59,3 +61,14 @@ func TestCheckGkeWorkloadCertificate(t *testing.T) {
 		}
 	}
 }
+
+func TestCredFetcherHTTPOptions(t *testing.T) {
+	test.SetForTest(t, &credFetcherHeaders, " Metadata=true, X-Custom = a=b ,")
+	opts, err := credFetcherHTTPOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, opts.Headers, map[string]string{"Metadata": "true", "X-Custom": "a=b"})
+
+	test.SetForTest(t, &credFetcherHeaders, "Metadata")
+	_, err = credFetcherHTTPOptions()
+	assert.Error(t, err)
+}
@@ -0,0 +1,370 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	kubecontroller "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
+	tb "istio.io/istio/pilot/pkg/trustbundle"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config/constants"
+	kubelib "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/kclient"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
+	caserver "istio.io/istio/security/pkg/server/ca"
+)
+
+var (
+	// caRootTransitionCheckInterval is the interval at which the acknowledgement of the combined trust bundle
+	// by the proxies is checked.
+	caRootTransitionCheckInterval = time.Second
+	// caRootTransitionLogInterval is the interval at which the proxies which have not yet acknowledged the
+	// combined trust bundle are logged.
+	caRootTransitionLogInterval = time.Minute
+)
+
+// errServerStopped is returned when istiod stops during the ROOT-CA transition.
+var errServerStopped = errors.New("istiod is stopping")
+
+const (
+	// caRootTransitionConfigMap is the ConfigMap in the istiod namespace in which each replica records the
+	// fingerprint of the combined trust bundle, once it has been acknowledged by the proxies connected to it.
+	caRootTransitionConfigMap = "istio-ca-root-transition"
+	// istiodAppLabel is the value of the app label of the istiod pods.
+	istiodAppLabel = "istiod"
+)
+
+// caRootTransitionStatus coordinates the ROOT-CA transition with the other istiod replicas, and checks the
+// distribution of the combined trust bundle in the istio-ca-root-cert ConfigMaps.
+type caRootTransitionStatus struct {
+	namespace string
+	podName   string
+
+	status    kclient.Client[*corev1.ConfigMap]
+	rootCerts kclient.Client[*corev1.ConfigMap]
+	pods      kclient.Client[*corev1.Pod]
+}
+
+// initCARootTransitionStatus watches the state of the ROOT-CA transitions shared with the other istiod replicas.
+func (s *Server) initCARootTransitionStatus(opts *caOptions) {
+	s.caRootTransitionStatus = newCARootTransitionStatus(s.kubeClient, opts.Namespace, opts.PodName)
+}
+
+func newCARootTransitionStatus(client kubelib.Client, namespace, podName string) *caRootTransitionStatus {
+	return &caRootTransitionStatus{
+		namespace: namespace,
+		podName:   podName,
+		status: kclient.NewFiltered[*corev1.ConfigMap](client, kclient.Filter{
+			Namespace:     namespace,
+			FieldSelector: "metadata.name=" + caRootTransitionConfigMap,
+		}),
+		rootCerts: kclient.NewFiltered[*corev1.ConfigMap](client, kclient.Filter{
+			FieldSelector: "metadata.name=" + kubecontroller.CACertNamespaceConfigMap,
+		}),
+		pods: kclient.NewFiltered[*corev1.Pod](client, kclient.Filter{
+			Namespace:     namespace,
+			LabelSelector: "app=" + istiodAppLabel,
+		}),
+	}
+}
+
+// pendingConfigMaps returns the istio-ca-root-cert ConfigMaps which do not contain all the roots.
+func (c *caRootTransitionStatus) pendingConfigMaps(roots []byte) []string {
+	if !c.rootCerts.HasSynced() {
+		return []string{kubecontroller.CACertNamespaceConfigMap + " ConfigMaps"}
+	}
+	var pending []string
+	for _, cm := range c.rootCerts.List(metav1.NamespaceAll, klabels.Everything()) {
+		if cm.Name != kubecontroller.CACertNamespaceConfigMap {
+			continue
+		}
+		if hasNewRootCerts([]byte(cm.Data[constants.CACertNamespaceConfigMapDataName]), roots) {
+			pending = append(pending, "ConfigMap "+cm.Namespace+"/"+cm.Name)
+		}
+	}
+	return pending
+}
+
+// ack records that the combined trust bundle with the fingerprint has been acknowledged through this replica,
+// and returns the other ready replicas which have not yet acknowledged it.
+func (c *caRootTransitionStatus) ack(fingerprint string) ([]string, error) {
+	if !c.status.HasSynced() || !c.pods.HasSynced() {
+		return nil, fmt.Errorf("the status of the istiod replicas is not synced")
+	}
+	replicas := map[string]*corev1.Pod{}
+	for _, pod := range c.pods.List(c.namespace, klabels.Everything()) {
+		if pod.Labels["app"] == istiodAppLabel {
+			replicas[pod.Name] = pod
+		}
+	}
+
+	cm := c.status.Get(caRootTransitionConfigMap, c.namespace)
+	data := map[string]string{c.podName: fingerprint}
+	if cm != nil {
+		for pod, fp := range cm.Data {
+			// The replicas which are gone are pruned.
+			if _, f := replicas[pod]; f && pod != c.podName {
+				data[pod] = fp
+			}
+		}
+	}
+	if cm == nil {
+		_, err := c.status.Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: caRootTransitionConfigMap, Namespace: c.namespace},
+			Data:       data,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if !maps.Equal(cm.Data, data) {
+		cm = cm.DeepCopy()
+		cm.Data = data
+		if _, err := c.status.Update(cm); err != nil {
+			return nil, err
+		}
+	}
+
+	var pending []string
+	for name, pod := range replicas {
+		if kubelib.CheckPodReady(pod) == nil && data[name] != fingerprint {
+			pending = append(pending, "istiod "+name)
+		}
+	}
+	slices.Sort(pending)
+	return pending, nil
+}
+
+// startCARootTransition starts the transition of the plugged-in CA to the new signing CA files, whose root
+// certificates are not all trusted yet. The combined trust bundle of the current and new roots is distributed
+// first, and the CA switches to the new signing key once it has been acknowledged by every connected proxy,
+// istio-ca-root-cert ConfigMap and istiod replica. The transition is aborted if this takes longer than
+// CA_DUAL_ROOT_TRANSITION_TIMEOUT.
+func (s *Server) startCARootTransition(fileBundle ca.SigningCAFileBundle, currentRoots []byte) error {
+	certBytes, keyBytes, chainBytes, newRoots, err := util.ReadKeyCertBundleFiles(
+		fileBundle.SigningCertFile,
+		fileBundle.SigningKeyFile,
+		fileBundle.CertChainFiles,
+		fileBundle.RootCertFile)
+	if err != nil {
+		return fmt.Errorf("failed reading the new cacerts: %v", err)
+	}
+	roots, err := mergeRootCerts(currentRoots, newRoots)
+	if err != nil {
+		return err
+	}
+	// Fail early if the new signing cert is not valid, rather than after the distribution of the roots.
+	if err := util.Verify(certBytes, keyBytes, chainBytes, roots); err != nil {
+		return fmt.Errorf("invalid new cacerts: %v", err)
+	}
+
+	log.Info("Distributing the trust bundle with the current and new ROOT-CA")
+	if err := s.setRootCerts(roots); err != nil {
+		return err
+	}
+	// Only the pushes triggered after the update of the trust bundle are known to carry it. A push is forced,
+	// as the trust bundle may not have changed, e.g. when a canceled transition is started again.
+	version := s.XDSServer.PushVersionCount()
+	s.XDSServer.ConfigUpdate(&model.PushRequest{
+		Full:   true,
+		Reason: model.NewReasonStats(model.GlobalUpdate),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.caRootTransitionMutex.Lock()
+	s.caRootTransitionCancel = cancel
+	s.caRootTransitionRoots = currentRoots
+	s.caRootTransitionMutex.Unlock()
+
+	go func() {
+		err := s.waitForTrustBundleAck(ctx, version, roots)
+		s.caRootTransitionMutex.Lock()
+		defer s.caRootTransitionMutex.Unlock()
+		// A newer update of the cacerts has canceled the transition, or istiod is stopping.
+		if ctx.Err() != nil || errors.Is(err, errServerStopped) {
+			return
+		}
+		s.caRootTransitionCancel = nil
+		s.caRootTransitionRoots = nil
+		cancel()
+		if err != nil {
+			log.Errorf("Aborting the ROOT-CA transition, istiod keeps signing with the current CA: %v", err)
+			// The new roots are distributed again when the cacerts are updated next.
+			if err := s.setRootCerts(currentRoots); err != nil {
+				log.Errorf("Failed to restore the current ROOT-CA: %v", err)
+			}
+			return
+		}
+
+		if err := s.CA.GetCAKeyCertBundle().VerifyAndSetAll(certBytes, keyBytes, chainBytes, roots); err != nil {
+			log.Errorf("Failed to update new Plug-in CA certs: %v", err)
+			return
+		}
+		caserver.RecordCertsExpiry(s.CA.GetCAKeyCertBundle())
+		if err := s.updateRootCertAndGenKeyCert(); err != nil {
+			log.Errorf("Failed generating plugged-in istiod key cert: %v", err)
+			return
+		}
+		log.Info("Istiod has switched to the new signing CA, after the acknowledgement of the new ROOT-CA by all the proxies")
+	}()
+	return nil
+}
+
+// cancelCARootTransition cancels the ongoing transition of the plugged-in CA to new root certificates, if any,
+// and returns the roots before it. The CA does not switch to the signing key of the canceled transition once
+// it returns.
+func (s *Server) cancelCARootTransition() []byte {
+	s.caRootTransitionMutex.Lock()
+	defer s.caRootTransitionMutex.Unlock()
+	if s.caRootTransitionCancel == nil {
+		return nil
+	}
+	log.Info("Canceling the ongoing ROOT-CA transition")
+	s.caRootTransitionCancel()
+	s.caRootTransitionCancel = nil
+	roots := s.caRootTransitionRoots
+	s.caRootTransitionRoots = nil
+	return roots
+}
+
+// setRootCerts sets the roots of the certificates issued with the current signing key, of the trust bundle
+// distributed to the proxies and of istiod.
+func (s *Server) setRootCerts(roots []byte) error {
+	bundle := s.CA.GetCAKeyCertBundle()
+	cert, key, chain, _ := bundle.GetAllPem()
+	if err := bundle.VerifyAndSetAll(cert, key, chain, roots); err != nil {
+		return fmt.Errorf("failed to set the ROOT-CA of the current cacerts: %v", err)
+	}
+	err := s.workloadTrustBundle.UpdateTrustAnchor(&tb.TrustAnchorUpdate{
+		TrustAnchorConfig: tb.TrustAnchorConfig{Certs: []string{string(roots)}},
+		Source:            tb.SourceIstioCA,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update trust anchor from source Istio CA: %v", err)
+	}
+	s.istiodCertBundleWatcher.SetAndNotify(nil, nil, roots)
+	return nil
+}
+
+// waitForTrustBundleAck waits until the combined roots have been acknowledged by every connected proxy with
+// a PCDS push more recent than version, have been written to every istio-ca-root-cert ConfigMap, and until
+// the other istiod replicas have recorded the same. It returns an error if this takes longer than
+// CA_DUAL_ROOT_TRANSITION_TIMEOUT, or if ctx is canceled or the server is stopped first.
+func (s *Server) waitForTrustBundleAck(ctx context.Context, version uint64, roots []byte) error {
+	sum := sha256.Sum256(roots)
+	fingerprint := hex.EncodeToString(sum[:])
+	ticker := time.NewTicker(caRootTransitionCheckInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(features.CADualRootTransitionTimeout)
+	defer timeout.Stop()
+	var lastLog time.Time
+	for {
+		pending := s.XDSServer.ProxiesPendingAck(v3.ProxyConfigType, version)
+		if s.caRootTransitionStatus != nil {
+			pending = append(pending, s.caRootTransitionStatus.pendingConfigMaps(roots)...)
+			if len(pending) == 0 {
+				replicas, err := s.caRootTransitionStatus.ack(fingerprint)
+				if err != nil {
+					log.Warnf("Failed to record the acknowledgement of the new ROOT-CA: %v", err)
+					replicas = []string{"istiod " + s.caRootTransitionStatus.podName}
+				}
+				pending = replicas
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Since(lastLog) >= caRootTransitionLogInterval {
+			lastLog = time.Now()
+			log.Infof("Waiting for %d proxies, ConfigMaps or istiod replicas to acknowledge the new ROOT-CA, including %v",
+				len(pending), pending[0])
+		}
+		select {
+		case <-ticker.C:
+		case <-timeout.C:
+			return fmt.Errorf("the new ROOT-CA has not been acknowledged within %v by %d proxies, ConfigMaps or istiod replicas: %v",
+				features.CADualRootTransitionTimeout, len(pending), pending)
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.internalStop:
+			return errServerStopped
+		}
+	}
+}
+
+// hasNewRootCerts returns true if newRoots contains certificates which are not in currentRoots.
+func hasNewRootCerts(currentRoots, newRoots []byte) bool {
+	current := sets.New(pemBlocks(currentRoots)...)
+	for _, b := range pemBlocks(newRoots) {
+		if !current.Contains(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeRootCerts returns the current root certificates followed by the new ones which are not already
+// part of the current ones.
+func mergeRootCerts(currentRoots, newRoots []byte) ([]byte, error) {
+	seen := sets.New[string]()
+	var merged bytes.Buffer
+	for _, roots := range [][]byte{currentRoots, newRoots} {
+		for _, b := range pemBlocks(roots) {
+			if seen.InsertContains(b) {
+				continue
+			}
+			if err := pem.Encode(&merged, &pem.Block{Type: "CERTIFICATE", Bytes: []byte(b)}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if merged.Len() == 0 {
+		return nil, fmt.Errorf("no root certificate")
+	}
+	return merged.Bytes(), nil
+}
+
+// pemBlocks returns the DER bytes of the certificates of a PEM bundle.
+func pemBlocks(certs []byte) []string {
+	var blocks []string
+	for {
+		var block *pem.Block
+		block, certs = pem.Decode(certs)
+		if block == nil {
+			return blocks
+		}
+		if block.Type == "CERTIFICATE" {
+			blocks = append(blocks, string(block.Bytes))
+		}
+	}
+}
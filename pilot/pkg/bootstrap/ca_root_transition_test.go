@@ -0,0 +1,242 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/keycertbundle"
+	"istio.io/istio/pilot/pkg/model"
+	kubecontroller "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
+	tb "istio.io/istio/pilot/pkg/trustbundle"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/kclient/clienttest"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+func sampleCAFileBundle(suffix string) ca.SigningCAFileBundle {
+	dir := path.Join(env.IstioSrc, "samples/certs")
+	return ca.SigningCAFileBundle{
+		RootCertFile:    path.Join(dir, "root-cert"+suffix+".pem"),
+		CertChainFiles:  []string{path.Join(dir, "cert-chain"+suffix+".pem")},
+		SigningCertFile: path.Join(dir, "ca-cert"+suffix+".pem"),
+		SigningKeyFile:  path.Join(dir, "ca-key"+suffix+".pem"),
+	}
+}
+
+func TestMergeRootCerts(t *testing.T) {
+	root, err := readSampleCertFromFile("root-cert.pem")
+	assert.NoError(t, err)
+	alt, err := readSampleCertFromFile("root-cert-alt.pem")
+	assert.NoError(t, err)
+
+	merged, err := mergeRootCerts(root, alt)
+	assert.NoError(t, err)
+	assert.Equal(t, len(pemBlocks(merged)), 2)
+	assert.Equal(t, hasNewRootCerts(root, alt), true)
+	assert.Equal(t, hasNewRootCerts(merged, alt), false)
+	assert.Equal(t, hasNewRootCerts(merged, root), false)
+	assert.Equal(t, hasNewRootCerts(root, root), false)
+
+	merged, err = mergeRootCerts(root, root)
+	assert.NoError(t, err)
+	assert.Equal(t, len(pemBlocks(merged)), 1)
+
+	_, err = mergeRootCerts(nil, []byte("invalid"))
+	assert.Error(t, err)
+}
+
+func newCARootTransitionServer(t *testing.T) *Server {
+	caOpts, err := ca.NewPluggedCertIstioCAOptions(sampleCAFileBundle(""), time.Hour, time.Hour, 2048)
+	assert.NoError(t, err)
+	istioCA, err := ca.NewIstioCA(caOpts)
+	assert.NoError(t, err)
+	s := &Server{
+		CA:                      istioCA,
+		XDSServer:               xds.NewDiscoveryServer(model.NewEnvironment(), nil),
+		workloadTrustBundle:     tb.NewTrustBundle(nil, mesh.NewFixedWatcher(&meshconfig.MeshConfig{TrustDomain: "cluster.local"})),
+		istiodCertBundleWatcher: keycertbundle.NewWatcher(),
+		dnsNames:                []string{"istiod.istio-system.svc"},
+		internalStop:            make(chan struct{}),
+	}
+	t.Cleanup(s.XDSServer.JwtKeyResolver.Close)
+	return s
+}
+
+// signingCert returns the signing cert of the CA of the server.
+func signingCert(s *Server) []byte {
+	cert, _, _, _ := s.CA.GetCAKeyCertBundle().GetAllPem()
+	return cert
+}
+
+// caRootTransitionDone returns true once the ongoing ROOT-CA transition has completed or has been aborted.
+func caRootTransitionDone(s *Server) bool {
+	s.caRootTransitionMutex.Lock()
+	defer s.caRootTransitionMutex.Unlock()
+	return s.caRootTransitionCancel == nil
+}
+
+func TestCARootTransition(t *testing.T) {
+	s := newCARootTransitionServer(t)
+	currentRoots := s.CA.GetCAKeyCertBundle().GetRootCertPem()
+	newRoots, err := readSampleCertFromFile("root-cert-alt.pem")
+	assert.NoError(t, err)
+	newSigningCert, err := readSampleCertFromFile("ca-cert-alt.pem")
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.startCARootTransition(sampleCAFileBundle("-alt"), currentRoots))
+	// Without any connected proxy the CA switches to the new signing cert right away, keeping the current roots.
+	assert.EventuallyEqual(t, func() []byte {
+		return signingCert(s)
+	}, newSigningCert)
+	roots := s.CA.GetCAKeyCertBundle().GetRootCertPem()
+	assert.Equal(t, hasNewRootCerts(roots, currentRoots), false)
+	assert.Equal(t, hasNewRootCerts(roots, newRoots), false)
+	assert.Equal(t, bytes.Equal(s.istiodCertBundleWatcher.GetKeyCertBundle().CABundle, roots), true)
+	assert.Equal(t, s.workloadTrustBundle.GetTrustBundle(), []string{string(roots)})
+
+	// Invalid cacerts do not start a transition.
+	assert.Error(t, s.startCARootTransition(sampleCAFileBundle("-invalid"), currentRoots))
+}
+
+func TestCARootTransitionReplicas(t *testing.T) {
+	test.SetForTest(t, &caRootTransitionCheckInterval, 10*time.Millisecond)
+	test.SetForTest(t, &features.CADualRootTransitionTimeout, time.Minute)
+	s := newCARootTransitionServer(t)
+	currentRoots := s.CA.GetCAKeyCertBundle().GetRootCertPem()
+	currentSigningCert := signingCert(s)
+	newRoots, err := readSampleCertFromFile("root-cert-alt.pem")
+	assert.NoError(t, err)
+	newSigningCert, err := readSampleCertFromFile("ca-cert-alt.pem")
+	assert.NoError(t, err)
+	roots, err := mergeRootCerts(currentRoots, newRoots)
+	assert.NoError(t, err)
+	sum := sha256.Sum256(roots)
+	fingerprint := hex.EncodeToString(sum[:])
+
+	readyPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "istio-system", Labels: map[string]string{"app": "istiod"}},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "discovery", Ready: true}},
+			},
+		}
+	}
+	client := kube.NewFakeClient(readyPod("istiod-a"), readyPod("istiod-b"), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: kubecontroller.CACertNamespaceConfigMap, Namespace: "default"},
+		Data:       map[string]string{constants.CACertNamespaceConfigMapDataName: string(currentRoots)},
+	}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: caRootTransitionConfigMap, Namespace: "istio-system"},
+		Data:       map[string]string{"istiod-gone": "stale"},
+	})
+	s.caRootTransitionStatus = newCARootTransitionStatus(client, "istio-system", "istiod-a")
+	stop := test.NewStop(t)
+	client.RunAndWait(stop)
+	configmaps := clienttest.NewWriter[*corev1.ConfigMap](t, client)
+	status := func() map[string]string {
+		cm := s.caRootTransitionStatus.status.Get(caRootTransitionConfigMap, "istio-system")
+		if cm == nil {
+			return nil
+		}
+		return cm.Data
+	}
+
+	// The transition waits for the distribution of the roots in the istio-ca-root-cert ConfigMaps.
+	assert.NoError(t, s.startCARootTransition(sampleCAFileBundle("-alt"), currentRoots))
+	assert.Equal(t, s.CA.GetCAKeyCertBundle().GetRootCertPem(), roots)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, status(), map[string]string{"istiod-gone": "stale"})
+
+	// Then the replica records the acknowledgement, pruning the replicas which are gone, and waits for the others.
+	configmaps.Update(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: kubecontroller.CACertNamespaceConfigMap, Namespace: "default"},
+		Data:       map[string]string{constants.CACertNamespaceConfigMapDataName: string(roots)},
+	})
+	assert.EventuallyEqual(t, status, map[string]string{"istiod-a": fingerprint})
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, signingCert(s), currentSigningCert)
+
+	configmaps.Update(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: caRootTransitionConfigMap, Namespace: "istio-system"},
+		Data:       map[string]string{"istiod-a": fingerprint, "istiod-b": fingerprint},
+	})
+	assert.EventuallyEqual(t, func() []byte {
+		return signingCert(s)
+	}, newSigningCert)
+}
+
+func TestCARootTransitionTimeout(t *testing.T) {
+	test.SetForTest(t, &caRootTransitionCheckInterval, 10*time.Millisecond)
+	test.SetForTest(t, &features.CADualRootTransitionTimeout, 100*time.Millisecond)
+	s := newCARootTransitionServer(t)
+	currentRoots := s.CA.GetCAKeyCertBundle().GetRootCertPem()
+	currentSigningCert := signingCert(s)
+
+	client := kube.NewFakeClient(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: kubecontroller.CACertNamespaceConfigMap, Namespace: "default"},
+		Data:       map[string]string{constants.CACertNamespaceConfigMapDataName: string(currentRoots)},
+	})
+	s.caRootTransitionStatus = newCARootTransitionStatus(client, "istio-system", "istiod-a")
+	client.RunAndWait(test.NewStop(t))
+
+	// The transition is aborted as the roots are never distributed to the ConfigMap, and the current roots
+	// are restored.
+	assert.NoError(t, s.startCARootTransition(sampleCAFileBundle("-alt"), currentRoots))
+	assert.EventuallyEqual(t, func() bool {
+		return caRootTransitionDone(s)
+	}, true)
+	assert.Equal(t, signingCert(s), currentSigningCert)
+	assert.Equal(t, s.CA.GetCAKeyCertBundle().GetRootCertPem(), currentRoots)
+	assert.Equal(t, s.workloadTrustBundle.GetTrustBundle(), []string{string(currentRoots)})
+	assert.Equal(t, s.cancelCARootTransition(), nil)
+}
+
+func TestCancelCARootTransition(t *testing.T) {
+	test.SetForTest(t, &features.CADualRootTransitionTimeout, time.Minute)
+	s := newCARootTransitionServer(t)
+	currentRoots := s.CA.GetCAKeyCertBundle().GetRootCertPem()
+	currentSigningCert := signingCert(s)
+	// The other replica never acknowledges the roots.
+	client := kube.NewFakeClient(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "istiod-b", Namespace: "istio-system", Labels: map[string]string{"app": "istiod"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	})
+	s.caRootTransitionStatus = newCARootTransitionStatus(client, "istio-system", "istiod-a")
+	client.RunAndWait(test.NewStop(t))
+
+	// The roots before the canceled transition are returned, and the CA does not switch to the new signing cert.
+	assert.NoError(t, s.startCARootTransition(sampleCAFileBundle("-alt"), currentRoots))
+	assert.Equal(t, s.cancelCARootTransition(), currentRoots)
+	assert.Equal(t, caRootTransitionDone(s), true)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, signingCert(s), currentSigningCert)
+}
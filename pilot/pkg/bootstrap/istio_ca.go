@@ -32,11 +32,13 @@ import (
 	"istio.io/api/security/v1beta1"
 	"istio.io/istio/pilot/pkg/features"
 	securityModel "istio.io/istio/pilot/pkg/security/model"
+	tb "istio.io/istio/pilot/pkg/trustbundle"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/env"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/security/pkg/cmd"
+	"istio.io/istio/security/pkg/k8s/revocation"
 	"istio.io/istio/security/pkg/pki/ca"
 	"istio.io/istio/security/pkg/pki/ra"
 	caserver "istio.io/istio/security/pkg/server/ca"
@@ -52,6 +54,8 @@ type caOptions struct {
 	Namespace        string
 	Authenticators   []security.Authenticator
 	CertSignerDomain string
+	// PodName is the name of the istiod pod, which identifies the CA replica.
+	PodName string
 }
 
 // Based on istio_ca main - removing creation of Secrets with private keys in all namespaces and install complexity.
@@ -129,7 +133,14 @@ var (
 
 	// TODO: Likely to be removed and added to mesh config
 	externalCaType = env.Register("EXTERNAL_CA", "",
-		"External CA Integration Type. Permitted value is ISTIOD_RA_KUBERNETES_API.").Get()
+		"External CA Integration Type. Permitted values are ISTIOD_RA_KUBERNETES_API and ISTIOD_RA_EXTERNAL_SIGNER.").Get()
+
+	externalSignerAddress = env.Register("EXTERNAL_SIGNER_ADDRESS", "/var/run/istio-signer/signer.sock",
+		"Unix domain socket of the external signer the workload certificates are signed by, "+
+			"when EXTERNAL_CA is ISTIOD_RA_EXTERNAL_SIGNER.").Get()
+
+	externalSignerTimeout = env.Register("EXTERNAL_SIGNER_TIMEOUT", 10*time.Second,
+		"Timeout of the requests to the external signer.").Get()
 
 	// TODO: Likely to be removed and added to mesh config
 	k8sSigner = env.Register("K8S_SIGNER", "",
@@ -145,6 +156,18 @@ func (s *Server) initCAServer(ca caserver.CertificateAuthority, opts *caOptions)
 		log.Fatalf("failed to create istio ca server: %v", startErr)
 	}
 	s.caServer = caServer
+	if audit := caServer.Audit(); audit != nil {
+		s.XDSServer.AddDebugHandler(s.monitoringMux, s.internalDebugMux, "/debug/ca_auditz",
+			"Most recent certificate signing decisions of the Istio CA", audit.ServeHTTP)
+		// Debug handlers are also added on the readiness mux, if it is not shared with the monitoring one.
+		if s.httpMux != s.monitoringMux {
+			s.XDSServer.AddDebugHandler(s.httpMux, nil, "/debug/ca_auditz", "Most recent certificate signing decisions of the Istio CA", audit.ServeHTTP)
+		}
+		s.addTerminatingStartFunc("ca audit log", func(stop <-chan struct{}) error {
+			<-stop
+			return audit.Close()
+		})
+	}
 }
 
 // RunCA will start the cert signing GRPC service on an existing server.
@@ -304,6 +327,11 @@ func handleEvent(s *Server) {
 	var err error
 
 	currentCABundle := s.CA.GetCAKeyCertBundle().GetRootCertPem()
+	// The latest cacerts supersede the ones of an ongoing ROOT-CA transition, during which the roots of the CA
+	// already include the new ones.
+	if roots := s.cancelCARootTransition(); roots != nil {
+		currentCABundle = roots
+	}
 
 	fileBundle, err := detectSigningCABundle()
 	if err != nil {
@@ -316,6 +344,13 @@ func handleEvent(s *Server) {
 		return
 	}
 
+	if features.EnableCADualRootTransition && features.MultiRootMesh && hasNewRootCerts(currentCABundle, newCABundle) {
+		if err := s.startCARootTransition(fileBundle, currentCABundle); err != nil {
+			log.Errorf("Failed to start the ROOT-CA transition: %v", err)
+		}
+		return
+	}
+
 	// Only updating intermediate CA is supported now
 	if !bytes.Equal(currentCABundle, newCABundle) {
 		if !features.MultiRootMesh {
@@ -420,6 +455,16 @@ func (s *Server) initCACertsWatcher() {
 	go s.handleCACertsFileWatch()
 }
 
+// initCARevocation starts resolving the certificate revocation requests and distributing the CRL
+// of the revoked certificates with the root cert.
+func (s *Server) initCARevocation(opts *caOptions) {
+	c := revocation.NewController(s.kubeClient, opts.Namespace, opts.PodName, s.CA, s.istiodCertBundleWatcher.SetCRLAndNotify)
+	s.addStartFunc("ca revocation", func(stop <-chan struct{}) error {
+		go c.Run(stop)
+		return nil
+	})
+}
+
 // createIstioCA initializes the Istio CA signing functionality.
 // - for 'plugged in', uses ./etc/cacert directory, mounted from 'cacerts' secret in k8s.
 //
@@ -471,6 +516,9 @@ func (s *Server) createIstioCA(opts *caOptions) (*ca.IstioCA, error) {
 
 		s.initCACertsWatcher()
 	}
+	if features.EnableCACRL {
+		caOpts.IssuedCertsCapacity = features.CAIssuedCertsCapacity
+	}
 	istioCA, err := ca.NewIstioCA(caOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create an istiod CA: %v", err)
@@ -498,14 +546,14 @@ func (s *Server) createSelfSignedCACertificateOptions(fileBundle *ca.SigningCAFi
 			selfSignedRootCertCheckInterval.Get(), workloadCertTTL.Get(),
 			maxWorkloadCertTTL.Get(), opts.TrustDomain, features.UseCacertsForSelfSignedCA, true,
 			opts.Namespace, s.kubeClient.Kube().CoreV1(), fileBundle.RootCertFile,
-			enableJitterForRootCertRotator.Get(), caRSAKeySize.Get())
+			enableJitterForRootCertRotator.Get(), caRSAKeySize.Get(), features.EnableCACRL)
 	} else {
 		log.Warnf(
 			"Use local self-signed CA certificate for testing. Will use in-memory root CA, no K8S access and no ca key file %s",
 			fileBundle.SigningKeyFile)
 
 		caOpts, err = ca.NewSelfSignedDebugIstioCAOptions(fileBundle.RootCertFile, SelfSignedCACertTTL.Get(),
-			workloadCertTTL.Get(), maxWorkloadCertTTL.Get(), opts.TrustDomain, caRSAKeySize.Get())
+			workloadCertTTL.Get(), maxWorkloadCertTTL.Get(), opts.TrustDomain, caRSAKeySize.Get(), features.EnableCACRL)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a self-signed istiod CA: %v", err)
@@ -524,6 +572,9 @@ func (s *Server) createSelfSignedCACertificateOptions(fileBundle *ca.SigningCAFi
 //
 // 3. Extract from the cert-chain signed by other CSR signer.
 func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error) {
+	if opts.ExternalCAType == ra.ExtCASigner {
+		return s.createExternalSignerRA(opts)
+	}
 	caCertFile := path.Join(ra.DefaultExtCACertDir, constants.CACertNamespaceConfigMapDataName)
 	certSignerDomain := opts.CertSignerDomain
 	_, err := os.Stat(caCertFile)
@@ -568,3 +619,35 @@ func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error
 	})
 	return raServer, err
 }
+
+// createExternalSignerRA initializes the RA delegating the signing of the workload certificates to an
+// external signer, whose root certificates are added to the workload trust bundle once fetched. istiod
+// is not ready until then.
+func (s *Server) createExternalSignerRA(opts *caOptions) (ra.RegistrationAuthority, error) {
+	signerRA, err := ra.NewExternalSignerRA(&ra.IstioRAOptions{
+		ExternalCAType: opts.ExternalCAType,
+		DefaultCertTTL: workloadCertTTL.Get(),
+		MaxCertTTL:     maxWorkloadCertTTL.Get(),
+		TrustDomain:    opts.TrustDomain,
+		SignerAddress:  externalSignerAddress,
+		SignerTimeout:  externalSignerTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	signerRA.AddRootsHandler(func() {
+		err := s.workloadTrustBundle.UpdateTrustAnchor(&tb.TrustAnchorUpdate{
+			TrustAnchorConfig: tb.TrustAnchorConfig{Certs: []string{string(signerRA.GetCAKeyCertBundle().GetRootCertPem())}},
+			Source:            tb.SourceIstioRA,
+		})
+		if err != nil {
+			log.Errorf("failed to update the root certificates of the external signer in the trust bundle: %v", err)
+		}
+	})
+	s.addReadinessProbe("external signer", signerRA.Healthy)
+	s.addStartFunc("external signer", func(stop <-chan struct{}) error {
+		go signerRA.Run(stop)
+		return nil
+	})
+	return signerRA, nil
+}
@@ -149,6 +149,15 @@ type Server struct {
 	RA       ra.RegistrationAuthority
 	caServer *caserver.Server
 
+	// caRootTransitionMutex protects caRootTransitionCancel, which cancels the ongoing transition of the
+	// plugged-in CA to new root certificates, if any, and caRootTransitionRoots, the roots before it.
+	caRootTransitionMutex  sync.Mutex
+	caRootTransitionCancel context.CancelFunc
+	caRootTransitionRoots  []byte
+	// caRootTransitionStatus coordinates the ROOT-CA transitions with the other istiod replicas. It is nil
+	// without a Kubernetes cluster.
+	caRootTransitionStatus *caRootTransitionStatus
+
 	// TrustAnchors for workload to workload mTLS
 	workloadTrustBundle *tb.TrustBundle
 	certMu              sync.RWMutex
@@ -296,6 +305,7 @@ func NewServer(args *PilotArgs, initFuncs ...func(*Server)) (*Server, error) {
 		Namespace:        args.Namespace,
 		ExternalCAType:   ra.CaExternalType(externalCaType),
 		CertSignerDomain: features.CertSignerDomain,
+		PodName:          args.PodName,
 	}
 
 	if caOpts.ExternalCAType == ra.ExtCAK8s {
@@ -448,6 +458,11 @@ func (s *Server) Start(stop <-chan struct{}) error {
 	if !s.waitForCacheSync(stop) {
 		return fmt.Errorf("failed to sync cache")
 	}
+	if features.XDSCacheSnapshotPath != "" {
+		if err := s.XDSServer.RestoreCacheSnapshot(features.XDSCacheSnapshotPath); err != nil {
+			log.Warnf("failed to restore the XDS cache: %v", err)
+		}
+	}
 	// Inform Discovery Server so that it can start accepting connections.
 	s.XDSServer.CachesSynced()
 
@@ -727,6 +742,13 @@ func (s *Server) waitForShutdown(stop <-chan struct{}) {
 			}
 		}
 
+		// Persist the XDS cache, now that the gRPC services stopped.
+		if features.XDSCacheSnapshotPath != "" {
+			if err := s.XDSServer.SaveCacheSnapshot(features.XDSCacheSnapshotPath); err != nil {
+				log.Warnf("failed to save the XDS cache: %v", err)
+			}
+		}
+
 		// Shutdown the DiscoveryServer.
 		s.XDSServer.Shutdown()
 	}()
@@ -1250,6 +1272,12 @@ func (s *Server) startCA(caOpts *caOptions) {
 	} else if s.CA != nil {
 		log.Infof("initializing CA server with IstioD CA")
 		s.initCAServer(s.CA, caOpts)
+		if features.EnableCACRL && s.kubeClient != nil {
+			s.initCARevocation(caOpts)
+		}
+		if features.EnableCADualRootTransition && s.kubeClient != nil {
+			s.initCARootTransitionStatus(caOpts)
+		}
 	}
 	s.addStartFunc("ca", func(stop <-chan struct{}) error {
 		grpcServer := s.secureGrpcServer
@@ -1329,8 +1357,8 @@ func (s *Server) initWorkloadTrustBundle(args *PilotArgs) error {
 		return err
 	}
 
-	// IstioRA: Explicitly add roots corresponding to RA
-	if s.RA != nil {
+	// IstioRA: Explicitly add roots corresponding to RA. The roots of an external signer are added once fetched.
+	if s.RA != nil && len(s.RA.GetCAKeyCertBundle().GetRootCertPem()) > 0 {
 		// Implicitly add the Istio RA certificates to the Workload Trust Bundle
 		rootCerts := []string{string(s.RA.GetCAKeyCertBundle().GetRootCertPem())}
 		err = s.workloadTrustBundle.UpdateTrustAnchor(&tb.TrustAnchorUpdate{
@@ -348,6 +348,12 @@ func (s *KubeSource) parseChunk(r *collection.Schemas, name string, lineNum int,
 		for _, resourceChunk := range resourceChunks {
 			lr, err := s.parseChunk(r, name, resourceChunk.lineNum+lineNum, resourceChunk.yamlChunk)
 			if err != nil {
+				// Lists, like the output of `kubectl get -o yaml`, often mix known and unknown kinds.
+				var uerr *unknownSchemaError
+				if errors.As(err, &uerr) {
+					scope.Debugf("skipping unknown list item %s: %s", name, uerr.Error())
+					continue
+				}
 				return resources, fmt.Errorf("failed parsing resource chunk: %v", err)
 			}
 			resources = append(resources, lr...)
@@ -20,6 +20,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8s "sigs.k8s.io/gateway-api/apis/v1"
+	k8salpha "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model/kstatus"
@@ -172,6 +173,62 @@ func createRouteStatus(parentResults []RouteParentResult, obj config.Config, cur
 	return parents
 }
 
+// PolicyAncestorResult holds the result of a policy for a specific ancestor
+type PolicyAncestorResult struct {
+	// AncestorReference contains the reference to the ancestor, typically the policy target
+	AncestorReference k8s.ParentReference
+	// AcceptedError, if present, indicates why the policy was not accepted for the ancestor
+	AcceptedError *ConfigError
+	// ResolvedRefsError, if present, indicates why references in the policy could not be resolved
+	ResolvedRefsError *ConfigError
+}
+
+func createPolicyStatus(
+	ancestorResults []PolicyAncestorResult,
+	obj config.Config,
+	currentAncestors []k8salpha.PolicyAncestorStatus,
+) []k8salpha.PolicyAncestorStatus {
+	ancestors := make([]k8salpha.PolicyAncestorStatus, 0, len(ancestorResults))
+	// Keep status reported by other controllers around, as we do for routes.
+	for _, a := range currentAncestors {
+		if a.ControllerName != k8s.GatewayController(features.ManagedGatewayController) {
+			ancestors = append(ancestors, a)
+		}
+	}
+	for _, r := range ancestorResults {
+		conds := map[string]*condition{
+			string(k8salpha.PolicyConditionAccepted): {
+				reason:  string(k8salpha.PolicyReasonAccepted),
+				message: "Configuration is valid",
+				error:   r.AcceptedError,
+			},
+			string(k8s.RouteConditionResolvedRefs): {
+				reason:  string(k8s.RouteReasonResolvedRefs),
+				message: "All references resolved",
+				error:   r.ResolvedRefsError,
+			},
+		}
+		var currentConditions []metav1.Condition
+		currentStatus := slices.FindFunc(currentAncestors, func(s k8salpha.PolicyAncestorStatus) bool {
+			return parentRefString(s.AncestorRef) == parentRefString(r.AncestorReference) &&
+				s.ControllerName == k8s.GatewayController(features.ManagedGatewayController)
+		})
+		if currentStatus != nil {
+			currentConditions = currentStatus.Conditions
+		}
+		ancestors = append(ancestors, k8salpha.PolicyAncestorStatus{
+			AncestorRef:    r.AncestorReference,
+			ControllerName: k8s.GatewayController(features.ManagedGatewayController),
+			Conditions:     setConditions(obj.Generation, currentConditions, conds),
+		})
+	}
+	// Ensure output is deterministic.
+	sort.SliceStable(ancestors, func(i, j int) bool {
+		return parentRefString(ancestors[i].AncestorRef) > parentRefString(ancestors[j].AncestorRef)
+	})
+	return ancestors
+}
+
 type ParentErrorReason string
 
 const (
@@ -208,6 +265,19 @@ const (
 	InvalidConfiguration ConfigErrorReason = "InvalidConfiguration"
 	InvalidResources     ConfigErrorReason = ConfigErrorReason(k8s.GatewayReasonNoResources)
 	DeprecateFieldUsage                    = "DeprecatedField"
+
+	// InvalidCACertificateRef indicates a BackendTLSPolicy CA certificate reference could not be resolved
+	InvalidCACertificateRef ConfigErrorReason = "InvalidCACertificateRef"
+	// InvalidCACertificateKind indicates a BackendTLSPolicy CA certificate reference is of an unsupported kind
+	InvalidCACertificateKind ConfigErrorReason = ConfigErrorReason(k8s.RouteReasonInvalidKind)
+	// PolicyTargetNotFound indicates a policy target does not exist
+	PolicyTargetNotFound ConfigErrorReason = ConfigErrorReason(k8salpha.PolicyReasonTargetNotFound)
+	// PolicyConflicted indicates a policy target is already targeted by another policy
+	PolicyConflicted ConfigErrorReason = ConfigErrorReason(k8salpha.PolicyReasonConflicted)
+	// PolicyInvalid indicates a policy is not valid
+	PolicyInvalid ConfigErrorReason = ConfigErrorReason(k8salpha.PolicyReasonInvalid)
+	// UnsupportedValue indicates a valid configuration that is not supported by the implementation
+	UnsupportedValue ConfigErrorReason = ConfigErrorReason(k8s.RouteReasonUnsupportedValue)
 )
 
 // ParentError represents that a parent could not be referenced
@@ -337,7 +407,8 @@ func generateSupportedKinds(l k8s.Listener) ([]k8s.RouteGroupKind, bool) {
 		} else {
 			supported = []k8s.RouteGroupKind{{Group: (*k8s.Group)(ptr.Of(gvk.TCPRoute.Group)), Kind: k8s.Kind(gvk.TCPRoute.Kind)}}
 		}
-		// UDP route note support
+	case k8s.UDPProtocolType:
+		supported = []k8s.RouteGroupKind{{Group: (*k8s.Group)(ptr.Of(gvk.UDPRoute.Group)), Kind: k8s.Kind(gvk.UDPRoute.Kind)}}
 	}
 	if l.AllowedRoutes != nil && len(l.AllowedRoutes.Kinds) > 0 {
 		// We need to filter down to only ones we actually support
@@ -133,6 +133,7 @@ func (c *Controller) Schemas() collection.Schemas {
 	return collection.SchemasFor(
 		collections.VirtualService,
 		collections.Gateway,
+		collections.DestinationRule,
 	)
 }
 
@@ -141,7 +142,7 @@ func (c *Controller) Get(typ config.GroupVersionKind, name, namespace string) *c
 }
 
 func (c *Controller) List(typ config.GroupVersionKind, namespace string) []config.Config {
-	if typ != gvk.Gateway && typ != gvk.VirtualService {
+	if typ != gvk.Gateway && typ != gvk.VirtualService && typ != gvk.DestinationRule {
 		return nil
 	}
 
@@ -152,6 +153,8 @@ func (c *Controller) List(typ config.GroupVersionKind, namespace string) []confi
 		return filterNamespace(c.state.Gateway, namespace)
 	case gvk.VirtualService:
 		return filterNamespace(c.state.VirtualService, namespace)
+	case gvk.DestinationRule:
+		return filterNamespace(c.state.DestinationRule, namespace)
 	default:
 		return nil
 	}
@@ -182,20 +185,26 @@ func (c *Controller) Reconcile(ps *model.PushContext) error {
 	grpcRoute := c.cache.List(gvk.GRPCRoute, metav1.NamespaceAll)
 	tcpRoute := c.cache.List(gvk.TCPRoute, metav1.NamespaceAll)
 	tlsRoute := c.cache.List(gvk.TLSRoute, metav1.NamespaceAll)
+	udpRoute := c.cache.List(gvk.UDPRoute, metav1.NamespaceAll)
 	referenceGrant := c.cache.List(gvk.ReferenceGrant, metav1.NamespaceAll)
+	backendTLSPolicy := c.cache.List(gvk.BackendTLSPolicy, metav1.NamespaceAll)
 	serviceEntry := c.cache.List(gvk.ServiceEntry, metav1.NamespaceAll) // TODO lazy load only referenced SEs?
+	wasmPlugin := c.cache.List(gvk.WasmPlugin, metav1.NamespaceAll)
 
 	input := GatewayResources{
-		GatewayClass:   deepCopyStatus(gatewayClass),
-		Gateway:        deepCopyStatus(gateway),
-		HTTPRoute:      deepCopyStatus(httpRoute),
-		GRPCRoute:      deepCopyStatus(grpcRoute),
-		TCPRoute:       deepCopyStatus(tcpRoute),
-		TLSRoute:       deepCopyStatus(tlsRoute),
-		ReferenceGrant: referenceGrant,
-		ServiceEntry:   serviceEntry,
-		Domain:         c.domain,
-		Context:        NewGatewayContext(ps, c.cluster),
+		GatewayClass:     deepCopyStatus(gatewayClass),
+		Gateway:          deepCopyStatus(gateway),
+		HTTPRoute:        deepCopyStatus(httpRoute),
+		GRPCRoute:        deepCopyStatus(grpcRoute),
+		TCPRoute:         deepCopyStatus(tcpRoute),
+		TLSRoute:         deepCopyStatus(tlsRoute),
+		UDPRoute:         deepCopyStatus(udpRoute),
+		ReferenceGrant:   referenceGrant,
+		ServiceEntry:     serviceEntry,
+		BackendTLSPolicy: deepCopyStatus(backendTLSPolicy),
+		WasmPlugin:       wasmPlugin,
+		Domain:           c.domain,
+		Context:          NewGatewayContext(ps, c.cluster),
 	}
 
 	if !input.hasResources() {
@@ -240,6 +249,8 @@ func (c *Controller) QueueStatusUpdates(r GatewayResources) {
 	c.handleStatusUpdates(r.GRPCRoute)
 	c.handleStatusUpdates(r.TCPRoute)
 	c.handleStatusUpdates(r.TLSRoute)
+	c.handleStatusUpdates(r.UDPRoute)
+	c.handleStatusUpdates(r.BackendTLSPolicy)
 }
 
 func (c *Controller) handleStatusUpdates(configs []config.Config) {
@@ -305,7 +316,8 @@ func (c *Controller) HasSynced() bool {
 func (c *Controller) SecretAllowed(resourceName string, namespace string) bool {
 	c.stateMu.RLock()
 	defer c.stateMu.RUnlock()
-	return c.state.AllowedReferences.SecretAllowed(resourceName, namespace)
+	return c.state.AllowedReferences.SecretAllowed(resourceName, namespace) ||
+		c.state.CACertificateReferences[resourceName].Contains(namespace)
 }
 
 // namespaceEvent handles a namespace add/update. Gateway's can select routes by label, so we need to handle
@@ -353,9 +365,13 @@ func (c *Controller) secretEvent(name, namespace string) {
 	if len(impactedConfigs) > 0 {
 		log.Debugf("secret %s/%s changed, triggering secret handler", namespace, name)
 		for _, cfg := range impactedConfigs {
+			k := gvk.KubernetesGateway
+			if cfg.Kind == kind.BackendTLSPolicy {
+				k = gvk.BackendTLSPolicy
+			}
 			gw := config.Config{
 				Meta: config.Meta{
-					GroupVersionKind: gvk.KubernetesGateway,
+					GroupVersionKind: k,
 					Namespace:        cfg.Namespace,
 					Name:             cfg.Name,
 				},
@@ -398,5 +414,7 @@ func (kr GatewayResources) hasResources() bool {
 		len(kr.GRPCRoute) > 0 ||
 		len(kr.TCPRoute) > 0 ||
 		len(kr.TLSRoute) > 0 ||
-		len(kr.ReferenceGrant) > 0
+		len(kr.UDPRoute) > 0 ||
+		len(kr.ReferenceGrant) > 0 ||
+		len(kr.BackendTLSPolicy) > 0
 }
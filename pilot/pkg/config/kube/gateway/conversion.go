@@ -28,8 +28,10 @@ import (
 	"google.golang.org/protobuf/types/known/durationpb"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	k8s "sigs.k8s.io/gateway-api/apis/v1"
 	k8salpha "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	k8salpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
 	k8sbeta "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"istio.io/api/annotation"
@@ -46,6 +48,7 @@ import (
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/maps"
 	"istio.io/istio/pkg/ptr"
 	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/util/sets"
@@ -69,6 +72,7 @@ func convertResources(r GatewayResources) IstioResources {
 	// sort HTTPRoutes by creation timestamp and namespace/name
 	sortConfigByCreationTime(r.HTTPRoute)
 	sortConfigByCreationTime(r.GRPCRoute)
+	sortConfigByCreationTime(r.BackendTLSPolicy)
 
 	result := IstioResources{}
 	ctx := configContext{
@@ -82,6 +86,9 @@ func convertResources(r GatewayResources) IstioResources {
 	result.Gateway = gw
 
 	result.VirtualService = convertVirtualService(ctx)
+	var caCertificateHosts map[string]sets.String
+	result.DestinationRule, caCertificateHosts = convertBackendTLSPolicies(ctx)
+	result.CACertificateReferences = caCertificateReaders(result.VirtualService, caCertificateHosts)
 
 	// Once we have gone through all route computation, we will know how many routes bound to each gateway.
 	// Report this in the status.
@@ -125,6 +132,8 @@ func convertReferencePolicies(r GatewayResources) AllowedReferences {
 				fromKey.Kind = gvk.TLSRoute
 			} else if string(from.Group) == gvk.TCPRoute.Group && string(from.Kind) == gvk.TCPRoute.Kind {
 				fromKey.Kind = gvk.TCPRoute
+			} else if string(from.Group) == gvk.UDPRoute.Group && string(from.Kind) == gvk.UDPRoute.Kind {
+				fromKey.Kind = gvk.UDPRoute
 			} else {
 				// Not supported type. Not an error; may be for another controller
 				continue
@@ -171,6 +180,10 @@ func convertVirtualService(r configContext) []config.Config {
 		result = append(result, buildTLSVirtualService(r, obj)...)
 	}
 
+	for _, obj := range r.UDPRoute {
+		result = append(result, buildUDPVirtualService(r, obj)...)
+	}
+
 	// for gateway routes, build one VS per gateway+host
 	gatewayRoutes := make(map[string]map[string]*config.Config)
 	// for mesh routes, build one VS per namespace+host
@@ -194,9 +207,275 @@ func convertVirtualService(r configContext) []config.Config {
 	return result
 }
 
+// convertBackendTLSPolicies takes all BackendTLSPolicies and generates corresponding DestinationRules.
+// DestinationRule merging does not merge port level settings, so a single DestinationRule is generated per
+// targeted Service. If multiple policies target the same Service (and section), the oldest one wins.
+// The SDS resource names of all referenced CA certificates are returned as well, along with the hostnames of
+// the Services targeted by the policies referencing them.
+func convertBackendTLSPolicies(ctx configContext) ([]config.Config, map[string]sets.String) {
+	caCertificates := map[string]sets.String{}
+	destinationRules := map[types.NamespacedName]*config.Config{}
+	// key: namespace/name/sectionName of the target, value: the policy that owns the target
+	owners := map[string]string{}
+	for _, obj := range ctx.BackendTLSPolicy {
+		spec := obj.Spec.(*k8salpha3.BackendTLSPolicySpec)
+		tls, policyErr, refErr := buildBackendTLSSettings(ctx, obj, spec.Validation)
+		results := make([]PolicyAncestorResult, 0, len(spec.TargetRefs))
+		for _, ref := range spec.TargetRefs {
+			res := PolicyAncestorResult{
+				AncestorReference: k8s.ParentReference{
+					Group:       ptr.Of(ref.Group),
+					Kind:        ptr.Of(ref.Kind),
+					Name:        ref.Name,
+					Namespace:   ptr.Of(k8s.Namespace(obj.Namespace)),
+					SectionName: ref.SectionName,
+				},
+				AcceptedError:     policyErr,
+				ResolvedRefsError: refErr,
+			}
+			results = append(results, res)
+			if policyErr != nil {
+				continue
+			}
+			if string(ref.Group) != gvk.Service.Group || string(ref.Kind) != gvk.Service.Kind {
+				results[len(results)-1].AcceptedError = &ConfigError{
+					Reason:  PolicyInvalid,
+					Message: fmt.Sprintf("unsupported target kind %s/%s, only Service is supported", ref.Group, ref.Kind),
+				}
+				continue
+			}
+			hostname := fmt.Sprintf("%s.%s.svc.%s", ref.Name, obj.Namespace, ctx.Domain)
+			svc := ctx.Context.GetService(hostname, obj.Namespace)
+			if svc == nil {
+				results[len(results)-1].AcceptedError = &ConfigError{
+					Reason:  PolicyTargetNotFound,
+					Message: fmt.Sprintf("Service %s/%s not found", obj.Namespace, ref.Name),
+				}
+				continue
+			}
+			var port *model.Port
+			if ref.SectionName != nil {
+				p, f := svc.Ports.Get(string(*ref.SectionName))
+				if !f {
+					results[len(results)-1].AcceptedError = &ConfigError{
+						Reason:  PolicyTargetNotFound,
+						Message: fmt.Sprintf("port %q not found in Service %s/%s", *ref.SectionName, obj.Namespace, ref.Name),
+					}
+					continue
+				}
+				port = p
+			}
+			owner := fmt.Sprintf("%s/%s/%s", obj.Namespace, ref.Name, ptr.OrEmpty(ref.SectionName))
+			if existing, f := owners[owner]; f {
+				results[len(results)-1].AcceptedError = &ConfigError{
+					Reason:  PolicyConflicted,
+					Message: fmt.Sprintf("target is already targeted by BackendTLSPolicy %s", existing),
+				}
+				continue
+			}
+			owners[owner] = obj.Namespace + "/" + obj.Name
+			if strings.HasPrefix(tls.CredentialName, creds.KubernetesGatewaySecretType) {
+				caName := tls.CredentialName + creds.SdsCaSuffix
+				if caCertificates[caName] == nil {
+					caCertificates[caName] = sets.New[string]()
+				}
+				caCertificates[caName].Insert(hostname)
+			}
+
+			key := types.NamespacedName{Namespace: obj.Namespace, Name: string(ref.Name)}
+			dr := destinationRules[key]
+			if dr == nil {
+				dr = &config.Config{
+					Meta: config.Meta{
+						CreationTimestamp: obj.CreationTimestamp,
+						GroupVersionKind:  gvk.DestinationRule,
+						Name:              fmt.Sprintf("%s-backendtls-%s", ref.Name, constants.KubernetesGatewayName),
+						Namespace:         obj.Namespace,
+						Domain:            ctx.Domain,
+					},
+					Spec: &istio.DestinationRule{
+						Host:          hostname,
+						TrafficPolicy: &istio.TrafficPolicy{},
+					},
+				}
+				destinationRules[key] = dr
+			}
+			tp := dr.Spec.(*istio.DestinationRule).TrafficPolicy
+			if port == nil {
+				tp.Tls = tls
+			} else {
+				tp.PortLevelSettings = append(tp.PortLevelSettings, &istio.TrafficPolicy_PortTrafficPolicy{
+					Port: &istio.PortSelector{Number: uint32(port.Port)},
+					Tls:  tls,
+				})
+			}
+		}
+		obj.Status.(*kstatus.WrappedStatus).Mutate(func(s config.Status) config.Status {
+			ps := s.(*k8salpha.PolicyStatus)
+			ps.Ancestors = createPolicyStatus(results, obj, ps.Ancestors)
+			return ps
+		})
+	}
+
+	result := make([]config.Config, 0, len(destinationRules))
+	for _, key := range slices.SortFunc(maps.Keys(destinationRules), func(a, b types.NamespacedName) int {
+		return cmp.Compare(a.String(), b.String())
+	}) {
+		dr := destinationRules[key]
+		slices.SortBy(dr.Spec.(*istio.DestinationRule).TrafficPolicy.PortLevelSettings, func(p *istio.TrafficPolicy_PortTrafficPolicy) uint32 {
+			return p.Port.Number
+		})
+		result = append(result, *dr)
+	}
+	return result, caCertificates
+}
+
+// caCertificateReaders returns the namespaces of the Gateways allowed to read each CA certificate referenced by
+// BackendTLSPolicies: those with a route to one of the Services targeted by the policies. Cross namespace routes
+// to a Service already require a ReferenceGrant, so no other Gateway can read the CA certificate of a policy.
+func caCertificateReaders(virtualServices []config.Config, caCertificateHosts map[string]sets.String) map[string]sets.String {
+	// key: destination hostname, value: namespaces of the Gateways routing to it
+	routedFrom := map[string]sets.String{}
+	for _, vsConfig := range virtualServices {
+		vs := vsConfig.Spec.(*istio.VirtualService)
+		namespaces := sets.New[string]()
+		for _, gw := range vs.Gateways {
+			if ns, _, f := strings.Cut(gw, "/"); f {
+				namespaces.Insert(ns)
+			}
+		}
+		if namespaces.IsEmpty() {
+			continue
+		}
+		addDestination := func(d *istio.Destination) {
+			if d == nil {
+				return
+			}
+			if routedFrom[d.Host] == nil {
+				routedFrom[d.Host] = sets.New[string]()
+			}
+			routedFrom[d.Host].Merge(namespaces)
+		}
+		for _, r := range vs.Http {
+			for _, d := range r.Route {
+				addDestination(d.Destination)
+			}
+			if r.Mirror != nil {
+				addDestination(r.Mirror)
+			}
+			for _, m := range r.Mirrors {
+				addDestination(m.Destination)
+			}
+		}
+		for _, r := range vs.Tcp {
+			for _, d := range r.Route {
+				addDestination(d.Destination)
+			}
+		}
+		for _, r := range vs.Tls {
+			for _, d := range r.Route {
+				addDestination(d.Destination)
+			}
+		}
+	}
+	readers := make(map[string]sets.String, len(caCertificateHosts))
+	for name, hosts := range caCertificateHosts {
+		namespaces := sets.New[string]()
+		for host := range hosts {
+			namespaces.Merge(routedFrom[host])
+		}
+		readers[name] = namespaces
+	}
+	return readers
+}
+
+// buildBackendTLSSettings builds the TLS settings for a BackendTLSPolicy. Two errors may be returned; the first
+// indicates the policy is invalid and should not be accepted at all, the second indicates a reference could not be
+// resolved. In the latter case, the settings are still returned but will reference a credential that can never be
+// resolved, so traffic fails rather than being sent in plaintext.
+func buildBackendTLSSettings(
+	ctx configContext,
+	obj config.Config,
+	v k8salpha3.BackendTLSPolicyValidation,
+) (*istio.ClientTLSSettings, *ConfigError, *ConfigError) {
+	tls := &istio.ClientTLSSettings{
+		Mode: istio.ClientTLSSettings_SIMPLE,
+		Sni:  string(v.Hostname),
+	}
+	for _, san := range v.SubjectAltNames {
+		switch san.Type {
+		case k8salpha3.HostnameSubjectAltNameType:
+			tls.SubjectAltNames = append(tls.SubjectAltNames, string(san.Hostname))
+		case k8salpha3.URISubjectAltNameType:
+			tls.SubjectAltNames = append(tls.SubjectAltNames, string(san.URI))
+		}
+	}
+	if len(tls.SubjectAltNames) == 0 {
+		// The hostname is used for authentication when no SubjectAltNames are set.
+		tls.SubjectAltNames = []string{string(v.Hostname)}
+	}
+
+	switch {
+	case len(v.CACertificateRefs) > 0 && v.WellKnownCACertificates != nil:
+		return tls, &ConfigError{
+			Reason:  PolicyInvalid,
+			Message: "only one of caCertificateRefs or wellKnownCACertificates may be specified",
+		}, nil
+	case v.WellKnownCACertificates != nil:
+		if *v.WellKnownCACertificates != k8salpha3.WellKnownCACertificatesSystem {
+			return tls, &ConfigError{
+				Reason:  PolicyInvalid,
+				Message: fmt.Sprintf("unsupported wellKnownCACertificates %q", *v.WellKnownCACertificates),
+			}, nil
+		}
+		// The system CA certificates are the default, so nothing more to do.
+		return tls, nil, nil
+	case len(v.CACertificateRefs) > 1:
+		return tls, &ConfigError{
+			Reason:  PolicyInvalid,
+			Message: "only a single caCertificateRef is supported",
+		}, nil
+	case len(v.CACertificateRefs) == 1:
+		ref := v.CACertificateRefs[0]
+		if string(ref.Group) != gvk.Secret.Group || string(ref.Kind) != gvk.Secret.Kind {
+			tls.CredentialName = creds.InvalidSecretTypeURI
+			return tls, nil, &ConfigError{
+				Reason:  InvalidCACertificateKind,
+				Message: fmt.Sprintf("unsupported caCertificateRef kind %s/%s, only Secret is supported", ref.Group, ref.Kind),
+			}
+		}
+		secret := model.ConfigKey{
+			Kind:      kind.Secret,
+			Name:      string(ref.Name),
+			Namespace: obj.Namespace,
+		}
+		ctx.resourceReferences[secret] = append(ctx.resourceReferences[secret], model.ConfigKey{
+			Kind:      kind.BackendTLSPolicy,
+			Namespace: obj.Namespace,
+			Name:      obj.Name,
+		})
+		tls.CredentialName = creds.ToKubernetesGatewayResource(secret.Namespace, secret.Name)
+		if ctx.Credentials != nil {
+			if _, err := ctx.Credentials.GetCaCert(secret.Name, secret.Namespace); err != nil {
+				tls.CredentialName = creds.InvalidSecretTypeURI
+				return tls, nil, &ConfigError{
+					Reason:  InvalidCACertificateRef,
+					Message: fmt.Sprintf("invalid caCertificateRef %s: %v", ref.Name, err),
+				}
+			}
+		}
+		return tls, nil, nil
+	default:
+		return tls, &ConfigError{
+			Reason:  PolicyInvalid,
+			Message: "one of caCertificateRefs or wellKnownCACertificates must be specified",
+		}, nil
+	}
+}
+
 func convertHTTPRoute(r k8s.HTTPRouteRule, ctx configContext,
 	obj config.Config, pos int, enforceRefGrant bool,
-) (*istio.HTTPRoute, *ConfigError) {
+) (*istio.HTTPRoute, []types.NamespacedName, *ConfigError) {
 	// TODO: implement rewrite, corspolicy, retries
 	vs := &istio.HTTPRoute{}
 	if r.Name != nil {
@@ -210,19 +489,19 @@ func convertHTTPRoute(r k8s.HTTPRouteRule, ctx configContext,
 	for _, match := range r.Matches {
 		uri, err := createURIMatch(match)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		headers, err := createHeadersMatch(match)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		qp, err := createQueryParamsMatch(match)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		method, err := createMethodMatch(match)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		vs.Match = append(vs.Match, &istio.HTTPMatchRequest{
 			Uri:         uri,
@@ -231,6 +510,8 @@ func convertHTTPRoute(r k8s.HTTPRouteRule, ctx configContext,
 			Method:      method,
 		})
 	}
+	var extensions []types.NamespacedName
+	var extensionErr *ConfigError
 	for _, filter := range r.Filters {
 		switch filter.Type {
 		case k8s.HTTPRouteFilterRequestHeaderModifier:
@@ -256,13 +537,20 @@ func convertHTTPRoute(r k8s.HTTPRouteRule, ctx configContext,
 		case k8s.HTTPRouteFilterRequestMirror:
 			mirror, err := createMirrorFilter(ctx, filter.RequestMirror, obj.Namespace, enforceRefGrant, gvk.HTTPRoute)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			vs.Mirrors = append(vs.Mirrors, mirror)
 		case k8s.HTTPRouteFilterURLRewrite:
 			vs.Rewrite = createRewriteFilter(filter.URLRewrite)
+		case k8s.HTTPRouteFilterExtensionRef:
+			ext, err := createExtensionRefFilter(ctx, filter.ExtensionRef, obj.Namespace)
+			if err != nil {
+				extensionErr = err
+				continue
+			}
+			extensions = append(extensions, ext)
 		default:
-			return nil, &ConfigError{
+			return nil, nil, &ConfigError{
 				Reason:  InvalidFilter,
 				Message: fmt.Sprintf("unsupported filter type %q", filter.Type),
 			}
@@ -289,6 +577,15 @@ func convertHTTPRoute(r k8s.HTTPRouteRule, ctx configContext,
 		}
 	}
 
+	if extensionErr != nil {
+		// The spec requires us to return 500 when a filter cannot be resolved, rather than skipping it
+		vs.Redirect = nil
+		vs.DirectResponse = &istio.HTTPDirectResponse{
+			Status: 500,
+		}
+		return vs, nil, extensionErr
+	}
+
 	if weightSum(r.BackendRefs) == 0 && vs.Redirect == nil {
 		// The spec requires us to return 500 when there are no >0 weight backends
 		vs.DirectResponse = &istio.HTTPDirectResponse{
@@ -297,18 +594,18 @@ func convertHTTPRoute(r k8s.HTTPRouteRule, ctx configContext,
 	} else {
 		route, backendErr, err := buildHTTPDestination(ctx, r.BackendRefs, obj.Namespace, enforceRefGrant)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		vs.Route = route
-		return vs, backendErr
+		return vs, extensions, backendErr
 	}
 
-	return vs, nil
+	return vs, extensions, nil
 }
 
 func convertGRPCRoute(r k8s.GRPCRouteRule, ctx configContext,
 	obj config.Config, pos int, enforceRefGrant bool,
-) (*istio.HTTPRoute, *ConfigError) {
+) (*istio.HTTPRoute, []types.NamespacedName, *ConfigError) {
 	// TODO: implement rewrite, timeout, mirror, corspolicy, retries
 	vs := &istio.HTTPRoute{}
 	if r.Name != nil {
@@ -322,17 +619,19 @@ func convertGRPCRoute(r k8s.GRPCRouteRule, ctx configContext,
 	for _, match := range r.Matches {
 		uri, err := createGRPCURIMatch(match)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		headers, err := createGRPCHeadersMatch(match)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		vs.Match = append(vs.Match, &istio.HTTPMatchRequest{
 			Uri:     uri,
 			Headers: headers,
 		})
 	}
+	var extensions []types.NamespacedName
+	var extensionErr *ConfigError
 	for _, filter := range r.Filters {
 		switch filter.Type {
 		case k8s.GRPCRouteFilterRequestHeaderModifier:
@@ -356,17 +655,33 @@ func convertGRPCRoute(r k8s.GRPCRouteRule, ctx configContext,
 		case k8s.GRPCRouteFilterRequestMirror:
 			mirror, err := createMirrorFilter(ctx, filter.RequestMirror, obj.Namespace, enforceRefGrant, gvk.GRPCRoute)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			vs.Mirrors = append(vs.Mirrors, mirror)
+		case k8s.GRPCRouteFilterExtensionRef:
+			ext, err := createExtensionRefFilter(ctx, filter.ExtensionRef, obj.Namespace)
+			if err != nil {
+				extensionErr = err
+				continue
+			}
+			extensions = append(extensions, ext)
 		default:
-			return nil, &ConfigError{
+			return nil, nil, &ConfigError{
 				Reason:  InvalidFilter,
 				Message: fmt.Sprintf("unsupported filter type %q", filter.Type),
 			}
 		}
 	}
 
+	if extensionErr != nil {
+		// The spec requires us to return 500 when a filter cannot be resolved, rather than skipping it
+		vs.Redirect = nil
+		vs.DirectResponse = &istio.HTTPDirectResponse{
+			Status: 500,
+		}
+		return vs, nil, extensionErr
+	}
+
 	if grpcWeightSum(r.BackendRefs) == 0 && vs.Redirect == nil {
 		// The spec requires us to return 500 when there are no >0 weight backends
 		vs.DirectResponse = &istio.HTTPDirectResponse{
@@ -375,13 +690,13 @@ func convertGRPCRoute(r k8s.GRPCRouteRule, ctx configContext,
 	} else {
 		route, backendErr, err := buildGRPCDestination(ctx, r.BackendRefs, obj.Namespace, enforceRefGrant)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		vs.Route = route
-		return vs, backendErr
+		return vs, extensions, backendErr
 	}
 
-	return vs, nil
+	return vs, extensions, nil
 }
 
 func parentTypes(rpi []routeParentReference) (mesh, gateway bool) {
@@ -412,8 +727,9 @@ func buildHTTPVirtualServices(
 	}
 
 	type conversionResult struct {
-		error  *ConfigError
-		routes []*istio.HTTPRoute
+		error      *ConfigError
+		routes     []*istio.HTTPRoute
+		extensions model.RouteExtensionRefs
 	}
 	convertRules := func(mesh bool) conversionResult {
 		res := conversionResult{}
@@ -427,18 +743,28 @@ func buildHTTPVirtualServices(
 				if m != nil {
 					r.Matches = []k8s.HTTPRouteMatch{*m}
 				}
-				vs, err := convertHTTPRoute(r, ctx, obj, n, !mesh)
+				vs, extensions, err := convertHTTPRoute(r, ctx, obj, n, !mesh)
 				// This was a hard error
 				if vs == nil {
 					res.error = err
 					return conversionResult{error: err}
 				}
+				if len(extensions) > 0 && mesh {
+					err = &ConfigError{Reason: InvalidFilter, Message: "extensionRef filters are only supported for Gateway parents"}
+					return conversionResult{error: err}
+				}
 				// Got an error but also routes
 				if err != nil {
 					res.error = err
 				}
 
 				res.routes = append(res.routes, vs)
+				if len(extensions) > 0 {
+					if res.extensions == nil {
+						res.extensions = model.RouteExtensionRefs{}
+					}
+					res.extensions[vs.Name] = append(res.extensions[vs.Name], extensions...)
+				}
 			}
 		}
 		return res
@@ -463,8 +789,10 @@ func buildHTTPVirtualServices(
 		routeKey := parent.InternalName
 		vsHosts := hostnameToStringList(route.Hostnames)
 		routes := gwResult.routes
+		extensions := gwResult.extensions
 		if parent.IsMesh() {
 			routes = meshResult.routes
+			extensions = nil
 			// for mesh routes, build one VS per namespace/port->host
 			routeMap = meshRoutes
 			routeKey = obj.Namespace
@@ -502,6 +830,7 @@ func buildHTTPVirtualServices(
 				// append parents
 				cfg.Annotations[constants.InternalParentNames] = fmt.Sprintf("%s,%s/%s.%s",
 					cfg.Annotations[constants.InternalParentNames], obj.GroupVersionKind.Kind, obj.Name, obj.Namespace)
+				appendRouteExtensions(cfg, extensions)
 			} else {
 				name := fmt.Sprintf("%s-%d-%s", obj.Name, count, constants.KubernetesGatewayName)
 				routeMap[routeKey][h] = &config.Config{
@@ -519,6 +848,7 @@ func buildHTTPVirtualServices(
 						Http:     routes,
 					},
 				}
+				appendRouteExtensions(routeMap[routeKey][h], extensions)
 				count++
 			}
 		}
@@ -652,8 +982,9 @@ func buildGRPCVirtualServices(
 	}
 
 	type conversionResult struct {
-		error  *ConfigError
-		routes []*istio.HTTPRoute
+		error      *ConfigError
+		routes     []*istio.HTTPRoute
+		extensions model.RouteExtensionRefs
 	}
 	convertRules := func(mesh bool) conversionResult {
 		res := conversionResult{}
@@ -667,18 +998,28 @@ func buildGRPCVirtualServices(
 				if m != nil {
 					r.Matches = []k8s.GRPCRouteMatch{*m}
 				}
-				vs, err := convertGRPCRoute(r, ctx, obj, n, !mesh)
+				vs, extensions, err := convertGRPCRoute(r, ctx, obj, n, !mesh)
 				// This was a hard error
 				if vs == nil {
 					res.error = err
 					return conversionResult{error: err}
 				}
+				if len(extensions) > 0 && mesh {
+					err = &ConfigError{Reason: InvalidFilter, Message: "extensionRef filters are only supported for Gateway parents"}
+					return conversionResult{error: err}
+				}
 				// Got an error but also routes
 				if err != nil {
 					res.error = err
 				}
 
 				res.routes = append(res.routes, vs)
+				if len(extensions) > 0 {
+					if res.extensions == nil {
+						res.extensions = model.RouteExtensionRefs{}
+					}
+					res.extensions[vs.Name] = append(res.extensions[vs.Name], extensions...)
+				}
 			}
 		}
 		return res
@@ -703,8 +1044,10 @@ func buildGRPCVirtualServices(
 		routeKey := parent.InternalName
 		vsHosts := hostnameToStringList(route.Hostnames)
 		routes := gwResult.routes
+		extensions := gwResult.extensions
 		if parent.IsMesh() {
 			routes = meshResult.routes
+			extensions = nil
 			// for mesh routes, build one VS per namespace/port->host
 			routeMap = meshRoutes
 			routeKey = obj.Namespace
@@ -738,6 +1081,7 @@ func buildGRPCVirtualServices(
 				// append parents
 				cfg.Annotations[constants.InternalParentNames] = fmt.Sprintf("%s,%s/%s.%s",
 					cfg.Annotations[constants.InternalParentNames], obj.GroupVersionKind.Kind, obj.Name, obj.Namespace)
+				appendRouteExtensions(cfg, extensions)
 			} else {
 				name := fmt.Sprintf("%s-%d-%s", obj.Name, count, constants.KubernetesGatewayName)
 				routeMap[routeKey][h] = &config.Config{
@@ -755,6 +1099,7 @@ func buildGRPCVirtualServices(
 						Http:     routes,
 					},
 				}
+				appendRouteExtensions(routeMap[routeKey][h], extensions)
 				count++
 			}
 		}
@@ -773,6 +1118,25 @@ func buildGRPCVirtualServices(
 	}
 }
 
+// appendRouteExtensions records the extensions referenced by routes of the VirtualService
+func appendRouteExtensions(cfg *config.Config, extensions model.RouteExtensionRefs) {
+	if len(extensions) == 0 {
+		return
+	}
+	// The extensions may be shared with other VirtualServices built from the same route, so never mutate them.
+	merged := maps.Clone(model.RouteExtensions(*cfg))
+	if merged == nil {
+		merged = make(model.RouteExtensionRefs, len(extensions))
+	}
+	for route, refs := range extensions {
+		merged[route] = append(slices.Clone(merged[route]), refs...)
+	}
+	if cfg.Extra == nil {
+		cfg.Extra = map[string]any{}
+	}
+	cfg.Extra[constants.InternalRouteExtensions] = merged
+}
+
 func routeMeta(obj config.Config) map[string]string {
 	m := parentMeta(obj, nil)
 	m[constants.InternalRouteSemantics] = constants.RouteSemanticsGateway
@@ -1223,6 +1587,66 @@ func buildTLSVirtualService(ctx configContext, obj config.Config) []config.Confi
 	return vs
 }
 
+func buildUDPVirtualService(ctx configContext, obj config.Config) []config.Config {
+	route := obj.Spec.(*k8salpha.UDPRouteSpec)
+	parentRefs := extractParentReferenceInfo(ctx.GatewayReferences, route.ParentRefs, nil, gvk.UDPRoute, obj.Namespace)
+
+	reportStatus := func(results []RouteParentResult) {
+		obj.Status.(*kstatus.WrappedStatus).Mutate(func(s config.Status) config.Status {
+			rs := s.(*k8salpha.UDPRouteStatus)
+			rs.Parents = createRouteStatus(results, obj, rs.Parents)
+			return rs
+		})
+	}
+
+	// UDPRoute is only supported for Gateways; mesh parents are rejected as the kind is not allowed.
+	var routeErr *ConfigError
+	routes := []*istio.TCPRoute{}
+	for _, r := range route.Rules {
+		vs, err := convertUDPRoute(ctx, r, obj)
+		// This was a hard error
+		if vs == nil {
+			routeErr = err
+			routes = nil
+			break
+		}
+		// Got an error but also routes
+		if err != nil {
+			routeErr = err
+		}
+		routes = append(routes, vs)
+	}
+	reportStatus(slices.Map(parentRefs, func(r routeParentReference) RouteParentResult {
+		return RouteParentResult{
+			OriginalReference: r.OriginalReference,
+			DeniedReason:      r.DeniedReason,
+			RouteError:        routeErr,
+		}
+	}))
+
+	vs := []config.Config{}
+	for _, parent := range filteredReferences(parentRefs) {
+		vs = append(vs, config.Config{
+			Meta: config.Meta{
+				CreationTimestamp: obj.CreationTimestamp,
+				GroupVersionKind:  gvk.VirtualService,
+				Name:              fmt.Sprintf("%s-udp-%s", obj.Name, constants.KubernetesGatewayName),
+				Annotations:       routeMeta(obj),
+				Namespace:         obj.Namespace,
+				Domain:            ctx.Domain,
+			},
+			Spec: &istio.VirtualService{
+				// UDP has no notion of hostnames, so we use a wildcard. Each listener can have at most one route bound
+				// to it, and the gateway selects the TCP routes for UDP servers.
+				Hosts:    []string{"*"},
+				Gateways: []string{parent.InternalName},
+				Tcp:      routes,
+			},
+		})
+	}
+	return vs
+}
+
 func convertTCPRoute(ctx configContext, r k8salpha.TCPRouteRule, obj config.Config, enforceRefGrant bool) (*istio.TCPRoute, *ConfigError) {
 	if tcpWeightSum(r.BackendRefs) == 0 {
 		// The spec requires us to reject connections when there are no >0 weight backends
@@ -1272,6 +1696,46 @@ func convertTLSRoute(ctx configContext, r k8salpha.TLSRouteRule, obj config.Conf
 	}, backendErr
 }
 
+func convertUDPRoute(ctx configContext, r k8salpha.UDPRouteRule, obj config.Config) (*istio.TCPRoute, *ConfigError) {
+	if tcpWeightSum(r.BackendRefs) == 0 {
+		// The spec requires us to reject traffic when there are no >0 weight backends
+		return &istio.TCPRoute{
+			Route: []*istio.RouteDestination{{
+				Destination: &istio.Destination{
+					Host:   "internal.cluster.local",
+					Subset: "zero-weight",
+					Port:   &istio.PortSelector{Number: 65535},
+				},
+				Weight: 0,
+			}},
+		}, nil
+	}
+	dest, backendErr, err := buildTCPDestination(ctx, r.BackendRefs, obj.Namespace, true, gvk.UDPRoute)
+	if err != nil {
+		return nil, err
+	}
+	// The UDP proxy does not support weighted clusters, so only the backend with the highest weight is used.
+	if len(dest) > 1 {
+		picked := dest[0]
+		for _, d := range dest[1:] {
+			if d.Weight > picked.Weight {
+				picked = d
+			}
+		}
+		dest = []*istio.RouteDestination{{Destination: picked.Destination}}
+		if backendErr == nil {
+			backendErr = &ConfigError{
+				Reason: UnsupportedValue,
+				Message: fmt.Sprintf("multiple backendRefs are not supported for UDPRoute; all traffic is sent to backend(%s)",
+					picked.Destination.Host),
+			}
+		}
+	}
+	return &istio.TCPRoute{
+		Route: dest,
+	}, backendErr
+}
+
 func buildTCPDestination(
 	ctx configContext,
 	forwardTo []k8s.BackendRef,
@@ -1623,6 +2087,30 @@ func createMirrorFilter(ctx configContext, filter *k8s.HTTPRequestMirrorFilter,
 	return &istio.HTTPMirrorPolicy{Destination: dst, Percentage: percent}, nil
 }
 
+// createExtensionRefFilter resolves an ExtensionRef filter to the extension it references.
+// Currently, only WasmPlugin is supported.
+func createExtensionRefFilter(ctx configContext, ref *k8s.LocalObjectReference, ns string) (types.NamespacedName, *ConfigError) {
+	if ref == nil {
+		return types.NamespacedName{}, &ConfigError{Reason: InvalidFilter, Message: "extensionRef must be set"}
+	}
+	if string(ref.Group) != gvk.WasmPlugin.Group || string(ref.Kind) != gvk.WasmPlugin.Kind {
+		return types.NamespacedName{}, &ConfigError{
+			Reason:  InvalidFilter,
+			Message: fmt.Sprintf("unsupported extensionRef %v/%v", ref.Group, ref.Kind),
+		}
+	}
+	name := types.NamespacedName{Namespace: ns, Name: string(ref.Name)}
+	for _, wp := range ctx.WasmPlugin {
+		if wp.Namespace == name.Namespace && wp.Name == name.Name {
+			return name, nil
+		}
+	}
+	return types.NamespacedName{}, &ConfigError{
+		Reason:  InvalidFilter,
+		Message: fmt.Sprintf("extensionRef %v/%v not found", ref.Kind, name),
+	}
+}
+
 func createRewriteFilter(filter *k8s.HTTPURLRewriteFilter) *istio.HTTPRewrite {
 	if filter == nil {
 		return nil
@@ -2724,5 +3212,10 @@ func (kr GatewayResources) FuzzValidate() bool {
 			return false
 		}
 	}
+	for _, ur := range kr.UDPRoute {
+		if ur.Spec == nil {
+			return false
+		}
+	}
 	return true
 }
@@ -27,6 +27,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	k8s "sigs.k8s.io/gateway-api/apis/v1"
 	k8salpha "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	"sigs.k8s.io/yaml"
@@ -67,6 +68,11 @@ var ports = []*model.Port{
 		Port:     34001,
 		Protocol: "TCP",
 	},
+	{
+		Name:     "udp",
+		Port:     5353,
+		Protocol: "UDP",
+	},
 }
 
 var services = []*model.Service{
@@ -342,6 +348,15 @@ D2lWusoe2/nEqfDVVWGWlyJ7yOmqaVm/iNUN9B2N2g==
 				"tls.key": []byte(rsaKeyPEM),
 			},
 		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ca-cert",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"ca.crt": []byte(rsaCertPEM),
+			},
+		},
 		&corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      "malformed",
@@ -378,6 +393,7 @@ func TestConvertResources(t *testing.T) {
 		{name: "http"},
 		{name: "tcp"},
 		{name: "tls"},
+		{name: "udp"},
 		{name: "grpc"},
 		{name: "mismatch"},
 		{name: "weighted"},
@@ -416,6 +432,8 @@ func TestConvertResources(t *testing.T) {
 		{name: "route-precedence"},
 		{name: "waypoint"},
 		{name: "isolation"},
+		{name: "backend-tls"},
+		{name: "extension-ref"},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -435,6 +453,10 @@ func TestConvertResources(t *testing.T) {
 					Service:     svc,
 					ServicePort: ports[2],
 					Endpoint:    &model.IstioEndpoint{},
+				}, &model.ServiceInstance{
+					Service:     svc,
+					ServicePort: ports[3],
+					Endpoint:    &model.IstioEndpoint{},
 				})
 			}
 			cg := core.NewConfigGenTest(t, core.TestOptions{
@@ -447,6 +469,10 @@ func TestConvertResources(t *testing.T) {
 			output.AllowedReferences = AllowedReferences{} // Not tested here
 			output.ReferencedNamespaceKeys = nil           // Not tested here
 			output.ResourceReferences = nil                // Not tested here
+			output.CACertificateReferences = nil           // Not tested here
+			for i := range output.VirtualService {
+				output.VirtualService[i].Extra = nil // Not tested here
+			}
 
 			// sort virtual services to make the order deterministic
 			sort.Slice(output.VirtualService, func(i, j int) bool {
@@ -454,6 +480,7 @@ func TestConvertResources(t *testing.T) {
 			})
 			goldenFile := fmt.Sprintf("testdata/%s.yaml.golden", tt.name)
 			res := append(output.Gateway, output.VirtualService...)
+			res = append(res, output.DestinationRule...)
 			util.CompareContent(t, marshalYaml(t, res), goldenFile)
 			golden := splitOutput(readConfig(t, goldenFile, validator, tt.validationIgnorer))
 
@@ -464,7 +491,7 @@ func TestConvertResources(t *testing.T) {
 
 			assert.Equal(t, golden, output)
 
-			outputStatus := getStatus(t, kr.GatewayClass, kr.Gateway, kr.HTTPRoute, kr.GRPCRoute, kr.TLSRoute, kr.TCPRoute)
+			outputStatus := getStatus(t, kr.GatewayClass, kr.Gateway, kr.HTTPRoute, kr.GRPCRoute, kr.TLSRoute, kr.TCPRoute, kr.UDPRoute, kr.BackendTLSPolicy)
 			goldenStatusFile := fmt.Sprintf("testdata/%s.status.yaml.golden", tt.name)
 			if util.Refresh() {
 				if err := os.WriteFile(goldenStatusFile, outputStatus, 0o644); err != nil {
@@ -1156,8 +1183,9 @@ var timestampRegex = regexp.MustCompile(`lastTransitionTime:.*`)
 
 func splitOutput(configs []config.Config) IstioResources {
 	out := IstioResources{
-		Gateway:        []config.Config{},
-		VirtualService: []config.Config{},
+		Gateway:         []config.Config{},
+		VirtualService:  []config.Config{},
+		DestinationRule: []config.Config{},
 	}
 	for _, c := range configs {
 		c.Domain = "domain.suffix"
@@ -1166,6 +1194,8 @@ func splitOutput(configs []config.Config) IstioResources {
 			out.Gateway = append(out.Gateway, c)
 		case gvk.VirtualService:
 			out.VirtualService = append(out.VirtualService, c)
+		case gvk.DestinationRule:
+			out.DestinationRule = append(out.DestinationRule, c)
 		}
 	}
 	return out
@@ -1189,10 +1219,16 @@ func splitInput(t test.Failer, configs []config.Config) GatewayResources {
 			out.TCPRoute = append(out.TCPRoute, c)
 		case gvk.TLSRoute:
 			out.TLSRoute = append(out.TLSRoute, c)
+		case gvk.UDPRoute:
+			out.UDPRoute = append(out.UDPRoute, c)
 		case gvk.ReferenceGrant:
 			out.ReferenceGrant = append(out.ReferenceGrant, c)
 		case gvk.ServiceEntry:
 			out.ServiceEntry = append(out.ServiceEntry, c)
+		case gvk.BackendTLSPolicy:
+			out.BackendTLSPolicy = append(out.BackendTLSPolicy, c)
+		case gvk.WasmPlugin:
+			out.WasmPlugin = append(out.WasmPlugin, c)
 		}
 	}
 	out.Namespaces = map[string]*corev1.Namespace{}
@@ -1255,6 +1291,10 @@ func insertDefaults(cfgs []config.Config) []config.Config {
 			c.Status = kstatus.Wrap(&k8salpha.TCPRouteStatus{})
 		case gvk.TLSRoute:
 			c.Status = kstatus.Wrap(&k8salpha.TLSRouteStatus{})
+		case gvk.UDPRoute:
+			c.Status = kstatus.Wrap(&k8salpha.UDPRouteStatus{})
+		case gvk.BackendTLSPolicy:
+			c.Status = kstatus.Wrap(&k8salpha.PolicyStatus{})
 		}
 		res = append(res, c)
 	}
@@ -1451,3 +1491,46 @@ func TestExtractGatewayServices(t *testing.T) {
 		})
 	}
 }
+
+func TestCACertificateReaders(t *testing.T) {
+	vs := func(gateways []string, hosts ...string) config.Config {
+		spec := &istio.VirtualService{Gateways: gateways}
+		for _, h := range hosts {
+			spec.Http = append(spec.Http, &istio.HTTPRoute{Route: []*istio.HTTPRouteDestination{{Destination: &istio.Destination{Host: h}}}})
+		}
+		return config.Config{Meta: config.Meta{GroupVersionKind: gvk.VirtualService}, Spec: spec}
+	}
+	caName := "kubernetes-gateway://default/ca-cert-cacert"
+	readers := caCertificateReaders([]config.Config{
+		vs([]string{"ns-1/gateway-istio-autogenerated-k8s-gateway-http"}, "httpbin.default.svc.cluster.local"),
+		vs([]string{"ns-2/gateway-istio-autogenerated-k8s-gateway-http"}, "other.default.svc.cluster.local"),
+		vs([]string{"mesh"}, "httpbin.default.svc.cluster.local"),
+	}, map[string]sets.String{caName: sets.New("httpbin.default.svc.cluster.local")})
+	c := &Controller{state: IstioResources{CACertificateReferences: readers}}
+	// Only the gateways routing to a target of the policy can read its CA certificate.
+	assert.Equal(t, c.SecretAllowed(caName, "ns-1"), true)
+	assert.Equal(t, c.SecretAllowed(caName, "ns-2"), false)
+	assert.Equal(t, c.SecretAllowed(caName, "default"), false)
+	assert.Equal(t, c.SecretAllowed("kubernetes-gateway://default/other-cacert", "ns-1"), false)
+}
+
+func TestConvertRouteExtensions(t *testing.T) {
+	validator := crdvalidation.NewIstioValidator(t)
+	input := readConfig(t, "testdata/extension-ref.yaml", validator, nil)
+	cg := core.NewConfigGenTest(t, core.TestOptions{Services: services})
+	kr := splitInput(t, input)
+	kr.Context = NewGatewayContext(cg.PushContext(), "Kubernetes")
+	output := convertResources(kr)
+
+	got := map[string]model.RouteExtensionRefs{}
+	for _, vs := range output.VirtualService {
+		if refs := model.RouteExtensions(vs); refs != nil {
+			got[vs.Namespace+"/"+vs.Name] = refs
+		}
+	}
+	auth := []types.NamespacedName{{Namespace: "default", Name: "auth"}}
+	assert.Equal(t, got, map[string]model.RouteExtensionRefs{
+		"default/grpc-0-istio-autogenerated-k8s-gateway": {"default.grpc.0": auth},
+		"default/http-0-istio-autogenerated-k8s-gateway": {"default.http.0": auth},
+	})
+}
@@ -657,11 +657,15 @@ func extractServicePorts(gw gateway.Gateway) []corev1.ServicePort {
 			name = fmt.Sprintf("%s-%d", strings.ToLower(string(l.Protocol)), i)
 		}
 		appProtocol := strings.ToLower(string(l.Protocol))
-		svcPorts = append(svcPorts, corev1.ServicePort{
+		svcPort := corev1.ServicePort{
 			Name:        name,
 			Port:        int32(l.Port),
 			AppProtocol: &appProtocol,
-		})
+		}
+		if l.Protocol == gatewayv1.UDPProtocolType {
+			svcPort.Protocol = corev1.ProtocolUDP
+		}
+		svcPorts = append(svcPorts, svcPort)
 	}
 	return svcPorts
 }
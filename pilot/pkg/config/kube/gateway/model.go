@@ -34,14 +34,17 @@ const (
 
 // GatewayResources stores all gateway resources used for our conversion.
 type GatewayResources struct {
-	GatewayClass   []config.Config
-	Gateway        []config.Config
-	HTTPRoute      []config.Config
-	GRPCRoute      []config.Config
-	TCPRoute       []config.Config
-	TLSRoute       []config.Config
-	ReferenceGrant []config.Config
-	ServiceEntry   []config.Config
+	GatewayClass     []config.Config
+	Gateway          []config.Config
+	HTTPRoute        []config.Config
+	GRPCRoute        []config.Config
+	TCPRoute         []config.Config
+	TLSRoute         []config.Config
+	UDPRoute         []config.Config
+	ReferenceGrant   []config.Config
+	ServiceEntry     []config.Config
+	BackendTLSPolicy []config.Config
+	WasmPlugin       []config.Config
 	// Namespaces stores all namespace in the cluster, keyed by name
 	Namespaces map[string]*corev1.Namespace
 	// Credentials stores all credentials in the cluster
@@ -91,8 +94,9 @@ func (refs AllowedReferences) BackendAllowed(
 
 // IstioResources stores all outputs of our conversion
 type IstioResources struct {
-	Gateway        []config.Config
-	VirtualService []config.Config
+	Gateway         []config.Config
+	VirtualService  []config.Config
+	DestinationRule []config.Config
 	// AllowedReferences stores all allowed references, from Reference -> to Reference(s)
 	AllowedReferences AllowedReferences
 	// ReferencedNamespaceKeys stores the label key of all namespace selections. This allows us to quickly
@@ -103,6 +107,10 @@ type IstioResources struct {
 	// determine if a resource update could have impacted any Gateways.
 	// key: referenced resources(e.g. secrets), value: gateway-api resources(e.g. gateways)
 	ResourceReferences map[model.ConfigKey][]model.ConfigKey
+
+	// CACertificateReferences stores the SDS resource names of all CA certificates referenced by BackendTLSPolicies,
+	// and the namespaces of the gateways allowed to read them: those routing to a Service targeted by the policies.
+	CACertificateReferences map[string]sets.String
 }
 
 // Reference stores a reference to a namespaced GVK, as used by ReferencePolicy
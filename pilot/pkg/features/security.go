@@ -16,6 +16,7 @@ package features
 
 import (
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/types"
 
@@ -90,6 +91,49 @@ var (
 
 	CertSignerDomain = env.Register("CERT_SIGNER_DOMAIN", "", "The cert signer domain info").Get()
 
+	EnableCACRL = env.Register("ENABLE_CA_CRL", false,
+		"If enabled, the Istio CA tracks the workload certificates it issues, which can be revoked by adding revocation "+
+			"requests to the istio-ca-revocations ConfigMap, and distributes the CRL of the revoked certificates to the proxies. "+
+			"The proxies only use the CRL if it is issued by every CA of their trust bundle, as the peers whose issuer has "+
+			"no CRL would be rejected: e.g. not during a root rotation, or with an intermediate CA. The CA signing "+
+			"certificate must have the cRLSign key usage, or istiod fails to start.").Get()
+
+	CAIssuedCertsCapacity = env.Register("CA_ISSUED_CERTS_CAPACITY", 100000,
+		"The number of issued workload certificates the Istio CA keeps track of to revoke them by identity or node, "+
+			"when ENABLE_CA_CRL is set. The issued certificates are also persisted in the istio-ca-issued-* ConfigMaps of the "+
+			"Istio namespace until they expire, so they can be revoked after istiod restarts.").Get()
+
+	EnableCAAuditLog = env.Register("ENABLE_CA_AUDIT_LOG", false,
+		"If enabled, the Istio CA server records every certificate signing decision, with the caller, the authenticator, the "+
+			"requested identities and TTL, the node impersonation decision and the rejection reason, to the caaudit log scope "+
+			"at debug level. The most recent records are available on the /debug/ca_auditz endpoint.").Get()
+
+	CAAuditLogFile = env.Register("CA_AUDIT_LOG_FILE", "",
+		"If set, the Istio CA audit records are also written as JSON lines to this file, when ENABLE_CA_AUDIT_LOG is set. "+
+			"The records are written asynchronously, and dropped if the writes cannot keep up, as counted by the "+
+			"citadel_server_audit_dropped_count metric.").Get()
+
+	CAAuditLogMaxSizeMB = env.Register("CA_AUDIT_LOG_MAX_SIZE_MB", 100,
+		"The size in megabytes at which CA_AUDIT_LOG_FILE is rotated.").Get()
+
+	CAAuditLogMaxBackups = env.Register("CA_AUDIT_LOG_MAX_BACKUPS", 5,
+		"The number of rotated CA_AUDIT_LOG_FILE files to keep.").Get()
+
+	CAAuditRecentRecords = env.Register("CA_AUDIT_RECENT_RECORDS", 1000,
+		"The number of most recent CA audit records available on the /debug/ca_auditz endpoint.").Get()
+
+	EnableCADualRootTransition = env.Register("ENABLE_CA_DUAL_ROOT_TRANSITION", false,
+		"If enabled, when the plugged-in cacerts are updated with a new root certificate, istiod first distributes a trust "+
+			"bundle with both the old and the new roots, waits until it has been acknowledged by every connected proxy, "+
+			"istio-ca-root-cert ConfigMap and istiod replica, and only then signs the workload certificates with the new "+
+			"signing key. Requires ISTIO_MULTIROOT_MESH. The proxies acknowledge the trust bundle over PCDS: the proxies which "+
+			"do not watch it, such as the ones without PROXY_CONFIG_XDS_AGENT, are pending until the transition times out.").Get()
+
+	CADualRootTransitionTimeout = env.Register("CA_DUAL_ROOT_TRANSITION_TIMEOUT", time.Hour,
+		"The maximum duration of the ROOT-CA transition of ENABLE_CA_DUAL_ROOT_TRANSITION. If the trust bundle with both the old "+
+			"and the new roots is not acknowledged by every connected proxy, istio-ca-root-cert ConfigMap and istiod replica in time, "+
+			"the transition is aborted and istiod keeps signing with the current key.").Get()
+
 	UseCacertsForSelfSignedCA = env.Register("USE_CACERTS_FOR_SELF_SIGNED_CA", false,
 		"If enabled, istiod will use a secret named cacerts to store its self-signed istio-"+
 			"generated root certificate.").Get()
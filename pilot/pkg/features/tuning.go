@@ -55,6 +55,28 @@ var (
 		return min(15+5*procs, 100)
 	}()
 
+	PushThrottleByProxyType = env.Register(
+		"PILOT_PUSH_THROTTLE_BY_PROXY_TYPE",
+		"",
+		"Limits the number of concurrent pushes per proxy type, as a comma separated list of <type>=<limit>, "+
+			"for example `sidecar=50,router=20`. The pushes to a proxy type over its limit wait without blocking "+
+			"the pushes to the other types. Proxy types without a limit are only limited by PILOT_PUSH_THROTTLE.",
+	).Get()
+
+	EnablePushPrioritization = env.Register(
+		"PILOT_ENABLE_PUSH_PRIORITIZATION",
+		false,
+		"If enabled, pushes to gateways and waypoints are sent first, then the incremental (EDS only) pushes, "+
+			"then the other pushes. Otherwise, pushes are sent in the order they are queued.",
+	).Get()
+
+	PushPriorityMaxWait = env.Register(
+		"PILOT_PUSH_PRIORITY_MAX_WAIT",
+		5*time.Second,
+		"When push prioritization is enabled, the pushes waiting in the queue for longer than this are sent first, "+
+			"regardless of their priority, so that the lower priority pushes are not starved. Zero disables it.",
+	).Get()
+
 	RequestLimit = func() float64 {
 		v := env.Register(
 			"PILOT_MAX_REQUESTS_PER_SECOND",
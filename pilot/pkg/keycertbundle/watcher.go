@@ -24,6 +24,8 @@ type KeyCertBundle struct {
 	CertPem  []byte
 	KeyPem   []byte
 	CABundle []byte
+	// CRL is the CRL of the workload certificates revoked by the Istio CA, if any.
+	CRL []byte
 }
 
 type Watcher struct {
@@ -75,6 +77,11 @@ func (w *Watcher) SetAndNotify(key, cert, caBundle []byte) {
 	if len(caBundle) != 0 {
 		w.bundle.CABundle = caBundle
 	}
+	w.notify()
+}
+
+// notify notifies the watchers. The caller must hold the lock.
+func (w *Watcher) notify() {
 	for _, ch := range w.watchers {
 		select {
 		case ch <- struct{}{}:
@@ -101,6 +108,21 @@ func (w *Watcher) SetFromFilesAndNotify(keyFile, certFile, rootCert string) erro
 	return nil
 }
 
+// SetCRLAndNotify sets the CRL and notify the watchers. Unlike the other items, the CRL may be cleared.
+func (w *Watcher) SetCRLAndNotify(crl []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.bundle.CRL = crl
+	w.notify()
+}
+
+// GetCRL returns the CRL.
+func (w *Watcher) GetCRL() []byte {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.bundle.CRL
+}
+
 // GetCABundle returns the CABundle.
 func (w *Watcher) GetCABundle() []byte {
 	w.mutex.RLock()
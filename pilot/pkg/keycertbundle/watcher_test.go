@@ -76,6 +76,27 @@ func TestWatcher(t *testing.T) {
 	default:
 		t.Errorf("watcher2 watched non keyCertBundle")
 	}
+
+	// 4. set and clear the CRL, notify all watchers
+	crl := []byte("crl")
+	watcher.SetCRLAndNotify(crl)
+	select {
+	case <-watch1:
+		if !bytes.Equal(watcher.GetCRL(), crl) || !bytes.Equal(watcher.GetCABundle(), ca) {
+			t.Errorf("got wrong keyCertBundle %v", watcher.GetKeyCertBundle())
+		}
+	default:
+		t.Errorf("watcher1 watched non CRL")
+	}
+	watcher.SetCRLAndNotify(nil)
+	select {
+	case <-watch2:
+		if watcher.GetCRL() != nil {
+			t.Errorf("got unexpected CRL %v", watcher.GetCRL())
+		}
+	default:
+		t.Errorf("watcher2 watched non CRL")
+	}
 }
 
 func TestWatcherFromFile(t *testing.T) {
@@ -974,6 +974,18 @@ func (node *Proxy) NonceAcked(typeURL string) string {
 	return ""
 }
 
+// VersionAcked returns the version of the last response of typeURL acked by the proxy.
+func (node *Proxy) VersionAcked(typeURL string) string {
+	node.RLock()
+	defer node.RUnlock()
+
+	wr := node.WatchedResources[typeURL]
+	if wr != nil {
+		return wr.VersionAcked
+	}
+	return ""
+}
+
 func (node *Proxy) Clusters() []string {
 	node.RLock()
 	defer node.RUnlock()
@@ -1053,6 +1065,7 @@ type GatewayController interface {
 	// SecretAllowed determines if a SDS credential is accessible to a given namespace.
 	// For example, for resourceName of `kubernetes-gateway://ns-name/secret-name` and namespace of `ingress-ns`,
 	// this would return true only if there was a policy allowing `ingress-ns` to access Secrets in the `ns-name` namespace.
+	// CA certificates referenced by a BackendTLSPolicy are accessible to the namespaces of the gateways routing to its targets.
 	SecretAllowed(resourceName string, namespace string) bool
 }
 
@@ -33,6 +33,10 @@ const (
 	// BuiltinGatewaySecretType is the name of a SDS secret that uses the workloads own mTLS certificate
 	BuiltinGatewaySecretType    = "builtin"
 	BuiltinGatewaySecretTypeURI = BuiltinGatewaySecretType + "://"
+	// InvalidSecretType is the name of a SDS secret that can never be resolved. This is used when a reference is invalid,
+	// to ensure traffic fails rather than falling back to an insecure configuration.
+	InvalidSecretType    = "invalid"
+	InvalidSecretTypeURI = InvalidSecretType + "://"
 	// SdsCaSuffix is the suffix of the sds resource name for root CA.
 	SdsCaSuffix = "-cacert"
 )
@@ -73,7 +77,8 @@ func ToResourceName(name string) string {
 		return "default"
 	}
 	// If they explicitly defined the type, keep it
-	if strings.HasPrefix(name, KubernetesSecretTypeURI) || strings.HasPrefix(name, kubernetesGatewaySecretTypeURI) {
+	if strings.HasPrefix(name, KubernetesSecretTypeURI) || strings.HasPrefix(name, kubernetesGatewaySecretTypeURI) ||
+		strings.HasPrefix(name, InvalidSecretTypeURI) {
 		return name
 	}
 	// Otherwise, to kubernetes://
@@ -251,12 +251,17 @@ func convertToWasmPluginWrapper(originPlugin config.Config) *WasmPluginWrapper {
 	return &WasmPluginWrapper{
 		Name:            plugin.Name,
 		Namespace:       plugin.Namespace,
-		ResourceName:    WasmPluginResourceNamePrefix + plugin.Namespace + "." + plugin.Name,
+		ResourceName:    WasmPluginResourceName(types.NamespacedName{Namespace: plugin.Namespace, Name: plugin.Name}),
 		WasmPlugin:      wasmPlugin,
 		ResourceVersion: plugin.ResourceVersion,
 	}
 }
 
+// WasmPluginResourceName returns the ECDS resource name of the WasmPlugin with the given name.
+func WasmPluginResourceName(name types.NamespacedName) string {
+	return WasmPluginResourceNamePrefix + name.Namespace + "." + name.Name
+}
+
 // toSecretResourceName converts a imagePullSecret to a resource name referenced at Wasm SDS.
 // NOTE: the secret referenced by WasmPlugin has to be in the same namespace as the WasmPlugin,
 // so this function makes sure that the secret resource name, which will be used to retrieve secret at
@@ -65,6 +65,10 @@ type MergedGateway struct {
 	// is limited to HTTP3 only
 	MergedQUICTransportServers map[ServerPort]*MergedServers
 
+	// MergedUDPServers map from physical port to servers using the UDP protocol.
+	// These are served by a dedicated UDP listener, and do not conflict with servers on the same port using TCP.
+	MergedUDPServers map[ServerPort]*MergedServers
+
 	// HTTP3AdvertisingRoutes represents the set of HTTP routes which advertise HTTP/3.
 	// This mapping is used to generate alt-svc header that is needed for HTTP/3 server discovery.
 	HTTP3AdvertisingRoutes sets.String
@@ -99,6 +103,14 @@ type MergedGateway struct {
 	VerifiedCertificateReferences sets.String
 }
 
+// HasUDPServers returns true if any of the merged gateways define a UDP server.
+func (g *MergedGateway) HasUDPServers() bool {
+	if g != nil {
+		return len(g.MergedUDPServers) > 0
+	}
+	return false
+}
+
 func (g *MergedGateway) HasAutoPassthroughGateways() bool {
 	if g != nil {
 		return g.ContainsAutoPassthroughGateways
@@ -160,6 +172,7 @@ func mergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 	nonPlainTextGatewayPortsBindMap := map[uint32]sets.String{}
 	mergedServers := make(map[ServerPort]*MergedServers)
 	mergedQUICServers := make(map[ServerPort]*MergedServers)
+	mergedUDPServers := make(map[ServerPort]*MergedServers)
 	serverPorts := make([]ServerPort, 0)
 	plainTextServers := make(map[uint32]ServerPort)
 	serversByRouteName := make(map[string][]*networking.Server)
@@ -213,6 +226,17 @@ func mergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 				}
 			}
 			for _, resolvedPort := range resolvePorts(s.Port.Number, gwAndInstance.instances, gwAndInstance.legacyGatewaySelector) {
+				if protocol.Parse(s.Port.Protocol) == protocol.UDP {
+					// UDP servers get their own listener, so they never need to be merged with TCP based servers.
+					serverPort := ServerPort{resolvedPort, s.Port.Protocol, s.Bind}
+					if mergedUDPServers[serverPort] == nil {
+						mergedUDPServers[serverPort] = &MergedServers{Servers: []*networking.Server{}}
+						serverPorts = append(serverPorts, serverPort)
+					}
+					mergedUDPServers[serverPort].Servers = append(mergedUDPServers[serverPort].Servers, s)
+					log.Debugf("mergeGateways: gateway %q merged UDP server %v", gatewayName, s.Hosts)
+					continue
+				}
 				routeName := gatewayRDSRouteName(s, resolvedPort, gatewayConfig)
 				if s.Tls != nil {
 					// Envoy will reject config that has multiple filter chain matches with the same matching rules.
@@ -379,6 +403,7 @@ func mergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 	return &MergedGateway{
 		MergedServers:                   mergedServers,
 		MergedQUICTransportServers:      mergedQUICServers,
+		MergedUDPServers:                mergedUDPServers,
 		ServerPorts:                     serverPorts,
 		GatewayNameForServer:            gatewayNameForServer,
 		TLSServerInfo:                   tlsServerInfo,
@@ -161,6 +161,31 @@ func TestMergeGateways(t *testing.T) {
 	}
 }
 
+func TestMergeGatewaysUDP(t *testing.T) {
+	gwTCP := makeConfig("tcp", "not-default", "*", "tcp", "TCP", 53, "ingressgateway", "", networking.ServerTLSSettings_SIMPLE)
+	gwUDP := makeConfig("udp", "not-default", "*", "udp", "UDP", 53, "ingressgateway", "", networking.ServerTLSSettings_SIMPLE)
+	gwUDPOther := makeConfig("udp-other", "not-default", "*", "udp", "UDP", 53, "ingressgateway", "", networking.ServerTLSSettings_SIMPLE)
+
+	mgw := mergeGateways([]gatewayWithInstances{{gwTCP, true, nil}, {gwUDP, true, nil}, {gwUDPOther, true, nil}}, &Proxy{}, nil)
+	// UDP servers do not conflict with TCP servers on the same port
+	if len(mgw.MergedServers) != 1 {
+		t.Errorf("Incorrect number of merged servers. Expected: 1 Got: %d", len(mgw.MergedServers))
+	}
+	if len(mgw.MergedUDPServers) != 1 {
+		t.Fatalf("Incorrect number of merged UDP servers. Expected: 1 Got: %d", len(mgw.MergedUDPServers))
+	}
+	udpServers := mgw.MergedUDPServers[ServerPort{Number: 53, Protocol: "UDP"}]
+	if udpServers == nil || len(udpServers.Servers) != 2 {
+		t.Errorf("Expected 2 UDP servers on port 53, got %v", udpServers)
+	}
+	if len(mgw.ServerPorts) != 2 {
+		t.Errorf("Incorrect number of server ports. Expected: 2 Got: %d", len(mgw.ServerPorts))
+	}
+	if !mgw.HasUDPServers() {
+		t.Errorf("Expected gateway to have UDP servers")
+	}
+}
+
 func TestGetAutoPassthroughSNIHosts(t *testing.T) {
 	gateway := config.Config{
 		Meta: config.Meta{
@@ -1365,6 +1365,9 @@ func (ps *PushContext) updateContext(
 			sidecarsChanged = true
 		case kind.WasmPlugin:
 			wasmPluginsChanged = true
+			// WasmPlugins may be referenced by gateway-api routes, which fail closed when a reference is missing.
+			gatewayAPIChanged = true
+			virtualServicesChanged = true
 		case kind.EnvoyFilter:
 			envoyFiltersChanged = true
 			changedEnvoyFilters.Insert(conf)
@@ -1373,11 +1376,15 @@ func (ps *PushContext) updateContext(
 		case kind.RequestAuthentication,
 			kind.PeerAuthentication:
 			authnChanged = true
-		case kind.HTTPRoute, kind.TCPRoute, kind.TLSRoute, kind.GRPCRoute, kind.GatewayClass, kind.KubernetesGateway, kind.ReferenceGrant:
+		case kind.HTTPRoute, kind.TCPRoute, kind.TLSRoute, kind.GRPCRoute, kind.UDPRoute, kind.GatewayClass, kind.KubernetesGateway, kind.ReferenceGrant:
 			gatewayAPIChanged = true
 			// VS and GW are derived from gatewayAPI, so if it changed we need to update those as well
 			virtualServicesChanged = true
 			gatewayChanged = true
+		case kind.BackendTLSPolicy:
+			gatewayAPIChanged = true
+			// DR is derived from BackendTLSPolicy, so if it changed we need to update those as well
+			destinationRulesChanged = true
 		case kind.Telemetry:
 			telemetryChanged = true
 		case kind.ProxyConfig:
@@ -2115,10 +2122,17 @@ func (ps *PushContext) WasmPlugins(proxy *Proxy) map[extensions.PluginPhase][]*W
 
 func (ps *PushContext) WasmPluginsByName(proxy *Proxy, names []types.NamespacedName) []*WasmPluginWrapper {
 	res := make([]*WasmPluginWrapper, 0, len(names))
+	var routeRefs sets.Set[types.NamespacedName]
 	for _, n := range names {
 		if n.Namespace != proxy.ConfigNamespace && n.Namespace != ps.Mesh.RootNamespace {
-			log.Warnf("proxy requested invalid WASM configuration: %v", n)
-			continue
+			// Gateways may also use plugins referenced by their routes, which can live in any namespace.
+			if routeRefs == nil {
+				routeRefs = ps.routeWasmPluginNames(proxy)
+			}
+			if !routeRefs.Contains(n) {
+				log.Warnf("proxy requested invalid WASM configuration: %v", n)
+				continue
+			}
 		}
 		for _, wsm := range ps.wasmPluginsByNamespace[n.Namespace] {
 			if wsm.Name == n.Name {
@@ -2160,8 +2174,63 @@ func (ps *PushContext) WasmPluginsByListenerInfo(proxy *Proxy, info WasmPluginLi
 		}
 	}
 
-	// sort slices by priority
-	for i, slice := range matchedPlugins {
+	sortWasmPlugins(matchedPlugins)
+	return matchedPlugins
+}
+
+// RouteWasmPlugins adds the HTTP WasmPlugins referenced by routes bound to the gateways of the proxy,
+// via gateway-api ExtensionRef filters, to the plugins matched for a listener. Plugins which are already
+// matched are left untouched, as they apply to all routes. The resource names of the added plugins are returned;
+// these should be disabled by default and only enabled on the routes referencing them.
+func (ps *PushContext) RouteWasmPlugins(proxy *Proxy, matchedPlugins map[extensions.PluginPhase][]*WasmPluginWrapper) sets.String {
+	refs := ps.routeWasmPluginNames(proxy)
+	if len(refs) == 0 {
+		return nil
+	}
+	for _, plugins := range matchedPlugins {
+		for _, plugin := range plugins {
+			refs.Delete(types.NamespacedName{Namespace: plugin.Namespace, Name: plugin.Name})
+		}
+	}
+	added := sets.New[string]()
+	for _, ref := range slices.SortBy(refs.UnsortedList(), types.NamespacedName.String) {
+		for _, plugin := range ps.wasmPluginsByNamespace[ref.Namespace] {
+			if plugin.Name == ref.Name && plugin.MatchType(WasmPluginTypeHTTP) {
+				matchedPlugins[plugin.Phase] = append(matchedPlugins[plugin.Phase], plugin)
+				added.Insert(plugin.ResourceName)
+				break
+			}
+		}
+	}
+	sortWasmPlugins(matchedPlugins)
+	return added
+}
+
+// routeWasmPluginNames returns the WasmPlugins referenced by routes bound to the gateways of the proxy.
+func (ps *PushContext) routeWasmPluginNames(proxy *Proxy) sets.Set[types.NamespacedName] {
+	// MergedGateway will be nil when there are no configs in the
+	// system during initial installation.
+	if proxy == nil || proxy.MergedGateway == nil {
+		return nil
+	}
+	res := sets.New[types.NamespacedName]()
+	gateways := sets.New[string]()
+	for _, gw := range proxy.MergedGateway.GatewayNameForServer {
+		gateways.Insert(gw)
+	}
+	for gw := range gateways {
+		for _, vs := range ps.VirtualServicesForGateway(proxy.ConfigNamespace, gw) {
+			for _, refs := range RouteExtensions(vs) {
+				res.InsertAll(refs...)
+			}
+		}
+	}
+	return res
+}
+
+// sortWasmPlugins sorts the plugins of each phase by priority.
+func sortWasmPlugins(plugins map[extensions.PluginPhase][]*WasmPluginWrapper) {
+	for i, slice := range plugins {
 		sort.SliceStable(slice, func(i, j int) bool {
 			iPriority := int32(math.MinInt32)
 			if prio := slice[i].Priority; prio != nil {
@@ -2173,10 +2242,8 @@ func (ps *PushContext) WasmPluginsByListenerInfo(proxy *Proxy, info WasmPluginLi
 			}
 			return iPriority > jPriority
 		})
-		matchedPlugins[i] = slice
+		plugins[i] = slice
 	}
-
-	return matchedPlugins
 }
 
 // pre computes envoy filters per namespace
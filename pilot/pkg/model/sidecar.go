@@ -52,6 +52,7 @@ var (
 		kind.TCPRoute,
 		kind.TLSRoute,
 		kind.GRPCRoute,
+		kind.UDPRoute,
 	)
 
 	// clusterScopedKnownConfigTypes includes configs when they are in root namespace,
@@ -124,6 +125,60 @@ func (hc hostClassification) VSMatches(vsHost host.Name, useGatewaySemantics boo
 	return false
 }
 
+// SidecarEgressHosts are the hosts imported by a Sidecar egress listener, by namespace.
+type SidecarEgressHosts map[string]hostClassification
+
+// ParseSidecarEgressHosts parses the `namespace/dnsName` hosts of a Sidecar egress listener, resolving
+// the `.` namespace to configNamespace. Illegal hosts are skipped.
+func ParseSidecarEgressHosts(hosts []string, configNamespace string) SidecarEgressHosts {
+	hostsByNamespace := make(SidecarEgressHosts)
+	for _, h := range hosts {
+		if strings.Count(h, "/") != 1 {
+			log.Errorf("Illegal host in sidecar resource: %s, host must be of form namespace/dnsName", h)
+			continue
+		}
+		ns, name, _ := strings.Cut(h, "/")
+		if ns == currentNamespace {
+			ns = configNamespace
+		}
+
+		hName := host.Name(name)
+		hc, exists := hostsByNamespace[ns]
+		if !exists {
+			hc = hostClassification{exactHosts: sets.New[host.Name](), allHosts: make([]host.Name, 0)}
+		}
+
+		// exact hosts are saved separately for map lookup
+		if !hName.IsWildCarded() {
+			hc.exactHosts.Insert(hName)
+		}
+
+		// allHosts contains the exact hosts and wildcard hosts,
+		// since SelectVirtualServices will use `Matches` semantic matching.
+		hc.allHosts = append(hc.allHosts, hName)
+		hostsByNamespace[ns] = hc
+	}
+	return hostsByNamespace
+}
+
+// ImportsService checks if the hosts import a service with the hostname defined in the namespace.
+func (h SidecarEgressHosts) ImportsService(hostname host.Name, namespace string) bool {
+	if hc, ok := h[namespace]; ok && hc.Matches(hostname) {
+		return true
+	}
+	hc, ok := h[wildcardNamespace]
+	return ok && hc.Matches(hostname)
+}
+
+// ImportsVirtualService checks if the hosts import a virtual service with the host defined in the namespace.
+func (h SidecarEgressHosts) ImportsVirtualService(vsHost host.Name, namespace string) bool {
+	if hc, ok := h[namespace]; ok && hc.VSMatches(vsHost, false) {
+		return true
+	}
+	hc, ok := h[wildcardNamespace]
+	return ok && hc.VSMatches(vsHost, false)
+}
+
 // SidecarScope is a wrapper over the Sidecar resource with some
 // preprocessed data to determine the list of services, virtualServices,
 // and destinationRules that are accessible to a given
@@ -498,34 +553,7 @@ func convertIstioListenerToWrapper(ps *PushContext, configNamespace string,
 		matchPort:     needsPortMatch(istioListener),
 	}
 
-	hostsByNamespace := make(map[string]hostClassification)
-	for _, h := range istioListener.Hosts {
-		if strings.Count(h, "/") != 1 {
-			log.Errorf("Illegal host in sidecar resource: %s, host must be of form namespace/dnsName", h)
-			continue
-		}
-		ns, name, _ := strings.Cut(h, "/")
-		if ns == currentNamespace {
-			ns = configNamespace
-		}
-
-		hName := host.Name(name)
-		hc, exists := hostsByNamespace[ns]
-		if !exists {
-			hc = hostClassification{exactHosts: sets.New[host.Name](), allHosts: make([]host.Name, 0)}
-		}
-
-		// exact hosts are saved separately for map lookup
-		if !hName.IsWildCarded() {
-			hc.exactHosts.Insert(hName)
-		}
-
-		// allHosts contains the exact hosts and wildcard hosts,
-		// since SelectVirtualServices will use `Matches` semantic matching.
-		hc.allHosts = append(hc.allHosts, hName)
-		hostsByNamespace[ns] = hc
-	}
-
+	hostsByNamespace := ParseSidecarEgressHosts(istioListener.Hosts, configNamespace)
 	out.virtualServices = SelectVirtualServices(ps.virtualServiceIndex, configNamespace, hostsByNamespace)
 	svces := ps.servicesExportedToNamespace(configNamespace)
 	out.services = out.selectServices(svces, configNamespace, hostsByNamespace)
@@ -3570,3 +3570,32 @@ func TestComputeWildcardHostVirtualServiceIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestSidecarEgressHosts(t *testing.T) {
+	hosts := ParseSidecarEgressHosts([]string{"./*.svc.cluster.local", "*/api.example.com", "ns2/virtual.example.com", "invalid"}, "ns1")
+	tests := []struct {
+		name      string
+		host      host.Name
+		namespace string
+		service   bool
+		vs        bool
+	}{
+		{"current namespace wildcard", "foo.ns1.svc.cluster.local", "ns1", true, true},
+		{"current namespace other host", "foo.example.com", "ns1", false, false},
+		{"other namespace", "foo.ns2.svc.cluster.local", "ns2", false, false},
+		{"wildcard namespace", "api.example.com", "ns3", true, true},
+		{"explicit namespace", "virtual.example.com", "ns2", true, true},
+		{"explicit namespace mismatch", "virtual.example.com", "ns1", false, false},
+		{"wildcard virtual service host", "*.example.com", "ns3", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hosts.ImportsService(tt.host, tt.namespace); got != tt.service {
+				t.Errorf("ImportsService(%v, %v) = %v, want %v", tt.host, tt.namespace, got, tt.service)
+			}
+			if got := hosts.ImportsVirtualService(tt.host, tt.namespace); got != tt.vs {
+				t.Errorf("ImportsVirtualService(%v, %v) = %v, want %v", tt.host, tt.namespace, got, tt.vs)
+			}
+		})
+	}
+}
@@ -103,6 +103,13 @@ type typedXdsCache[K comparable] interface {
 	Keys() []K
 	// Snapshot returns a snapshot of all keys and values. This is for testing/debug only
 	Snapshot() []*discovery.Resource
+	// entries returns a copy of all entries, to persist them in a cache snapshot.
+	entries() map[K]cacheValue
+	// restore adds an entry read from a cache snapshot.
+	restore(key K, value cacheValue)
+	// clearStale clears the entries dependent on the configs, or all entries if there are no configs, except the
+	// restored entries to keep. It returns the number of restored entries left.
+	clearStale(configs sets.Set[ConfigKey], keep func(cacheValue) bool) int
 }
 
 // newTypedXdsCache returns an instance of a cache.
@@ -276,6 +283,8 @@ type cacheValue struct {
 	value            *discovery.Resource
 	token            CacheToken
 	dependentConfigs []ConfigHash
+	// restored marks the entries read from a cache snapshot, to measure the efficiency of the snapshot.
+	restored bool
 }
 
 func (l *lruCache[K]) Get(key K) *discovery.Resource {
@@ -295,6 +304,9 @@ func (l *lruCache[K]) get(key K, token CacheToken) *discovery.Resource {
 	}
 	if cv.token >= token {
 		hit()
+		if cv.restored {
+			xdsCacheSnapshotHits.Increment()
+		}
 		return cv.value
 	}
 	miss()
@@ -359,6 +371,67 @@ func (l *lruCache[K]) Snapshot() []*discovery.Resource {
 	return res
 }
 
+func (l *lruCache[K]) entries() map[K]cacheValue {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	res := make(map[K]cacheValue, l.store.Len())
+	for _, k := range l.store.Keys() {
+		// Peek does not update the recency of the entry.
+		if v, ok := l.store.Peek(k); ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func (l *lruCache[K]) restore(k K, v cacheValue) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, f := l.store.Get(k); f {
+		// The entry was generated after the start, which is more recent than the snapshot.
+		return
+	}
+	l.store.Add(k, v)
+	l.updateConfigIndex(k, v.dependentConfigs)
+	size(l.store.Len())
+}
+
+func (l *lruCache[K]) clearStale(configs sets.Set[ConfigKey], keep func(cacheValue) bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.token = CacheToken(time.Now().UnixNano())
+	l.evictedOnClear = true
+	defer func() {
+		l.evictedOnClear = false
+	}()
+	remove := func(key K) {
+		if v, ok := l.store.Peek(key); ok && v.restored && keep(v) {
+			return
+		}
+		l.store.Remove(key)
+	}
+	if len(configs) == 0 {
+		for _, key := range l.store.Keys() {
+			remove(key)
+		}
+	} else {
+		for ckey := range configs {
+			// The index is cleaned up on eviction, as kept entries still depend on the config.
+			for key := range l.configIndex[ckey.HashCode()] {
+				remove(key)
+			}
+		}
+	}
+	restored := 0
+	for _, key := range l.store.Keys() {
+		if v, ok := l.store.Peek(key); ok && v.restored {
+			restored++
+		}
+	}
+	size(l.store.Len())
+	return restored
+}
+
 func (l *lruCache[K]) indexLength() int {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -397,3 +470,11 @@ func (d disabledCache[K]) ClearAll() {}
 func (d disabledCache[K]) Keys() []K { return nil }
 
 func (d disabledCache[K]) Snapshot() []*discovery.Resource { return nil }
+
+func (d disabledCache[K]) entries() map[K]cacheValue { return nil }
+
+func (d disabledCache[K]) restore(k K, v cacheValue) {}
+
+func (d disabledCache[K]) clearStale(configs sets.Set[ConfigKey], keep func(cacheValue) bool) int {
+	return 0
+}
@@ -575,6 +575,17 @@ func UseGatewaySemantics(cfg config.Config) bool {
 	return cfg.Annotations[constants.InternalRouteSemantics] == constants.RouteSemanticsGateway
 }
 
+// RouteExtensionRefs are the extensions referenced by each http route of an internally-generated VirtualService,
+// keyed by route name.
+type RouteExtensionRefs map[string][]types.NamespacedName
+
+// RouteExtensions returns the extensions referenced by each http route of an internally-generated VirtualService.
+// These are populated from gateway-api ExtensionRef filters.
+func RouteExtensions(cfg config.Config) RouteExtensionRefs {
+	refs, _ := cfg.Extra[constants.InternalRouteExtensions].(RouteExtensionRefs)
+	return refs
+}
+
 // VirtualServiceDependencies returns dependent configs of the vs,
 // for internal vs generated from gateway-api routes, it returns the parent routes,
 // otherwise it just returns the vs as is.
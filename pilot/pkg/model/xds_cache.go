@@ -29,6 +29,8 @@ type XdsCacheImpl struct {
 	eds typedXdsCache[uint64]
 	rds typedXdsCache[uint64]
 	sds typedXdsCache[string]
+	// restored tracks the entries restored from a cache snapshot.
+	restored *restoredSnapshot
 }
 
 // XdsCache interface defines a store for caching XDS responses.
@@ -78,7 +80,8 @@ const (
 // NewXdsCache returns an instance of a cache.
 func NewXdsCache() XdsCache {
 	cache := XdsCacheImpl{
-		eds: newTypedXdsCache[uint64](),
+		eds:      newTypedXdsCache[uint64](),
+		restored: &restoredSnapshot{},
 	}
 	if features.EnableCDSCaching {
 		cache.cds = newTypedXdsCache[uint64]()
@@ -165,13 +168,17 @@ func (x XdsCacheImpl) Get(entry XdsCacheEntry) *discovery.Resource {
 
 func (x XdsCacheImpl) Clear(s sets.Set[ConfigKey]) {
 	x.cds.Clear(s)
+	x.clearEndpointsAndSecrets(s)
+	x.rds.Clear(s)
+}
+
+func (x XdsCacheImpl) clearEndpointsAndSecrets(s sets.Set[ConfigKey]) {
 	// clear all EDS cache for PA change
 	if HasConfigsOfKind(s, kind.PeerAuthentication) {
 		x.eds.ClearAll()
 	} else {
 		x.eds.Clear(s)
 	}
-	x.rds.Clear(s)
 	x.sds.Clear(s)
 }
 
@@ -0,0 +1,257 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/monitoring"
+	"istio.io/istio/pkg/util/sets"
+)
+
+var (
+	resultTag = monitoring.CreateLabel("result")
+
+	xdsCacheSnapshotEntries = monitoring.NewSum(
+		"xds_cache_snapshot_entries",
+		"Total number of xds cache entries read from the cache snapshot, by type and whether they were restored or dropped.",
+	)
+
+	xdsCacheSnapshotHits = monitoring.NewSum(
+		"xds_cache_snapshot_hits",
+		"Total number of xds cache reads served by entries restored from the cache snapshot.",
+	)
+)
+
+// snapshotTypes are the types of cache entries persisted in a cache snapshot. EDS and SDS entries
+// depend on endpoints and secrets, which are not versioned by the configs, so they are never persisted.
+var snapshotTypes = []string{CDSType, RDSType}
+
+// XdsCacheSnapshotter is implemented by the caches which can be persisted, so that a restarted
+// Istiod does not need to regenerate all the responses at once.
+type XdsCacheSnapshotter interface {
+	// WriteSnapshot writes the entries whose dependent configs all have a fingerprint. It returns
+	// the number of entries written.
+	WriteSnapshot(w io.Writer, fingerprints ConfigFingerprints, generation string) (int, error)
+	// ReadSnapshot restores the entries of a snapshot written by the same generation, whose dependent
+	// configs have the same fingerprints. It returns the number of entries restored.
+	ReadSnapshot(r io.Reader, fingerprints ConfigFingerprints, generation string) (int, error)
+	// HasRestoredEntries returns whether entries restored from a snapshot are still cached.
+	HasRestoredEntries() bool
+	// ClearStale removes the cache entries dependent on the configs passed, or all entries if there are no
+	// configs, like Clear and ClearAll. The restored entries are kept as long as the generation is the same and
+	// their dependent configs have the fingerprints of the snapshot, as the config stores send updates for
+	// unchanged configs while they start.
+	ClearStale(configs sets.Set[ConfigKey], fingerprints ConfigFingerprints, generation string)
+}
+
+// restoredSnapshot holds the generation and the fingerprints of the restored snapshot, while restored
+// entries are cached.
+type restoredSnapshot struct {
+	mu           sync.Mutex
+	generation   string
+	fingerprints ConfigFingerprints
+}
+
+func (r *restoredSnapshot) get() (string, ConfigFingerprints) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.generation, r.fingerprints
+}
+
+func (r *restoredSnapshot) set(generation string, fingerprints ConfigFingerprints) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation = generation
+	r.fingerprints = fingerprints
+}
+
+var _ XdsCacheSnapshotter = XdsCacheImpl{}
+
+// ConfigFingerprints are the versions of the configs, by the hash of their key. While cache invalidation
+// handles the configs changing at runtime, the fingerprints detect the configs which changed while Istiod
+// was not running.
+type ConfigFingerprints map[ConfigHash]string
+
+// NewConfigFingerprints returns the fingerprints of the configs and services of the environment. Services
+// resolved with DNS have no fingerprint, as their endpoints are part of CDS but not versioned by the service.
+func NewConfigFingerprints(env *Environment) ConfigFingerprints {
+	out := ConfigFingerprints{}
+	add := func(key ConfigKey, version string) {
+		h := key.HashCode()
+		if existing, f := out[h]; f && existing != version {
+			// Several objects map to the same key, for example the same host in several registries.
+			// They are not fingerprinted, so that the entries depending on them are never restored.
+			version = ""
+		}
+		out[h] = version
+	}
+	if env.ConfigStore != nil {
+		for _, s := range env.ConfigStore.Schemas().All() {
+			for _, cfg := range env.ConfigStore.List(s.GroupVersionKind(), NamespaceAll) {
+				add(ConfigKey{Kind: kind.MustFromGVK(s.GroupVersionKind()), Name: cfg.Name, Namespace: cfg.Namespace}, cfg.ResourceVersion)
+			}
+		}
+	}
+	if env.ServiceDiscovery != nil {
+		for _, svc := range env.ServiceDiscovery.Services() {
+			version := svc.ResourceVersion
+			if svc.Resolution == DNSLB || svc.Resolution == DNSRoundRobinLB {
+				version = ""
+			}
+			add(ConfigKey{Kind: kind.ServiceEntry, Name: string(svc.Hostname), Namespace: svc.Attributes.Namespace}, version)
+		}
+	}
+	return out
+}
+
+// matches checks that all the configs have a fingerprint, and that it is the same in both fingerprints.
+func (f ConfigFingerprints) matches(configs []ConfigHash, other ConfigFingerprints) bool {
+	for _, c := range configs {
+		v := f[c]
+		if v == "" || other[c] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// xdsCacheSnapshot is the persisted form of the cache.
+type xdsCacheSnapshot struct {
+	// Generation identifies the inputs of the cache which are not part of the dependent configs, like the
+	// version of Istiod and the mesh config. A snapshot is only restored by the same generation.
+	Generation string
+	// Fingerprints are the fingerprints of the dependent configs of the entries, when the snapshot was written.
+	Fingerprints ConfigFingerprints
+	Entries      []xdsCacheSnapshotEntry
+}
+
+type xdsCacheSnapshotEntry struct {
+	Type             string
+	Key              uint64
+	Resource         []byte
+	DependentConfigs []ConfigHash
+}
+
+func (x XdsCacheImpl) snapshotCache(t string) typedXdsCache[uint64] {
+	switch t {
+	case CDSType:
+		return x.cds
+	case RDSType:
+		return x.rds
+	default:
+		return nil
+	}
+}
+
+func (x XdsCacheImpl) WriteSnapshot(w io.Writer, fingerprints ConfigFingerprints, generation string) (int, error) {
+	snapshot := xdsCacheSnapshot{
+		Generation:   generation,
+		Fingerprints: ConfigFingerprints{},
+	}
+	for _, t := range snapshotTypes {
+		for k, v := range x.snapshotCache(t).entries() {
+			if v.value == nil || !fingerprints.matches(v.dependentConfigs, fingerprints) {
+				continue
+			}
+			b, err := proto.Marshal(v.value)
+			if err != nil {
+				return 0, fmt.Errorf("failed to marshal %s cache entry %d: %v", t, k, err)
+			}
+			for _, c := range v.dependentConfigs {
+				snapshot.Fingerprints[c] = fingerprints[c]
+			}
+			snapshot.Entries = append(snapshot.Entries, xdsCacheSnapshotEntry{
+				Type:             t,
+				Key:              k,
+				Resource:         b,
+				DependentConfigs: v.dependentConfigs,
+			})
+		}
+	}
+	if err := gob.NewEncoder(w).Encode(snapshot); err != nil {
+		return 0, err
+	}
+	return len(snapshot.Entries), nil
+}
+
+func (x XdsCacheImpl) ReadSnapshot(r io.Reader, fingerprints ConfigFingerprints, generation string) (int, error) {
+	var snapshot xdsCacheSnapshot
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return 0, err
+	}
+	restored := 0
+	token := CacheToken(time.Now().UnixNano())
+	for _, e := range snapshot.Entries {
+		cache := x.snapshotCache(e.Type)
+		if cache == nil {
+			continue
+		}
+		entries := xdsCacheSnapshotEntries.With(typeTag.Value(e.Type))
+		if snapshot.Generation != generation || !snapshot.Fingerprints.matches(e.DependentConfigs, fingerprints) {
+			entries.With(resultTag.Value("stale")).Increment()
+			continue
+		}
+		res := &discovery.Resource{}
+		if err := proto.Unmarshal(e.Resource, res); err != nil {
+			entries.With(resultTag.Value("invalid")).Increment()
+			continue
+		}
+		cache.restore(e.Key, cacheValue{value: res, token: token, dependentConfigs: e.DependentConfigs, restored: true})
+		entries.With(resultTag.Value("restored")).Increment()
+		restored++
+	}
+	if restored > 0 {
+		x.restored.set(generation, snapshot.Fingerprints)
+	}
+	return restored, nil
+}
+
+func (x XdsCacheImpl) HasRestoredEntries() bool {
+	_, fingerprints := x.restored.get()
+	return fingerprints != nil
+}
+
+func (x XdsCacheImpl) ClearStale(configs sets.Set[ConfigKey], fingerprints ConfigFingerprints, generation string) {
+	restoredGeneration, restoredFingerprints := x.restored.get()
+	if restoredGeneration != generation {
+		// The inputs which are not tracked by the configs changed, so no restored entry is valid anymore.
+		restoredFingerprints = nil
+	}
+	keep := func(v cacheValue) bool {
+		return restoredFingerprints != nil && restoredFingerprints.matches(v.dependentConfigs, fingerprints)
+	}
+	left := 0
+	for _, t := range snapshotTypes {
+		left += x.snapshotCache(t).clearStale(configs, keep)
+	}
+	if len(configs) == 0 {
+		x.eds.ClearAll()
+		x.sds.ClearAll()
+	} else {
+		x.clearEndpointsAndSecrets(configs)
+	}
+	if left == 0 {
+		x.restored.set("", nil)
+	}
+}
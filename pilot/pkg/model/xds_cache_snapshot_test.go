@@ -0,0 +1,131 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+type snapshotEntry struct {
+	entry
+	typ string
+}
+
+func (e snapshotEntry) Type() string {
+	return e.typ
+}
+
+func (e snapshotEntry) Key() any {
+	return e.entry.Key()
+}
+
+func (e snapshotEntry) Cacheable() bool {
+	return true
+}
+
+func TestXdsCacheSnapshot(t *testing.T) {
+	dr := ConfigKey{Kind: kind.DestinationRule, Name: "dr", Namespace: "default"}
+	vs := ConfigKey{Kind: kind.VirtualService, Name: "vs", Namespace: "default"}
+	ef := ConfigKey{Kind: kind.EnvoyFilter, Name: "ef", Namespace: "default"}
+	cluster := snapshotEntry{typ: CDSType, entry: entry{key: "cluster", dependentConfigs: []ConfigHash{dr.HashCode()}}}
+	route := snapshotEntry{typ: RDSType, entry: entry{key: "route", dependentConfigs: []ConfigHash{vs.HashCode()}}}
+	filtered := snapshotEntry{typ: RDSType, entry: entry{key: "filtered", dependentConfigs: []ConfigHash{vs.HashCode(), ef.HashCode()}}}
+	endpoints := snapshotEntry{typ: EDSType, entry: entry{key: "endpoints", dependentConfigs: []ConfigHash{dr.HashCode()}}}
+
+	fingerprints := ConfigFingerprints{
+		dr.HashCode(): "1",
+		vs.HashCode(): "2",
+		// The EnvoyFilter has no fingerprint, so the entries depending on it are not persisted.
+		ef.HashCode(): "",
+	}
+	generation := "1.0"
+
+	write := func() *bytes.Buffer {
+		c := NewXdsCache()
+		req := &PushRequest{Start: time.Now()}
+		for _, e := range []snapshotEntry{cluster, route, filtered, endpoints} {
+			c.Add(e, req, &discovery.Resource{Name: e.key})
+		}
+		buf := &bytes.Buffer{}
+		n, err := c.(XdsCacheSnapshotter).WriteSnapshot(buf, fingerprints, generation)
+		assert.NoError(t, err)
+		assert.Equal(t, n, 2)
+		return buf
+	}
+
+	cases := []struct {
+		name         string
+		fingerprints ConfigFingerprints
+		generation   string
+		restored     []snapshotEntry
+	}{
+		{
+			name:         "unchanged",
+			fingerprints: fingerprints,
+			generation:   generation,
+			restored:     []snapshotEntry{cluster, route},
+		},
+		{
+			name:         "config changed",
+			fingerprints: ConfigFingerprints{dr.HashCode(): "1", vs.HashCode(): "3", ef.HashCode(): "4"},
+			generation:   generation,
+			restored:     []snapshotEntry{cluster},
+		},
+		{
+			name:         "config removed",
+			fingerprints: ConfigFingerprints{vs.HashCode(): "2"},
+			generation:   generation,
+			restored:     []snapshotEntry{route},
+		},
+		{
+			name:         "generation changed",
+			fingerprints: fingerprints,
+			generation:   "2.0",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewXdsCache()
+			n, err := c.(XdsCacheSnapshotter).ReadSnapshot(write(), tt.fingerprints, tt.generation)
+			assert.NoError(t, err)
+			assert.Equal(t, n, len(tt.restored))
+
+			restored := sets.New[string]()
+			for _, e := range []snapshotEntry{cluster, route, filtered, endpoints} {
+				if res := c.Get(e); res != nil {
+					assert.Equal(t, res.Name, e.key)
+					restored.Insert(e.key)
+				}
+			}
+			expected := sets.New[string]()
+			for _, e := range tt.restored {
+				expected.Insert(e.key)
+			}
+			assert.Equal(t, restored, expected)
+
+			// Restored entries are invalidated like the others.
+			c.Clear(sets.New(dr, vs))
+			assert.Equal(t, len(c.Keys(CDSType))+len(c.Keys(RDSType)), 0)
+		})
+	}
+}
@@ -281,6 +281,21 @@ func (configgen *ConfigGeneratorImpl) buildClusters(proxy *model.Proxy, req *mod
 	return resources, model.XdsLogDetails{AdditionalInfo: fmt.Sprintf("cached:%v/%v", cacheStats.hits, cacheStats.hits+cacheStats.miss)}
 }
 
+// skipUDPCluster returns true if no cluster should be built for the UDP port of the service. UDP clusters are only
+// needed by gateways forwarding UDP traffic with udp_proxy. A port number which the service also exposes over another
+// protocol, such as 53 for DNS, has a single cluster of the same name, built from the other port.
+func skipUDPCluster(proxy *model.Proxy, service *model.Service, port *model.Port) bool {
+	if !proxy.MergedGateway.HasUDPServers() {
+		return true
+	}
+	for _, p := range service.Ports {
+		if p.Port == port.Port && p.Protocol != protocol.UDP {
+			return true
+		}
+	}
+	return false
+}
+
 func shouldUseDelta(updates *model.PushRequest) bool {
 	return updates != nil && deltaAwareConfigTypes(updates.ConfigsUpdated) && len(updates.ConfigsUpdated) > 0
 }
@@ -307,7 +322,7 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(cb *ClusterBuilder,
 			continue
 		}
 		for _, port := range service.Ports {
-			if port.Protocol == protocol.UDP {
+			if port.Protocol == protocol.UDP && skipUDPCluster(proxy, service, port) {
 				continue
 			}
 			clusterKey := buildClusterKey(service, port, cb, proxy, efKeys)
@@ -2279,6 +2279,63 @@ func TestRedisProtocolWithPassThroughResolutionAtGateway(t *testing.T) {
 	}
 }
 
+func TestGatewayUDPClusters(t *testing.T) {
+	// kube-dns exposes the port 53 over both UDP and TCP.
+	service := &model.Service{
+		Hostname: host.Name("kube-dns.kube-system.svc.cluster.local"),
+		Ports: model.PortList{
+			{Name: "dns", Port: 53, Protocol: protocol.UDP},
+			{Name: "dns-tcp", Port: 53, Protocol: protocol.TCP},
+			{Name: "syslog", Port: 514, Protocol: protocol.UDP},
+		},
+		Resolution: model.ClientSideLB,
+		Attributes: model.ServiceAttributes{Namespace: "kube-system"},
+	}
+	gateway := config.Config{
+		Meta: config.Meta{Name: "gateway", Namespace: "default", GroupVersionKind: gvk.Gateway},
+		Spec: &networking.Gateway{
+			Servers: []*networking.Server{{
+				Port:  &networking.Port{Name: "udp", Number: 53, Protocol: "UDP"},
+				Hosts: []string{"*"},
+			}},
+		},
+	}
+	for _, tt := range []struct {
+		name     string
+		configs  []config.Config
+		expected []string
+	}{
+		{
+			name:     "without UDP servers",
+			expected: []string{"outbound|53||kube-dns.kube-system.svc.cluster.local"},
+		},
+		{
+			name:    "with UDP servers",
+			configs: []config.Config{gateway},
+			expected: []string{
+				"outbound|514||kube-dns.kube-system.svc.cluster.local",
+				"outbound|53||kube-dns.kube-system.svc.cluster.local",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			test.SetForTest(t, &features.FilterGatewayClusterConfig, false)
+			cg := NewConfigGenTest(t, TestOptions{Services: []*model.Service{service}, Configs: tt.configs})
+			clusters := cg.Clusters(cg.SetupProxy(&model.Proxy{Type: model.Router, ConfigNamespace: "default"}))
+			xdstest.ValidateClusters(t, clusters)
+			var got []string
+			for _, name := range xdstest.MapKeys(xdstest.ExtractClusters(clusters)) {
+				if strings.HasSuffix(name, "||kube-dns.kube-system.svc.cluster.local") {
+					got = append(got, name)
+				}
+			}
+			assert.Equal(t, got, tt.expected)
+			// The cluster of the port 53 is built from the TCP port only.
+			assert.Equal(t, len(cg.PushContext().GetMetric(model.DuplicatedClusters.Name())), 0)
+		})
+	}
+}
+
 func TestAutoMTLSClusterSubsets(t *testing.T) {
 	g := NewWithT(t)
 
@@ -22,11 +22,14 @@ import (
 	"strings"
 	"unsafe"
 
+	xds "github.com/cncf/xds/go/xds/core/v3"
+	matcher "github.com/cncf/xds/go/xds/type/matcher/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	statefulsession "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/stateful_session/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	udp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/hashicorp/go-multierror"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -63,6 +66,12 @@ type mutableListenerOpts struct {
 	transport istionetworking.TransportProtocol
 }
 
+// udpListenerOpts holds the options to build a UDP listener for a set of UDP servers.
+type udpListenerOpts struct {
+	opts    *gatewayListenerOpts
+	servers *model.MergedServers
+}
+
 // MutableGatewayListener represents a listener that is being built.
 // Historically, this was used for all listener building. At this point, outbound and inbound have specialized code.
 // This only applies to gateways now.
@@ -130,6 +139,8 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 	proxyConfig := builder.node.Metadata.ProxyConfigOrDefault(builder.push.Mesh.DefaultConfig)
 	// listener port -> host/bind
 	tlsHostsByPort := map[uint32]map[string]string{}
+	// UDP listeners do not have filter chains, so they are built separately once all other listeners are known.
+	udpListeners := make([]udpListenerOpts, 0)
 	for _, port := range mergedGateway.ServerPorts {
 		// Skip ports we cannot bind to. Note that mergeGateways will already translate Service port to
 		// targetPort, which handles the common case of exposing ports like 80 and 443 but listening on
@@ -149,6 +160,20 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 			extraBind = nil
 		}
 
+		if udpServers := mergedGateway.MergedUDPServers[port]; udpServers != nil {
+			udpListeners = append(udpListeners, udpListenerOpts{
+				opts: &gatewayListenerOpts{
+					push:      builder.push,
+					proxy:     builder.node,
+					bind:      bind,
+					extraBind: extraBind,
+					port:      int(port.Number),
+				},
+				servers: udpServers,
+			})
+			continue
+		}
+
 		// NOTE: There is no gating here to check for the value of the QUIC feature flag. However,
 		// they are created in MergeGatways only when the flag is set. So when it is turned off, the
 		// MergedQUICTransportServers would be nil so that no listener would be created. It is written this way
@@ -218,6 +243,17 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 		}
 		listeners = append(listeners, ml.mutable.Listener)
 	}
+	for _, ul := range udpListeners {
+		lname := getListenerName(ul.opts.bind, ul.opts.port, istionetworking.TransportProtocolUDP)
+		if _, exists := mutableopts[lname]; exists {
+			// QUIC listeners share the same UDP socket
+			errs = multierror.Append(errs, fmt.Errorf("gateway omitting UDP listener %q due to conflict with QUIC listener", lname))
+			continue
+		}
+		if l := builder.buildGatewayUDPListener(ul.opts, ul.servers); l != nil {
+			listeners = append(listeners, l)
+		}
+	}
 	// We'll try to return any listeners we successfully marshaled; if we have none, we'll emit the error we built up
 	err := errs.ErrorOrNil()
 	if err != nil {
@@ -225,7 +261,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 		log.Info(err.Error())
 	}
 
-	if len(mutableopts) == 0 {
+	if len(mutableopts) == 0 && len(udpListeners) == 0 {
 		log.Warnf("gateway has zero listeners for node %v", builder.node.ID)
 		return builder
 	}
@@ -315,7 +351,7 @@ func getListenerName(bind string, port int, transport istionetworking.TransportP
 	switch transport {
 	case istionetworking.TransportProtocolTCP:
 		return bind + "_" + strconv.Itoa(port)
-	case istionetworking.TransportProtocolQUIC:
+	case istionetworking.TransportProtocolQUIC, istionetworking.TransportProtocolUDP:
 		return "udp_" + bind + "_" + strconv.Itoa(port)
 	}
 	return "unknown"
@@ -856,6 +892,109 @@ func (lb *ListenerBuilder) buildGatewayNetworkFiltersFromTCPRoutes(server *netwo
 	return nil
 }
 
+// buildGatewayUDPListener builds a UDP listener for a set of UDP servers. Unlike TCP listeners, UDP listeners
+// do not have filter chains; all datagrams are handled by the udp_proxy listener filter.
+func (lb *ListenerBuilder) buildGatewayUDPListener(opts *gatewayListenerOpts, serversForPort *model.MergedServers) *listener.Listener {
+	var udpProxy *udp.UdpProxyConfig
+	for _, server := range serversForPort.Servers {
+		gatewayName := lb.node.MergedGateway.GatewayNameForServer[server]
+		if udpProxy = lb.buildGatewayUDPProxyFromTCPRoutes(server, gatewayName); udpProxy != nil {
+			break
+		}
+	}
+	if udpProxy == nil {
+		log.Warnf("gateway UDP listener on port %d missed udp proxy route", opts.port)
+		return nil
+	}
+
+	res := &listener.Listener{
+		Name:             getListenerName(opts.bind, opts.port, istionetworking.TransportProtocolUDP),
+		TrafficDirection: core.TrafficDirection_OUTBOUND,
+		Address:          util.BuildNetworkAddress(opts.bind, uint32(opts.port), istionetworking.TransportProtocolUDP),
+		UdpListenerConfig: &listener.UdpListenerConfig{
+			DownstreamSocketConfig: &core.UdpSocketConfig{},
+		},
+		ListenerFilters: []*listener.ListenerFilter{{
+			Name:       wellknown.UDPProxy,
+			ConfigType: &listener.ListenerFilter_TypedConfig{TypedConfig: protoconv.MessageToAny(udpProxy)},
+		}},
+	}
+	if features.EnableDualStack && len(opts.extraBind) > 0 {
+		res.AdditionalAddresses = util.BuildAdditionalAddresses(opts.extraBind, uint32(opts.port))
+		for _, additionalAddress := range res.AdditionalAddresses {
+			additionalAddress.GetAddress().GetSocketAddress().Protocol = core.SocketAddress_UDP
+		}
+	}
+	log.Debugf("buildGatewayListeners: built UDP listener %q", res.Name)
+	return res
+}
+
+// buildGatewayUDPProxyFromTCPRoutes builds a udp proxy config from the first VirtualService TCP route bound to
+// the server. TCP routes are used for UDP servers as well, as the routing semantics are identical.
+func (lb *ListenerBuilder) buildGatewayUDPProxyFromTCPRoutes(server *networking.Server, gateway string) *udp.UdpProxyConfig {
+	port := &model.Port{
+		Name:     server.Port.Name,
+		Port:     int(server.Port.Number),
+		Protocol: protocol.Parse(server.Port.Protocol),
+	}
+
+	gatewayServerHosts := sets.NewWithLength[host.Name](len(server.Hosts))
+	for _, hostname := range server.Hosts {
+		gatewayServerHosts.Insert(host.Name(hostname))
+	}
+
+	virtualServices := lb.push.VirtualServicesForGateway(lb.node.ConfigNamespace, gateway)
+	if len(virtualServices) == 0 {
+		log.Warnf("no virtual service bound to gateway: %v", gateway)
+	}
+	for _, v := range virtualServices {
+		vsvc := v.Spec.(*networking.VirtualService)
+		if len(pickMatchingGatewayHosts(gatewayServerHosts, v)) == 0 {
+			continue
+		}
+		for _, tcp := range vsvc.Tcp {
+			if l4MultiMatch(tcp.Match, server, gateway) && len(tcp.Route) > 0 {
+				return lb.buildUDPProxy(tcp.Route, port)
+			}
+		}
+	}
+	return nil
+}
+
+// buildUDPProxy builds a udp proxy forwarding to the given destinations.
+// Currently, udp_proxy does not support weighted clusters, so the destination with the highest weight is picked.
+func (lb *ListenerBuilder) buildUDPProxy(routes []*networking.RouteDestination, port *model.Port) *udp.UdpProxyConfig {
+	dest := routes[0]
+	for _, r := range routes[1:] {
+		if r.Weight > dest.Weight {
+			dest = r
+		}
+	}
+	service := lb.push.ServiceForHostname(lb.node, host.Name(dest.Destination.Host))
+	clusterName := istio_route.GetDestinationCluster(dest.Destination, service, port.Port)
+	statPrefix := clusterName
+	// If stat name is configured, build the stat prefix from configured pattern.
+	if len(lb.push.Mesh.OutboundClusterStatName) != 0 && service != nil {
+		statPrefix = telemetry.BuildStatPrefix(lb.push.Mesh.OutboundClusterStatName, dest.Destination.Host,
+			dest.Destination.Subset, port, 0, &service.Attributes)
+	}
+	return &udp.UdpProxyConfig{
+		StatPrefix: statPrefix,
+		RouteSpecifier: &udp.UdpProxyConfig_Matcher{
+			Matcher: &matcher.Matcher{
+				OnNoMatch: &matcher.Matcher_OnMatch{
+					OnMatch: &matcher.Matcher_OnMatch_Action{
+						Action: &xds.TypedExtensionConfig{
+							Name:        "route",
+							TypedConfig: protoconv.MessageToAny(&udp.Route{Cluster: clusterName}),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 // buildGatewayNetworkFiltersFromTLSRoutes builds tcp proxy routes for all VirtualServices with TLS blocks.
 // It first obtains all virtual services bound to the set of Gateways for this workload, filters them by this
 // server's port and hostnames, and produces network filters for each destination from the filtered services
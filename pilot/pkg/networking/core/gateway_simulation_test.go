@@ -15,8 +15,12 @@
 package core_test
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/simulation"
 	"istio.io/istio/pilot/test/xds"
@@ -1189,8 +1193,8 @@ func runSimulationTest(t *testing.T, proxy *model.Proxy, o xds.FakeOptions, tt s
 		o.ConfigString = tt.config
 		o.KubernetesObjectString = tt.kubeConfig
 		s := xds.NewFakeDiscoveryServer(t, o)
-		sim := simulation.NewSimulation(t, s, s.SetupProxy(proxy))
-		sim.RunExpectations(tt.calls)
+		sim := simulation.NewSimulationFromConfigGen(s.ConfigGenTest, s.SetupProxy(proxy))
+		runExpectations(t, sim, tt.calls)
 		if t.Failed() && debugMode {
 			t.Log(xdstest.MapKeys(xdstest.ExtractClusters(sim.Clusters)))
 			t.Log(xdstest.ExtractListenerNames(sim.Listeners))
@@ -1215,6 +1219,70 @@ func runSimulationTest(t *testing.T, proxy *model.Proxy, o xds.FakeOptions, tt s
 	}
 }
 
+func runExpectations(t *testing.T, sim *simulation.Simulation, es []simulation.Expect) {
+	for _, e := range es {
+		t.Run(e.Name, func(t *testing.T) {
+			assertResult(t, sim.Run(e.Call), e.Result)
+		})
+	}
+}
+
+// assertResult checks the simulation result matches the expectation. Unless StrictMatch is set, empty fields in
+// the expectation are ignored.
+func assertResult(t *testing.T, r simulation.Result, want simulation.Result) {
+	t.Helper()
+	r.StrictMatch = want.StrictMatch // to make diff pass
+	r.Skip = want.Skip               // to make diff pass
+	ignore := cmpopts.IgnoreFields(simulation.Result{}, "MTLSRequired", "Trace")
+	diff := cmp.Diff(want, r, ignore, cmpopts.EquateErrors())
+	if want.StrictMatch && diff != "" {
+		t.Errorf("Diff: %v", diff)
+		return
+	}
+	if want.Error != r.Error {
+		t.Errorf("want error %v got %v", want.Error, r.Error)
+	}
+	if want.ListenerMatched != "" && want.ListenerMatched != r.ListenerMatched {
+		t.Errorf("want listener matched %q got %q", want.ListenerMatched, r.ListenerMatched)
+	} else {
+		// Populate each field in case we did not care about it. This avoids confusing errors when we have fields
+		// we don't care about in the test that are present in the result.
+		want.ListenerMatched = r.ListenerMatched
+	}
+	if want.FilterChainMatched != "" && want.FilterChainMatched != r.FilterChainMatched {
+		t.Errorf("want filter chain matched %q got %q", want.FilterChainMatched, r.FilterChainMatched)
+	} else {
+		want.FilterChainMatched = r.FilterChainMatched
+	}
+	if want.RouteMatched != "" && want.RouteMatched != r.RouteMatched {
+		t.Errorf("want route matched %q got %q", want.RouteMatched, r.RouteMatched)
+	} else {
+		want.RouteMatched = r.RouteMatched
+	}
+	if want.RouteConfigMatched != "" && want.RouteConfigMatched != r.RouteConfigMatched {
+		t.Errorf("want route config matched %q got %q", want.RouteConfigMatched, r.RouteConfigMatched)
+	} else {
+		want.RouteConfigMatched = r.RouteConfigMatched
+	}
+	if want.VirtualHostMatched != "" && want.VirtualHostMatched != r.VirtualHostMatched {
+		t.Errorf("want virtual host matched %q got %q", want.VirtualHostMatched, r.VirtualHostMatched)
+	} else {
+		want.VirtualHostMatched = r.VirtualHostMatched
+	}
+	if want.ClusterMatched != "" && want.ClusterMatched != r.ClusterMatched {
+		t.Errorf("want cluster matched %q got %q", want.ClusterMatched, r.ClusterMatched)
+	} else {
+		want.ClusterMatched = r.ClusterMatched
+	}
+	if t.Failed() {
+		t.Logf("Diff: %+v", diff)
+		t.Logf("Full Diff: %+v", cmp.Diff(want, r, ignore, cmpopts.EquateErrors()))
+		t.Logf("Trace: %v", strings.Join(r.Trace, "\n"))
+	} else if want.Skip != "" {
+		t.Skipf("Known bug: %v", r.Skip)
+	}
+}
+
 func createGateway(name, namespace string, servers ...string) string {
 	if name == "" {
 		name = "default"
@@ -23,12 +23,14 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	udp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
 	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/durationpb"
 	wrappers "google.golang.org/protobuf/types/known/wrapperspb"
+	"k8s.io/apimachinery/pkg/types"
 
 	extensions "istio.io/api/extensions/v1alpha1"
 	meshconfig "istio.io/api/mesh/v1alpha1"
@@ -45,6 +47,7 @@ import (
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pilot/test/xdstest"
 	config "istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/protocol"
@@ -3349,6 +3352,59 @@ func TestBuildGatewayListeners(t *testing.T) {
 			},
 			[]string{"10.0.0.1_443", "10.0.0.2_443"},
 		},
+		{
+			"gateway with UDP and TCP servers on the same port",
+			&pilot_model.Proxy{},
+			[]config.Config{
+				{
+					Meta: config.Meta{Name: "gateway1", Namespace: "testns", GroupVersionKind: gvk.Gateway},
+					Spec: &networking.Gateway{
+						Servers: []*networking.Server{
+							{
+								Port:  &networking.Port{Name: "udp", Number: 53, Protocol: "UDP"},
+								Hosts: []string{"*"},
+							},
+							{
+								Port:  &networking.Port{Name: "tcp", Number: 53, Protocol: "TCP"},
+								Hosts: []string{"*"},
+							},
+						},
+					},
+				},
+			},
+			[]config.Config{
+				{
+					Meta: config.Meta{Name: uuid.NewString(), Namespace: uuid.NewString(), GroupVersionKind: gvk.VirtualService},
+					Spec: &networking.VirtualService{
+						Gateways: []string{"testns/gateway1"},
+						Hosts:    []string{"*"},
+						Tcp: []*networking.TCPRoute{{
+							Route: []*networking.RouteDestination{{Destination: &networking.Destination{Host: "example.com"}}},
+						}},
+					},
+				},
+			},
+			[]string{"0.0.0.0_53", "udp_0.0.0.0_53"},
+		},
+		{
+			"gateway with UDP server without routes",
+			&pilot_model.Proxy{},
+			[]config.Config{
+				{
+					Meta: config.Meta{Name: "gateway1", Namespace: "testns", GroupVersionKind: gvk.Gateway},
+					Spec: &networking.Gateway{
+						Servers: []*networking.Server{
+							{
+								Port:  &networking.Port{Name: "udp", Number: 53, Protocol: "UDP"},
+								Hosts: []string{"*"},
+							},
+						},
+					},
+				},
+			},
+			nil,
+			[]string{},
+		},
 	}
 
 	for _, tt := range cases {
@@ -3386,6 +3442,24 @@ func TestBuildGatewayListeners(t *testing.T) {
 	}
 }
 
+func TestBuildUDPProxy(t *testing.T) {
+	cg := NewConfigGenTest(t, TestOptions{})
+	lb := NewListenerBuilder(cg.SetupProxy(nil), cg.PushContext())
+	udpProxy := lb.buildUDPProxy([]*networking.RouteDestination{
+		{Destination: &networking.Destination{Host: "a.example.com"}, Weight: 20},
+		{Destination: &networking.Destination{Host: "b.example.com"}, Weight: 80},
+	}, &pilot_model.Port{Name: "udp", Port: 53, Protocol: protocol.UDP})
+
+	r := &udp.Route{}
+	if err := udpProxy.GetMatcher().GetOnNoMatch().GetAction().GetTypedConfig().UnmarshalTo(r); err != nil {
+		t.Fatal(err)
+	}
+	// udp_proxy does not support weighted clusters, so the destination with the highest weight should be used.
+	if r.Cluster != "outbound|53||b.example.com" {
+		t.Fatalf("expected cluster outbound|53||b.example.com, got %v", r.Cluster)
+	}
+}
+
 func TestBuildNameToServiceMapForHttpRoutes(t *testing.T) {
 	virtualServiceSpec := &networking.VirtualService{
 		Hosts: []string{"*"},
@@ -4442,3 +4516,92 @@ func TestGatewayHCMInternalAddressConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGatewayRouteWasmPlugins(t *testing.T) {
+	cg := NewConfigGenTest(t, TestOptions{
+		Configs: []config.Config{
+			{
+				Meta: config.Meta{Name: "gateway", Namespace: "testns", GroupVersionKind: gvk.Gateway},
+				Spec: &networking.Gateway{
+					Servers: []*networking.Server{
+						{
+							Port:  &networking.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+							Hosts: []string{"*"},
+						},
+					},
+				},
+			},
+			{
+				Meta: config.Meta{
+					Name:             "vs",
+					Namespace:        "default",
+					GroupVersionKind: gvk.VirtualService,
+				},
+				Extra: map[string]any{
+					constants.InternalRouteExtensions: pilot_model.RouteExtensionRefs{
+						"with-plugin": {{Namespace: "default", Name: "auth"}},
+					},
+				},
+				Spec: &networking.VirtualService{
+					Gateways: []string{"testns/gateway"},
+					Hosts:    []string{"example.org"},
+					Http: []*networking.HTTPRoute{
+						{
+							Name:  "with-plugin",
+							Match: []*networking.HTTPMatchRequest{{Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: "/auth"}}}},
+							Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "example.org"}}},
+						},
+						{
+							Name:  "without-plugin",
+							Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "example.org"}}},
+						},
+					},
+				},
+			},
+			{
+				Meta: config.Meta{Name: "auth", Namespace: "default", GroupVersionKind: gvk.WasmPlugin},
+				Spec: &extensions.WasmPlugin{
+					Type: extensions.PluginType_HTTP,
+				},
+			},
+		},
+	})
+	proxy := cg.SetupProxy(&proxyGateway)
+	resourceName := pilot_model.WasmPluginResourceName(types.NamespacedName{Namespace: "default", Name: "auth"})
+
+	// The plugin is inserted in the HTTP filter chain, but disabled
+	builder := cg.ConfigGen.buildGatewayListeners(NewListenerBuilder(proxy, cg.PushContext()))
+	l := xdstest.ExtractListener("0.0.0.0_80", builder.gatewayListeners)
+	if l == nil {
+		t.Fatal("expected listener 0.0.0.0_80")
+	}
+	connectionManager := xdstest.ExtractHTTPConnectionManager(t, l.FilterChains[0])
+	found := false
+	for _, f := range connectionManager.HttpFilters {
+		if f.Name == resourceName {
+			found = true
+			if !f.Disabled {
+				t.Errorf("expected filter %v to be disabled", resourceName)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected filter %v in %v", resourceName, connectionManager.HttpFilters)
+	}
+
+	// The plugin can be fetched over ECDS, even though it is not in the gateway namespace
+	if got := cg.PushContext().WasmPluginsByName(proxy, []types.NamespacedName{{Namespace: "default", Name: "auth"}}); len(got) != 1 {
+		t.Errorf("expected plugin to be allowed, got %v", got)
+	}
+
+	// The plugin is only enabled on the route referencing it
+	r := cg.ConfigGen.buildGatewayHTTPRouteConfig(proxy, cg.PushContext(), "http.80")
+	for _, vh := range r.VirtualHosts {
+		for _, rt := range vh.Routes {
+			_, enabled := rt.TypedPerFilterConfig[resourceName]
+			if want := rt.Name == "with-plugin"; enabled != want {
+				t.Errorf("route %v: expected plugin enabled %v, got %v", rt.Name, want, enabled)
+			}
+		}
+	}
+}
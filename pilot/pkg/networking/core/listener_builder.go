@@ -43,6 +43,7 @@ import (
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/proto"
+	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/pkg/wellknown"
 )
 
@@ -375,6 +376,10 @@ func (lb *ListenerBuilder) buildHTTPConnectionManager(httpOpts *httpListenerOpts
 			Port:  httpOpts.port,
 			Class: httpOpts.class,
 		}, model.WasmPluginTypeHTTP)
+		var routeWasm sets.String
+		if httpOpts.class == istionetworking.ListenerClassGateway {
+			routeWasm = lb.push.RouteWasmPlugins(lb.node, wasm)
+		}
 
 		// Metadata exchange filter needs to be added before any other HTTP filters are added. This is done to
 		// ensure that mx filter comes before HTTP RBAC filter. This is related to https://github.com/istio/istio/issues/41066
@@ -388,6 +393,12 @@ func (lb *ListenerBuilder) buildHTTPConnectionManager(httpOpts *httpListenerOpts
 		// TODO: these feel like the wrong place to insert, but this retains backwards compatibility with the original implementation
 		filters = extension.PopAppendHTTP(filters, wasm, extensions.PluginPhase_STATS)
 		filters = extension.PopAppendHTTP(filters, wasm, extensions.PluginPhase_UNSPECIFIED_PHASE)
+		// Plugins referenced only by some routes are disabled by default, and enabled on those routes.
+		for _, f := range filters {
+			if routeWasm.Contains(f.Name) {
+				f.Disabled = true
+			}
+		}
 	}
 
 	if httpOpts.protocol == protocol.GRPCWeb {
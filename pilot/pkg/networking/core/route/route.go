@@ -514,6 +514,14 @@ func translateRoute(
 	if in.CorsPolicy != nil {
 		out.TypedPerFilterConfig[wellknown.CORS] = protoconv.MessageToAny(TranslateCORSPolicy(node, in.CorsPolicy))
 	}
+	// Enable the WasmPlugins referenced by this route through gateway-api ExtensionRef filters. These are disabled
+	// by default on the listener, as they only apply to the routes referencing them.
+	for _, ext := range model.RouteExtensions(virtualService)[in.Name] {
+		if out.TypedPerFilterConfig == nil {
+			out.TypedPerFilterConfig = make(map[string]*anypb.Any)
+		}
+		out.TypedPerFilterConfig[model.WasmPluginResourceName(ext)] = protoconv.MessageToAny(&route.FilterConfig{IsOptional: true})
+	}
 	var statefulConfig *statefulsession.StatefulSession
 	for _, hostname := range hostnames {
 		perSvcStatefulConfig := util.MaybeBuildStatefulSessionFilterConfig(serviceRegistry[hostname])
@@ -403,7 +403,7 @@ func TestInboundClusters(t *testing.T) {
 					return m
 				}(),
 			})
-			sim := simulation.NewSimulationFromConfigGen(t, s, s.SetupProxy(tt.proxy))
+			sim := simulation.NewSimulationFromConfigGen(s, s.SetupProxy(tt.proxy))
 
 			clusters := xdstest.FilterClusters(sim.Clusters, func(c *cluster.Cluster) bool {
 				return strings.HasPrefix(c.Name, "inbound")
@@ -444,12 +444,12 @@ func TestInboundClusters(t *testing.T) {
 						}
 					}
 				}
-				sim.Run(simulation.Call{
+				assertResult(t, sim.Run(simulation.Call{
 					Port:     port,
 					Protocol: simulation.HTTP,
 					Address:  "1.2.3.4",
 					CallMode: simulation.CallModeInbound,
-				}).Matches(t, simulation.Result{
+				}), simulation.Result{
 					ClusterMatched: cname,
 				})
 			}
@@ -2626,7 +2626,7 @@ spec:
 						Configs:           istio,
 						KubernetesObjects: kubeo,
 					})
-					sim := simulation.NewSimulation(t, s, s.SetupProxy(tt.proxy))
+					sim := simulation.NewSimulationFromConfigGen(s.ConfigGenTest, s.SetupProxy(tt.proxy))
 					xdstest.ValidateListeners(t, sim.Listeners)
 					xdstest.ValidateRouteConfigurations(t, sim.Routes)
 					r := xdstest.ExtractRouteConfigurations(sim.Routes)
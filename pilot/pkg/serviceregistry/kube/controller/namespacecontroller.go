@@ -137,7 +137,14 @@ func (nc *NamespaceController) reconcileCACert(o types.NamespacedName) error {
 		Namespace: ns,
 		Labels:    configMapLabel,
 	}
-	return k8s.InsertDataToConfigMap(nc.configmaps, meta, nc.caBundleWatcher.GetCABundle())
+	data := map[string]string{
+		constants.CACertNamespaceConfigMapDataName: string(nc.caBundleWatcher.GetCABundle()),
+	}
+	if features.EnableCACRL {
+		// The CRL is cleared once no unexpired certificate is revoked.
+		data[constants.CACRLNamespaceConfigMapDataName] = string(nc.caBundleWatcher.GetCRL())
+	}
+	return k8s.InsertDataToConfigMap(nc.configmaps, meta, data)
 }
 
 // On namespace change, update the config map.
@@ -26,6 +26,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/keycertbundle"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/mesh"
@@ -86,6 +87,39 @@ func TestNamespaceController(t *testing.T) {
 	}
 }
 
+func TestNamespaceControllerCRL(t *testing.T) {
+	test.SetForTest(t, &features.EnableCACRL, true)
+	client := kube.NewFakeClient()
+	t.Cleanup(client.Shutdown)
+	watcher := keycertbundle.NewWatcher()
+	caBundle := []byte("caBundle")
+	watcher.SetAndNotify(nil, nil, caBundle)
+	stop := test.NewStop(t)
+	nc := NewNamespaceController(client, watcher)
+	client.RunAndWait(stop)
+	go nc.Run(stop)
+	retry.UntilOrFail(t, nc.queue.HasSynced)
+
+	createNamespace(t, client.Kube(), "foo", nil)
+	expectConfigMap(t, nc.configmaps, CACertNamespaceConfigMap, "foo", map[string]string{
+		constants.CACertNamespaceConfigMapDataName: string(caBundle),
+		constants.CACRLNamespaceConfigMapDataName:  "",
+	})
+
+	crl := []byte("crl")
+	watcher.SetCRLAndNotify(crl)
+	expectConfigMap(t, nc.configmaps, CACertNamespaceConfigMap, "foo", map[string]string{
+		constants.CACertNamespaceConfigMapDataName: string(caBundle),
+		constants.CACRLNamespaceConfigMapDataName:  string(crl),
+	})
+
+	watcher.SetCRLAndNotify(nil)
+	expectConfigMap(t, nc.configmaps, CACertNamespaceConfigMap, "foo", map[string]string{
+		constants.CACertNamespaceConfigMapDataName: string(caBundle),
+		constants.CACRLNamespaceConfigMapDataName:  "",
+	})
+}
+
 func TestNamespaceControllerWithDiscoverySelectors(t *testing.T) {
 	client := kube.NewFakeClient()
 	t.Cleanup(client.Shutdown)
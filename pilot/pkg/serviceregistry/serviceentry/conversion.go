@@ -262,6 +262,7 @@ func convertServices(cfg config.Config, clusterID cluster.ID) []*model.Service {
 				K8sAttributes:          model.K8sAttributes{ObjectName: cfg.Name},
 			},
 			ServiceAccounts: serviceEntry.SubjectAltNames,
+			ResourceVersion: cfg.ResourceVersion,
 		}
 		if ha.autoAssignedV4 != "" {
 			svc.AutoAllocatedIPv4Address = ha.autoAssignedV4
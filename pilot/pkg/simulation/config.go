@@ -0,0 +1,143 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"fmt"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	memregistry "istio.io/istio/pilot/pkg/serviceregistry/memory"
+	"istio.io/istio/pilot/pkg/serviceregistry/provider"
+	"istio.io/istio/pilot/pkg/serviceregistry/serviceentry"
+	istiocluster "istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/kube"
+)
+
+// NewSimulationFromConfigs generates the configuration of the proxy from the Istio configuration and services,
+// as istiod would, and builds a Simulation from it. The proxy is initialized with the defaults of the generated
+// environment, so only its type, namespace, labels and IP addresses need to be set.
+func NewSimulationFromConfigs(configs []config.Config, services []*model.Service, meshConfig *meshconfig.MeshConfig,
+	proxy *model.Proxy,
+) (*Simulation, error) {
+	if meshConfig == nil {
+		meshConfig = mesh.DefaultMeshConfig()
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	store := memory.NewSyncController(memory.MakeSkipValidation(collections.PilotGatewayAPI()))
+	env := model.NewEnvironment()
+	env.Watcher = mesh.NewFixedWatcher(meshConfig)
+	xdsUpdater := model.NewEndpointIndexUpdater(env.EndpointIndex)
+
+	serviceDiscovery := aggregate.NewController(aggregate.Options{})
+	se := serviceentry.NewController(store, xdsUpdater, env.Watcher)
+	serviceDiscovery.AddRegistry(se)
+	msd := memregistry.NewServiceDiscovery(services...)
+	msd.XdsUpdater = xdsUpdater
+	msd.ClusterID = istiocluster.ID(provider.Mock)
+	serviceDiscovery.AddRegistry(serviceregistry.Simple{
+		ClusterID:           istiocluster.ID(provider.Mock),
+		ProviderID:          provider.Mock,
+		DiscoveryController: msd,
+	})
+	env.ServiceDiscovery = serviceDiscovery
+	env.ConfigStore = store
+	env.NetworksWatcher = mesh.NewFixedNetworksWatcher(nil)
+	env.Init()
+
+	go serviceDiscovery.Run(stop)
+	go store.Run(stop)
+	for _, cfg := range configs {
+		if _, err := store.Create(cfg); err != nil {
+			return nil, fmt.Errorf("failed to create config %v/%v: %v", cfg.Namespace, cfg.Name, err)
+		}
+	}
+	if !kube.WaitForCacheSync("simulation", stop, store.HasSynced, serviceDiscovery.HasSynced) {
+		return nil, fmt.Errorf("failed to sync configuration")
+	}
+	se.ResyncEDS()
+	if err := env.InitNetworksManager(xdsUpdater); err != nil {
+		return nil, err
+	}
+	push := env.PushContext()
+	if err := push.InitContext(env, nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to initialize push context: %v", err)
+	}
+
+	setupProxy(proxy, env, push)
+	cg := core.NewConfigGenerator(&model.DisabledCache{})
+	listeners := cg.BuildListeners(proxy, push)
+	req := &model.PushRequest{Push: push}
+	sim := &Simulation{Listeners: listeners}
+	rawClusters, _ := cg.BuildClusters(proxy, req)
+	for _, r := range rawClusters {
+		c := &cluster.Cluster{}
+		if err := r.Resource.UnmarshalTo(c); err != nil {
+			return nil, err
+		}
+		sim.Clusters = append(sim.Clusters, c)
+	}
+	rawRoutes, _ := cg.BuildHTTPRoutes(proxy, req, core.ExtractRoutesFromListeners(listeners))
+	for _, r := range rawRoutes {
+		rc := &route.RouteConfiguration{}
+		if err := r.Resource.UnmarshalTo(rc); err != nil {
+			return nil, err
+		}
+		sim.Routes = append(sim.Routes, rc)
+	}
+	return sim, nil
+}
+
+// setupProxy fills the defaults of the proxy, and initializes it for the push context.
+func setupProxy(p *model.Proxy, env *model.Environment, push *model.PushContext) {
+	if p.Metadata == nil {
+		p.Metadata = &model.NodeMetadata{}
+	}
+	if p.IstioVersion == nil {
+		p.IstioVersion = model.ParseIstioVersion(p.Metadata.IstioVersion)
+	}
+	if p.Type == "" {
+		p.Type = model.SidecarProxy
+	}
+	if p.ConfigNamespace == "" {
+		p.ConfigNamespace = "default"
+	}
+	if p.Metadata.Namespace == "" {
+		p.Metadata.Namespace = p.ConfigNamespace
+	}
+	if p.ID == "" {
+		p.ID = "simulation." + p.ConfigNamespace
+	}
+	if p.DNSDomain == "" {
+		p.DNSDomain = p.ConfigNamespace + ".svc." + constants.DefaultClusterLocalDomain
+	}
+	p.SetSidecarScope(push)
+	p.SetServiceTargets(env.ServiceDiscovery)
+	p.SetGatewaysForProxy(push)
+	p.DiscoverIPMode()
+}
@@ -0,0 +1,67 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+)
+
+func extractListener(name string, ll []*listener.Listener) *listener.Listener {
+	for _, l := range ll {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+func extractRouteConfigurations(rc []*route.RouteConfiguration) map[string]*route.RouteConfiguration {
+	res := map[string]*route.RouteConfiguration{}
+	for _, l := range rc {
+		res[l.Name] = l
+	}
+	return res
+}
+
+func extractListenerFilters(l *listener.Listener) map[string]*listener.ListenerFilter {
+	res := map[string]*listener.ListenerFilter{}
+	for _, lf := range l.ListenerFilters {
+		res[lf.Name] = lf
+	}
+	return res
+}
+
+// evaluateListenerFilterPredicates runs through the ListenerFilterChainMatchPredicate logic.
+// Unsupported predicates never match.
+func evaluateListenerFilterPredicates(predicate *listener.ListenerFilterChainMatchPredicate, port int) bool {
+	if predicate == nil {
+		return true
+	}
+	switch r := predicate.Rule.(type) {
+	case *listener.ListenerFilterChainMatchPredicate_NotMatch:
+		return !evaluateListenerFilterPredicates(r.NotMatch, port)
+	case *listener.ListenerFilterChainMatchPredicate_OrMatch:
+		matches := false
+		for _, r := range r.OrMatch.Rules {
+			matches = matches || evaluateListenerFilterPredicates(r, port)
+		}
+		return matches
+	case *listener.ListenerFilterChainMatchPredicate_DestinationPortRange:
+		return int32(port) >= r.DestinationPortRange.GetStart() && int32(port) < r.DestinationPortRange.GetEnd()
+	default:
+		return false
+	}
+}
@@ -24,28 +24,25 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
-	"testing"
 
 	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoycore "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	tcpproxy "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
-	"github.com/google/go-cmp/cmp"
-	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/core"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
-	"istio.io/istio/pilot/test/xds"
-	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config/host"
 	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/maps"
 	"istio.io/istio/pkg/slices"
-	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/wellknown"
 )
 
 var log = istiolog.RegisterScope("simulation", "")
@@ -168,6 +165,11 @@ type Result struct {
 	RouteConfigMatched string
 	VirtualHostMatched string
 	ClusterMatched     string
+	// MTLSRequired reports whether the matched filter chain only accepts mTLS traffic
+	MTLSRequired bool
+	// Trace records the decisions made while evaluating the call, in order. This explains why a given
+	// listener, filter chain, route, and cluster were matched.
+	Trace []string
 	// StrictMatch controls whether we will strictly match the result. If unset, empty fields will
 	// be ignored, allowing testing only fields we care about This allows asserting that the result
 	// is *exactly* equal, allowing asserting a field is empty
@@ -176,72 +178,23 @@ type Result struct {
 	// if we pass the test. This is to ensure that if the behavior changes, we still capture it; the skip
 	// just ensures we notice a test is wrong
 	Skip string
-	t    test.Failer
 }
 
-func (r Result) Matches(t *testing.T, want Result) {
-	t.Helper()
-	r.StrictMatch = want.StrictMatch // to make diff pass
-	r.Skip = want.Skip               // to make diff pass
-	diff := cmp.Diff(want, r, cmpopts.IgnoreUnexported(Result{}), cmpopts.EquateErrors())
-	if want.StrictMatch && diff != "" {
-		t.Errorf("Diff: %v", diff)
-		return
-	}
-	if want.Error != r.Error {
-		t.Errorf("want error %v got %v", want.Error, r.Error)
-	}
-	if want.ListenerMatched != "" && want.ListenerMatched != r.ListenerMatched {
-		t.Errorf("want listener matched %q got %q", want.ListenerMatched, r.ListenerMatched)
-	} else {
-		// Populate each field in case we did not care about it. This avoids confusing errors when we have fields
-		// we don't care about in the test that are present in the result.
-		want.ListenerMatched = r.ListenerMatched
-	}
-	if want.FilterChainMatched != "" && want.FilterChainMatched != r.FilterChainMatched {
-		t.Errorf("want filter chain matched %q got %q", want.FilterChainMatched, r.FilterChainMatched)
-	} else {
-		want.FilterChainMatched = r.FilterChainMatched
-	}
-	if want.RouteMatched != "" && want.RouteMatched != r.RouteMatched {
-		t.Errorf("want route matched %q got %q", want.RouteMatched, r.RouteMatched)
-	} else {
-		want.RouteMatched = r.RouteMatched
-	}
-	if want.RouteConfigMatched != "" && want.RouteConfigMatched != r.RouteConfigMatched {
-		t.Errorf("want route config matched %q got %q", want.RouteConfigMatched, r.RouteConfigMatched)
-	} else {
-		want.RouteConfigMatched = r.RouteConfigMatched
-	}
-	if want.VirtualHostMatched != "" && want.VirtualHostMatched != r.VirtualHostMatched {
-		t.Errorf("want virtual host matched %q got %q", want.VirtualHostMatched, r.VirtualHostMatched)
-	} else {
-		want.VirtualHostMatched = r.VirtualHostMatched
-	}
-	if want.ClusterMatched != "" && want.ClusterMatched != r.ClusterMatched {
-		t.Errorf("want cluster matched %q got %q", want.ClusterMatched, r.ClusterMatched)
-	} else {
-		want.ClusterMatched = r.ClusterMatched
-	}
-	if t.Failed() {
-		t.Logf("Diff: %+v", diff)
-		t.Logf("Full Diff: %+v", cmp.Diff(want, r, cmpopts.IgnoreUnexported(Result{}), cmpopts.EquateErrors()))
-	} else if want.Skip != "" {
-		t.Skipf("Known bug: %v", r.Skip)
-	}
+func (r *Result) tracef(format string, args ...any) {
+	r.Trace = append(r.Trace, fmt.Sprintf(format, args...))
 }
 
+// Simulation evaluates calls against a set of generated listeners, routes, and clusters.
 type Simulation struct {
-	t         *testing.T
 	Listeners []*listener.Listener
 	Clusters  []*cluster.Cluster
 	Routes    []*route.RouteConfiguration
 }
 
-func NewSimulationFromConfigGen(t *testing.T, s *core.ConfigGenTest, proxy *model.Proxy) *Simulation {
+// NewSimulationFromConfigGen builds a Simulation from the configuration generated for the proxy.
+func NewSimulationFromConfigGen(s *core.ConfigGenTest, proxy *model.Proxy) *Simulation {
 	l := s.Listeners(proxy)
 	sim := &Simulation{
-		t:         t,
 		Listeners: l,
 		Clusters:  s.Clusters(proxy),
 		Routes:    s.RoutesFromListeners(proxy, l),
@@ -249,43 +202,27 @@ func NewSimulationFromConfigGen(t *testing.T, s *core.ConfigGenTest, proxy *mode
 	return sim
 }
 
-func NewSimulation(t *testing.T, s *xds.FakeDiscoveryServer, proxy *model.Proxy) *Simulation {
-	return NewSimulationFromConfigGen(t, s.ConfigGenTest, proxy)
-}
-
-// withT swaps out the testing struct. This allows executing sub tests.
-func (sim *Simulation) withT(t *testing.T) *Simulation {
-	cpy := *sim
-	cpy.t = t
-	return &cpy
-}
-
-func (sim *Simulation) RunExpectations(es []Expect) {
-	for _, e := range es {
-		sim.t.Run(e.Name, func(t *testing.T) {
-			sim.withT(t).Run(e.Call).Matches(t, e.Result)
-		})
-	}
-}
-
 func hasFilterOnPort(l *listener.Listener, filter string, port int) bool {
-	got, f := xdstest.ExtractListenerFilters(l)[filter]
+	got, f := extractListenerFilters(l)[filter]
 	if !f {
 		return false
 	}
 	if got.FilterDisabled == nil {
 		return true
 	}
-	return !xdstest.EvaluateListenerFilterPredicates(got.FilterDisabled, port)
+	return !evaluateListenerFilterPredicates(got.FilterDisabled, port)
 }
 
 func (sim *Simulation) Run(input Call) (result Result) {
-	result = Result{t: sim.t}
 	input = input.FillDefaults()
 	if input.Alpn != "" && input.TLS == Plaintext {
 		result.Error = fmt.Errorf("invalid call, ALPN can only be sent in TLS requests")
 		return result
 	}
+	if _, err := netip.ParseAddr(input.Address); err != nil {
+		result.Error = fmt.Errorf("invalid call, address %q is not an IP address", input.Address)
+		return result
+	}
 
 	// First we will match a listener
 	l := matchListener(sim.Listeners, input)
@@ -294,29 +231,38 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		return
 	}
 	result.ListenerMatched = l.Name
+	result.tracef("matched listener %v for %v:%v (call mode %q)", l.Name, input.Address, input.Port, input.CallMode)
 
 	hasTLSInspector := hasFilterOnPort(l, xdsfilters.TLSInspector.Name, input.Port)
 	if !hasTLSInspector {
 		// Without tls inspector, Envoy would not read the ALPN in the TLS handshake
 		// HTTP inspector still may set it though
 		input.Alpn = ""
+		result.tracef("no TLS inspector on port %v, the transport protocol is not inspected", input.Port)
 	}
 
 	// Apply listener filters
 	if hasFilterOnPort(l, xdsfilters.HTTPInspector.Name, input.Port) {
 		if alpn := protocolToAlpn(input.Protocol); alpn != "" && input.TLS == Plaintext {
 			input.Alpn = alpn
+			result.tracef("HTTP inspector detected application protocol %q", alpn)
 		}
 	}
 
-	fc, err := sim.matchFilterChain(l.FilterChains, l.DefaultFilterChain, input, hasTLSInspector)
+	fc, err := sim.matchFilterChain(l.FilterChains, l.DefaultFilterChain, input, hasTLSInspector, &result)
 	if err != nil {
 		result.Error = err
 		return
 	}
 	result.FilterChainMatched = fc.Name
+	if fc == l.DefaultFilterChain {
+		result.tracef("matched default filter chain %q", fc.Name)
+	} else {
+		result.tracef("matched filter chain %q", fc.Name)
+	}
 	// Plaintext to TLS is an error
 	if fc.TransportSocket != nil && input.TLS == Plaintext {
+		result.tracef("filter chain terminates TLS, but the call is plaintext")
 		result.Error = ErrTLSError
 		return
 	}
@@ -327,10 +273,22 @@ func (sim *Simulation) Run(input Call) (result Result) {
 	}
 
 	// mTLS listener will only accept mTLS traffic
-	if fc.TransportSocket != nil && sim.requiresMTLS(fc, mTLSSecretConfigName) != (input.TLS == MTLS) {
-		// If there is no tls inspector, then
-		result.Error = ErrMTLSError
-		return
+	if fc.TransportSocket != nil {
+		required, err := requiresMTLS(fc, mTLSSecretConfigName)
+		if err != nil {
+			result.Error = err
+			return
+		}
+		result.MTLSRequired = required
+		if required {
+			result.tracef("filter chain requires mTLS: it presents the %q certificate and requires a client certificate", mTLSSecretConfigName)
+		} else {
+			result.tracef("filter chain terminates TLS, but does not require mTLS")
+		}
+		if required != (input.TLS == MTLS) {
+			result.Error = ErrMTLSError
+			return
+		}
 	}
 
 	if len(input.CustomListenerValidations) > 0 {
@@ -341,25 +299,38 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		}
 	}
 
-	if hcm := xdstest.ExtractHTTPConnectionManager(sim.t, fc); hcm != nil {
+	httpConnectionManager, err := extractHTTPConnectionManager(fc)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	tcp, err := extractTCPProxy(fc)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	if httpConnectionManager != nil {
 		// We matched HCM and didn't terminate TLS, but we are sending TLS traffic - decoding will fail
 		if input.TLS != Plaintext && fc.TransportSocket == nil {
+			result.tracef("filter chain expects HTTP, but the call is %v", input.TLS)
 			result.Error = ErrProtocolError
 			return
 		}
 		// TCP to HCM is invalid
 		if input.Protocol != HTTP && input.Protocol != HTTP2 {
+			result.tracef("filter chain expects HTTP, but the call is %v", input.Protocol)
 			result.Error = ErrProtocolError
 			return
 		}
 
 		// Fetch inline route
-		rc := hcm.GetRouteConfig()
+		rc := httpConnectionManager.GetRouteConfig()
 		if rc == nil {
 			// If not set, fallback to RDS
-			routeName := hcm.GetRds().RouteConfigName
+			routeName := httpConnectionManager.GetRds().RouteConfigName
 			result.RouteConfigMatched = routeName
-			rc = xdstest.ExtractRouteConfigurations(sim.Routes)[routeName]
+			rc = extractRouteConfigurations(sim.Routes)[routeName]
+			result.tracef("using route configuration %q", routeName)
 		}
 		hostHeader := ""
 		if len(input.Headers["Host"]) > 0 {
@@ -371,50 +342,94 @@ func (sim *Simulation) Run(input Call) (result Result) {
 			return
 		}
 		result.VirtualHostMatched = vh.Name
+		result.tracef("matched virtual host %q for host %q", vh.Name, hostHeader)
 		if vh.RequireTls == route.VirtualHost_ALL && input.TLS == Plaintext {
 			result.Error = ErrTLSRedirect
 			return
 		}
 
-		r := sim.matchRoute(vh, input)
+		r, err := sim.matchRoute(vh, input)
+		if err != nil {
+			result.Error = err
+			return
+		}
 		if r == nil {
 			result.Error = ErrNoRoute
 			return
 		}
 		result.RouteMatched = r.Name
+		result.tracef("matched route %q for path %q", r.Name, input.Path)
 		switch t := r.GetAction().(type) {
 		case *route.Route_Route:
 			result.ClusterMatched = t.Route.GetCluster()
+			if wc := t.Route.GetWeightedClusters(); wc != nil {
+				result.tracef("route splits traffic between %d weighted clusters", len(wc.GetClusters()))
+			}
+		case *route.Route_DirectResponse:
+			result.tracef("route sends a direct response with status %d", t.DirectResponse.GetStatus())
+		case *route.Route_Redirect:
+			result.tracef("route sends a redirect")
 		}
-	} else if tcp := xdstest.ExtractTCPProxy(sim.t, fc); tcp != nil {
+	} else if tcp != nil {
 		result.ClusterMatched = tcp.GetCluster()
+		result.tracef("filter chain proxies TCP traffic")
 	}
 	return
 }
 
-func (sim *Simulation) requiresMTLS(fc *listener.FilterChain, mTLSSecretConfigName string) bool {
+func extractHTTPConnectionManager(fc *listener.FilterChain) (*hcm.HttpConnectionManager, error) {
+	for _, f := range fc.Filters {
+		if f.Name == wellknown.HTTPConnectionManager {
+			h := &hcm.HttpConnectionManager{}
+			if f.GetTypedConfig() != nil {
+				if err := f.GetTypedConfig().UnmarshalTo(h); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal hcm: %v", err)
+				}
+			}
+			return h, nil
+		}
+	}
+	return nil, nil
+}
+
+func extractTCPProxy(fc *listener.FilterChain) (*tcpproxy.TcpProxy, error) {
+	for _, f := range fc.Filters {
+		if f.Name == wellknown.TCPProxy {
+			tcpProxy := &tcpproxy.TcpProxy{}
+			if f.GetTypedConfig() != nil {
+				if err := f.GetTypedConfig().UnmarshalTo(tcpProxy); err != nil {
+					return nil, fmt.Errorf("failed to unmarshal tcp proxy: %v", err)
+				}
+			}
+			return tcpProxy, nil
+		}
+	}
+	return nil, nil
+}
+
+func requiresMTLS(fc *listener.FilterChain, mTLSSecretConfigName string) (bool, error) {
 	if fc.TransportSocket == nil {
-		return false
+		return false, nil
 	}
 	t := &tls.DownstreamTlsContext{}
 	if err := fc.GetTransportSocket().GetTypedConfig().UnmarshalTo(t); err != nil {
-		sim.t.Fatal(err)
+		return false, err
 	}
 
 	if len(t.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs()) == 0 {
-		return false
+		return false, nil
 	}
 	// This is a lazy heuristic, we could check for explicit default resource or spiffe if it becomes necessary
 	if t.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs()[0].Name != mTLSSecretConfigName {
-		return false
+		return false, nil
 	}
-	if !t.RequireClientCertificate.Value {
-		return false
+	if !t.RequireClientCertificate.GetValue() {
+		return false, nil
 	}
-	return true
+	return true, nil
 }
 
-func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Route {
+func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) (*route.Route, error) {
 	for _, r := range vh.Routes {
 		// check path
 		switch pt := r.Match.GetPathSpecifier().(type) {
@@ -433,20 +448,20 @@ func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Rout
 		case *route.RouteMatch_SafeRegex:
 			r, err := regexp.Compile(pt.SafeRegex.GetRegex())
 			if err != nil {
-				sim.t.Fatalf("invalid regex %v: %v", pt.SafeRegex.GetRegex(), err)
+				return nil, fmt.Errorf("invalid regex %v: %v", pt.SafeRegex.GetRegex(), err)
 			}
 			if !r.MatchString(input.Path) {
 				continue
 			}
 		default:
-			sim.t.Fatalf("unknown route path type %T", pt)
+			return nil, fmt.Errorf("unknown route path type %T", pt)
 		}
 
 		// TODO this only handles path - we need to add headers, query params, etc to be complete.
 
-		return r
+		return r, nil
 	}
-	return nil
+	return nil, nil
 }
 
 func (sim *Simulation) matchVirtualHost(rc *route.RouteConfiguration, host string) *route.VirtualHost {
@@ -514,18 +529,20 @@ func (sim *Simulation) matchVirtualHost(rc *route.RouteConfiguration, host strin
 // not match. This means an empty match (`{}`) may not match if another chain
 // matches one criteria but not another.
 func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultChain *listener.FilterChain,
-	input Call, hasTLSInspector bool,
+	input Call, hasTLSInspector bool, result *Result,
 ) (*listener.FilterChain, error) {
-	chains = filter("DestinationPort", chains, (*listener.FilterChainMatch).GetDestinationPort, func(port *wrapperspb.UInt32Value) bool {
+	var cidrErr error
+	chains = filter(result, "DestinationPort", chains, (*listener.FilterChainMatch).GetDestinationPort, func(port *wrapperspb.UInt32Value) bool {
 		return int(port.GetValue()) == input.Port
 	})
-	chains = filterRank("PrefixRanges", chains, (*listener.FilterChainMatch).GetPrefixRanges, func(ranges []*envoycore.CidrRange) int {
+	chains = filterRank(result, "PrefixRanges", chains, (*listener.FilterChainMatch).GetPrefixRanges, func(ranges []*envoycore.CidrRange) int {
 		best := 0
 		for _, a := range ranges {
 			s := fmt.Sprintf("%s/%d", a.AddressPrefix, a.GetPrefixLen().GetValue())
 			cidr, err := netip.ParsePrefix(s)
 			if err != nil {
-				sim.t.Fatalf("failed to parse cidr %v: %v", s, err)
+				cidrErr = fmt.Errorf("failed to parse cidr %v: %v", s, err)
+				continue
 			}
 			if cidr.Contains(netip.MustParseAddr(input.Address)) {
 				// Rank by how exact of a match it is. A /32 should match before a /8 even if they both match.
@@ -534,7 +551,10 @@ func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultC
 		}
 		return best
 	})
-	chains = filterRank("ServerNames", chains, (*listener.FilterChainMatch).GetServerNames, func(serverNames []string) int {
+	if cidrErr != nil {
+		return nil, cidrErr
+	}
+	chains = filterRank(result, "ServerNames", chains, (*listener.FilterChainMatch).GetServerNames, func(serverNames []string) int {
 		sni := host.Name(input.Sni)
 		best := 0
 		for _, s := range serverNames {
@@ -548,7 +568,7 @@ func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultC
 		}
 		return best
 	})
-	chains = filter("TransportProtocol", chains, (*listener.FilterChainMatch).GetTransportProtocol, func(transport string) bool {
+	chains = filter(result, "TransportProtocol", chains, (*listener.FilterChainMatch).GetTransportProtocol, func(transport string) bool {
 		if !hasTLSInspector {
 			// Without tls inspector, transport protocol will always be raw buffer
 			return transport == xdsfilters.RawBufferTransportProtocol
@@ -561,7 +581,7 @@ func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultC
 		}
 		return false
 	})
-	chains = filter("ApplicationProtocols", chains, (*listener.FilterChainMatch).GetApplicationProtocols, func(appProtocols []string) bool {
+	chains = filter(result, "ApplicationProtocols", chains, (*listener.FilterChainMatch).GetApplicationProtocols, func(appProtocols []string) bool {
 		return sets.New(appProtocols...).Contains(input.Alpn)
 	})
 	// We do not implement the "source" based filters as we do not use them
@@ -583,11 +603,11 @@ func (sim *Simulation) matchFilterChain(chains []*listener.FilterChain, defaultC
 
 // filter applies a FCM filtering expression.
 // extract gets the component of the FCM we are operating on. match declares if the request matches the chain.
-func filter[T any](desc string, chains []*listener.FilterChain,
+func filter[T any](result *Result, desc string, chains []*listener.FilterChain,
 	extract func(fc *listener.FilterChainMatch) T,
 	match func(field T) bool,
 ) []*listener.FilterChain {
-	return filterRank(desc, chains, extract, func(field T) int {
+	return filterRank(result, desc, chains, extract, func(field T) int {
 		if match(field) {
 			return 1
 		}
@@ -597,7 +617,7 @@ func filter[T any](desc string, chains []*listener.FilterChain,
 
 // filterRank allows an FCM to 'rank' how well the match is. The higher the rank, the better the match. Only the equivalently ranked matches win.
 // For instance, if my ranks are [0,1,2,2], the last 2 will remain.
-func filterRank[T any](desc string, chains []*listener.FilterChain,
+func filterRank[T any](result *Result, desc string, chains []*listener.FilterChain,
 	extract func(fc *listener.FilterChainMatch) T,
 	match func(field T) int,
 ) []*listener.FilterChain {
@@ -624,6 +644,7 @@ func filterRank[T any](desc string, chains []*listener.FilterChain,
 		res := resByRank[best]
 		// Return all matching filter chains
 		if len(res) > 0 {
+			result.tracef("%v: matched filter chains %v", desc, filterChainNames(res))
 			return res
 		}
 	}
@@ -636,9 +657,14 @@ func filterRank[T any](desc string, chains []*listener.FilterChain,
 			res = append(res, c)
 		}
 	}
+	result.tracef("%v: no filter chain matched, keeping filter chains without %v set %v", desc, desc, filterChainNames(res))
 	return res
 }
 
+func filterChainNames(chains []*listener.FilterChain) []string {
+	return slices.Map(chains, (*listener.FilterChain).GetName)
+}
+
 func protocolToMTLSAlpn(s Protocol) string {
 	switch s {
 	case HTTP:
@@ -674,7 +700,7 @@ func protocolToAlpn(s Protocol) string {
 
 func matchListener(listeners []*listener.Listener, input Call) *listener.Listener {
 	if input.CallMode == CallModeInbound {
-		return xdstest.ExtractListener(model.VirtualInboundListenerName, listeners)
+		return extractListener(model.VirtualInboundListenerName, listeners)
 	}
 	// First find exact match for the IP/Port, then fallback to wildcard IP/Port
 	// There is no wildcard port
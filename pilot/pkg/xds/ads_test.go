@@ -35,6 +35,7 @@ import (
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/pkg/test/util/retry"
 	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/tests/util/leak"
@@ -158,6 +159,25 @@ func TestVersionNonce(t *testing.T) {
 	}
 }
 
+func TestProxiesPendingAck(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+	ads := s.ConnectADS().WithType(v3.ClusterType)
+	ads.RequestResponseAck(t, nil)
+	count := s.Discovery.PushVersionCount()
+	assert.Equal(t, s.Discovery.ProxiesPendingAck(v3.ClusterType, count), []string{s.Discovery.AllClients()[0].ID()})
+	// The proxy does not watch PCDS, so it cannot acknowledge it.
+	assert.Equal(t, len(s.Discovery.ProxiesPendingAck(v3.ProxyConfigType, count)), 1)
+
+	fullPush(s)
+	resp := ads.ExpectResponse(t)
+	assert.Equal(t, len(s.Discovery.ProxiesPendingAck(v3.ClusterType, count)), 1)
+	ads.Request(t, &discovery.DiscoveryRequest{ResponseNonce: resp.Nonce, VersionInfo: resp.VersionInfo})
+	retry.UntilOrFail(t, func() bool {
+		return len(s.Discovery.ProxiesPendingAck(v3.ClusterType, count)) == 0
+	}, retry.Timeout(time.Second*5))
+	assert.Equal(t, s.Discovery.AllClients()[0].Proxy().VersionAcked(v3.ClusterType), resp.VersionInfo)
+}
+
 func TestAdsClusterUpdate(t *testing.T) {
 	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
 	ads := s.ConnectADS().WithType(v3.EndpointType)
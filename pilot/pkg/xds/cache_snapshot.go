@@ -0,0 +1,101 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/env"
+	"istio.io/istio/pkg/file"
+	"istio.io/istio/pkg/util/hash"
+	"istio.io/istio/pkg/version"
+)
+
+// SaveCacheSnapshot writes the XDS cache to the file, to be restored by RestoreCacheSnapshot after a restart.
+// It must be called once the server stopped serving, so that no entry is added concurrently.
+func (s *DiscoveryServer) SaveCacheSnapshot(path string) error {
+	snapshotter, ok := s.Cache.(model.XdsCacheSnapshotter)
+	if !ok {
+		return fmt.Errorf("the XDS cache does not support snapshots")
+	}
+	if inbound, committed := s.InboundUpdates.Load(), s.CommittedUpdates.Load(); inbound != committed {
+		// The cache may not be invalidated yet for the pending updates, so it may have stale entries.
+		return fmt.Errorf("%d config updates are not processed yet", inbound-committed)
+	}
+	t0 := time.Now()
+	buf := &bytes.Buffer{}
+	n, err := snapshotter.WriteSnapshot(buf, model.NewConfigFingerprints(s.Env), s.cacheGeneration())
+	if err != nil {
+		return err
+	}
+	if err := file.AtomicWrite(path, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	log.Infof("saved %d XDS cache entries to %s in %v", n, path, time.Since(t0))
+	return nil
+}
+
+// RestoreCacheSnapshot restores the XDS cache entries written by SaveCacheSnapshot, whose dependent configs
+// did not change since. It must be called once the config stores are synced, before serving.
+func (s *DiscoveryServer) RestoreCacheSnapshot(path string) error {
+	snapshotter, ok := s.Cache.(model.XdsCacheSnapshotter)
+	if !ok {
+		return fmt.Errorf("the XDS cache does not support snapshots")
+	}
+	t0 := time.Now()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		log.Infof("no XDS cache snapshot found at %s", path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	n, err := snapshotter.ReadSnapshot(f, model.NewConfigFingerprints(s.Env), s.cacheGeneration())
+	if err != nil {
+		return fmt.Errorf("failed to read XDS cache snapshot %s: %v", path, err)
+	}
+	log.Infof("restored %d XDS cache entries from %s in %v", n, path, time.Since(t0))
+	return nil
+}
+
+// cacheGeneration identifies the inputs of the generated XDS which are not tracked by the dependent configs
+// of the cache entries: the Istiod version, the registered environment variables and the mesh config.
+// Any registered variable may change the generated XDS, whatever its prefix.
+func (s *DiscoveryServer) cacheGeneration() string {
+	h := hash.New()
+	h.WriteString(version.Info.String())
+	for _, v := range env.VarDescriptions() {
+		value, set := os.LookupEnv(v.Name)
+		h.WriteString(v.Name + "=" + strconv.FormatBool(set) + ":" + value + "\n")
+	}
+	if mesh := s.Env.Mesh(); mesh != nil {
+		b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(mesh)
+		h.Write(b)
+	}
+	if networks := s.Env.MeshNetworks(); networks != nil {
+		b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(networks)
+		h.Write(b)
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
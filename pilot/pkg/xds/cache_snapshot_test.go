@@ -0,0 +1,127 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestCacheSnapshot(t *testing.T) {
+	config := `
+apiVersion: networking.istio.io/v1
+kind: ServiceEntry
+metadata:
+  name: se
+  namespace: default
+  resourceVersion: "1"
+spec:
+  hosts:
+  - a.example.com
+  ports:
+  - number: 80
+    name: http
+    protocol: HTTP
+  resolution: STATIC
+  endpoints:
+  - address: 1.1.1.1
+---
+apiVersion: networking.istio.io/v1
+kind: DestinationRule
+metadata:
+  name: dr
+  namespace: default
+  resourceVersion: "{{ .DestinationRuleVersion }}"
+spec:
+  host: a.example.com
+  trafficPolicy:
+    loadBalancer:
+      simple: ROUND_ROBIN
+`
+	path := filepath.Join(t.TempDir(), "cache")
+	newServer := func(drVersion string) *xds.FakeDiscoveryServer {
+		return xds.NewFakeDiscoveryServer(t, xds.FakeOptions{
+			ConfigString:        config,
+			ConfigTemplateInput: map[string]string{"DestinationRuleVersion": drVersion},
+		})
+	}
+
+	s := newServer("1")
+	ads := s.ConnectADS().WithType(v3.ClusterType)
+	ads.RequestResponseAck(t, nil)
+	// The clusters are cached by pushes.
+	s.Discovery.ConfigUpdate(&model.PushRequest{Full: true, Reason: model.NewReasonStats(model.UnknownTrigger)})
+	ads.ExpectResponse(t)
+	s.EnsureSynced(t)
+	saved := s.Discovery.Cache.Keys(model.CDSType)
+	assert.Equal(t, len(saved) > 0, true)
+	assert.NoError(t, s.Discovery.SaveCacheSnapshot(path))
+
+	t.Run("unchanged", func(t *testing.T) {
+		s := newServer("1")
+		assert.NoError(t, s.Discovery.RestoreCacheSnapshot(path))
+		assert.Equal(t, len(s.Discovery.Cache.Keys(model.CDSType)), len(saved))
+	})
+	t.Run("changed", func(t *testing.T) {
+		s := newServer("2")
+		assert.NoError(t, s.Discovery.RestoreCacheSnapshot(path))
+		assert.Equal(t, len(s.Discovery.Cache.Keys(model.CDSType)), 0)
+	})
+	t.Run("changed setting", func(t *testing.T) {
+		// The settings change the generated XDS whatever their prefix.
+		t.Setenv("ENABLE_ENHANCED_DESTINATIONRULE_MERGE", "false")
+		s := newServer("1")
+		assert.NoError(t, s.Discovery.RestoreCacheSnapshot(path))
+		assert.Equal(t, len(s.Discovery.Cache.Keys(model.CDSType)), 0)
+	})
+	t.Run("startup pushes", func(t *testing.T) {
+		s := newServer("1")
+		assert.NoError(t, s.Discovery.RestoreCacheSnapshot(path))
+		snapshotter := s.Discovery.Cache.(model.XdsCacheSnapshotter)
+		push := func(configs ...model.ConfigKey) {
+			s.Discovery.ConfigUpdate(&model.PushRequest{Full: true, ConfigsUpdated: sets.New(configs...), Reason: model.NewReasonStats(model.ConfigUpdate)})
+			s.EnsureSynced(t)
+		}
+		dr := model.ConfigKey{Kind: kind.DestinationRule, Name: "dr", Namespace: "default"}
+		se := model.ConfigKey{Kind: kind.ServiceEntry, Name: "se", Namespace: "default"}
+
+		// The config stores send updates for the unchanged configs as they start, and a global push is
+		// triggered once the status writers start.
+		push(dr, se)
+		push()
+		assert.Equal(t, len(s.Discovery.Cache.Keys(model.CDSType)), len(saved))
+		assert.Equal(t, snapshotter.HasRestoredEntries(), true)
+
+		// The restored entries depending on a changed config are dropped.
+		cfg := s.Discovery.Env.ConfigStore.Get(gvk.DestinationRule, "dr", "default")
+		_, err := s.Discovery.Env.ConfigStore.Update(*cfg)
+		assert.NoError(t, err)
+		push(dr)
+		assert.Equal(t, len(s.Discovery.Cache.Keys(model.CDSType)) < len(saved), true)
+	})
+	t.Run("missing", func(t *testing.T) {
+		s := newServer("1")
+		assert.NoError(t, s.Discovery.RestoreCacheSnapshot(filepath.Join(t.TempDir(), "missing")))
+		assert.Equal(t, len(s.Discovery.Cache.Keys(model.CDSType)), 0)
+	})
+}
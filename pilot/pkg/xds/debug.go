@@ -224,6 +224,14 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/list", "List all supported debug commands in json", s.list)
 }
 
+// AddDebugHandler adds a debug handler of a component created after the debug handlers were added.
+func (s *DiscoveryServer) AddDebugHandler(mux, internalMux *http.ServeMux, path string, help string, handler func(http.ResponseWriter, *http.Request)) {
+	if !features.EnableDebugOnHTTP {
+		return
+	}
+	s.addDebugHandler(mux, internalMux, path, help, handler)
+}
+
 func (s *DiscoveryServer) addDebugHandler(mux *http.ServeMux, internalMux *http.ServeMux,
 	path string, help string, handler func(http.ResponseWriter, *http.Request),
 ) {
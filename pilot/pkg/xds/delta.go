@@ -249,6 +249,7 @@ func (conn *Connection) sendDelta(res *discovery.DeltaDiscoveryResponse, newReso
 					wr.ResourceNames = newResourceNames
 				}
 				wr.NonceSent = res.Nonce
+				wr.VersionSent = res.SystemVersionInfo
 				wr.LastSendTime = time.Now()
 				if features.EnableUnsafeDeltaTest {
 					wr.LastResources = applyDelta(wr.LastResources, res)
@@ -423,6 +424,7 @@ func (s *DiscoveryServer) shouldRespondDelta(con *Connection, request *discovery
 			// Otherwise, this is just a change in resource subscription, so leave the last ACK info in place.
 			wr.LastError = ""
 			wr.NonceAcked = request.ResponseNonce
+			wr.VersionAcked = wr.VersionSent
 		}
 		wr.ResourceNames = currentResources
 		alwaysRespond = wr.AlwaysRespond
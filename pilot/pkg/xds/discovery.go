@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -145,7 +146,7 @@ func NewDiscoveryServer(env *model.Environment, clusterAliases map[string]string
 		InboundUpdates:      atomic.NewInt64(0),
 		CommittedUpdates:    atomic.NewInt64(0),
 		pushChannel:         make(chan *model.PushRequest, 10),
-		pushQueue:           NewPushQueue(),
+		pushQueue:           NewPushQueueWithOptions(pushQueueOptions()),
 		debugHandlers:       map[string]string{},
 		adsClients:          map[string]*Connection{},
 		DebounceOptions: DebounceOptions{
@@ -241,6 +242,12 @@ func (s *DiscoveryServer) periodicRefreshMetrics(stopCh <-chan struct{}) {
 
 // dropCacheForRequest clears the cache in response to a push request
 func (s *DiscoveryServer) dropCacheForRequest(req *model.PushRequest) {
+	// The entries restored from a cache snapshot are kept until their dependent configs change, as the config
+	// stores send updates for all configs while they start.
+	if snapshotter, ok := s.Cache.(model.XdsCacheSnapshotter); ok && snapshotter.HasRestoredEntries() {
+		snapshotter.ClearStale(req.ConfigsUpdated, model.NewConfigFingerprints(s.Env), s.cacheGeneration())
+		return
+	}
 	// If we don't know what updated, cannot safely cache. Clear the whole cache
 	if len(req.ConfigsUpdated) == 0 {
 		s.Cache.ClearAll()
@@ -500,6 +507,31 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 	}
 }
 
+// pushQueueOptions returns the options of the push queue set by the features.
+func pushQueueOptions() PushQueueOptions {
+	opts := PushQueueOptions{
+		ConcurrencyBudgets: map[model.NodeType]int{},
+	}
+	if features.EnablePushPrioritization {
+		opts.Prioritizer = ProxyTypePushPrioritizer
+		opts.MaxPriorityWait = features.PushPriorityMaxWait
+	}
+	for _, budget := range strings.Split(features.PushThrottleByProxyType, ",") {
+		if strings.TrimSpace(budget) == "" {
+			continue
+		}
+		t, limit, _ := strings.Cut(budget, "=")
+		nodeType := model.NodeType(strings.TrimSpace(t))
+		v, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || v <= 0 || !slices.Contains(model.NodeTypes[:], nodeType) {
+			log.Warnf("ignoring invalid push throttle %q in PILOT_PUSH_THROTTLE_BY_PROXY_TYPE", budget)
+			continue
+		}
+		opts.ConcurrencyBudgets[nodeType] = v
+	}
+	return opts
+}
+
 // initPushContext creates a global push context and stores it on the environment. Note: while this
 // method is technically thread safe (there are no data races), it should not be called in parallel;
 // if it is, then we may start two push context creations (say A, and B), but then write them in
@@ -589,3 +621,34 @@ func (s *DiscoveryServer) WaitForRequestLimit(ctx context.Context) error {
 func (s *DiscoveryServer) NextVersion() string {
 	return time.Now().Format(time.RFC3339) + "/" + strconv.FormatUint(s.pushVersion.Inc(), 10)
 }
+
+// PushVersionCount returns the numeric part of the latest push version returned by NextVersion.
+func (s *DiscoveryServer) PushVersionCount() uint64 {
+	return s.pushVersion.Load()
+}
+
+// ProxiesPendingAck returns the IDs of the connected proxies which have not yet acknowledged a response of typeURL
+// of a push with a version more recent than count, as returned by PushVersionCount. This includes the proxies
+// which do not watch typeURL.
+func (s *DiscoveryServer) ProxiesPendingAck(typeURL string, count uint64) []string {
+	var pending []string
+	for _, con := range s.Clients() {
+		if acked, ok := pushVersionCount(con.proxy.VersionAcked(typeURL)); !ok || acked <= count {
+			pending = append(pending, con.ID())
+		}
+	}
+	return pending
+}
+
+// pushVersionCount returns the numeric part of a push version returned by NextVersion.
+func pushVersionCount(version string) (uint64, bool) {
+	i := strings.LastIndex(version, "/")
+	if i < 0 {
+		return 0, false
+	}
+	count, err := strconv.ParseUint(version[i+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return count, true
+}
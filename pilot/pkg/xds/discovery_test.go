@@ -366,3 +366,17 @@ func BenchmarkPushRequest(b *testing.B) {
 		}
 	}
 }
+
+func TestPushVersionCount(t *testing.T) {
+	s := &DiscoveryServer{}
+	version := s.NextVersion()
+	count, ok := pushVersionCount(version)
+	if !ok || count != 1 {
+		t.Fatalf("expected count 1 for version %v, got %v %v", version, count, ok)
+	}
+	for _, v := range []string{"", "abc", "2024-01-01T00:00:00Z/x"} {
+		if _, ok := pushVersionCount(v); ok {
+			t.Fatalf("expected no count for version %q", v)
+		}
+	}
+}
@@ -106,6 +106,8 @@ var skippedEdsConfigs = sets.New(
 	kind.TCPRoute,
 	kind.TLSRoute,
 	kind.GRPCRoute,
+	kind.UDPRoute,
+	kind.BackendTLSPolicy,
 )
 
 func edsNeedsPush(updates model.XdsUpdates) bool {
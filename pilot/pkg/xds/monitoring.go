@@ -26,8 +26,9 @@ import (
 )
 
 var (
-	typeTag    = monitoring.CreateLabel("type")
-	versionTag = monitoring.CreateLabel("version")
+	typeTag     = monitoring.CreateLabel("type")
+	versionTag  = monitoring.CreateLabel("version")
+	priorityTag = monitoring.CreateLabel("priority")
 
 	monServices = monitoring.NewGauge(
 		"pilot_services",
@@ -78,6 +79,12 @@ var (
 		[]float64{.1, .5, 1, 3, 5, 10, 20, 30},
 	)
 
+	pushQueueWaitTime = monitoring.NewDistribution(
+		"pilot_push_queue_wait_time",
+		"Time in seconds, a proxy waits in the push queue before being dequeued, by priority class.",
+		[]float64{.01, .1, .5, 1, 3, 5, 10, 20, 30},
+	)
+
 	pushTriggers = monitoring.NewSum(
 		"pilot_push_triggers",
 		"Total number of times a push was triggered, labeled by reason for the push.",
@@ -16,34 +16,133 @@ package xds
 
 import (
 	"sync"
+	"time"
 
 	"istio.io/istio/pilot/pkg/model"
 )
 
+// PushPriority is the priority class of a queued push. Pushes of a higher priority class (lower value) are
+// dequeued first; pushes of the same class are dequeued in the order they were queued.
+type PushPriority int
+
+const (
+	// PushPriorityHigh is for the proxies serving traffic for many workloads, like gateways and waypoints.
+	PushPriorityHigh PushPriority = iota
+	// PushPriorityIncremental is for the incremental (EDS only) pushes, which are cheap to build.
+	PushPriorityIncremental
+	// PushPriorityNormal is for the other pushes.
+	PushPriorityNormal
+
+	numPushPriorities = int(PushPriorityNormal) + 1
+)
+
+func (p PushPriority) String() string {
+	switch p {
+	case PushPriorityHigh:
+		return "high"
+	case PushPriorityIncremental:
+		return "incremental"
+	default:
+		return "normal"
+	}
+}
+
+// PushPrioritizer returns the priority class of a push to a connection. It is called each time a push is
+// queued; a queued push keeps the highest priority it was given until it is dequeued.
+type PushPrioritizer func(con *Connection, req *model.PushRequest) PushPriority
+
+// FIFOPushPrioritizer gives the same priority to all the pushes, so they are dequeued in order.
+func FIFOPushPrioritizer(*Connection, *model.PushRequest) PushPriority {
+	return PushPriorityNormal
+}
+
+// ProxyTypePushPrioritizer pushes to gateways and waypoints first, then the incremental pushes, then the others.
+func ProxyTypePushPrioritizer(con *Connection, req *model.PushRequest) PushPriority {
+	switch proxyType(con) {
+	case model.Router, model.Waypoint:
+		return PushPriorityHigh
+	}
+	if !req.Full {
+		return PushPriorityIncremental
+	}
+	return PushPriorityNormal
+}
+
+// PushQueueOptions configures a PushQueue.
+type PushQueueOptions struct {
+	// Prioritizer orders the queued pushes. Defaults to FIFOPushPrioritizer.
+	Prioritizer PushPrioritizer
+	// ConcurrencyBudgets limits the number of pushes being processed per proxy type. The pushes to a proxy type
+	// whose budget is used wait in the queue, without blocking the pushes to the other types.
+	ConcurrencyBudgets map[model.NodeType]int
+	// MaxPriorityWait bounds the time a push waits behind the pushes of a higher priority: pushes queued for longer
+	// are dequeued first, in order, regardless of their priority. Zero disables it.
+	MaxPriorityWait time.Duration
+}
+
+// pushQueueItem is a connection pending a push.
+type pushQueueItem struct {
+	con       *Connection
+	request   *model.PushRequest
+	priority  PushPriority
+	proxyType model.NodeType
+	enqueued  time.Time
+}
+
 type PushQueue struct {
 	cond *sync.Cond
 
 	// pending stores all connections in the queue. If the same connection is enqueued again,
 	// the PushRequest will be merged.
-	pending map[*Connection]*model.PushRequest
+	pending map[*Connection]*pushQueueItem
 
-	// queue maintains ordering of the queue
-	queue []*Connection
+	// queues maintains ordering of the queue, per priority and proxy type. When the priority of a pending item
+	// is raised, the item is added to the queue of the new priority and skipped in the queue of the former one.
+	queues [numPushPriorities]map[model.NodeType][]*pushQueueItem
 
 	// processing stores all connections that have been Dequeue(), but not MarkDone().
-	// The value stored will be initially be nil, but may be populated if the connection is Enqueue().
+	// The request stored will be initially be nil, but may be populated if the connection is Enqueue().
 	// If model.PushRequest is not nil, it will be Enqueued again once MarkDone has been called.
-	processing map[*Connection]*model.PushRequest
+	processing map[*Connection]processingItem
+
+	// inProgress is the number of connections processing, per proxy type.
+	inProgress map[model.NodeType]int
+
+	prioritizer PushPrioritizer
+	budgets     map[model.NodeType]int
+	maxWait     time.Duration
 
 	shuttingDown bool
 }
 
+// processingItem is a connection whose push is being processed.
+type processingItem struct {
+	request *model.PushRequest
+	// proxyType is the proxy type the push was counted for when dequeued, which is released by MarkDone.
+	proxyType model.NodeType
+}
+
 func NewPushQueue() *PushQueue {
-	return &PushQueue{
-		pending:    make(map[*Connection]*model.PushRequest),
-		processing: make(map[*Connection]*model.PushRequest),
-		cond:       sync.NewCond(&sync.Mutex{}),
+	return NewPushQueueWithOptions(PushQueueOptions{})
+}
+
+func NewPushQueueWithOptions(opts PushQueueOptions) *PushQueue {
+	p := &PushQueue{
+		pending:     make(map[*Connection]*pushQueueItem),
+		processing:  make(map[*Connection]processingItem),
+		inProgress:  make(map[model.NodeType]int),
+		prioritizer: opts.Prioritizer,
+		budgets:     opts.ConcurrencyBudgets,
+		maxWait:     opts.MaxPriorityWait,
+		cond:        sync.NewCond(&sync.Mutex{}),
+	}
+	if p.prioritizer == nil {
+		p.prioritizer = FIFOPushPrioritizer
+	}
+	for i := range p.queues {
+		p.queues[i] = make(map[model.NodeType][]*pushQueueItem)
 	}
+	return p
 }
 
 // Enqueue will mark a proxy as pending a push. If it is already pending, pushInfo will be merged.
@@ -57,72 +156,160 @@ func (p *PushQueue) Enqueue(con *Connection, pushRequest *model.PushRequest) {
 	}
 
 	// If its already in progress, merge the info and return
-	if request, f := p.processing[con]; f {
-		p.processing[con] = request.CopyMerge(pushRequest)
+	if item, f := p.processing[con]; f {
+		item.request = item.request.CopyMerge(pushRequest)
+		p.processing[con] = item
 		return
 	}
 
-	if request, f := p.pending[con]; f {
-		p.pending[con] = request.CopyMerge(pushRequest)
+	if item, f := p.pending[con]; f {
+		item.request = item.request.CopyMerge(pushRequest)
+		if priority := p.prioritizer(con, item.request); priority < item.priority {
+			item.priority = priority
+			p.push(item)
+			p.cond.Signal()
+		}
 		return
 	}
 
-	p.pending[con] = pushRequest
-	p.queue = append(p.queue, con)
+	p.add(con, pushRequest)
+}
+
+// add queues a connection which is not pending. The lock must be held.
+func (p *PushQueue) add(con *Connection, request *model.PushRequest) {
+	item := &pushQueueItem{
+		con:       con,
+		request:   request,
+		priority:  p.prioritizer(con, request),
+		proxyType: proxyType(con),
+		enqueued:  time.Now(),
+	}
+	p.pending[con] = item
+	p.push(item)
 	// Signal waiters on Dequeue that a new item is available
 	p.cond.Signal()
 }
 
+func (p *PushQueue) push(item *pushQueueItem) {
+	p.queues[item.priority][item.proxyType] = append(p.queues[item.priority][item.proxyType], item)
+}
+
 // Remove a proxy from the queue. If there are no proxies ready to be removed, this will block
 func (p *PushQueue) Dequeue() (con *Connection, request *model.PushRequest, shutdown bool) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 
-	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.queue) == 0 && !p.shuttingDown {
+	// Block until there is one to remove. Enqueue and MarkDone will signal when one is available.
+	item := p.next()
+	for item == nil {
+		if p.shuttingDown {
+			return nil, nil, true
+		}
 		p.cond.Wait()
+		item = p.next()
 	}
 
-	if len(p.queue) == 0 {
-		// We must be shutting down.
-		return nil, nil, true
-	}
-
-	con = p.queue[0]
-	// The underlying array will still exist, despite the slice changing, so the object may not GC without this
-	// See https://github.com/grpc/grpc-go/issues/4758
-	p.queue[0] = nil
-	p.queue = p.queue[1:]
-
-	request = p.pending[con]
+	con = item.con
+	request = item.request
 	delete(p.pending, con)
+	pushQueueWaitTime.With(priorityTag.Value(item.priority.String())).Record(time.Since(item.enqueued).Seconds())
 
 	// Mark the connection as in progress
-	p.processing[con] = nil
+	p.processing[con] = processingItem{proxyType: item.proxyType}
+	p.inProgress[item.proxyType]++
 
 	return con, request, false
 }
 
+// next removes and returns the item to dequeue: the oldest item of the highest priority, whose proxy type did
+// not use its concurrency budget. It returns nil if there is none. The lock must be held.
+func (p *PushQueue) next() *pushQueueItem {
+	now := time.Now()
+	var next *pushQueueItem
+	for priority, queues := range p.queues {
+		for t, queue := range queues {
+			// Drop the items which were dequeued or moved to a higher priority.
+			for len(queue) > 0 && (p.pending[queue[0].con] != queue[0] || int(queue[0].priority) != priority) {
+				queue = p.pop(priority, t)
+			}
+			if len(queue) == 0 || p.overBudget(t) {
+				continue
+			}
+			if next == nil || p.before(queue[0], next, now) {
+				next = queue[0]
+			}
+		}
+	}
+	if next != nil {
+		p.pop(int(next.priority), next.proxyType)
+	}
+	return next
+}
+
+// before returns whether the item a is dequeued before the item b: the items which waited for longer than the
+// maximum wait come first, in order, then the items by priority, in order.
+func (p *PushQueue) before(a, b *pushQueueItem, now time.Time) bool {
+	if p.maxWait > 0 {
+		aExpired, bExpired := now.Sub(a.enqueued) >= p.maxWait, now.Sub(b.enqueued) >= p.maxWait
+		if aExpired != bExpired {
+			return aExpired
+		}
+		if aExpired {
+			return a.enqueued.Before(b.enqueued)
+		}
+	}
+	if a.priority != b.priority {
+		return a.priority < b.priority
+	}
+	return a.enqueued.Before(b.enqueued)
+}
+
+// pop removes the first item of a queue, and returns the remaining items.
+func (p *PushQueue) pop(priority int, t model.NodeType) []*pushQueueItem {
+	queue := p.queues[priority][t]
+	// The underlying array will still exist, despite the slice changing, so the object may not GC without this
+	// See https://github.com/grpc/grpc-go/issues/4758
+	queue[0] = nil
+	queue = queue[1:]
+	if len(queue) == 0 {
+		delete(p.queues[priority], t)
+		return nil
+	}
+	p.queues[priority][t] = queue
+	return queue
+}
+
+func (p *PushQueue) overBudget(t model.NodeType) bool {
+	budget, f := p.budgets[t]
+	return f && budget > 0 && p.inProgress[t] >= budget
+}
+
 func (p *PushQueue) MarkDone(con *Connection) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	request := p.processing[con]
+	item, f := p.processing[con]
+	if !f {
+		return
+	}
 	delete(p.processing, con)
+	// The proxy type of the connection may have been set since it was dequeued.
+	p.inProgress[item.proxyType]--
 
 	// If the info is present, that means Enqueue was called while connection was not yet marked done.
 	// This means we need to add it back to the queue.
-	if request != nil {
-		p.pending[con] = request
-		p.queue = append(p.queue, con)
-		p.cond.Signal()
+	if item.request != nil {
+		p.add(con, item.request)
+		return
 	}
+	// A push may be waiting for the budget released by this connection.
+	p.cond.Signal()
 }
 
 // Get number of pending proxies
 func (p *PushQueue) Pending() int {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	return len(p.queue)
+	return len(p.pending)
 }
 
 // ShutDown will cause queue to ignore all new items added to it. As soon as the
@@ -134,3 +321,11 @@ func (p *PushQueue) ShutDown() {
 	p.shuttingDown = true
 	p.cond.Broadcast()
 }
+
+// proxyType returns the type of the proxy of the connection, which is empty until the connection is initialized.
+func proxyType(con *Connection) model.NodeType {
+	if con.proxy == nil {
+		return ""
+	}
+	return con.proxy.Type
+}
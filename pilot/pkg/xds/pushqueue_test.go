@@ -373,3 +373,151 @@ func TestProxyQueue(t *testing.T) {
 		}
 	})
 }
+
+func newTypedConnection(id string, t model.NodeType) *Connection {
+	con := newConnection("", nil)
+	con.SetID(id)
+	con.proxy = &model.Proxy{Type: t}
+	return con
+}
+
+func TestProxyQueuePriority(t *testing.T) {
+	sidecar := newTypedConnection("sidecar", model.SidecarProxy)
+	incremental := newTypedConnection("incremental", model.SidecarProxy)
+	gateway := newTypedConnection("gateway", model.Router)
+	waypoint := newTypedConnection("waypoint", model.Waypoint)
+
+	t.Run("fifo", func(t *testing.T) {
+		p := NewPushQueue()
+		defer p.ShutDown()
+
+		p.Enqueue(sidecar, &model.PushRequest{Full: true})
+		p.Enqueue(incremental, &model.PushRequest{})
+		p.Enqueue(gateway, &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, sidecar)
+		ExpectDequeue(t, p, incremental)
+		ExpectDequeue(t, p, gateway)
+	})
+
+	t.Run("by proxy type", func(t *testing.T) {
+		p := NewPushQueueWithOptions(PushQueueOptions{Prioritizer: ProxyTypePushPrioritizer})
+		defer p.ShutDown()
+
+		p.Enqueue(sidecar, &model.PushRequest{Full: true})
+		p.Enqueue(incremental, &model.PushRequest{})
+		p.Enqueue(gateway, &model.PushRequest{Full: true})
+		p.Enqueue(waypoint, &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, gateway)
+		ExpectDequeue(t, p, waypoint)
+		ExpectDequeue(t, p, incremental)
+		ExpectDequeue(t, p, sidecar)
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("merge keeps the highest priority", func(t *testing.T) {
+		p := NewPushQueueWithOptions(PushQueueOptions{Prioritizer: ProxyTypePushPrioritizer})
+		defer p.ShutDown()
+
+		p.Enqueue(sidecar, &model.PushRequest{Full: true})
+		p.Enqueue(incremental, &model.PushRequest{})
+		// The merged push is full, but it was queued as incremental.
+		p.Enqueue(incremental, &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, incremental)
+		ExpectDequeue(t, p, sidecar)
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("raised priority", func(t *testing.T) {
+		prioritizer := func(con *Connection, req *model.PushRequest) PushPriority {
+			if req.Full {
+				return PushPriorityHigh
+			}
+			return PushPriorityNormal
+		}
+		p := NewPushQueueWithOptions(PushQueueOptions{Prioritizer: prioritizer})
+		defer p.ShutDown()
+
+		p.Enqueue(sidecar, &model.PushRequest{})
+		p.Enqueue(incremental, &model.PushRequest{})
+		p.Enqueue(incremental, &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, incremental)
+		ExpectDequeue(t, p, sidecar)
+		ExpectTimeout(t, p)
+		if p.Pending() != 0 {
+			t.Fatalf("expected no pending push, got %v", p.Pending())
+		}
+	})
+}
+
+func TestProxyQueueConcurrencyBudget(t *testing.T) {
+	sidecars := []*Connection{
+		newTypedConnection("sidecar-0", model.SidecarProxy),
+		newTypedConnection("sidecar-1", model.SidecarProxy),
+	}
+	gateway := newTypedConnection("gateway", model.Router)
+
+	p := NewPushQueueWithOptions(PushQueueOptions{ConcurrencyBudgets: map[model.NodeType]int{model.SidecarProxy: 1}})
+	defer p.ShutDown()
+
+	p.Enqueue(sidecars[0], &model.PushRequest{})
+	p.Enqueue(sidecars[1], &model.PushRequest{})
+	p.Enqueue(gateway, &model.PushRequest{})
+
+	ExpectDequeue(t, p, sidecars[0])
+	// The second sidecar waits for the budget, without blocking the gateway.
+	ExpectDequeue(t, p, gateway)
+	if p.Pending() != 1 {
+		t.Fatalf("expected 1 pending push, got %v", p.Pending())
+	}
+
+	p.MarkDone(sidecars[0])
+	ExpectDequeue(t, p, sidecars[1])
+}
+
+func TestProxyQueueConcurrencyBudgetUninitializedProxy(t *testing.T) {
+	sidecars := []*Connection{
+		newTypedConnection("sidecar-0", model.SidecarProxy),
+		newTypedConnection("sidecar-1", model.SidecarProxy),
+	}
+	initializing := newConnection("", nil)
+	initializing.SetID("initializing")
+
+	p := NewPushQueueWithOptions(PushQueueOptions{ConcurrencyBudgets: map[model.NodeType]int{model.SidecarProxy: 1}})
+	defer p.ShutDown()
+
+	p.Enqueue(initializing, &model.PushRequest{})
+	ExpectDequeue(t, p, initializing)
+	// The proxy is initialized while its push is processed; this must not release the budget of sidecars.
+	initializing.proxy = &model.Proxy{Type: model.SidecarProxy}
+	p.MarkDone(initializing)
+
+	p.Enqueue(sidecars[0], &model.PushRequest{})
+	p.Enqueue(sidecars[1], &model.PushRequest{})
+	ExpectDequeue(t, p, sidecars[0])
+	ExpectTimeout(t, p)
+}
+
+func TestProxyQueueMaxPriorityWait(t *testing.T) {
+	sidecar := newTypedConnection("sidecar", model.SidecarProxy)
+	gateways := []*Connection{
+		newTypedConnection("gateway-0", model.Router),
+		newTypedConnection("gateway-1", model.Router),
+	}
+
+	p := NewPushQueueWithOptions(PushQueueOptions{Prioritizer: ProxyTypePushPrioritizer, MaxPriorityWait: 100 * time.Millisecond})
+	defer p.ShutDown()
+
+	p.Enqueue(sidecar, &model.PushRequest{Full: true})
+	p.Enqueue(gateways[0], &model.PushRequest{Full: true})
+	ExpectDequeue(t, p, gateways[0])
+
+	// Once it waited for longer than the maximum wait, the sidecar is no longer starved by the gateways.
+	time.Sleep(150 * time.Millisecond)
+	p.Enqueue(gateways[1], &model.PushRequest{Full: true})
+	ExpectDequeue(t, p, sidecar)
+	ExpectDequeue(t, p, gateways[1])
+}
@@ -39,6 +39,7 @@ import (
 	securitymodel "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/util/sets"
 )
@@ -136,7 +137,7 @@ func (s *SecretGen) Generate(proxy *model.Proxy, w *model.WatchedResource, req *
 	// Filter down to resources we can access. We do not return an error if they attempt to access a Secret
 	// they cannot; instead we just exclude it. This ensures that a single bad reference does not break the whole
 	// SDS flow. The pilotSDSCertificateErrors metric and logs handle visibility into invalid references.
-	resources := filterAuthorizedResources(s.parseResources(w.ResourceNames, proxy), proxy, proxyClusterSecrets)
+	resources := filterAuthorizedResources(s.parseResources(w.ResourceNames, proxy), proxy, req.Push, proxyClusterSecrets)
 
 	var results model.Resources
 	cached, regenerated := 0, 0
@@ -231,7 +232,12 @@ func recordInvalidCertificate(name string, err error) {
 }
 
 // filterAuthorizedResources takes a list of SecretResource and filters out resources that proxy cannot access
-func filterAuthorizedResources(resources []SecretResource, proxy *model.Proxy, secrets credscontroller.Controller) []SecretResource {
+func filterAuthorizedResources(
+	resources []SecretResource,
+	proxy *model.Proxy,
+	push *model.PushContext,
+	secrets credscontroller.Controller,
+) []SecretResource {
 	var authzResult *bool
 	var authzError error
 	// isAuthorized is a small wrapper around credscontroller.Authorize so we only call it once instead of each time in the loop
@@ -259,6 +265,10 @@ func filterAuthorizedResources(resources []SecretResource, proxy *model.Proxy, s
 	for _, r := range resources {
 		sameNamespace := r.Namespace == proxy.VerifiedIdentity.Namespace
 		verified := proxy.MergedGateway != nil && proxy.MergedGateway.VerifiedCertificateReferences.Contains(r.ResourceName)
+		if !verified && proxy.MergedGateway != nil && push != nil && strings.HasSuffix(r.Name, securitymodel.SdsCaSuffix) {
+			// CA certificates referenced by a BackendTLSPolicy are used to originate TLS from the gateway.
+			verified = push.ReferenceAllowed(gvk.Secret, r.ResourceName, proxy.VerifiedIdentity.Namespace)
+		}
 		switch r.ResourceType {
 		case credentials.KubernetesGatewaySecretType:
 			// For KubernetesGateway, we only allow VerifiedCertificateReferences.
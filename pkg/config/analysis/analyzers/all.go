@@ -27,6 +27,7 @@ import (
 	"istio.io/istio/pkg/config/analysis/analyzers/injection"
 	"istio.io/istio/pkg/config/analysis/analyzers/k8sgateway"
 	"istio.io/istio/pkg/config/analysis/analyzers/multicluster"
+	"istio.io/istio/pkg/config/analysis/analyzers/peerauthentication"
 	"istio.io/istio/pkg/config/analysis/analyzers/schema"
 	"istio.io/istio/pkg/config/analysis/analyzers/service"
 	"istio.io/istio/pkg/config/analysis/analyzers/serviceentry"
@@ -55,7 +56,10 @@ func All() []analysis.Analyzer {
 		&injection.ImageAutoAnalyzer{},
 		&k8sgateway.SelectorAnalyzer{},
 		&multicluster.MeshNetworksAnalyzer{},
+		&peerauthentication.DestinationRuleAnalyzer{},
+		&peerauthentication.SelectorAnalyzer{},
 		&service.PortNameAnalyzer{},
+		&sidecar.EgressHostsAnalyzer{},
 		&sidecar.SelectorAnalyzer{},
 		&virtualservice.ConflictingMeshGatewayHostsAnalyzer{},
 		&virtualservice.DestinationHostAnalyzer{},
@@ -63,6 +67,7 @@ func All() []analysis.Analyzer {
 		&virtualservice.GatewayAnalyzer{},
 		&virtualservice.JWTClaimRouteAnalyzer{},
 		&destinationrule.CaCertificateAnalyzer{},
+		&destinationrule.SubsetAnalyzer{},
 		&serviceentry.ProtocolAddressesAnalyzer{},
 		&webhook.Analyzer{},
 		&envoyfilter.EnvoyPatchAnalyzer{},
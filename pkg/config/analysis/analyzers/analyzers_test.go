@@ -37,6 +37,7 @@ import (
 	"istio.io/istio/pkg/config/analysis/analyzers/k8sgateway"
 	"istio.io/istio/pkg/config/analysis/analyzers/maturity"
 	"istio.io/istio/pkg/config/analysis/analyzers/multicluster"
+	"istio.io/istio/pkg/config/analysis/analyzers/peerauthentication"
 	schemaValidation "istio.io/istio/pkg/config/analysis/analyzers/schema"
 	"istio.io/istio/pkg/config/analysis/analyzers/service"
 	"istio.io/istio/pkg/config/analysis/analyzers/serviceentry"
@@ -512,6 +513,54 @@ var testGrid = []testCase{
 			{msg.UnknownMeshNetworksServiceRegistry, "MeshNetworks istio-system/meshnetworks"},
 		},
 	},
+	{
+		name: "destinationrule subsets",
+		inputFiles: []string{
+			"testdata/destinationrule-subset.yaml",
+		},
+		analyzer: &destinationrule.SubsetAnalyzer{},
+		expected: []message{
+			{msg.DestinationRuleSubsetNotSelectWorkloads, "DestinationRule default/reviews-invalid"},
+			{msg.DestinationRulePortNotFound, "DestinationRule default/reviews-invalid"},
+		},
+	},
+	{
+		name: "sidecar egress hosts",
+		inputFiles: []string{
+			"testdata/sidecar-egress-hosts.yaml",
+		},
+		analyzer: &sidecar.EgressHostsAnalyzer{},
+		expected: []message{
+			{msg.SidecarEgressHostNotFound, "Sidecar default/default"},
+			{msg.SidecarEgressHostNotFound, "Sidecar default/default"},
+			{msg.VirtualServiceDestinationNotImportedBySidecar, "VirtualService default/reviews"},
+			{msg.VirtualServiceDestinationNotImportedBySidecar, "VirtualService other/details"},
+		},
+	},
+	{
+		name: "peerauthentication selector",
+		inputFiles: []string{
+			"testdata/peerauthentication-selector.yaml",
+		},
+		analyzer: &peerauthentication.SelectorAnalyzer{},
+		expected: []message{
+			{msg.MultiplePeerAuthenticationsWithoutWorkloadSelectors, "PeerAuthentication istio-system/default"},
+			{msg.MultiplePeerAuthenticationsWithoutWorkloadSelectors, "PeerAuthentication istio-system/second-mesh-wide"},
+			{msg.PeerAuthenticationPortLevelMTLSWithoutSelector, "PeerAuthentication other/port-level-without-selector"},
+			{msg.PeerAuthenticationPortNotExposed, "PeerAuthentication default/port-not-exposed"},
+		},
+	},
+	{
+		name: "peerauthentication destinationrule",
+		inputFiles: []string{
+			"testdata/peerauthentication-destinationrule.yaml",
+		},
+		analyzer: &peerauthentication.DestinationRuleAnalyzer{},
+		expected: []message{
+			{msg.PeerAuthenticationDestinationRuleConflict, "DestinationRule default/reviews-disable"},
+			{msg.PeerAuthenticationDestinationRuleConflict, "DestinationRule default/reviews-subset-strict"},
+		},
+	},
 	{
 		name: "authorizationpolicies",
 		inputFiles: []string{
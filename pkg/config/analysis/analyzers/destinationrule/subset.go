@@ -0,0 +1,151 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package destinationrule
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// SubsetAnalyzer checks that the subsets of a DestinationRule select workloads of the host Service,
+// and that port level settings reference ports of the Service.
+type SubsetAnalyzer struct{}
+
+var _ analysis.Analyzer = &SubsetAnalyzer{}
+
+func (s *SubsetAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "destinationrule.SubsetAnalyzer",
+		Description: "Checks that DestinationRule subsets select workloads and port level settings reference Service ports",
+		Inputs: []config.GroupVersionKind{
+			gvk.DestinationRule,
+			gvk.Service,
+			gvk.Pod,
+			gvk.WorkloadEntry,
+		},
+	}
+}
+
+func (s *SubsetAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(gvk.DestinationRule, func(r *resource.Instance) bool {
+		s.analyzeDestinationRule(r, ctx)
+		return true
+	})
+}
+
+func (s *SubsetAnalyzer) analyzeDestinationRule(r *resource.Instance, ctx analysis.Context) {
+	dr := r.Message.(*v1alpha3.DestinationRule)
+	if strings.HasPrefix(dr.GetHost(), "*") {
+		return
+	}
+	svcName := util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, dr.GetHost())
+	svcResource := ctx.Find(gvk.Service, svcName)
+	if svcResource == nil {
+		return
+	}
+	svc := svcResource.Message.(*corev1.ServiceSpec)
+
+	ports := sets.New[uint32]()
+	for _, p := range svc.Ports {
+		ports.Insert(uint32(p.Port))
+	}
+	s.analyzePortLevelSettings(r, ctx, dr.GetTrafficPolicy(), ports, "{.spec.trafficPolicy")
+	for i, subset := range dr.GetSubsets() {
+		s.analyzePortLevelSettings(r, ctx, subset.GetTrafficPolicy(), ports, fmt.Sprintf("{.spec.subsets[%d].trafficPolicy", i))
+	}
+
+	if len(svc.Selector) == 0 {
+		// The endpoints of a Service without selector are not managed by Istio.
+		return
+	}
+	workloads := selectedWorkloadLabels(ctx, svcName.Namespace, svc.Selector)
+	if len(workloads) == 0 {
+		// Without workloads every subset is empty, which is a problem of the Service rather than of the subsets.
+		return
+	}
+	for i, subset := range dr.GetSubsets() {
+		if selectsAny(subset.GetLabels(), workloads) {
+			continue
+		}
+		m := msg.NewDestinationRuleSubsetNotSelectWorkloads(r, subset.GetName(), dr.GetHost())
+		if line, ok := util.ErrorLine(r, fmt.Sprintf(util.DestinationRuleSubsetName, i)); ok {
+			m.Line = line
+		}
+		ctx.Report(gvk.DestinationRule, m)
+	}
+}
+
+func (s *SubsetAnalyzer) analyzePortLevelSettings(r *resource.Instance, ctx analysis.Context, policy *v1alpha3.TrafficPolicy,
+	ports sets.Set[uint32], path string,
+) {
+	dr := r.Message.(*v1alpha3.DestinationRule)
+	for i, pls := range policy.GetPortLevelSettings() {
+		port := pls.GetPort().GetNumber()
+		if port == 0 || ports.Contains(port) {
+			continue
+		}
+		m := msg.NewDestinationRulePortNotFound(r, int(port), dr.GetHost())
+		if line, ok := util.ErrorLine(r, fmt.Sprintf("%s.portLevelSettings[%d].port.number}", path, i)); ok {
+			m.Line = line
+		}
+		ctx.Report(gvk.DestinationRule, m)
+	}
+}
+
+// selectedWorkloadLabels returns the labels of the pods and workload entries in the namespace matching the selector.
+func selectedWorkloadLabels(ctx analysis.Context, namespace resource.Namespace, selector map[string]string) []labels.Instance {
+	var out []labels.Instance
+	ctx.ForEach(gvk.Pod, func(r *resource.Instance) bool {
+		if r.Metadata.FullName.Namespace == namespace && labels.Instance(selector).SubsetOf(r.Metadata.Labels) {
+			out = append(out, r.Metadata.Labels)
+		}
+		return true
+	})
+	ctx.ForEach(gvk.WorkloadEntry, func(r *resource.Instance) bool {
+		if r.Metadata.FullName.Namespace != namespace {
+			return true
+		}
+		we := r.Message.(*v1alpha3.WorkloadEntry)
+		// As in Istiod, the labels of the metadata are merged with the labels of the spec, with precedence to the metadata.
+		l := labels.Instance(maps.MergeCopy(we.GetLabels(), r.Metadata.Labels))
+		if labels.Instance(selector).SubsetOf(l) {
+			out = append(out, l)
+		}
+		return true
+	})
+	return out
+}
+
+func selectsAny(subsetLabels map[string]string, workloads []labels.Instance) bool {
+	for _, l := range workloads {
+		if labels.Instance(subsetLabels).SubsetOf(l) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerauthentication
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// DestinationRuleAnalyzer checks that DestinationRules don't disable TLS for services whose
+// workloads require STRICT mTLS
+type DestinationRuleAnalyzer struct{}
+
+var _ analysis.Analyzer = &DestinationRuleAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *DestinationRuleAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "peerauthentication.DestinationRuleAnalyzer",
+		Description: "Checks that DestinationRules don't disable TLS for services whose workloads require STRICT mTLS",
+		Inputs: []config.GroupVersionKind{
+			gvk.PeerAuthentication,
+			gvk.DestinationRule,
+			gvk.Service,
+			gvk.Pod,
+			gvk.MeshConfig,
+		},
+	}
+}
+
+// tlsSettings is a TLS setting of a DestinationRule and where it is set.
+type tlsSettings struct {
+	policy *v1alpha3.TrafficPolicy
+	// subsetLabels are the labels of the subset the settings apply to, if any.
+	subsetLabels map[string]string
+	// path is the path of the traffic policy in the DestinationRule.
+	path string
+}
+
+// Analyze implements Analyzer
+func (a *DestinationRuleAnalyzer) Analyze(c analysis.Context) {
+	p := newPolicies(c)
+	c.ForEach(gvk.DestinationRule, func(r *resource.Instance) bool {
+		a.analyzeDestinationRule(c, p, r)
+		return true
+	})
+}
+
+func (a *DestinationRuleAnalyzer) analyzeDestinationRule(c analysis.Context, p *policies, r *resource.Instance) {
+	dr := r.Message.(*v1alpha3.DestinationRule)
+	if strings.HasPrefix(dr.GetHost(), "*") {
+		return
+	}
+	svcName := util.GetResourceNameFromHost(r.Metadata.FullName.Namespace, dr.GetHost())
+	svcResource := c.Find(gvk.Service, svcName)
+	if svcResource == nil {
+		return
+	}
+	svc := svcResource.Message.(*corev1.ServiceSpec)
+	if len(svc.Selector) == 0 {
+		return
+	}
+	pods := selectedPods(c, svcName.Namespace, svc.Selector)
+
+	settings := []tlsSettings{{policy: dr.GetTrafficPolicy(), path: "{.spec.trafficPolicy"}}
+	for i, subset := range dr.GetSubsets() {
+		if subset.GetTrafficPolicy() != nil {
+			settings = append(settings, tlsSettings{
+				policy:       subset.GetTrafficPolicy(),
+				subsetLabels: subset.GetLabels(),
+				path:         fmt.Sprintf("{.spec.subsets[%d].trafficPolicy", i),
+			})
+		}
+	}
+
+	reported := sets.New[uint32]()
+	for _, s := range settings {
+		for _, sp := range svc.Ports {
+			port := uint32(sp.Port)
+			if reported.Contains(port) {
+				continue
+			}
+			path, disabled := disablesTLS(s, port)
+			if !disabled {
+				continue
+			}
+			for _, pod := range pods {
+				if !labels.Instance(s.subsetLabels).SubsetOf(pod.Metadata.Labels) {
+					continue
+				}
+				tp := targetPort(sp, pod.Message.(*corev1.PodSpec))
+				mode, pa := p.effectiveMode(pod.Metadata.FullName.Namespace, pod.Metadata.Labels, tp)
+				if mode != v1beta1.PeerAuthentication_MutualTLS_STRICT {
+					continue
+				}
+				m := msg.NewPeerAuthenticationDestinationRuleConflict(r, r.Metadata.FullName.String(), dr.GetHost(),
+					int(port), pa.Metadata.FullName.String(), int(tp))
+				if line, ok := util.ErrorLine(r, path); ok {
+					m.Line = line
+				}
+				c.Report(gvk.DestinationRule, m)
+				reported.Insert(port)
+				break
+			}
+		}
+	}
+}
+
+// disablesTLS returns whether the settings disable TLS for the service port, and the path of the TLS mode
+// that does. Port level settings take precedence over the settings for all ports.
+func disablesTLS(s tlsSettings, port uint32) (string, bool) {
+	for i, pls := range s.policy.GetPortLevelSettings() {
+		if pls.GetPort().GetNumber() != port || pls.GetTls() == nil {
+			continue
+		}
+		return fmt.Sprintf("%s.portLevelSettings[%d].tls.mode}", s.path, i),
+			pls.GetTls().GetMode() == v1alpha3.ClientTLSSettings_DISABLE
+	}
+	if s.policy.GetTls() == nil {
+		return "", false
+	}
+	return s.path + ".tls.mode}", s.policy.GetTls().GetMode() == v1alpha3.ClientTLSSettings_DISABLE
+}
@@ -0,0 +1,157 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerauthentication
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"istio.io/api/mesh/v1alpha1"
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+)
+
+// policies indexes the PeerAuthentications by namespace, to compute the effective mTLS mode
+// of a workload the same way Istiod does.
+type policies struct {
+	rootNamespace resource.Namespace
+	// namespaceWide holds the policies without a workload selector, oldest first.
+	namespaceWide map[resource.Namespace][]*resource.Instance
+	// workload holds the policies with a workload selector, oldest first.
+	workload map[resource.Namespace][]*resource.Instance
+}
+
+func newPolicies(c analysis.Context) *policies {
+	p := &policies{
+		rootNamespace: rootNamespace(c),
+		namespaceWide: map[resource.Namespace][]*resource.Instance{},
+		workload:      map[resource.Namespace][]*resource.Instance{},
+	}
+	c.ForEach(gvk.PeerAuthentication, func(r *resource.Instance) bool {
+		ns := r.Metadata.FullName.Namespace
+		if hasSelector(r.Message.(*v1beta1.PeerAuthentication)) {
+			p.workload[ns] = append(p.workload[ns], r)
+		} else {
+			p.namespaceWide[ns] = append(p.namespaceWide[ns], r)
+		}
+		return true
+	})
+	for _, m := range []map[resource.Namespace][]*resource.Instance{p.namespaceWide, p.workload} {
+		for _, rs := range m {
+			sortByCreationTime(rs)
+		}
+	}
+	return p
+}
+
+// effectiveMode returns the mTLS mode of the workload on the port, and the policy that decided it.
+// The policy is nil if no policy applies, in which case the mode is PERMISSIVE. As in Istiod, the
+// most specific policy wins, the oldest policy wins within a scope, and UNSET inherits from the parent scope.
+func (p *policies) effectiveMode(namespace resource.Namespace, workloadLabels map[string]string,
+	port uint32,
+) (v1beta1.PeerAuthentication_MutualTLS_Mode, *resource.Instance) {
+	mode := v1beta1.PeerAuthentication_MutualTLS_PERMISSIVE
+	var decidedBy *resource.Instance
+	apply := func(r *resource.Instance, mtls *v1beta1.PeerAuthentication_MutualTLS) {
+		if mtls.GetMode() != v1beta1.PeerAuthentication_MutualTLS_UNSET {
+			mode = mtls.GetMode()
+			decidedBy = r
+		}
+	}
+
+	if mesh := p.namespaceWide[p.rootNamespace]; len(mesh) > 0 {
+		apply(mesh[0], mesh[0].Message.(*v1beta1.PeerAuthentication).GetMtls())
+	}
+	if namespace != p.rootNamespace {
+		if ns := p.namespaceWide[namespace]; len(ns) > 0 {
+			apply(ns[0], ns[0].Message.(*v1beta1.PeerAuthentication).GetMtls())
+		}
+		// Policies with a workload selector in the root namespace are ignored.
+		for _, r := range p.workload[namespace] {
+			pa := r.Message.(*v1beta1.PeerAuthentication)
+			if !labels.Instance(pa.GetSelector().GetMatchLabels()).SubsetOf(workloadLabels) {
+				continue
+			}
+			apply(r, pa.GetMtls())
+			if portMTLS, ok := pa.GetPortLevelMtls()[port]; ok {
+				apply(r, portMTLS)
+			}
+			break
+		}
+	}
+	return mode, decidedBy
+}
+
+func hasSelector(pa *v1beta1.PeerAuthentication) bool {
+	return len(pa.GetSelector().GetMatchLabels()) > 0
+}
+
+func sortByCreationTime(rs []*resource.Instance) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		if !rs[i].Metadata.CreateTime.Equal(rs[j].Metadata.CreateTime) {
+			return rs[i].Metadata.CreateTime.Before(rs[j].Metadata.CreateTime)
+		}
+		return rs[i].Metadata.FullName.String() < rs[j].Metadata.FullName.String()
+	})
+}
+
+func rootNamespace(c analysis.Context) resource.Namespace {
+	ns := constants.IstioSystemNamespace
+	c.ForEach(gvk.MeshConfig, func(r *resource.Instance) bool {
+		if rn := r.Message.(*v1alpha1.MeshConfig).GetRootNamespace(); rn != "" {
+			ns = rn
+		}
+		return true
+	})
+	return resource.Namespace(ns)
+}
+
+// selectedPods returns the pods in the namespace matching the selector.
+func selectedPods(c analysis.Context, namespace resource.Namespace, selector map[string]string) []*resource.Instance {
+	var pods []*resource.Instance
+	c.ForEach(gvk.Pod, func(r *resource.Instance) bool {
+		if r.Metadata.FullName.Namespace == namespace && labels.Instance(selector).SubsetOf(r.Metadata.Labels) {
+			pods = append(pods, r)
+		}
+		return true
+	})
+	return pods
+}
+
+// targetPort returns the port of the pod the service port forwards to, or 0 if a named
+// target port is not defined by the pod.
+func targetPort(sp corev1.ServicePort, pod *corev1.PodSpec) uint32 {
+	switch {
+	case sp.TargetPort.Type == intstr.String && sp.TargetPort.StrVal != "":
+		for _, c := range pod.Containers {
+			for _, cp := range c.Ports {
+				if cp.Name == sp.TargetPort.StrVal {
+					return uint32(cp.ContainerPort)
+				}
+			}
+		}
+		return 0
+	case sp.TargetPort.IntVal != 0:
+		return uint32(sp.TargetPort.IntVal)
+	default:
+		return uint32(sp.Port)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerauthentication
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// SelectorAnalyzer validates, per namespace, that:
+// * port level mTLS is only set on policies with a workload selector
+// * port level mTLS is only set on ports exposed by a Service selecting the workloads
+// * there aren't multiple mesh-wide or namespace-wide policies
+type SelectorAnalyzer struct{}
+
+var _ analysis.Analyzer = &SelectorAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *SelectorAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "peerauthentication.SelectorAnalyzer",
+		Description: "Validates that port level mTLS is only set on policies with a workload selector for ports exposed by a Service, " +
+			"and that there aren't multiple mesh-wide or namespace-wide peer authentication policies",
+		Inputs: []config.GroupVersionKind{
+			gvk.PeerAuthentication,
+			gvk.Pod,
+			gvk.Service,
+			gvk.MeshConfig,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *SelectorAnalyzer) Analyze(c analysis.Context) {
+	p := newPolicies(c)
+
+	for ns, rs := range p.namespaceWide {
+		for _, r := range rs {
+			if len(r.Message.(*v1beta1.PeerAuthentication).GetPortLevelMtls()) == 0 {
+				continue
+			}
+			m := msg.NewPeerAuthenticationPortLevelMTLSWithoutSelector(r)
+			if line, ok := util.ErrorLine(r, util.PeerAuthenticationPortLevelMtls); ok {
+				m.Line = line
+			}
+			c.Report(gvk.PeerAuthentication, m)
+		}
+
+		if len(rs) < 2 {
+			continue
+		}
+		scope := "namespace-wide"
+		if ns == p.rootNamespace {
+			scope = "mesh-wide"
+		}
+		names := slices.Map(rs, func(r *resource.Instance) string {
+			return r.Metadata.FullName.Name.String()
+		})
+		for _, r := range rs {
+			c.Report(gvk.PeerAuthentication,
+				msg.NewMultiplePeerAuthenticationsWithoutWorkloadSelectors(r, names, ns.String(), names[0], scope))
+		}
+	}
+
+	for ns, rs := range p.workload {
+		for _, r := range rs {
+			a.analyzePortLevelMTLS(c, ns, r)
+		}
+	}
+}
+
+// analyzePortLevelMTLS reports the port level mTLS settings for ports that no Service selecting
+// the workloads forwards to.
+func (a *SelectorAnalyzer) analyzePortLevelMTLS(c analysis.Context, ns resource.Namespace, r *resource.Instance) {
+	pa := r.Message.(*v1beta1.PeerAuthentication)
+	if len(pa.GetPortLevelMtls()) == 0 {
+		return
+	}
+	pods := selectedPods(c, ns, pa.GetSelector().GetMatchLabels())
+	if len(pods) == 0 {
+		// Without workloads, there are no exposed ports to compare to.
+		return
+	}
+
+	exposed := sets.New[uint32]()
+	c.ForEach(gvk.Service, func(rs *resource.Instance) bool {
+		if rs.Metadata.FullName.Namespace != ns {
+			return true
+		}
+		svc := rs.Message.(*corev1.ServiceSpec)
+		if len(svc.Selector) == 0 {
+			return true
+		}
+		for _, pod := range pods {
+			if !labels.Instance(svc.Selector).SubsetOf(pod.Metadata.Labels) {
+				continue
+			}
+			for _, sp := range svc.Ports {
+				exposed.Insert(targetPort(sp, pod.Message.(*corev1.PodSpec)))
+			}
+		}
+		return true
+	})
+
+	for _, port := range slices.Sort(maps.Keys(pa.GetPortLevelMtls())) {
+		if exposed.Contains(port) {
+			continue
+		}
+		m := msg.NewPeerAuthenticationPortNotExposed(r, int(port))
+		if line, ok := util.ErrorLine(r, fmt.Sprintf(util.PeerAuthenticationPortLevelMtlsPort, port)); ok {
+			m.Line = line
+		}
+		c.Report(gvk.PeerAuthentication, m)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/annotation"
+	"istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// EgressHostsAnalyzer validates, per namespace, that:
+// * the egress hosts of sidecar resources match at least one Service or ServiceEntry
+// * the destinations of virtual services imported by a namespace wide sidecar are imported by the sidecar too
+type EgressHostsAnalyzer struct{}
+
+var _ analysis.Analyzer = &EgressHostsAnalyzer{}
+
+// Metadata implements Analyzer
+func (a *EgressHostsAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name: "sidecar.EgressHostsAnalyzer",
+		Description: "Validates that the egress hosts of sidecars match a Service or ServiceEntry, " +
+			"and that the sidecars import the destinations of the virtual services they import",
+		Inputs: []config.GroupVersionKind{
+			gvk.Sidecar,
+			gvk.Service,
+			gvk.ServiceEntry,
+			gvk.VirtualService,
+			gvk.Namespace,
+			gvk.MeshConfig,
+		},
+	}
+}
+
+// registryHost is a host of a Service or ServiceEntry, with the namespaces it is exported to.
+type registryHost struct {
+	hostname  host.Name
+	namespace string
+	exportTo  sets.String
+}
+
+func (h registryHost) visibleTo(namespace string) bool {
+	return h.exportTo.Contains(util.ExportToAllNamespaces) || h.exportTo.Contains(namespace)
+}
+
+// Analyze implements Analyzer
+func (a *EgressHostsAnalyzer) Analyze(c analysis.Context) {
+	hosts := registryHosts(c)
+
+	rootNamespace := constants.IstioSystemNamespace
+	c.ForEach(gvk.MeshConfig, func(r *resource.Instance) bool {
+		if rn := r.Message.(*v1alpha1.MeshConfig).GetRootNamespace(); rn != "" {
+			rootNamespace = rn
+		}
+		return true
+	})
+
+	namespaceSidecars := map[string]*resource.Instance{}
+	c.ForEach(gvk.Sidecar, func(r *resource.Instance) bool {
+		a.analyzeEgressHosts(c, r, hosts, rootNamespace)
+
+		s := r.Message.(*v1alpha3.Sidecar)
+		ns := r.Metadata.FullName.Namespace.String()
+		if len(s.GetWorkloadSelector().GetLabels()) > 0 {
+			return true
+		}
+		// Istiod uses the oldest sidecar when there are several, which sidecar.SelectorAnalyzer reports.
+		if existing, f := namespaceSidecars[ns]; !f || r.Metadata.CreateTime.Before(existing.Metadata.CreateTime) {
+			namespaceSidecars[ns] = r
+		}
+		return true
+	})
+	if len(namespaceSidecars) == 0 {
+		return
+	}
+
+	// The sidecar in the root namespace is the default for the namespaces without one.
+	namespaces := sets.New(maps.Keys(namespaceSidecars)...).Delete(rootNamespace)
+	if _, f := namespaceSidecars[rootNamespace]; f {
+		c.ForEach(gvk.Namespace, func(r *resource.Instance) bool {
+			if ns := r.Metadata.FullName.Name.String(); ns != rootNamespace {
+				namespaces.Insert(ns)
+			}
+			return true
+		})
+	}
+	for _, ns := range sets.SortedList(namespaces) {
+		sidecar, f := namespaceSidecars[ns]
+		if !f {
+			sidecar = namespaceSidecars[rootNamespace]
+		}
+		a.analyzeVirtualServices(c, ns, sidecar, hosts)
+	}
+}
+
+// analyzeEgressHosts reports the egress hosts of the sidecar which match no Service or ServiceEntry.
+func (a *EgressHostsAnalyzer) analyzeEgressHosts(c analysis.Context, r *resource.Instance, hosts []registryHost, rootNamespace string) {
+	s := r.Message.(*v1alpha3.Sidecar)
+	ns := r.Metadata.FullName.Namespace.String()
+	// `.` in the default sidecar of the root namespace refers to the namespace of each workload.
+	meshDefault := ns == rootNamespace && len(s.GetWorkloadSelector().GetLabels()) == 0
+	for i, eg := range s.GetEgress() {
+		for j, h := range eg.GetHosts() {
+			// `~` imports nothing on purpose, and illegal hosts are reported by validation.
+			if strings.HasPrefix(h, "~/") || strings.Count(h, "/") != 1 || meshDefault && strings.HasPrefix(h, "./") {
+				continue
+			}
+			imported := model.ParseSidecarEgressHosts([]string{h}, ns)
+			if slices.FindFunc(hosts, func(rh registryHost) bool {
+				return rh.visibleTo(ns) && imported.ImportsService(rh.hostname, rh.namespace)
+			}) != nil {
+				continue
+			}
+			m := msg.NewSidecarEgressHostNotFound(r, h)
+			if line, ok := util.ErrorLine(r, fmt.Sprintf(util.SidecarEgressHost, i, j)); ok {
+				m.Line = line
+			}
+			c.Report(gvk.Sidecar, m)
+		}
+	}
+}
+
+// analyzeVirtualServices reports the destinations of the virtual services imported by the sidecar of the
+// namespace which the sidecar does not import. Destinations matching no host are reported by
+// virtualservice.DestinationHostAnalyzer.
+func (a *EgressHostsAnalyzer) analyzeVirtualServices(c analysis.Context, namespace string, sidecar *resource.Instance,
+	hosts []registryHost,
+) {
+	var egressHosts []string
+	for _, eg := range sidecar.Message.(*v1alpha3.Sidecar).GetEgress() {
+		egressHosts = append(egressHosts, eg.GetHosts()...)
+	}
+	// As in Istiod, `.` refers to the namespace of the workloads even for the sidecar of the root namespace.
+	imported := model.ParseSidecarEgressHosts(egressHosts, namespace)
+
+	c.ForEach(gvk.VirtualService, func(r *resource.Instance) bool {
+		vs := r.Message.(*v1alpha3.VirtualService)
+		vsNs := r.Metadata.FullName.Namespace
+		if !appliesToSidecars(vs) {
+			return true
+		}
+		if e := exportTo(vs.GetExportTo(), vsNs.String()); !e.Contains(util.ExportToAllNamespaces) && !e.Contains(namespace) {
+			return true
+		}
+		if slices.FindFunc(vs.GetHosts(), func(h string) bool {
+			return imported.ImportsVirtualService(host.Name(util.ConvertHostToFQDN(vsNs, h)), vsNs.String())
+		}) == nil {
+			return true
+		}
+
+		reported := sets.New[string]()
+		for _, d := range routeDestinations(vs) {
+			dest := host.Name(util.ConvertHostToFQDN(vsNs, d.GetHost()))
+			if reported.Contains(dest.String()) {
+				continue
+			}
+			var found, importedDest bool
+			for _, rh := range hosts {
+				if !rh.visibleTo(namespace) || !dest.SubsetOf(rh.hostname) {
+					continue
+				}
+				found = true
+				if imported.ImportsService(rh.hostname, rh.namespace) {
+					importedDest = true
+					break
+				}
+			}
+			if !found || importedDest {
+				continue
+			}
+			reported.Insert(dest.String())
+			c.Report(gvk.VirtualService,
+				msg.NewVirtualServiceDestinationNotImportedBySidecar(r, d.GetHost(), sidecar.Metadata.FullName.String(), namespace))
+		}
+		return true
+	})
+}
+
+// registryHosts returns the hosts of the Services and ServiceEntries.
+func registryHosts(c analysis.Context) []registryHost {
+	var hosts []registryHost
+	c.ForEach(gvk.Service, func(r *resource.Instance) bool {
+		ns := r.Metadata.FullName.Namespace
+		var e []string
+		if anno := r.Metadata.Annotations[annotation.NetworkingExportTo.Name]; anno != "" {
+			e = strings.Split(anno, ",")
+		}
+		hosts = append(hosts, registryHost{
+			hostname:  host.Name(util.ConvertHostToFQDN(ns, r.Metadata.FullName.Name.String())),
+			namespace: ns.String(),
+			exportTo:  exportTo(e, ns.String()),
+		})
+		return true
+	})
+	c.ForEach(gvk.ServiceEntry, func(r *resource.Instance) bool {
+		se := r.Message.(*v1alpha3.ServiceEntry)
+		ns := r.Metadata.FullName.Namespace.String()
+		for _, h := range se.GetHosts() {
+			hosts = append(hosts, registryHost{
+				hostname:  host.Name(h),
+				namespace: ns,
+				exportTo:  exportTo(se.GetExportTo(), ns),
+			})
+		}
+		return true
+	})
+	return hosts
+}
+
+// exportTo returns the namespaces a resource in the namespace is exported to.
+func exportTo(e []string, namespace string) sets.String {
+	if len(e) == 0 {
+		return sets.New(util.ExportToAllNamespaces)
+	}
+	out := sets.New[string]()
+	for _, ns := range e {
+		ns = strings.TrimSpace(ns)
+		if ns == util.ExportToNamespaceLocal {
+			ns = namespace
+		}
+		out.Insert(ns)
+	}
+	return out
+}
+
+// appliesToSidecars checks if the virtual service applies to sidecars, which is the case when it
+// has no gateways or the mesh gateway.
+func appliesToSidecars(vs *v1alpha3.VirtualService) bool {
+	return len(vs.GetGateways()) == 0 || slices.Contains(vs.GetGateways(), util.MeshGateway)
+}
+
+func routeDestinations(vs *v1alpha3.VirtualService) []*v1alpha3.Destination {
+	var out []*v1alpha3.Destination
+	for _, r := range vs.GetHttp() {
+		for _, rd := range r.GetRoute() {
+			out = append(out, rd.GetDestination())
+		}
+	}
+	for _, r := range vs.GetTls() {
+		for _, rd := range r.GetRoute() {
+			out = append(out, rd.GetDestination())
+		}
+	}
+	for _, r := range vs.GetTcp() {
+		for _, rd := range r.GetRoute() {
+			out = append(out, rd.GetDestination())
+		}
+	}
+	return out
+}
@@ -106,6 +106,14 @@ const (
 	// Required parameters: portLevelSettings index.
 	DestinationRuleTLSPortLevelCert = "{.spec.trafficPolicy.portLevelSettings[%d].tls.caCertificates}"
 
+	// Path for DestinationRule subset name.
+	// Required parameters: subset index.
+	DestinationRuleSubsetName = "{.spec.subsets[%d].name}"
+
+	// Path for Sidecar egress host.
+	// Required parameters: egress index, host index.
+	SidecarEgressHost = "{.spec.egress[%d].hosts[%d]}"
+
 	// Path for ConfigPatch in envoyFilter
 	// Required parameters: envoyFilter config patch index
 	EnvoyFilterConfigPath = "{.spec.configPatches[%d].patch.value}"
@@ -113,6 +121,14 @@ const (
 	// Path for selector in telemetry.
 	// Required parameters: selector label.
 	TelemetrySelector = "{.spec.selector.matchLabels.%s}"
+
+	// Path for port level mTLS in PeerAuthentication.
+	// Required parameters: none.
+	PeerAuthenticationPortLevelMtls = "{.spec.portLevelMtls}"
+
+	// Path for the mTLS settings of a port in PeerAuthentication.
+	// Required parameters: port number.
+	PeerAuthenticationPortLevelMtlsPort = "{.spec.portLevelMtls.%d}"
 )
 
 // ErrorLine returns the line number of the input path key in the resource
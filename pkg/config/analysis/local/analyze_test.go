@@ -31,6 +31,7 @@ import (
 	"istio.io/istio/pkg/config/analysis/msg"
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/kube/controllers"
 	"istio.io/istio/pkg/test/util/assert"
@@ -218,6 +219,42 @@ func TestAddReaderKubeSource(t *testing.T) {
 	g.Expect(sa.meshCfg.RootNamespace).To(Equal(testRootNamespace)) // Should be mesh config from the file now
 }
 
+func TestAddSnapshotKubeSource(t *testing.T) {
+	g := NewWithT(t)
+
+	istioNamespace := resource.Namespace("istio-system")
+	sa := NewSourceAnalyzer(blankCombinedAnalyzer, "", istioNamespace, nil)
+
+	snapshot := `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: productpage
+    namespace: default
+- apiVersion: v1
+  kind: Event
+  metadata:
+    name: unknown-kind
+    namespace: default
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: istio
+    namespace: istio-system
+  data:
+    mesh: "rootNamespace: testNamespace"
+    meshNetworks: 'networks: {"n1": {}}'
+`
+	err := sa.AddSnapshotKubeSource([]ReaderSource{{Name: "k8s-resources", Reader: strings.NewReader(snapshot)}})
+	g.Expect(err).To(BeNil())
+	// Runtime resources are included, unknown kinds of the list are skipped.
+	g.Expect(sa.fileSource.Get(gvk.Pod, "productpage", "default")).NotTo(BeNil())
+	g.Expect(sa.meshCfg.RootNamespace).To(Equal("testNamespace"))
+	g.Expect(sa.meshNetworks.Networks).To(HaveLen(1))
+}
+
 func TestAddReaderKubeSourceSkipsBadEntries(t *testing.T) {
 	g := NewWithT(t)
 
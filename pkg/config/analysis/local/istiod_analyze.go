@@ -15,6 +15,7 @@
 package local
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -321,6 +322,37 @@ func (sa *IstiodAnalyzer) addReaderKubeSourceInternal(readers []ReaderSource, in
 	return errs
 }
 
+// AddSnapshotKubeSource adds files holding a snapshot of a cluster, like the resources collected by bug reports, as a
+// source. Unlike AddReaderKubeSource, the runtime resources like pods and services are included, and the mesh config
+// and networks are read from the Istio config map of the snapshot, if it has one.
+func (sa *IstiodAnalyzer) AddSnapshotKubeSource(readers []ReaderSource) error {
+	var errs error
+	// The file source only keeps the config maps if an analyzer needs them, so the Istio one is read separately.
+	configMaps := file.NewKubeSource(collection.SchemasFor(collections.ConfigMap))
+	contents := make([]ReaderSource, 0, len(readers))
+	for _, r := range readers {
+		by, err := io.ReadAll(r.Reader)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		// Parse errors are reported by the file source.
+		_ = configMaps.ApplyContent(r.Name, string(by))
+		contents = append(contents, ReaderSource{Name: r.Name, Reader: bytes.NewReader(by)})
+	}
+	if err := sa.addReaderKubeSourceInternal(contents, true); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	if cfg := configMaps.Get(gvk.ConfigMap, meshConfigMapName, sa.istioNamespace.String()); cfg != nil {
+		if cm, ok := cfg.Spec.(*v1.ConfigMap); ok {
+			if err := sa.addIstioConfigMap(cm); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
 // AddRunningKubeSource adds a source based on a running k8s cluster to the current IstiodAnalyzer
 // Also tries to get mesh config from the running cluster, if it can
 func (sa *IstiodAnalyzer) AddRunningKubeSource(c kubelib.Client) {
@@ -520,7 +552,11 @@ func (sa *IstiodAnalyzer) addRunningKubeIstioConfigMapSource(client kubelib.Clie
 	if err != nil {
 		return fmt.Errorf("could not read configmap %q from namespace %q: %v", meshConfigMapName, sa.istioNamespace, err)
 	}
+	return sa.addIstioConfigMap(meshConfigMap)
+}
 
+// addIstioConfigMap reads the mesh config and networks from the Istio config map.
+func (sa *IstiodAnalyzer) addIstioConfigMap(meshConfigMap *v1.ConfigMap) error {
 	configYaml, ok := meshConfigMap.Data[meshConfigMapKey]
 	if !ok {
 		return fmt.Errorf("missing config map key %q", meshConfigMapKey)
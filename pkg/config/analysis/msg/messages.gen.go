@@ -251,6 +251,46 @@ var (
 	// MultiClusterInconsistentService defines a diag.MessageType for message "MultiClusterInconsistentService".
 	// Description: The services live in different clusters under multi-cluster deployment model are inconsistent
 	MultiClusterInconsistentService = diag.NewMessageType(diag.Warning, "IST0170", "The service %v in namespace %q is inconsistent across clusters %q, which can lead to undefined behaviors. The inconsistent behaviors are: %v.")
+
+	// PeerAuthenticationDestinationRuleConflict defines a diag.MessageType for message "PeerAuthenticationDestinationRuleConflict".
+	// Description: A DestinationRule disables TLS for workloads that require mTLS
+	PeerAuthenticationDestinationRuleConflict = diag.NewMessageType(diag.Error, "IST0171", "DestinationRule %s disables TLS for %s port %d, but PeerAuthentication %s requires STRICT mTLS for the workloads on target port %d. Requests from sidecars will be rejected.")
+
+	// PeerAuthenticationPortLevelMTLSWithoutSelector defines a diag.MessageType for message "PeerAuthenticationPortLevelMTLSWithoutSelector".
+	// Description: A PeerAuthentication without a workload selector has port level mTLS settings
+	PeerAuthenticationPortLevelMTLSWithoutSelector = diag.NewMessageType(diag.Error, "IST0172", "The PeerAuthentication has port level mTLS settings but no workload selector. Port level mTLS is only supported for workload specific policies and is ignored.")
+
+	// PeerAuthenticationPortNotExposed defines a diag.MessageType for message "PeerAuthenticationPortNotExposed".
+	// Description: A PeerAuthentication has port level mTLS settings for a port no Service exposes
+	PeerAuthenticationPortNotExposed = diag.NewMessageType(diag.Warning, "IST0173", "Port %d in the port level mTLS settings is not a target port of any Service selecting the workloads, the setting has no effect on it.")
+
+	// MultiplePeerAuthenticationsWithoutWorkloadSelectors defines a diag.MessageType for message "MultiplePeerAuthenticationsWithoutWorkloadSelectors".
+	// Description: More than one PeerAuthentication resource in a namespace has no workload selector
+	MultiplePeerAuthenticationsWithoutWorkloadSelectors = diag.NewMessageType(diag.Warning, "IST0174", "The PeerAuthentications %v in namespace %q have no workload selector. Only the oldest one, %s, is applied as the %s policy.")
+
+	// DestinationRuleSubsetNotSelectWorkloads defines a diag.MessageType for message "DestinationRuleSubsetNotSelectWorkloads".
+	// Description: A DestinationRule subset does not select any pod or workload entry of its host
+	DestinationRuleSubsetNotSelectWorkloads = diag.NewMessageType(diag.Warning, "IST0175", "The labels of subset %s do not select any pod or workload entry of host %s. Traffic routed to the subset will fail.")
+
+	// DestinationRulePortNotFound defines a diag.MessageType for message "DestinationRulePortNotFound".
+	// Description: A DestinationRule has port level settings for a port the Service does not expose
+	DestinationRulePortNotFound = diag.NewMessageType(diag.Warning, "IST0176", "Port %d in the port level settings is not a port of service %s, the settings have no effect.")
+
+	// SidecarEgressHostNotFound defines a diag.MessageType for message "SidecarEgressHostNotFound".
+	// Description: A Sidecar egress host does not match any Service or ServiceEntry
+	SidecarEgressHostNotFound = diag.NewMessageType(diag.Warning, "IST0177", "The egress host %s does not match any Service or ServiceEntry visible to the namespace.")
+
+	// VirtualServiceDestinationNotImportedBySidecar defines a diag.MessageType for message "VirtualServiceDestinationNotImportedBySidecar".
+	// Description: A VirtualService routes to a destination that the Sidecar of a namespace does not import
+	VirtualServiceDestinationNotImportedBySidecar = diag.NewMessageType(diag.Warning, "IST0178", "The destination %s is not imported by Sidecar %s, requests from namespace %s routed to it by this VirtualService will fail.")
+
+	// UpgradeBehaviorChange defines a diag.MessageType for message "UpgradeBehaviorChange".
+	// Description: The configuration uses a feature whose behavior changes in the target release of an upgrade
+	UpgradeBehaviorChange = diag.NewMessageType(diag.Warning, "IST0179", "The %s %q changes in release %s: %s. Remediation: %s.")
+
+	// UpgradeRemovedConfig defines a diag.MessageType for message "UpgradeRemovedConfig".
+	// Description: The configuration uses a feature which is removed in the target release of an upgrade
+	UpgradeRemovedConfig = diag.NewMessageType(diag.Error, "IST0180", "The %s %q is removed in release %s: %s. Remediation: %s.")
 )
 
 // All returns a list of all known message types.
@@ -317,6 +357,16 @@ func All() []*diag.MessageType {
 		UnknownUpgradeCompatibility,
 		UpdateIncompatibility,
 		MultiClusterInconsistentService,
+		PeerAuthenticationDestinationRuleConflict,
+		PeerAuthenticationPortLevelMTLSWithoutSelector,
+		PeerAuthenticationPortNotExposed,
+		MultiplePeerAuthenticationsWithoutWorkloadSelectors,
+		DestinationRuleSubsetNotSelectWorkloads,
+		DestinationRulePortNotFound,
+		SidecarEgressHostNotFound,
+		VirtualServiceDestinationNotImportedBySidecar,
+		UpgradeBehaviorChange,
+		UpgradeRemovedConfig,
 	}
 }
 
@@ -919,3 +969,111 @@ func NewMultiClusterInconsistentService(r *resource.Instance, serviceName string
 		error,
 	)
 }
+
+// NewPeerAuthenticationDestinationRuleConflict returns a new diag.Message based on PeerAuthenticationDestinationRuleConflict.
+func NewPeerAuthenticationDestinationRuleConflict(r *resource.Instance, destinationRule string, host string, port int, peerAuthentication string, targetPort int) diag.Message {
+	return diag.NewMessage(
+		PeerAuthenticationDestinationRuleConflict,
+		r,
+		destinationRule,
+		host,
+		port,
+		peerAuthentication,
+		targetPort,
+	)
+}
+
+// NewPeerAuthenticationPortLevelMTLSWithoutSelector returns a new diag.Message based on PeerAuthenticationPortLevelMTLSWithoutSelector.
+func NewPeerAuthenticationPortLevelMTLSWithoutSelector(r *resource.Instance) diag.Message {
+	return diag.NewMessage(
+		PeerAuthenticationPortLevelMTLSWithoutSelector,
+		r,
+	)
+}
+
+// NewPeerAuthenticationPortNotExposed returns a new diag.Message based on PeerAuthenticationPortNotExposed.
+func NewPeerAuthenticationPortNotExposed(r *resource.Instance, port int) diag.Message {
+	return diag.NewMessage(
+		PeerAuthenticationPortNotExposed,
+		r,
+		port,
+	)
+}
+
+// NewMultiplePeerAuthenticationsWithoutWorkloadSelectors returns a new diag.Message based on MultiplePeerAuthenticationsWithoutWorkloadSelectors.
+func NewMultiplePeerAuthenticationsWithoutWorkloadSelectors(r *resource.Instance, conflictingPolicies []string, namespace string, appliedPolicy string, scope string) diag.Message {
+	return diag.NewMessage(
+		MultiplePeerAuthenticationsWithoutWorkloadSelectors,
+		r,
+		conflictingPolicies,
+		namespace,
+		appliedPolicy,
+		scope,
+	)
+}
+
+// NewDestinationRuleSubsetNotSelectWorkloads returns a new diag.Message based on DestinationRuleSubsetNotSelectWorkloads.
+func NewDestinationRuleSubsetNotSelectWorkloads(r *resource.Instance, subset string, host string) diag.Message {
+	return diag.NewMessage(
+		DestinationRuleSubsetNotSelectWorkloads,
+		r,
+		subset,
+		host,
+	)
+}
+
+// NewDestinationRulePortNotFound returns a new diag.Message based on DestinationRulePortNotFound.
+func NewDestinationRulePortNotFound(r *resource.Instance, port int, host string) diag.Message {
+	return diag.NewMessage(
+		DestinationRulePortNotFound,
+		r,
+		port,
+		host,
+	)
+}
+
+// NewSidecarEgressHostNotFound returns a new diag.Message based on SidecarEgressHostNotFound.
+func NewSidecarEgressHostNotFound(r *resource.Instance, host string) diag.Message {
+	return diag.NewMessage(
+		SidecarEgressHostNotFound,
+		r,
+		host,
+	)
+}
+
+// NewVirtualServiceDestinationNotImportedBySidecar returns a new diag.Message based on VirtualServiceDestinationNotImportedBySidecar.
+func NewVirtualServiceDestinationNotImportedBySidecar(r *resource.Instance, destination string, sidecar string, namespace string) diag.Message {
+	return diag.NewMessage(
+		VirtualServiceDestinationNotImportedBySidecar,
+		r,
+		destination,
+		sidecar,
+		namespace,
+	)
+}
+
+// NewUpgradeBehaviorChange returns a new diag.Message based on UpgradeBehaviorChange.
+func NewUpgradeBehaviorChange(r *resource.Instance, configType string, name string, release string, info string, remediation string) diag.Message {
+	return diag.NewMessage(
+		UpgradeBehaviorChange,
+		r,
+		configType,
+		name,
+		release,
+		info,
+		remediation,
+	)
+}
+
+// NewUpgradeRemovedConfig returns a new diag.Message based on UpgradeRemovedConfig.
+func NewUpgradeRemovedConfig(r *resource.Instance, configType string, name string, release string, info string, remediation string) diag.Message {
+	return diag.NewMessage(
+		UpgradeRemovedConfig,
+		r,
+		configType,
+		name,
+		release,
+		info,
+		remediation,
+	)
+}
@@ -89,6 +89,9 @@ const (
 	// The data name in the ConfigMap of each namespace storing the root cert of non-Kube CA.
 	CACertNamespaceConfigMapDataName = "root-cert.pem"
 
+	// The data name in the ConfigMap of each namespace storing the CRL of the workload certificates revoked by the Istio CA.
+	CACRLNamespaceConfigMapDataName = "ca-crl.pem"
+
 	// PodInfoLabelsPath is the filepath that pod labels will be stored
 	// This is typically set by the downward API
 	PodInfoLabelsPath = "./etc/istio/pod/labels"
@@ -133,6 +136,10 @@ const (
 	InternalGatewaySemantics = "internal.istio.io/gateway-semantics"
 	GatewaySemanticsGateway  = "gateway"
 
+	// InternalRouteExtensions is the key of the config Extra holding the extensions referenced by the http routes of
+	// an internally-generated VirtualService. This is used by k8s gateway-api ExtensionRef filters.
+	InternalRouteExtensions = "internal.istio.io/route-extensions"
+
 	// ThirdPartyJwtPath is the default 3P token to authenticate with third party services
 	ThirdPartyJwtPath = "./var/run/secrets/tokens/istio-token"
 
@@ -105,6 +105,9 @@ type Config struct {
 
 	// Status holds long-running status.
 	Status Status
+
+	// Extra holds additional, non-spec information for internal processing. It is not serialized.
+	Extra map[string]any
 }
 
 func LabelsInRevision(lbls map[string]string, rev string) bool {
@@ -355,6 +358,7 @@ func (c Config) DeepCopy() Config {
 	if c.Status != nil {
 		clone.Status = DeepCopy(c.Status)
 	}
+	clone.Extra = maps.Clone(c.Extra)
 	return clone
 }
 
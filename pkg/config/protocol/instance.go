@@ -46,7 +46,7 @@ const (
 	// TLS traffic is assumed to contain SNI as part of the handshake.
 	TLS Instance = "TLS"
 	// UDP declares that the port uses UDP.
-	// Note that UDP protocol is not currently supported by the proxy.
+	// Note that UDP protocol is currently only supported by gateways.
 	UDP Instance = "UDP"
 	// Mongo declares that the port carries MongoDB traffic.
 	Mongo Instance = "Mongo"
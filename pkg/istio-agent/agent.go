@@ -808,6 +808,10 @@ func (a *Agent) newSecretManager() (*cache.SecretManagerClient, error) {
 		return cache.NewSecretManagerClient(nil, a.secOpts)
 	}
 	log.Infof("CA Endpoint %s, provider %s", a.secOpts.CAEndpoint, a.secOpts.CAProviderName)
+	if a.secOpts.CACRLPath == "" {
+		// The CRL of the certificates revoked by the Istio CA is distributed with its root cert.
+		a.secOpts.CACRLPath = path.Join(CitadelCACertPath, constants.CACRLNamespaceConfigMapDataName)
+	}
 
 	caClient, err := createCAClient(a.secOpts, a)
 	if err != nil {
@@ -104,6 +104,14 @@ const (
 	// JWT is a Credential fetcher type that reads from a JWT token file
 	JWT = "JWT"
 
+	// MetadataToken is a Credential fetcher type that fetches the token from the token URL of an instance
+	// metadata service, such as the AWS or Azure ones
+	MetadataToken = "MetadataToken"
+
+	// OIDCTokenExchange is a Credential fetcher type that exchanges an OIDC token file, such as a workload
+	// identity federation token, for a token at an OAuth 2.0 token exchange endpoint
+	OIDCTokenExchange = "OIDCTokenExchange"
+
 	// Mock is Credential fetcher type of mock plugin
 	Mock = "Mock" // testing only
 
@@ -249,6 +257,10 @@ type Options struct {
 	// Root Cert read from the OS
 	CARootPath string
 
+	// The path of the CRL of the workload certificates revoked by the CA. If the file exists, the CRL
+	// is added to the root cert secret so that the proxy rejects the revoked peer certificates.
+	CACRLPath string
+
 	// The path for an existing certificate chain file
 	CertChainFilePath string
 	// The path for an existing key file
@@ -286,6 +298,9 @@ type SecretItem struct {
 
 	RootCert []byte
 
+	// CRL is the PEM encoded CRL of the revoked certificates, only set for the root cert.
+	CRL []byte
+
 	// ResourceName passed from envoy SDS discovery request.
 	// "ROOTCA" for root cert request, "default" for key/cert request.
 	ResourceName string
@@ -351,6 +366,8 @@ func (ac *AuthContext) Header(header string) []string {
 type Caller struct {
 	AuthSource AuthSource
 	Identities []string
+	// Authenticator is the type of the authenticator that authenticated the caller.
+	Authenticator string
 
 	KubernetesInfo KubernetesInfo
 }
@@ -362,6 +379,9 @@ type KubernetesInfo struct {
 	PodNamespace      string
 	PodUID            string
 	PodServiceAccount string
+	// NodeName is the node the pod is running on. It is only known for tokens bound to a pod on
+	// Kubernetes versions that include the node in the token.
+	NodeName string
 }
 
 func (k KubernetesInfo) String() string {
@@ -388,6 +408,7 @@ func (am *authenticationManager) authenticate(ctx context.Context) *Caller {
 		u, err := authn.Authenticate(req)
 		if u != nil && len(u.Identities) > 0 && err == nil {
 			securityLog.Debugf("Authentication successful through auth source %v", u.AuthSource)
+			u.Authenticator = authn.AuthenticatorType()
 			return u
 		}
 		am.authFailMsgs = append(am.authFailMsgs, fmt.Sprintf("Authenticator %s: %v", authn.AuthenticatorType(), err))
@@ -97,6 +97,12 @@ const (
 	OriginalSource = "envoy.filters.listener.original_src"
 )
 
+// UDP listener filter names
+const (
+	// UDPProxy UDP listener filter
+	UDPProxy = "envoy.filters.udp_listener.udp_proxy"
+)
+
 // Access log sink names
 const (
 	// FileAccessLog sink name
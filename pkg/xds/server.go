@@ -77,6 +77,10 @@ type WatchedResource struct {
 	// NonceAcked is the last acked message.
 	NonceAcked string
 
+	// VersionSent is the version of the last sent response, and VersionAcked the version of the last acked one.
+	VersionSent  string
+	VersionAcked string
+
 	// AlwaysRespond, if true, will ensure that even when a request would otherwise be treated as an
 	// ACK, it will be responded to. This typically happens when a proxy reconnects to another instance of
 	// Istiod. In that case, Envoy expects us to respond to EDS/RDS/SDS requests to finish warming of
@@ -407,6 +411,7 @@ func ShouldRespond(w Watcher, id string, request *discovery.DiscoveryRequest) (b
 		wr.LastError = ""
 		previousResources = wr.ResourceNames
 		wr.NonceAcked = request.ResponseNonce
+		wr.VersionAcked = wr.VersionSent
 		wr.ResourceNames = request.ResourceNames
 		alwaysRespond = wr.AlwaysRespond
 		wr.AlwaysRespond = false
@@ -469,6 +474,7 @@ func Send(ctx ConnectionContext, res *discovery.DiscoveryResponse) error {
 					wr = &WatchedResource{TypeUrl: res.TypeUrl}
 				}
 				wr.NonceSent = res.Nonce
+				wr.VersionSent = res.VersionInfo
 				wr.LastSendTime = time.Now()
 				return wr
 			})
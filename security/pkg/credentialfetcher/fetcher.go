@@ -22,10 +22,22 @@ import (
 	"istio.io/istio/security/pkg/credentialfetcher/plugin"
 )
 
-func NewCredFetcher(credtype, trustdomain, jwtPath, identityProvider string) (security.CredFetcher, error) {
+func NewCredFetcher(credtype, trustdomain, jwtPath, identityProvider string, httpOpts plugin.HTTPTokenOptions) (security.CredFetcher, error) {
 	switch credtype {
 	case security.GCE:
 		return plugin.CreateGCEPlugin(trustdomain, jwtPath, identityProvider), nil
+	case security.MetadataToken:
+		p, err := plugin.CreateMetadataTokenPlugin(httpOpts, jwtPath, identityProvider)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case security.OIDCTokenExchange:
+		p, err := plugin.CreateOIDCExchangePlugin(httpOpts, trustdomain, jwtPath, identityProvider)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
 	case security.JWT, "":
 		// If unset, also default to JWT for backwards compatibility
 		if jwtPath == "" {
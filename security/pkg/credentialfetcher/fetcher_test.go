@@ -27,6 +27,7 @@ func TestNewCredFetcher(t *testing.T) {
 		trustdomain      string
 		jwtPath          string
 		identityProvider string
+		httpOpts         plugin.HTTPTokenOptions
 		expectedErr      string
 		expectedToken    string
 		expectedIdp      string
@@ -49,6 +50,46 @@ func TestNewCredFetcher(t *testing.T) {
 			expectedToken:    "test_token",
 			expectedIdp:      "fakeIDP",
 		},
+		"metadata token test": {
+			fetcherType:      security.MetadataToken,
+			jwtPath:          "",
+			identityProvider: "fakeIDP",
+			httpOpts: plugin.HTTPTokenOptions{
+				URL:          "http://169.254.169.254/metadata/identity/oauth2/token",
+				HeaderScheme: plugin.HeaderSchemeAzure,
+			},
+			expectedIdp: "fakeIDP",
+		},
+		"metadata token without url test": {
+			fetcherType: security.MetadataToken,
+			expectedErr: "the token URL of the instance metadata service is unset",
+		},
+		"metadata token invalid header scheme test": {
+			fetcherType: security.MetadataToken,
+			httpOpts: plugin.HTTPTokenOptions{
+				URL:          "http://169.254.169.254/latest/meta-data/token",
+				HeaderScheme: "foo",
+			},
+			expectedErr: "invalid instance metadata header scheme foo",
+		},
+		"oidc token exchange test": {
+			fetcherType:      security.OIDCTokenExchange,
+			trustdomain:      "cluster.local",
+			jwtPath:          "",
+			identityProvider: "fakeIDP",
+			httpOpts: plugin.HTTPTokenOptions{
+				URL:              "https://sts.example.com/token",
+				SubjectTokenPath: "/var/run/secrets/oidc/token",
+			},
+			expectedIdp: "fakeIDP",
+		},
+		"oidc token exchange without token file test": {
+			fetcherType: security.OIDCTokenExchange,
+			httpOpts: plugin.HTTPTokenOptions{
+				URL: "https://sts.example.com/token",
+			},
+			expectedErr: "the OIDC token file to exchange is unset",
+		},
 		"invalid test": {
 			fetcherType:      "foo",
 			trustdomain:      "",
@@ -66,7 +107,7 @@ func TestNewCredFetcher(t *testing.T) {
 		t.Run(id, func(t *testing.T) {
 			t.Parallel()
 			cf, err := NewCredFetcher(
-				tc.fetcherType, tc.trustdomain, tc.jwtPath, tc.identityProvider)
+				tc.fetcherType, tc.trustdomain, tc.jwtPath, tc.identityProvider, tc.httpOpts)
 			if cf != nil {
 				defer cf.Stop()
 			}
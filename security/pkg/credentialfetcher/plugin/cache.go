@@ -0,0 +1,131 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/security/pkg/util"
+)
+
+// tokenCache caches the token returned by fetch, and fetches a new one once the cached one is about to expire.
+// The token is refreshed in the background as well, unless the token rotation is disabled.
+type tokenCache struct {
+	log   *log.Scope
+	fetch func() (string, time.Time, error)
+	// The location to save the token, if set.
+	jwtPath string
+
+	closing chan struct{}
+	// mutex lock is required to avoid race condition when updating token file and token cache.
+	mutex sync.Mutex
+	token string
+	// exp is the expiration of the token, if known, and refreshAt is the time the token is refreshed at.
+	exp       time.Time
+	refreshAt time.Time
+}
+
+// newTokenCache creates a token cache fetching the tokens, and their expiration if known, with fetch.
+func newTokenCache(scope *log.Scope, jwtPath string, fetch func() (string, time.Time, error)) *tokenCache {
+	c := &tokenCache{
+		log:     scope,
+		fetch:   fetch,
+		jwtPath: jwtPath,
+		closing: make(chan struct{}),
+	}
+	if rotateToken {
+		go c.startTokenRotationJob()
+	}
+	return c
+}
+
+func (c *tokenCache) stop() {
+	close(c.closing)
+}
+
+func (c *tokenCache) startTokenRotationJob() {
+	ticker := time.NewTicker(rotationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if c.shouldRefresh(time.Now()) {
+				if _, err := c.get(); err != nil {
+					c.log.Errorf("credential refresh failed: %v", err)
+				}
+			}
+		case <-c.closing:
+			return
+		}
+	}
+}
+
+func (c *tokenCache) shouldRefresh(now time.Time) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.token == "" || !now.Before(c.refreshAt)
+}
+
+// get returns the cached token, or fetches a new one if it is about to expire. The cached token is returned
+// if it fails to fetch a new one while the cached one has not expired yet.
+func (c *tokenCache) get() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if c.token != "" && now.Before(c.refreshAt) {
+		return c.token, nil
+	}
+	token, exp, err := c.fetch()
+	if err != nil {
+		if c.token != "" && now.Before(c.exp) {
+			c.log.Warnf("failed to refresh the credential, using the cached one expiring at %v: %v", c.exp, err)
+			return c.token, nil
+		}
+		return "", err
+	}
+	if exp.IsZero() {
+		// When the expiration is not part of the response, get it from the token.
+		exp, _ = util.GetExp(token)
+	}
+	if c.jwtPath != "" {
+		if err := os.WriteFile(c.jwtPath, []byte(token), 0o640); err != nil {
+			c.log.Errorf("Encountered error when writing the credential: %v", err)
+			return "", err
+		}
+	}
+	c.token = token
+	c.exp = exp
+	c.refreshAt = refreshTime(now, exp)
+	c.log.Debugf("got credential of length %d, expiring at %v, refreshed at %v", len(token), exp, c.refreshAt)
+	return token, nil
+}
+
+// refreshTime returns the time a token fetched at now and expiring at exp is refreshed at: when its remaining
+// lifetime goes below the grace period, or half of its lifetime for short-lived tokens. Tokens whose expiration
+// is unknown are refreshed after the rotation interval.
+func refreshTime(now, exp time.Time) time.Time {
+	if exp.IsZero() {
+		return now.Add(rotationInterval)
+	}
+	grace := gracePeriod
+	if lifetime := exp.Sub(now); lifetime < 2*grace {
+		grace = lifetime / 2
+	}
+	return exp.Add(-grace)
+}
@@ -0,0 +1,124 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpTimeout is the timeout of the requests of the credential fetchers to their token endpoint.
+var httpTimeout = 10 * time.Second
+
+// HTTPTokenOptions configures the credential fetchers fetching the tokens from an HTTP endpoint.
+type HTTPTokenOptions struct {
+	// URL is the token endpoint: the token URL of the instance metadata service, or the OIDC token
+	// exchange endpoint.
+	URL string
+	// HeaderScheme is the header scheme of the instance metadata service: one of the HeaderScheme constants.
+	HeaderScheme string
+	// Headers are additional headers sent to the token endpoint.
+	Headers map[string]string
+	// SubjectTokenPath is the OIDC token file exchanged at the OIDC token exchange endpoint.
+	SubjectTokenPath string
+	// Audience, ClientID and Scope are the parameters of the OIDC token exchange. The audience defaults
+	// to the trust domain.
+	Audience string
+	ClientID string
+	Scope    string
+}
+
+// tokenResponse is the JSON token response of an OAuth 2.0 token endpoint, or of an instance metadata service.
+// Numbers may be encoded as strings by the instance metadata services.
+type tokenResponse struct {
+	AccessToken string          `json:"access_token"`
+	IDToken     string          `json:"id_token"`
+	Token       string          `json:"token"`
+	ExpiresIn   json.RawMessage `json:"expires_in"`
+	ExpiresOn   json.RawMessage `json:"expires_on"`
+}
+
+// parseTokenResponse returns the token of a response body, and its expiration if part of the response.
+// The body is either a JSON token response, or the token itself.
+func parseTokenResponse(body []byte, now time.Time) (string, time.Time, error) {
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) == 0 {
+		return "", time.Time{}, fmt.Errorf("empty token response")
+	}
+	if body[0] != '{' {
+		return string(body), time.Time{}, nil
+	}
+	var resp tokenResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid token response: %v", err)
+	}
+	token := resp.AccessToken
+	if token == "" {
+		token = resp.IDToken
+	}
+	if token == "" {
+		token = resp.Token
+	}
+	if token == "" {
+		return "", time.Time{}, fmt.Errorf("no token in the token response")
+	}
+	var exp time.Time
+	if in, ok := jsonInt(resp.ExpiresIn); ok {
+		exp = now.Add(time.Duration(in) * time.Second)
+	} else if on, ok := jsonInt(resp.ExpiresOn); ok {
+		exp = time.Unix(on, 0)
+	}
+	return token, exp, nil
+}
+
+// jsonInt parses a JSON integer, which may be encoded as a string.
+func jsonInt(raw json.RawMessage) (int64, bool) {
+	s := strings.Trim(string(raw), `"`)
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	return v, err == nil
+}
+
+// doTokenRequest sends the request to the token endpoint and returns the body of the response.
+func doTokenRequest(client *http.Client, req *http.Request, headers map[string]string) ([]byte, error) {
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		const maxLen = 256
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > maxLen {
+			msg = msg[:maxLen]
+		}
+		return nil, fmt.Errorf("%s %s returned status %d: %s", req.Method, req.URL.Redacted(), resp.StatusCode, msg)
+	}
+	return body, nil
+}
@@ -0,0 +1,147 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This is the instance metadata service plugin of credentialfetcher.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/security"
+)
+
+var metadatacredLog = log.RegisterScope("metadatacred", "Instance metadata credential fetcher for istio agent")
+
+const (
+	// HeaderSchemeNone only sends the headers of the options to the instance metadata service.
+	HeaderSchemeNone = ""
+	// HeaderSchemeGCE sends the "Metadata-Flavor: Google" header, as required by the GCE metadata server.
+	HeaderSchemeGCE = "gce"
+	// HeaderSchemeAzure sends the "Metadata: true" header, as required by the Azure instance metadata service.
+	HeaderSchemeAzure = "azure"
+	// HeaderSchemeAWSIMDSv2 gets a session token from the /latest/api/token path of the AWS instance metadata
+	// service, and sends it in the "X-aws-ec2-metadata-token" header.
+	HeaderSchemeAWSIMDSv2 = "aws-imdsv2"
+)
+
+const (
+	awsSessionTokenPath      = "/latest/api/token"
+	awsSessionTokenHeader    = "X-aws-ec2-metadata-token"
+	awsSessionTokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+	// awsSessionTokenTTL is the lifetime requested for the AWS session tokens, which are only used once.
+	awsSessionTokenTTL = "60"
+)
+
+// MetadataTokenPlugin is the plugin object fetching the token from the token URL of an instance metadata service.
+type MetadataTokenPlugin struct {
+	opts   HTTPTokenOptions
+	client *http.Client
+
+	// identity provider
+	identityProvider string
+
+	cache *tokenCache
+}
+
+var _ security.CredFetcher = &MetadataTokenPlugin{}
+
+// CreateMetadataTokenPlugin creates an instance metadata service credential fetcher plugin, saving the token
+// to jwtPath if set. Return the pointer to the created plugin.
+func CreateMetadataTokenPlugin(opts HTTPTokenOptions, jwtPath, identityProvider string) (*MetadataTokenPlugin, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("the token URL of the instance metadata service is unset")
+	}
+	switch opts.HeaderScheme {
+	case HeaderSchemeNone, HeaderSchemeGCE, HeaderSchemeAzure, HeaderSchemeAWSIMDSv2:
+	default:
+		return nil, fmt.Errorf("invalid instance metadata header scheme %s", opts.HeaderScheme)
+	}
+	p := &MetadataTokenPlugin{
+		opts:             opts,
+		client:           &http.Client{Timeout: httpTimeout},
+		identityProvider: identityProvider,
+	}
+	p.cache = newTokenCache(metadatacredLog, jwtPath, p.fetch)
+	return p, nil
+}
+
+// GetPlatformCredential returns the cached token, fetching a new one from the instance metadata service
+// when it is about to expire.
+func (p *MetadataTokenPlugin) GetPlatformCredential() (string, error) {
+	return p.cache.get()
+}
+
+func (p *MetadataTokenPlugin) fetch() (string, time.Time, error) {
+	headers := maps.Clone(p.opts.Headers)
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	switch p.opts.HeaderScheme {
+	case HeaderSchemeGCE:
+		headers["Metadata-Flavor"] = "Google"
+	case HeaderSchemeAzure:
+		headers["Metadata"] = "true"
+	case HeaderSchemeAWSIMDSv2:
+		sessionToken, err := p.awsSessionToken()
+		if err != nil {
+			metadatacredLog.Errorf("Failed to get the session token from the instance metadata service: %v", err)
+			return "", time.Time{}, err
+		}
+		headers[awsSessionTokenHeader] = sessionToken
+	}
+	req, err := http.NewRequest(http.MethodGet, p.opts.URL, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	body, err := doTokenRequest(p.client, req, headers)
+	if err != nil {
+		metadatacredLog.Errorf("Failed to get the token from the instance metadata service: %v", err)
+		return "", time.Time{}, err
+	}
+	return parseTokenResponse(body, time.Now())
+}
+
+// awsSessionToken gets a session token from the AWS instance metadata service of the token URL.
+func (p *MetadataTokenPlugin) awsSessionToken() (string, error) {
+	u, err := url.Parse(p.opts.URL)
+	if err != nil {
+		return "", err
+	}
+	u = &url.URL{Scheme: u.Scheme, Host: u.Host, Path: awsSessionTokenPath}
+	req, err := http.NewRequest(http.MethodPut, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	body, err := doTokenRequest(p.client, req, map[string]string{awsSessionTokenTTLHeader: awsSessionTokenTTL})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// GetIdentityProvider returns the name of the identity provider that can authenticate the workload credential.
+func (p *MetadataTokenPlugin) GetIdentityProvider() string {
+	return p.identityProvider
+}
+
+func (p *MetadataTokenPlugin) Stop() {
+	p.cache.stop()
+}
@@ -0,0 +1,240 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+// testJwt returns an unsigned JWT expiring at exp.
+func testJwt(exp time.Time) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		enc.EncodeToString([]byte(fmt.Sprintf(`{"sub":"vm","exp":%d}`, exp.Unix()))) + ".sig"
+}
+
+// fakeMetadataServer is a local stand-in for the instance metadata services.
+type fakeMetadataServer struct {
+	*httptest.Server
+	requests *atomic.Int32
+	// status is the status of the token responses.
+	status *atomic.Int32
+}
+
+func newFakeMetadataServer(t *testing.T, token string) *fakeMetadataServer {
+	s := &fakeMetadataServer{requests: atomic.NewInt32(0), status: atomic.NewInt32(http.StatusOK)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.Header.Get(awsSessionTokenTTLHeader) == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("session-token"))
+	})
+	// The GCE metadata server returns the token itself.
+	mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/identity", func(w http.ResponseWriter, r *http.Request) {
+		if !s.serve(w, r, "Metadata-Flavor", "Google") {
+			return
+		}
+		_, _ = w.Write([]byte(token))
+	})
+	// The Azure instance metadata service returns a JSON token response, with the numbers as strings.
+	mux.HandleFunc("/metadata/identity/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		if !s.serve(w, r, "Metadata", "true") {
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"access_token":%q,"expires_in":"3599","token_type":"Bearer"}`, token)
+	})
+	mux.HandleFunc("/latest/meta-data/identity-token", func(w http.ResponseWriter, r *http.Request) {
+		if !s.serve(w, r, awsSessionTokenHeader, "session-token") {
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"token":%q}`, token)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if !s.serve(w, r, "X-Custom", "value") {
+			return
+		}
+		_, _ = w.Write([]byte(token))
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeMetadataServer) serve(w http.ResponseWriter, r *http.Request, header, value string) bool {
+	s.requests.Inc()
+	if r.Header.Get(header) != value {
+		http.Error(w, "missing "+header+" header", http.StatusBadRequest)
+		return false
+	}
+	if status := int(s.status.Load()); status != http.StatusOK {
+		http.Error(w, "unavailable", status)
+		return false
+	}
+	return true
+}
+
+func TestMetadataTokenPlugin(t *testing.T) {
+	token := testJwt(time.Now().Add(time.Hour))
+	server := newFakeMetadataServer(t, token)
+	testCases := map[string]HTTPTokenOptions{
+		"gce": {
+			URL:          server.URL + "/computeMetadata/v1/instance/service-accounts/default/identity?audience=cluster.local",
+			HeaderScheme: HeaderSchemeGCE,
+		},
+		"azure": {
+			URL:          server.URL + "/metadata/identity/oauth2/token?api-version=2018-02-01&resource=cluster.local",
+			HeaderScheme: HeaderSchemeAzure,
+		},
+		"aws imdsv2": {
+			URL:          server.URL + "/latest/meta-data/identity-token",
+			HeaderScheme: HeaderSchemeAWSIMDSv2,
+		},
+		"custom headers": {
+			URL:     server.URL + "/token",
+			Headers: map[string]string{"X-Custom": "value"},
+		},
+	}
+	for name, opts := range testCases {
+		t.Run(name, func(t *testing.T) {
+			jwtPath := filepath.Join(t.TempDir(), "istio-token")
+			p, err := CreateMetadataTokenPlugin(opts, jwtPath, "fakeIDP")
+			assert.NoError(t, err)
+			t.Cleanup(p.Stop)
+
+			got, err := p.GetPlatformCredential()
+			assert.NoError(t, err)
+			assert.Equal(t, got, token)
+			saved, err := os.ReadFile(jwtPath)
+			assert.NoError(t, err)
+			assert.Equal(t, string(saved), token)
+			assert.Equal(t, p.GetIdentityProvider(), "fakeIDP")
+		})
+	}
+
+	t.Run("missing header", func(t *testing.T) {
+		p, err := CreateMetadataTokenPlugin(HTTPTokenOptions{URL: server.URL + "/metadata/identity/oauth2/token"}, "", "")
+		assert.NoError(t, err)
+		t.Cleanup(p.Stop)
+		_, err = p.GetPlatformCredential()
+		assert.Error(t, err)
+	})
+}
+
+func TestMetadataTokenPluginCache(t *testing.T) {
+	token := testJwt(time.Now().Add(time.Hour))
+	server := newFakeMetadataServer(t, token)
+	p, err := CreateMetadataTokenPlugin(HTTPTokenOptions{
+		URL:          server.URL + "/metadata/identity/oauth2/token",
+		HeaderScheme: HeaderSchemeAzure,
+	}, "", "")
+	assert.NoError(t, err)
+	t.Cleanup(p.Stop)
+
+	for i := 0; i < 3; i++ {
+		got, err := p.GetPlatformCredential()
+		assert.NoError(t, err)
+		assert.Equal(t, got, token)
+	}
+	// The token is only fetched once, until it is about to expire.
+	assert.Equal(t, server.requests.Load(), int32(1))
+	assert.Equal(t, p.cache.shouldRefresh(time.Now()), false)
+	assert.Equal(t, p.cache.shouldRefresh(time.Now().Add(time.Hour-gracePeriod)), true)
+
+	// The cached token is still used while the metadata service is unavailable, until it expires.
+	server.status.Store(http.StatusServiceUnavailable)
+	p.cache.mutex.Lock()
+	p.cache.refreshAt = time.Now()
+	p.cache.mutex.Unlock()
+	got, err := p.GetPlatformCredential()
+	assert.NoError(t, err)
+	assert.Equal(t, got, token)
+	assert.Equal(t, server.requests.Load(), int32(2))
+
+	p.cache.mutex.Lock()
+	p.cache.exp = time.Now()
+	p.cache.mutex.Unlock()
+	_, err = p.GetPlatformCredential()
+	assert.Error(t, err)
+}
+
+func TestRefreshTime(t *testing.T) {
+	now := time.Now()
+	assert.Equal(t, refreshTime(now, time.Time{}), now.Add(rotationInterval))
+	assert.Equal(t, refreshTime(now, now.Add(time.Hour)), now.Add(time.Hour-gracePeriod))
+	// Short-lived tokens are refreshed at half of their lifetime.
+	assert.Equal(t, refreshTime(now, now.Add(10*time.Minute)), now.Add(5*time.Minute))
+}
+
+func TestParseTokenResponse(t *testing.T) {
+	now := time.Now()
+	testCases := map[string]struct {
+		body          string
+		expectedToken string
+		expectedExp   time.Time
+		expectedErr   bool
+	}{
+		"raw token": {
+			body:          "token\n",
+			expectedToken: "token",
+		},
+		"access token": {
+			body:          `{"access_token":"token","expires_in":3600}`,
+			expectedToken: "token",
+			expectedExp:   now.Add(time.Hour),
+		},
+		"string expiration": {
+			body:          `{"access_token":"token","expires_on":"1700000000"}`,
+			expectedToken: "token",
+			expectedExp:   time.Unix(1700000000, 0),
+		},
+		"id token": {
+			body:          `{"id_token":"token"}`,
+			expectedToken: "token",
+		},
+		"no token": {
+			body:        `{"expires_in":3600}`,
+			expectedErr: true,
+		},
+		"empty": {
+			body:        "",
+			expectedErr: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			token, exp, err := parseTokenResponse([]byte(tc.body), now)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, token, tc.expectedToken)
+			assert.Equal(t, exp, tc.expectedExp)
+		})
+	}
+}
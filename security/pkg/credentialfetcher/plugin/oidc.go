@@ -0,0 +1,124 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This is the OIDC token exchange plugin of credentialfetcher.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/security"
+)
+
+var oidccredLog = log.RegisterScope("oidccred", "OIDC token exchange credential fetcher for istio agent")
+
+const (
+	// tokenExchangeGrantType, jwtTokenType and idTokenType are defined by the OAuth 2.0 token exchange, RFC 8693.
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	jwtTokenType           = "urn:ietf:params:oauth:token-type:jwt"
+	idTokenType            = "urn:ietf:params:oauth:token-type:id_token"
+)
+
+// OIDCExchangePlugin is the plugin object exchanging an OIDC token file, such as a workload identity federation
+// token, for a token at an OAuth 2.0 token exchange endpoint.
+type OIDCExchangePlugin struct {
+	opts   HTTPTokenOptions
+	client *http.Client
+
+	// identity provider
+	identityProvider string
+
+	cache *tokenCache
+}
+
+var _ security.CredFetcher = &OIDCExchangePlugin{}
+
+// CreateOIDCExchangePlugin creates an OIDC token exchange credential fetcher plugin, saving the exchanged token
+// to jwtPath if set. The audience of the exchange defaults to the trust domain. Return the pointer to the created plugin.
+func CreateOIDCExchangePlugin(opts HTTPTokenOptions, trustdomain, jwtPath, identityProvider string) (*OIDCExchangePlugin, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("the OIDC token exchange endpoint is unset")
+	}
+	if opts.SubjectTokenPath == "" {
+		return nil, fmt.Errorf("the OIDC token file to exchange is unset")
+	}
+	if opts.SubjectTokenPath == jwtPath {
+		return nil, fmt.Errorf("the OIDC token file to exchange %s is also the path of the exchanged token", jwtPath)
+	}
+	if opts.Audience == "" {
+		opts.Audience = trustdomain
+	}
+	p := &OIDCExchangePlugin{
+		opts:             opts,
+		client:           &http.Client{Timeout: httpTimeout},
+		identityProvider: identityProvider,
+	}
+	p.cache = newTokenCache(oidccredLog, jwtPath, p.fetch)
+	return p, nil
+}
+
+// GetPlatformCredential returns the cached token, exchanging the OIDC token file for a new one when it is
+// about to expire.
+func (p *OIDCExchangePlugin) GetPlatformCredential() (string, error) {
+	return p.cache.get()
+}
+
+func (p *OIDCExchangePlugin) fetch() (string, time.Time, error) {
+	// The OIDC token file is read on every exchange, as it is rotated by the platform.
+	subjectToken, err := os.ReadFile(p.opts.SubjectTokenPath)
+	if err != nil {
+		oidccredLog.Errorf("Failed to read the OIDC token file: %v", err)
+		return "", time.Time{}, err
+	}
+	form := url.Values{
+		"grant_type":           {tokenExchangeGrantType},
+		"subject_token":        {strings.TrimSpace(string(subjectToken))},
+		"subject_token_type":   {jwtTokenType},
+		"requested_token_type": {idTokenType},
+		"audience":             {p.opts.Audience},
+	}
+	if p.opts.ClientID != "" {
+		form.Set("client_id", p.opts.ClientID)
+	}
+	if p.opts.Scope != "" {
+		form.Set("scope", p.opts.Scope)
+	}
+	req, err := http.NewRequest(http.MethodPost, p.opts.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, err := doTokenRequest(p.client, req, p.opts.Headers)
+	if err != nil {
+		oidccredLog.Errorf("Failed to exchange the OIDC token: %v", err)
+		return "", time.Time{}, err
+	}
+	return parseTokenResponse(body, time.Now())
+}
+
+// GetIdentityProvider returns the name of the identity provider that can authenticate the workload credential.
+func (p *OIDCExchangePlugin) GetIdentityProvider() string {
+	return p.identityProvider
+}
+
+func (p *OIDCExchangePlugin) Stop() {
+	p.cache.stop()
+}
@@ -0,0 +1,108 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestOIDCExchangePlugin(t *testing.T) {
+	dir := t.TempDir()
+	subjectTokenPath := filepath.Join(dir, "federated-token")
+	jwtPath := filepath.Join(dir, "istio-token")
+	assert.NoError(t, os.WriteFile(subjectTokenPath, []byte("subject-token-1\n"), 0o600))
+
+	token := testJwt(time.Now().Add(time.Hour))
+	var subjectTokens []string
+	// server is a local stand-in for an OAuth 2.0 token exchange endpoint.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Method != http.MethodPost ||
+			r.PostForm.Get("grant_type") != tokenExchangeGrantType ||
+			r.PostForm.Get("subject_token_type") != jwtTokenType ||
+			r.PostForm.Get("audience") != "cluster.local" ||
+			r.PostForm.Get("client_id") != "istio-agent" ||
+			r.Header.Get("X-Custom") != "value" {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		subjectTokens = append(subjectTokens, r.PostForm.Get("subject_token"))
+		_, _ = fmt.Fprintf(w, `{"access_token":%q,"issued_token_type":%q,"token_type":"N_A","expires_in":3600}`, token, idTokenType)
+	}))
+	t.Cleanup(server.Close)
+
+	p, err := CreateOIDCExchangePlugin(HTTPTokenOptions{
+		URL:              server.URL,
+		Headers:          map[string]string{"X-Custom": "value"},
+		SubjectTokenPath: subjectTokenPath,
+		ClientID:         "istio-agent",
+	}, "cluster.local", jwtPath, "fakeIDP")
+	assert.NoError(t, err)
+	t.Cleanup(p.Stop)
+
+	got, err := p.GetPlatformCredential()
+	assert.NoError(t, err)
+	assert.Equal(t, got, token)
+	saved, err := os.ReadFile(jwtPath)
+	assert.NoError(t, err)
+	assert.Equal(t, string(saved), token)
+	assert.Equal(t, p.GetIdentityProvider(), "fakeIDP")
+
+	// The cached token is returned until it is about to expire, then the rotated OIDC token file is exchanged.
+	_, err = p.GetPlatformCredential()
+	assert.NoError(t, err)
+	assert.Equal(t, subjectTokens, []string{"subject-token-1"})
+	assert.NoError(t, os.WriteFile(subjectTokenPath, []byte("subject-token-2"), 0o600))
+	p.cache.mutex.Lock()
+	p.cache.refreshAt = time.Now()
+	p.cache.mutex.Unlock()
+	_, err = p.GetPlatformCredential()
+	assert.NoError(t, err)
+	assert.Equal(t, subjectTokens, []string{"subject-token-1", "subject-token-2"})
+
+	// The exchange fails with another audience.
+	p2, err := CreateOIDCExchangePlugin(HTTPTokenOptions{
+		URL:              server.URL,
+		Headers:          map[string]string{"X-Custom": "value"},
+		SubjectTokenPath: subjectTokenPath,
+		ClientID:         "istio-agent",
+		Audience:         "other",
+	}, "cluster.local", "", "")
+	assert.NoError(t, err)
+	t.Cleanup(p2.Stop)
+	_, err = p2.GetPlatformCredential()
+	assert.Error(t, err)
+}
+
+func TestCreateOIDCExchangePluginErrors(t *testing.T) {
+	_, err := CreateOIDCExchangePlugin(HTTPTokenOptions{SubjectTokenPath: "/token"}, "cluster.local", "", "")
+	assert.Error(t, err)
+	_, err = CreateOIDCExchangePlugin(HTTPTokenOptions{URL: "https://sts.example.com/token"}, "cluster.local", "", "")
+	assert.Error(t, err)
+	_, err = CreateOIDCExchangePlugin(HTTPTokenOptions{URL: "https://sts.example.com/token", SubjectTokenPath: "/token"},
+		"cluster.local", "/token", "")
+	assert.Error(t, err)
+}
@@ -21,21 +21,18 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/kube/kclient"
 	"istio.io/istio/pkg/log"
 )
 
 // InsertDataToConfigMap inserts a data to a configmap in a namespace.
-func InsertDataToConfigMap(client kclient.Client[*v1.ConfigMap], meta metav1.ObjectMeta, caBundle []byte) error {
+func InsertDataToConfigMap(client kclient.Client[*v1.ConfigMap], meta metav1.ObjectMeta, data map[string]string) error {
 	configmap := client.Get(meta.Name, meta.Namespace)
 	if configmap == nil {
 		// Create a new ConfigMap.
 		configmap = &v1.ConfigMap{
 			ObjectMeta: meta,
-			Data: map[string]string{
-				constants.CACertNamespaceConfigMapDataName: string(caBundle),
-			},
+			Data:       data,
 		}
 		if _, err := client.Create(configmap); err != nil {
 			// Namespace may be deleted between now... and our previous check. Just skip this, we cannot create into deleted ns
@@ -51,7 +48,7 @@ func InsertDataToConfigMap(client kclient.Client[*v1.ConfigMap], meta metav1.Obj
 		}
 	} else {
 		// Otherwise, update the config map if changes are required
-		err := updateDataInConfigMap(client, configmap, caBundle)
+		err := updateDataInConfigMap(client, configmap, data)
 		if err != nil {
 			return err
 		}
@@ -75,14 +72,11 @@ func insertData(cm *v1.ConfigMap, data map[string]string) bool {
 	return needsUpdate
 }
 
-func updateDataInConfigMap(c kclient.Client[*v1.ConfigMap], cm *v1.ConfigMap, caBundle []byte) error {
+func updateDataInConfigMap(c kclient.Client[*v1.ConfigMap], cm *v1.ConfigMap, data map[string]string) error {
 	if cm == nil {
 		return fmt.Errorf("cannot update nil configmap")
 	}
 	newCm := cm.DeepCopy()
-	data := map[string]string{
-		constants.CACertNamespaceConfigMapDataName: string(caBundle),
-	}
 	if needsUpdate := insertData(newCm, data); !needsUpdate {
 		return nil
 	}
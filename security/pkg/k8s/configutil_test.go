@@ -45,7 +45,6 @@ func TestUpdateDataInConfigMap(t *testing.T) {
 		Resource: "configmaps",
 		Version:  "v1",
 	}
-	caBundle := "test-data"
 	testData := map[string]string{
 		constants.CACertNamespaceConfigMapDataName: "test-data",
 	}
@@ -94,7 +93,7 @@ func TestUpdateDataInConfigMap(t *testing.T) {
 				}
 			}
 			fake.ClearActions()
-			err := updateDataInConfigMap(configmaps, tc.existingConfigMap, []byte(caBundle))
+			err := updateDataInConfigMap(configmaps, tc.existingConfigMap, testData)
 			if err != nil && err.Error() != tc.expectedErr {
 				t.Errorf("actual error (%s) different from expected error (%s).", err.Error(), tc.expectedErr)
 			}
@@ -226,7 +225,7 @@ func TestInsertDataToConfigMap(t *testing.T) {
 			}
 			kc.RunAndWait(test.NewStop(t))
 			fake.ClearActions()
-			err := InsertDataToConfigMap(configmaps, tc.meta, tc.caBundle)
+			err := InsertDataToConfigMap(configmaps, tc.meta, map[string]string{constants.CACertNamespaceConfigMapDataName: string(tc.caBundle)})
 			if err != nil && err.Error() != tc.expectedErr {
 				t.Errorf("actual error (%s) different from expected error (%s).", err.Error(), tc.expectedErr)
 			}
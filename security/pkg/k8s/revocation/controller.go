@@ -0,0 +1,474 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/controllers"
+	"istio.io/istio/pkg/kube/kclient"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/monitoring"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/istiomultierror"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+var revocationLog = log.RegisterScope("carevocation", "CA certificate revocation controller log")
+
+var (
+	// crlCheckInterval is the interval at which the CRL is checked for expiry or for a change of the CA signing
+	// certificate, and at which the issued certificates are persisted.
+	crlCheckInterval = time.Minute
+	// issuedCertsPeriod is the issuance period of the certificates persisted in the same ConfigMaps.
+	issuedCertsPeriod = 10 * time.Minute
+	// maxIssuedCertsSize is the size of the issued certificates persisted in a ConfigMap above which they are
+	// persisted in the next ConfigMap of their issuance period, far below the 1MiB limit of the ConfigMaps.
+	maxIssuedCertsSize = 512 * 1024
+	// maxPersistFailures is the number of consecutive failures to persist the issued certificates after which
+	// they are dropped, so the backlog of certificates to persist does not keep growing.
+	maxPersistFailures = 5
+
+	issuedCertsDropped = monitoring.NewSum(
+		"citadel_server_issued_certs_dropped_count",
+		"The number of issued certificates not persisted because the ConfigMaps could not be written.",
+	)
+)
+
+// issuedCertsUpdatedAnnotation is the time the issued certificates ConfigMap was last updated at, which bounds the
+// issuance time of its certificates.
+const issuedCertsUpdatedAnnotation = "istio.io/ca-issued-certs-updated"
+
+// persistedCert is an issued certificate persisted in the ConfigMaps. Its issuance time is not stored, to keep
+// the ConfigMaps small: the time the ConfigMap was updated at is used instead.
+type persistedCert struct {
+	Serial     string    `json:"serial"`
+	SubjectIDs []string  `json:"subjectIDs,omitempty"`
+	Node       string    `json:"node,omitempty"`
+	NotAfter   time.Time `json:"notAfter"`
+}
+
+// Controller resolves the revocation requests of the revocations ConfigMap against the workload certificates
+// issued by the CA, and shares the certificates it revoked with the other CA replicas through the ConfigMap.
+// It publishes the CRL of the certificates revoked by all the replicas.
+//
+// The issued certificates are persisted in ConfigMaps, per replica and issuance period and split by size, so the
+// certificates issued before a restart of the replica can still be revoked by identity or node. Each replica resolves the
+// requests against the certificates persisted by the others too, and deletes the ConfigMaps once all their
+// certificates expired.
+type Controller struct {
+	ca      *ca.IstioCA
+	name    types.NamespacedName
+	key     string
+	publish func(crl []byte)
+	crl     []byte
+
+	// issuedPrefix is the name prefix of the ConfigMaps of the certificates issued by this replica.
+	issuedPrefix string
+	// issuedSeq identifies the last persisted issued certificate.
+	issuedSeq uint64
+	// persistFailures is the number of consecutive failures to persist the issued certificates.
+	persistFailures int
+	// recovered caches the certificates of the ConfigMaps of the other replicas, by name.
+	recovered map[string]recoveredCerts
+
+	queue      controllers.Queue
+	configmaps kclient.Client[*v1.ConfigMap]
+	issued     kclient.Client[*v1.ConfigMap]
+}
+
+type recoveredCerts struct {
+	resourceVersion string
+	certs           []ca.IssuedCert
+}
+
+// NewController returns a Controller for the CA replica running in the pod, which calls publish when the CRL changes.
+func NewController(client kube.Client, namespace, podName string, istioCA *ca.IstioCA, publish func(crl []byte)) *Controller {
+	c := &Controller{
+		ca:      istioCA,
+		name:    types.NamespacedName{Namespace: namespace, Name: ca.RevocationsConfigMap},
+		key:     ca.RevokedKeyPrefix + podName + ".json",
+		publish: publish,
+
+		issuedPrefix: ca.IssuedCertsConfigMapPrefix + podName + "-",
+		recovered:    map[string]recoveredCerts{},
+	}
+	c.queue = controllers.NewQueue("ca revocation",
+		controllers.WithReconciler(c.reconcile),
+		controllers.WithMaxAttempts(5))
+	c.configmaps = kclient.NewFiltered[*v1.ConfigMap](client, kclient.Filter{
+		Namespace:     namespace,
+		FieldSelector: "metadata.name=" + ca.RevocationsConfigMap,
+	})
+	c.issued = kclient.NewFiltered[*v1.ConfigMap](client, kclient.Filter{
+		Namespace:     namespace,
+		LabelSelector: ca.IssuedCertsLabel,
+	})
+	// The revocation requests are resolved again when the certificates persisted by the other replicas change.
+	handler := controllers.ObjectHandler(func(controllers.Object) {
+		c.queue.Add(c.name)
+	})
+	c.configmaps.AddEventHandler(handler)
+	c.issued.AddEventHandler(handler)
+	return c
+}
+
+// Run starts the Controller until a value is sent to stop.
+func (c *Controller) Run(stop <-chan struct{}) {
+	if !kube.WaitForCacheSync("ca revocation", stop, c.configmaps.HasSynced, c.issued.HasSynced) {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(crlCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.queue.Add(c.name)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	c.queue.Run(stop)
+	controllers.ShutdownAll(c.configmaps, c.issued)
+}
+
+func (c *Controller) reconcile(key types.NamespacedName) error {
+	persistErr := c.persistIssued()
+	c.recoverIssued()
+
+	cm := c.configmaps.Get(key.Name, key.Namespace)
+	data := map[string]string{}
+	if cm != nil && cm.Data != nil {
+		data = cm.Data
+	}
+	now := time.Now()
+
+	// Resolve the requests against the certificates issued by this replica, and keep the ones resolved before.
+	owned := map[string]ca.RevokedCert{}
+	if err := unmarshal(data[c.key], owned); err != nil {
+		revocationLog.Warnf("ignoring invalid %s in %s: %v", c.key, key, err)
+	}
+	changed := false
+	var requests []ca.RevocationFilter
+	if err := json.Unmarshal([]byte(orEmptyList(data[ca.RevocationRequestsKey])), &requests); err != nil {
+		revocationLog.Errorf("invalid %s in %s: %v", ca.RevocationRequestsKey, key, err)
+	}
+	for _, request := range requests {
+		revoked, err := c.ca.ResolveRevocation(request)
+		if err != nil {
+			revocationLog.Warnf("ignoring revocation request %+v: %v", request, err)
+			continue
+		}
+		for _, r := range revoked {
+			if _, f := owned[r.Serial]; !f {
+				owned[r.Serial] = r
+				changed = true
+			}
+		}
+	}
+	for serial, r := range owned {
+		if !r.NotAfter.After(now) {
+			delete(owned, serial)
+			changed = true
+		}
+	}
+
+	// Revoke the certificates revoked by all the replicas.
+	all := map[string]ca.RevokedCert{}
+	for k, v := range data {
+		if !strings.HasPrefix(k, ca.RevokedKeyPrefix) || k == c.key {
+			continue
+		}
+		if err := unmarshal(v, all); err != nil {
+			revocationLog.Warnf("ignoring invalid %s in %s: %v", k, key, err)
+		}
+	}
+	for serial, r := range owned {
+		if prev, f := all[serial]; !f || r.NotAfter.After(prev.NotAfter) {
+			all[serial] = r
+		}
+	}
+	revoked := make([]ca.RevokedCert, 0, len(all))
+	for _, r := range all {
+		revoked = append(revoked, r)
+	}
+	c.ca.SetRevoked(revoked)
+	c.publishCRL()
+
+	if changed && cm != nil {
+		if err := c.updateOwned(cm, owned); err != nil {
+			return err
+		}
+	}
+	return persistErr
+}
+
+// persistIssued adds the certificates issued by this replica since the last call to the ConfigMaps of their
+// issuance period. After maxPersistFailures consecutive failures, the certificates not persisted are dropped.
+func (c *Controller) persistIssued() error {
+	issued, seq := c.ca.IssuedSince(c.issuedSeq)
+	byPeriod := map[string][]persistedCert{}
+	for _, cert := range issued {
+		name := fmt.Sprintf("%s%d", c.issuedPrefix, cert.IssuedAt.Truncate(issuedCertsPeriod).Unix())
+		byPeriod[name] = append(byPeriod[name], persistedCert{
+			Serial:     cert.Serial,
+			SubjectIDs: cert.SubjectIDs,
+			Node:       cert.Node,
+			NotAfter:   cert.NotAfter,
+		})
+	}
+	errs := istiomultierror.New()
+	for name, certs := range byPeriod {
+		if err := c.addIssued(name, certs); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		c.persistFailures++
+		if c.persistFailures < maxPersistFailures {
+			// The certificates are added again on the next attempt.
+			return err
+		}
+		revocationLog.Errorf("dropping %d issued certificates after %d failures to persist them: %v", len(issued), c.persistFailures, err)
+		issuedCertsDropped.RecordInt(int64(len(issued)))
+	}
+	c.persistFailures = 0
+	c.issuedSeq = seq
+	return nil
+}
+
+// issuedName returns the name of the ConfigMap of the issuance period with the index.
+func issuedName(period string, index int) string {
+	if index == 0 {
+		return period
+	}
+	return fmt.Sprintf("%s.%d", period, index)
+}
+
+// addIssued adds the issued certificates to the ConfigMaps of the issuance period, creating them if needed. The
+// certificates are added to the last ConfigMap of the period until it reaches maxIssuedCertsSize, then to the next.
+func (c *Controller) addIssued(period string, certs []persistedCert) error {
+	index := 0
+	for c.issued.Get(issuedName(period, index+1), c.name.Namespace) != nil {
+		index++
+	}
+	// The certificates already persisted, e.g. by a partially failed attempt, are not added again.
+	persisted := sets.New[string]()
+	for i := 0; i <= index; i++ {
+		if cm := c.issued.Get(issuedName(period, i), c.name.Namespace); cm != nil {
+			var existing []persistedCert
+			_ = json.Unmarshal([]byte(orEmptyList(cm.Data[ca.IssuedCertsKey])), &existing)
+			for _, cert := range existing {
+				persisted.Insert(cert.Serial)
+			}
+		}
+	}
+	certs = slices.Filter(certs, func(cert persistedCert) bool {
+		return !persisted.InsertContains(cert.Serial)
+	})
+	for ; len(certs) > 0; index++ {
+		cm := c.issued.Get(issuedName(period, index), c.name.Namespace)
+		var existing []persistedCert
+		if cm != nil {
+			if err := json.Unmarshal([]byte(orEmptyList(cm.Data[ca.IssuedCertsKey])), &existing); err != nil {
+				revocationLog.Warnf("replacing invalid %s in %s/%s: %v", ca.IssuedCertsKey, cm.Namespace, cm.Name, err)
+			}
+		}
+		b, err := json.Marshal(existing)
+		if err != nil {
+			return err
+		}
+		size, added := len(b), 0
+		for _, cert := range certs {
+			b, err := json.Marshal(cert)
+			if err != nil {
+				return err
+			}
+			if size+len(b)+1 > maxIssuedCertsSize && (len(existing) > 0 || added > 0) {
+				break
+			}
+			size += len(b) + 1
+			added++
+		}
+		if added == 0 {
+			continue
+		}
+		if err := c.writeIssued(cm, issuedName(period, index), append(existing, certs[:added]...)); err != nil {
+			return err
+		}
+		certs = certs[added:]
+	}
+	return nil
+}
+
+// writeIssued creates or updates the ConfigMap of the issued certificates.
+func (c *Controller) writeIssued(cm *v1.ConfigMap, name string, certs []persistedCert) error {
+	b, err := json.Marshal(certs)
+	if err != nil {
+		return err
+	}
+	create := cm == nil
+	if create {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: c.name.Namespace,
+				Labels:    map[string]string{ca.IssuedCertsLabel: "true"},
+			},
+		}
+	} else {
+		cm = cm.DeepCopy()
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[issuedCertsUpdatedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	cm.Data = map[string]string{ca.IssuedCertsKey: string(b)}
+	if create {
+		_, err = c.issued.Create(cm)
+	} else {
+		_, err = c.issued.Update(cm)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to persist issued certificates in %s/%s: %v", c.name.Namespace, name, err)
+	}
+	return nil
+}
+
+// recoverIssued sets the unexpired certificates persisted by the previous and other replicas as recovered by
+// the CA, and deletes the ConfigMaps of the expired ones.
+func (c *Controller) recoverIssued() {
+	now := time.Now()
+	seen := sets.New[string]()
+	var recovered []ca.IssuedCert
+	for _, cm := range c.issued.List(c.name.Namespace, klabels.Everything()) {
+		if !strings.HasPrefix(cm.Name, ca.IssuedCertsConfigMapPrefix) {
+			continue
+		}
+		rc, f := c.recovered[cm.Name]
+		if !f || rc.resourceVersion != cm.ResourceVersion {
+			rc = recoveredCerts{resourceVersion: cm.ResourceVersion}
+			if err := json.Unmarshal([]byte(orEmptyList(cm.Data[ca.IssuedCertsKey])), &rc.certs); err != nil {
+				revocationLog.Warnf("ignoring invalid %s in %s/%s: %v", ca.IssuedCertsKey, cm.Namespace, cm.Name, err)
+			}
+			// The certificates were issued before the ConfigMap was last updated.
+			updated, err := time.Parse(time.RFC3339, cm.Annotations[issuedCertsUpdatedAnnotation])
+			if err != nil {
+				updated = now
+			}
+			for i := range rc.certs {
+				if rc.certs[i].IssuedAt.IsZero() {
+					rc.certs[i].IssuedAt = updated
+				}
+			}
+			c.recovered[cm.Name] = rc
+		}
+		seen.Insert(cm.Name)
+		if slices.FindFunc(rc.certs, func(cert ca.IssuedCert) bool { return cert.NotAfter.After(now) }) == nil {
+			if err := c.issued.Delete(cm.Name, cm.Namespace); err != nil && !kerrors.IsNotFound(err) {
+				revocationLog.Warnf("failed to delete expired issued certificates %s/%s: %v", cm.Namespace, cm.Name, err)
+			}
+			continue
+		}
+		if strings.HasPrefix(cm.Name, c.issuedPrefix) && !strings.Contains(strings.TrimPrefix(cm.Name, c.issuedPrefix), "-") {
+			// The certificates issued by this replica are tracked by the CA already.
+			continue
+		}
+		recovered = append(recovered, rc.certs...)
+	}
+	for name := range c.recovered {
+		if !seen.Contains(name) {
+			delete(c.recovered, name)
+		}
+	}
+	c.ca.SetRecovered(recovered)
+}
+
+// updateOwned stores the certificates revoked by this replica in the ConfigMap.
+func (c *Controller) updateOwned(cm *v1.ConfigMap, owned map[string]ca.RevokedCert) error {
+	updated := cm.DeepCopy()
+	if updated.Data == nil {
+		updated.Data = map[string]string{}
+	}
+	if len(owned) == 0 {
+		delete(updated.Data, c.key)
+	} else {
+		list := make([]ca.RevokedCert, 0, len(owned))
+		for _, r := range owned {
+			list = append(list, r)
+		}
+		list = slices.SortBy(list, func(r ca.RevokedCert) string {
+			return r.Serial
+		})
+		b, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		updated.Data[c.key] = string(b)
+	}
+	if _, err := c.configmaps.Update(updated); err != nil {
+		return fmt.Errorf("failed to update revoked certificates in %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	return nil
+}
+
+func (c *Controller) publishCRL() {
+	crl, err := c.ca.CRL()
+	if err != nil {
+		revocationLog.Errorf("failed to generate CRL: %v", err)
+		return
+	}
+	if !bytes.Equal(crl, c.crl) {
+		revocationLog.Infof("publishing CRL of %d revoked certificates", len(c.ca.Revoked()))
+		c.crl = crl
+		c.publish(crl)
+	}
+}
+
+// unmarshal adds the revoked certificates of a JSON list to the map.
+func unmarshal(data string, into map[string]ca.RevokedCert) error {
+	var list []ca.RevokedCert
+	if err := json.Unmarshal([]byte(orEmptyList(data)), &list); err != nil {
+		return err
+	}
+	for _, r := range list {
+		if prev, f := into[r.Serial]; !f || r.NotAfter.After(prev.NotAfter) {
+			into[r.Serial] = r
+		}
+	}
+	return nil
+}
+
+func orEmptyList(data string) string {
+	if strings.TrimSpace(data) == "" {
+		return "[]"
+	}
+	return data
+}
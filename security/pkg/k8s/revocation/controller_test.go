@@ -0,0 +1,326 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/monitoring/monitortest"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+type replica struct {
+	ca  *ca.IstioCA
+	mu  sync.Mutex
+	crl []byte
+}
+
+func (r *replica) publish(crl []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.crl = crl
+}
+
+// revokedSerials returns the serial numbers listed in the last published CRL.
+func (r *replica) revokedSerials() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.crl == nil {
+		return nil, nil
+	}
+	block, _ := pem.Decode(r.crl)
+	list, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	serials := slices.Map(list.RevokedCertificateEntries, func(e x509.RevocationListEntry) string {
+		return e.SerialNumber.Text(16)
+	})
+	return slices.Sort(serials), nil
+}
+
+func (r *replica) sign(t *testing.T, id, node string) string {
+	t.Helper()
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: id, RSAKeySize: 2048})
+	assert.NoError(t, err)
+	certPEM, err := r.ca.Sign(csrPEM, ca.CertOpts{SubjectIDs: []string{id}, TTL: time.Hour, NodeName: node})
+	assert.NoError(t, err)
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	assert.NoError(t, err)
+	return cert.SerialNumber.Text(16)
+}
+
+func newReplica(t *testing.T, client kube.Client, name string) *replica {
+	opts, err := ca.NewSelfSignedDebugIstioCAOptions("", time.Hour, time.Hour, time.Hour, "cluster.local", 2048, true)
+	assert.NoError(t, err)
+	opts.IssuedCertsCapacity = 10
+	istioCA, err := ca.NewIstioCA(opts)
+	assert.NoError(t, err)
+	r := &replica{ca: istioCA}
+	c := NewController(client, "istio-system", name, istioCA, r.publish)
+	stop := test.NewStop(t)
+	client.RunAndWait(stop)
+	go c.Run(stop)
+	return r
+}
+
+func TestController(t *testing.T) {
+	test.SetForTest(t, &crlCheckInterval, 50*time.Millisecond)
+	client := kube.NewFakeClient()
+	a := newReplica(t, client, "istiod-a")
+	b := newReplica(t, client, "istiod-b")
+
+	a1 := a.sign(t, "spiffe://cluster.local/ns/default/sa/a", "node-1")
+	b1 := b.sign(t, "spiffe://cluster.local/ns/default/sa/b", "node-1")
+	b.sign(t, "spiffe://cluster.local/ns/default/sa/b", "node-2")
+
+	requests, err := json.Marshal([]ca.RevocationFilter{
+		{Node: "node-1", RevokedAt: time.Now()},
+		{Serial: "not-a-serial", RevokedAt: time.Now()},
+	})
+	assert.NoError(t, err)
+	_, err = client.Kube().CoreV1().ConfigMaps("istio-system").Create(context.Background(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ca.RevocationsConfigMap, Namespace: "istio-system"},
+		Data:       map[string]string{ca.RevocationRequestsKey: string(requests)},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// Each replica revokes the certificates it issued or recovered, and publishes the CRL of the ones revoked by all of them.
+	want := slices.Sort([]string{a1, b1})
+	for _, r := range []*replica{a, b} {
+		retry.UntilSuccessOrFail(t, func() error {
+			serials, err := r.revokedSerials()
+			if err != nil {
+				return err
+			}
+			if !slices.Equal(serials, want) {
+				return fmt.Errorf("got revoked serials %v, want %v", serials, want)
+			}
+			return nil
+		}, retry.Timeout(5*time.Second))
+	}
+	// Each replica eventually revokes the certificates persisted by the other too.
+	retry.UntilSuccessOrFail(t, func() error {
+		cm, err := client.Kube().CoreV1().ConfigMaps("istio-system").Get(context.Background(), ca.RevocationsConfigMap, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"istiod-a", "istiod-b"} {
+			var revoked []ca.RevokedCert
+			if err := json.Unmarshal([]byte(orEmptyList(cm.Data[ca.RevokedKeyPrefix+name+".json"])), &revoked); err != nil {
+				return err
+			}
+			serials := slices.Map(revoked, func(r ca.RevokedCert) string {
+				return r.Serial
+			})
+			if !slices.Equal(serials, want) {
+				return fmt.Errorf("got serials %v revoked by %s, want %v", serials, name, want)
+			}
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+
+	// Once the requests and revoked certificates are removed, the CRL is cleared.
+	_, err = client.Kube().CoreV1().ConfigMaps("istio-system").Update(context.Background(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ca.RevocationsConfigMap, Namespace: "istio-system"},
+	}, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	for _, r := range []*replica{a, b} {
+		retry.UntilSuccessOrFail(t, func() error {
+			serials, err := r.revokedSerials()
+			if err != nil {
+				return err
+			}
+			if len(serials) != 0 {
+				return fmt.Errorf("got revoked serials %v", serials)
+			}
+			return nil
+		}, retry.Timeout(5*time.Second))
+	}
+}
+
+func TestControllerRecoversIssuedCerts(t *testing.T) {
+	test.SetForTest(t, &crlCheckInterval, 50*time.Millisecond)
+	client := kube.NewFakeClient()
+	a := newReplica(t, client, "istiod-a")
+	a1 := a.sign(t, "spiffe://cluster.local/ns/default/sa/a", "node-1")
+
+	// The issued certificates are persisted.
+	var persisted []runtime.Object
+	retry.UntilSuccessOrFail(t, func() error {
+		cms, err := client.Kube().CoreV1().ConfigMaps("istio-system").List(context.Background(), metav1.ListOptions{LabelSelector: ca.IssuedCertsLabel})
+		if err != nil {
+			return err
+		}
+		if len(cms.Items) != 1 {
+			return fmt.Errorf("got %d issued certificates ConfigMaps", len(cms.Items))
+		}
+		var issued []ca.IssuedCert
+		if err := json.Unmarshal([]byte(cms.Items[0].Data[ca.IssuedCertsKey]), &issued); err != nil {
+			return err
+		}
+		if len(issued) != 1 || issued[0].Serial != a1 || issued[0].Node != "node-1" {
+			return fmt.Errorf("got issued certificates %+v", issued)
+		}
+		persisted = []runtime.Object{&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        cms.Items[0].Name,
+				Namespace:   cms.Items[0].Namespace,
+				Labels:      cms.Items[0].Labels,
+				Annotations: cms.Items[0].Annotations,
+			},
+			Data: cms.Items[0].Data,
+		}}
+		return nil
+	}, retry.Timeout(5*time.Second))
+
+	// After a restart, the certificates issued before are still revoked by node.
+	restarted := kube.NewFakeClient(persisted...)
+	b := newReplica(t, restarted, "istiod-b")
+	requests, err := json.Marshal([]ca.RevocationFilter{{Node: "node-1", RevokedAt: time.Now()}})
+	assert.NoError(t, err)
+	_, err = restarted.Kube().CoreV1().ConfigMaps("istio-system").Create(context.Background(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ca.RevocationsConfigMap, Namespace: "istio-system"},
+		Data:       map[string]string{ca.RevocationRequestsKey: string(requests)},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	retry.UntilSuccessOrFail(t, func() error {
+		serials, err := b.revokedSerials()
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(serials, []string{a1}) {
+			return fmt.Errorf("got revoked serials %v, want %v", serials, []string{a1})
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+}
+
+func TestControllerDeletesExpiredIssuedCerts(t *testing.T) {
+	test.SetForTest(t, &crlCheckInterval, 50*time.Millisecond)
+	expired, err := json.Marshal([]ca.IssuedCert{{Serial: "abc", Node: "node-1", NotAfter: time.Now().Add(-time.Minute)}})
+	assert.NoError(t, err)
+	client := kube.NewFakeClient(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ca.IssuedCertsConfigMapPrefix + "istiod-old-1",
+			Namespace: "istio-system",
+			Labels:    map[string]string{ca.IssuedCertsLabel: "true"},
+		},
+		Data: map[string]string{ca.IssuedCertsKey: string(expired)},
+	})
+	newReplica(t, client, "istiod-a")
+	retry.UntilSuccessOrFail(t, func() error {
+		cms, err := client.Kube().CoreV1().ConfigMaps("istio-system").List(context.Background(), metav1.ListOptions{LabelSelector: ca.IssuedCertsLabel})
+		if err != nil {
+			return err
+		}
+		if len(cms.Items) != 0 {
+			return fmt.Errorf("got %d issued certificates ConfigMaps", len(cms.Items))
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+}
+
+func TestControllerSplitsIssuedCerts(t *testing.T) {
+	test.SetForTest(t, &crlCheckInterval, 50*time.Millisecond)
+	test.SetForTest(t, &maxIssuedCertsSize, 400)
+	client := kube.NewFakeClient()
+	a := newReplica(t, client, "istiod-a")
+	var want []string
+	for i := 0; i < 5; i++ {
+		want = append(want, a.sign(t, fmt.Sprintf("spiffe://cluster.local/ns/default/sa/%d", i), "node-1"))
+	}
+
+	// The issued certificates are split across ConfigMaps below the size limit, without their issuance time.
+	retry.UntilSuccessOrFail(t, func() error {
+		cms, err := client.Kube().CoreV1().ConfigMaps("istio-system").List(context.Background(), metav1.ListOptions{LabelSelector: ca.IssuedCertsLabel})
+		if err != nil {
+			return err
+		}
+		var serials []string
+		for _, cm := range cms.Items {
+			data := cm.Data[ca.IssuedCertsKey]
+			if len(data) > maxIssuedCertsSize {
+				return fmt.Errorf("got %d bytes of issued certificates in %s", len(data), cm.Name)
+			}
+			if strings.Contains(data, "issuedAt") {
+				return fmt.Errorf("got issuance time in %s", cm.Name)
+			}
+			if _, err := time.Parse(time.RFC3339, cm.Annotations[issuedCertsUpdatedAnnotation]); err != nil {
+				return fmt.Errorf("got invalid update time in %s: %v", cm.Name, err)
+			}
+			var issued []ca.IssuedCert
+			if err := json.Unmarshal([]byte(data), &issued); err != nil {
+				return err
+			}
+			for _, cert := range issued {
+				serials = append(serials, cert.Serial)
+			}
+		}
+		if len(cms.Items) < 3 {
+			return fmt.Errorf("got %d issued certificates ConfigMaps", len(cms.Items))
+		}
+		if !slices.Equal(slices.Sort(serials), slices.Sort(want)) {
+			return fmt.Errorf("got persisted serials %v, want %v", serials, want)
+		}
+		return nil
+	}, retry.Timeout(5*time.Second))
+}
+
+func TestControllerDropsIssuedCerts(t *testing.T) {
+	mt := monitortest.New(t)
+	client := kube.NewFakeClient()
+	client.Kube().(*fake.Clientset).PrependReactor("create", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("etcd is full")
+	})
+	opts, err := ca.NewSelfSignedDebugIstioCAOptions("", time.Hour, time.Hour, time.Hour, "cluster.local", 2048, true)
+	assert.NoError(t, err)
+	opts.IssuedCertsCapacity = 10
+	istioCA, err := ca.NewIstioCA(opts)
+	assert.NoError(t, err)
+	r := &replica{ca: istioCA}
+	c := NewController(client, "istio-system", "istiod-a", istioCA, r.publish)
+	client.RunAndWait(test.NewStop(t))
+	r.sign(t, "spiffe://cluster.local/ns/default/sa/a", "node-1")
+
+	// The certificates are kept until the writes failed maxPersistFailures times, then dropped.
+	for i := 1; i < maxPersistFailures; i++ {
+		assert.Error(t, c.persistIssued())
+	}
+	assert.NoError(t, c.persistIssued())
+	mt.Assert(issuedCertsDropped.Name(), nil, monitortest.Exactly(1))
+	_, seq := istioCA.IssuedSince(0)
+	assert.Equal(t, c.issuedSeq, seq)
+}
@@ -0,0 +1,160 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/slices"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+// crlPollInterval is the interval at which the CRL file is checked for changes. The file is mounted from a
+// ConfigMap, whose new keys are not reported by file watches.
+var crlPollInterval = time.Minute
+
+// getCRL returns the CRL of the workload certificates revoked by the CA, if any.
+func (sc *SecretManagerClient) getCRL() []byte {
+	sc.crlMutex.RLock()
+	defer sc.crlMutex.RUnlock()
+	return sc.crl
+}
+
+// watchCRL periodically loads the CRL, and pushes the root cert to the proxy when it changes.
+func (sc *SecretManagerClient) watchCRL() {
+	ticker := time.NewTicker(crlPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if sc.loadCRL() {
+				cacheLog.Info("CRL has changed, pushing root cert")
+				sc.OnSecretUpdate(security.RootCertReqResourceName)
+			}
+		case <-sc.stop:
+			return
+		}
+	}
+}
+
+// loadCRL loads the CRL file and returns true if the CRL changed. A missing or empty file means that no
+// certificate is revoked; an invalid one is ignored.
+func (sc *SecretManagerClient) loadCRL() bool {
+	crl, err := os.ReadFile(sc.configOptions.CACRLPath)
+	if err != nil && !os.IsNotExist(err) {
+		cacheLog.Warnf("failed to read CRL %s: %v", sc.configOptions.CACRLPath, err)
+		return false
+	}
+	if len(bytes.TrimSpace(crl)) == 0 {
+		crl = nil
+	} else if err := validateCRL(crl); err != nil {
+		cacheLog.Warnf("ignoring invalid CRL %s: %v", sc.configOptions.CACRLPath, err)
+		return false
+	}
+
+	sc.crlMutex.Lock()
+	defer sc.crlMutex.Unlock()
+	if bytes.Equal(sc.crl, crl) {
+		return false
+	}
+	sc.crl = crl
+	return true
+}
+
+func validateCRL(crl []byte) error {
+	_, err := parseCRLs(crl)
+	return err
+}
+
+// parseCRLs parses the PEM encoded CRLs, one per issuer.
+func parseCRLs(crl []byte) ([]*x509.RevocationList, error) {
+	var out []*x509.RevocationList
+	for rest := bytes.TrimSpace(crl); len(rest) > 0; rest = bytes.TrimSpace(rest) {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil || block.Type != "X509 CRL" {
+			return nil, fmt.Errorf("no PEM encoded CRL found")
+		}
+		rl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rl)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no PEM encoded CRL found")
+	}
+	return out, nil
+}
+
+// crlForTrustBundle returns the CRLs to validate the peers trusted by the trust bundle with, or nil if they do not
+// match it. A CRL is signed by the CA signing certificate, which is either a CA of the trust bundle, or an
+// intermediate CA of the workload certificate chain issued by one of them, e.g. with plugged-in CA certificates.
+// Envoy rejects the peers whose issuer has no CRL, so the CRLs are only used if every CA of the trust bundle issued
+// one of them: otherwise the peers issued by another CA, e.g. before a root rotation, would be rejected.
+func crlForTrustBundle(trustBundle, certChain, crl []byte) []byte {
+	if len(crl) == 0 {
+		return nil
+	}
+	crls, err := parseCRLs(crl)
+	if err != nil {
+		return nil
+	}
+	cas, _, err := pkiutil.ParsePemEncodedCertificateChain(trustBundle)
+	if err != nil {
+		cacheLog.Warnf("not using the CRL: invalid trust bundle: %v", err)
+		return nil
+	}
+	// The CA certificates of the workload certificate chain may have signed the CRLs.
+	var intermediates []*x509.Certificate
+	if chain, _, err := pkiutil.ParsePemEncodedCertificateChain(certChain); err == nil {
+		intermediates = slices.Filter(chain, func(c *x509.Certificate) bool {
+			return c.IsCA
+		})
+	}
+	intermediatePool := x509.NewCertPool()
+	for _, c := range intermediates {
+		intermediatePool.AddCert(c)
+	}
+	for _, ca := range cas {
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		// The signers of the CRLs issued by the CA: the CA itself, and the intermediate CAs it issued.
+		signers := append([]*x509.Certificate{ca}, slices.Filter(intermediates, func(c *x509.Certificate) bool {
+			_, err := c.Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediatePool,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+			})
+			return err == nil
+		})...)
+		issued := slices.FindFunc(crls, func(rl *x509.RevocationList) bool {
+			return slices.FindFunc(signers, func(signer *x509.Certificate) bool {
+				return rl.CheckSignatureFrom(signer) == nil
+			}) != nil
+		}) != nil
+		if !issued {
+			cacheLog.Warnf("not using the CRL: it is not issued by the trusted CA %q, whose peers would be rejected", ca.Subject)
+			return nil
+		}
+	}
+	return crl
+}
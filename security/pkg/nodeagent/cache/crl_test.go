@@ -0,0 +1,187 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+// testCA is a CA allowed to sign CRLs, either self-signed or an intermediate CA.
+type testCA struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     crypto.Signer
+	// chainPEM is the chain from the CA to its root, and rootPEM the root.
+	chainPEM []byte
+	rootPEM  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	return newTestCAFromOptions(t, nil, pkiutil.CertOptions{IsSelfSigned: true})
+}
+
+// newIntermediateCA returns a CA issued by the parent CA.
+func newIntermediateCA(t *testing.T, parent *testCA) *testCA {
+	return newTestCAFromOptions(t, parent, pkiutil.CertOptions{SignerCert: parent.cert, SignerPriv: parent.key})
+}
+
+func newTestCAFromOptions(t *testing.T, parent *testCA, options pkiutil.CertOptions) *testCA {
+	t.Helper()
+	options.TTL = time.Hour
+	options.Org = "Istio CA"
+	options.IsCA = true
+	options.IsCRLSigner = true
+	options.RSAKeySize = 2048
+	certPEM, keyPEM, err := pkiutil.GenCertKeyFromOptions(options)
+	assert.NoError(t, err)
+	cert, err := pkiutil.ParsePemEncodedCertificate(certPEM)
+	assert.NoError(t, err)
+	key, err := pkiutil.ParsePemEncodedKey(keyPEM)
+	assert.NoError(t, err)
+	ca := &testCA{cert: cert, certPEM: certPEM, key: key.(crypto.Signer), chainPEM: certPEM, rootPEM: certPEM}
+	if parent != nil {
+		ca.chainPEM = append(append([]byte{}, certPEM...), parent.chainPEM...)
+		ca.rootPEM = parent.rootPEM
+	}
+	return ca
+}
+
+func (ca *testCA) generateCRL(t *testing.T) []byte {
+	t.Helper()
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificateEntries: []x509.RevocationListEntry{{SerialNumber: big.NewInt(42), RevocationTime: time.Now()}},
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now(),
+		NextUpdate:                time.Now().Add(time.Hour),
+	}, ca.cert, ca.key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+// CSRSign implements security.Client, signing the workload certificates with the CA.
+func (ca *testCA) CSRSign(csrPEM []byte, certValidTTLInSec int64) ([]string, error) {
+	csr, err := pkiutil.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	der, err := pkiutil.GenCertFromCSR(csr, ca.cert, csr.PublicKey, ca.key, []string{"test"},
+		time.Duration(certValidTTLInSec)*time.Second, false)
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), string(ca.chainPEM)}, nil
+}
+
+func (ca *testCA) GetRootCertBundle() ([]string, error) {
+	return []string{string(ca.rootPEM)}, nil
+}
+
+func (ca *testCA) Close() {}
+
+func TestCRL(t *testing.T) {
+	test.SetForTest(t, &crlPollInterval, 10*time.Millisecond)
+	crlPath := filepath.Join(t.TempDir(), "ca-crl.pem")
+	ca := newTestCA(t)
+	crl := ca.generateCRL(t)
+	assert.NoError(t, os.WriteFile(crlPath, crl, 0o644))
+
+	u := NewUpdateTracker(t)
+	sc := createCache(t, ca, u.Callback, security.Options{WorkloadRSAKeySize: 2048, CACRLPath: crlPath})
+
+	// The CRL is added to the root cert.
+	root, err := sc.GenerateSecret(security.RootCertReqResourceName)
+	assert.NoError(t, err)
+	assert.Equal(t, root.CRL, crl)
+	u.Reset()
+	root, err = sc.GenerateSecret(security.RootCertReqResourceName)
+	assert.NoError(t, err)
+	assert.Equal(t, root.CRL, crl)
+	workload, err := sc.GenerateSecret(security.WorkloadKeyCertResourceName)
+	assert.NoError(t, err)
+	assert.Equal(t, workload.CRL, nil)
+
+	// An invalid CRL is ignored.
+	assert.NoError(t, os.WriteFile(crlPath, []byte("invalid"), 0o644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, sc.getCRL(), crl)
+
+	// Once the CRL is cleared, the root cert is pushed without it.
+	u.Reset()
+	assert.NoError(t, os.WriteFile(crlPath, nil, 0o644))
+	u.Expect(map[string]int{security.RootCertReqResourceName: 1})
+	root, err = sc.GenerateSecret(security.RootCertReqResourceName)
+	assert.NoError(t, err)
+	assert.Equal(t, root.CRL, nil)
+}
+
+func TestCRLForTrustBundle(t *testing.T) {
+	ca1, ca2 := newTestCA(t), newTestCA(t)
+	crl1, crl2 := ca1.generateCRL(t), ca2.generateCRL(t)
+	both := append(append([]byte{}, crl1...), crl2...)
+	bundle := append(append([]byte{}, ca1.certPEM...), ca2.certPEM...)
+
+	assert.Equal(t, crlForTrustBundle(ca1.certPEM, nil, crl1), crl1)
+	assert.Equal(t, crlForTrustBundle(ca1.certPEM, nil, nil), nil)
+	// The peers issued by a CA without CRL would be rejected.
+	assert.Equal(t, crlForTrustBundle(ca2.certPEM, nil, crl1), nil)
+	assert.Equal(t, crlForTrustBundle(bundle, nil, crl1), nil)
+	// One CRL per trusted CA.
+	assert.Equal(t, crlForTrustBundle(bundle, nil, both), both)
+	assert.Equal(t, crlForTrustBundle(ca1.certPEM, nil, both), both)
+	assert.Equal(t, validateCRL(both), nil)
+
+	// The CRL signed by an intermediate CA is only used with the certificate chain issued by it.
+	intermediate := newIntermediateCA(t, ca1)
+	crlIntermediate := intermediate.generateCRL(t)
+	assert.Equal(t, crlForTrustBundle(ca1.certPEM, intermediate.chainPEM, crlIntermediate), crlIntermediate)
+	assert.Equal(t, crlForTrustBundle(ca1.certPEM, nil, crlIntermediate), nil)
+	assert.Equal(t, crlForTrustBundle(ca2.certPEM, intermediate.chainPEM, crlIntermediate), nil)
+	// An intermediate CA issued by another root does not cover the trusted CA.
+	other := newIntermediateCA(t, ca2)
+	assert.Equal(t, crlForTrustBundle(ca1.certPEM, other.chainPEM, other.generateCRL(t)), nil)
+	bundleCRLs := append(append([]byte{}, crlIntermediate...), crl2...)
+	assert.Equal(t, crlForTrustBundle(bundle, intermediate.chainPEM, bundleCRLs), bundleCRLs)
+}
+
+func TestCRLIntermediateCA(t *testing.T) {
+	crlPath := filepath.Join(t.TempDir(), "ca-crl.pem")
+	ca := newIntermediateCA(t, newTestCA(t))
+	crl := ca.generateCRL(t)
+	assert.NoError(t, os.WriteFile(crlPath, crl, 0o644))
+
+	u := NewUpdateTracker(t)
+	sc := createCache(t, ca, u.Callback, security.Options{WorkloadRSAKeySize: 2048, CACRLPath: crlPath})
+
+	// The CRL signed by the intermediate CA is added to the root cert, both when it is generated and cached.
+	root, err := sc.GenerateSecret(security.RootCertReqResourceName)
+	assert.NoError(t, err)
+	assert.Equal(t, root.CRL, crl)
+	root, err = sc.GenerateSecret(security.RootCertReqResourceName)
+	assert.NoError(t, err)
+	assert.Equal(t, root.CRL, crl)
+}
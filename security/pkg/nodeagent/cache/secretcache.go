@@ -113,6 +113,10 @@ type SecretManagerClient struct {
 	// Dynamically configured Trust Bundle
 	configTrustBundle []byte
 
+	// CRL of the workload certificates revoked by the CA, loaded from configOptions.CACRLPath
+	crlMutex sync.RWMutex
+	crl      []byte
+
 	// queue maintains all certificate rotation events that need to be triggered when they are about to expire
 	queue queue.Delayed
 	stop  chan struct{}
@@ -187,6 +191,10 @@ func NewSecretManagerClient(caClient security.Client, options *security.Options)
 
 	go ret.queue.Run(ret.stop)
 	go ret.handleFileWatch()
+	if options.CACRLPath != "" {
+		ret.loadCRL()
+		go ret.watchCRL()
+	}
 	return ret, nil
 }
 
@@ -223,6 +231,7 @@ func (sc *SecretManagerClient) getCachedSecret(resourceName string) (secret *sec
 			ns = &security.SecretItem{
 				ResourceName: resourceName,
 				RootCert:     rootCertBundle,
+				CRL:          crlForTrustBundle(rootCertBundle, c.CertificateChain, sc.getCRL()),
 			}
 			cacheLog.WithLabels("ttl", time.Until(c.ExpireTime)).Info("returned workload trust anchor from cache")
 
@@ -305,6 +314,7 @@ func (sc *SecretManagerClient) GenerateSecret(resourceName string) (secret *secu
 
 	if resourceName == security.RootCertReqResourceName {
 		ns.RootCert = sc.mergeTrustAnchorBytes(ns.RootCert)
+		ns.CRL = crlForTrustBundle(ns.RootCert, ns.CertificateChain, sc.getCRL())
 	} else {
 		// If periodic cert refresh resulted in discovery of a new root, trigger a ROOTCA request to refresh trust anchor
 		oldRoot := sc.cache.GetRoot()
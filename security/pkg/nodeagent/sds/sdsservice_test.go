@@ -333,6 +333,27 @@ func TestSDS(t *testing.T) {
 	})
 }
 
+func TestToEnvoySecretCRL(t *testing.T) {
+	root := &ca2.SecretItem{ResourceName: rootResourceName, RootCert: fakeRootCert}
+	vc := toEnvoySecret(root, "", nil).GetValidationContext()
+	if vc.GetCrl() != nil || vc.GetOnlyVerifyLeafCertCrl() {
+		t.Fatalf("unexpected CRL in validation context %v", vc)
+	}
+
+	crl := []byte("crl")
+	root.CRL = crl
+	vc = toEnvoySecret(root, "", nil).GetValidationContext()
+	if diff := cmp.Diff(vc.GetCrl().GetInlineBytes(), crl); diff != "" {
+		t.Fatalf("got diff: %v", diff)
+	}
+	if !vc.GetOnlyVerifyLeafCertCrl() {
+		t.Fatalf("expected only the leaf certificate CRL to be verified")
+	}
+	if diff := cmp.Diff(vc.GetTrustedCa().GetInlineBytes(), fakeRootCert); diff != "" {
+		t.Fatalf("got diff: %v", diff)
+	}
+}
+
 func setupConnection(socket string) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
 
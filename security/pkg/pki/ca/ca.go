@@ -92,6 +92,10 @@ type CertOpts struct {
 
 	// Cert Signer info
 	CertSigner string
+
+	// NodeName is the node of the workload the certificate is issued for, if known.
+	// It is recorded so that the certificates issued to a node can be revoked.
+	NodeName string
 }
 
 const (
@@ -116,6 +120,10 @@ type IstioCAOptions struct {
 
 	// OnRootCertUpdate is the cb which can only be called by self-signed root cert rotator
 	OnRootCertUpdate func() error
+
+	// IssuedCertsCapacity is the number of issued workload certificates the CA keeps track of.
+	// If positive, the tracked certificates can be revoked and the CA publishes a CRL.
+	IssuedCertsCapacity int
 }
 
 type RootCertUpdateFunc func() error
@@ -124,7 +132,7 @@ type RootCertUpdateFunc func() error
 func NewSelfSignedIstioCAOptions(ctx context.Context,
 	rootCertGracePeriodPercentile int, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 	maxCertTTL time.Duration, org string, useCacertsSecretName, dualUse bool, namespace string, client corev1.CoreV1Interface,
-	rootCertFile string, enableJitter bool, caRSAKeySize int,
+	rootCertFile string, enableJitter bool, caRSAKeySize int, crlSigner bool,
 ) (caOpts *IstioCAOptions, err error) {
 	caOpts = &IstioCAOptions{
 		CAType:         selfSignedCA,
@@ -141,6 +149,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 			org:                org,
 			rootCertFile:       rootCertFile,
 			enableJitter:       enableJitter,
+			crlSigner:          crlSigner,
 			client:             client,
 		},
 	}
@@ -174,6 +183,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context,
 				TTL:          caCertTTL,
 				Org:          org,
 				IsCA:         true,
+				IsCRLSigner:  crlSigner,
 				IsSelfSigned: true,
 				RSAKeySize:   caRSAKeySize,
 				IsDualUse:    dualUse,
@@ -230,7 +240,7 @@ func loadSelfSignedCaSecret(client corev1.CoreV1Interface, namespace string, caC
 // NewSelfSignedDebugIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate produced by in-memory CA,
 // which runs without K8s, and no local ca key file presented.
 func NewSelfSignedDebugIstioCAOptions(rootCertFile string, caCertTTL, defaultCertTTL, maxCertTTL time.Duration,
-	org string, caRSAKeySize int,
+	org string, caRSAKeySize int, crlSigner bool,
 ) (caOpts *IstioCAOptions, err error) {
 	caOpts = &IstioCAOptions{
 		CAType:         selfSignedCA,
@@ -243,6 +253,7 @@ func NewSelfSignedDebugIstioCAOptions(rootCertFile string, caCertTTL, defaultCer
 		TTL:          caCertTTL,
 		Org:          org,
 		IsCA:         true,
+		IsCRLSigner:  crlSigner,
 		IsSelfSigned: true,
 		RSAKeySize:   caRSAKeySize,
 		IsDualUse:    true, // hardcoded to true for K8S as well
@@ -338,6 +349,10 @@ type IstioCA struct {
 	// rootCertRotator periodically rotates self-signed root cert for CA. It is nil
 	// if CA is not self-signed CA.
 	rootCertRotator *SelfSignedCARootCertRotator
+
+	// revocations tracks the issued workload certificates and the revoked ones. It is nil
+	// if revocation is not enabled.
+	revocations *revocationStore
 }
 
 // NewIstioCA returns a new IstioCA instance.
@@ -347,6 +362,15 @@ func NewIstioCA(opts *IstioCAOptions) (*IstioCA, error) {
 		keyCertBundle: opts.KeyCertBundle,
 		caRSAKeySize:  opts.CARSAKeySize,
 	}
+	if opts.IssuedCertsCapacity > 0 {
+		// The CRLs are signed by the CA signing certificate, so it must be allowed to sign them.
+		if signingCert, _, _, _ := opts.KeyCertBundle.GetAll(); signingCert != nil {
+			if err := checkCRLSigner(signingCert); err != nil {
+				return nil, err
+			}
+		}
+		ca.revocations = newRevocationStore(opts.IssuedCertsCapacity)
+	}
 
 	if opts.CAType == selfSignedCA && opts.RotatorConfig != nil && opts.RotatorConfig.CheckInterval > time.Duration(0) {
 		ca.rootCertRotator = NewSelfSignedCARootCertRotator(opts.RotatorConfig, ca, opts.OnRootCertUpdate)
@@ -375,7 +399,12 @@ func (ca *IstioCA) Run(stopChan chan struct{}) {
 func (ca *IstioCA) Sign(csrPEM []byte, certOpts CertOpts) (
 	[]byte, error,
 ) {
-	return ca.sign(csrPEM, certOpts.SubjectIDs, certOpts.TTL, true, certOpts.ForCA)
+	cert, err := ca.sign(csrPEM, certOpts.SubjectIDs, certOpts.TTL, true, certOpts.ForCA)
+	if err != nil {
+		return nil, err
+	}
+	ca.recordIssued(cert, certOpts)
+	return cert, nil
 }
 
 // SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain.
@@ -386,6 +415,7 @@ func (ca *IstioCA) SignWithCertChain(csrPEM []byte, certOpts CertOpts) (
 	if err != nil {
 		return nil, err
 	}
+	ca.recordIssued(cert, certOpts)
 	return []string{string(cert)}, nil
 }
 
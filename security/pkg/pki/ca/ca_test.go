@@ -101,7 +101,7 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 		maxCertTTL, org, false, false, caNamespace, client.CoreV1(),
-		rootCertFile, false, rsaKeySize)
+		rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -166,7 +166,7 @@ func TestCreateSelfSignedIstioCAWithoutSecretAndUseCacertsEnabled(t *testing.T)
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL,
 		maxCertTTL, org, true, false, caNamespace, client.CoreV1(),
-		rootCertFile, false, rsaKeySize)
+		rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -242,7 +242,7 @@ func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(),
 		0, caCertTTL, rootCertCheckInverval, defaultCertTTL, maxCertTTL,
 		org, false, false, caNamespace, client.CoreV1(),
-		rootCertFile, false, rsaKeySize)
+		rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -292,7 +292,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel0()
 	_, err := NewSelfSignedIstioCAOptions(ctx0, 0,
 		caCertTTL, defaultCertTTL, rootCertCheckInverval, maxCertTTL, org, false, false,
-		caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize)
+		caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Errorf("Got unexpected error: %v", err)
 	}
@@ -308,7 +308,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	defer cancel1()
 	caopts, err := NewSelfSignedIstioCAOptions(ctx1, 0,
 		caCertTTL, defaultCertTTL, rootCertCheckInverval, maxCertTTL, org, false, false,
-		caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize)
+		caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize, false)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -361,7 +361,7 @@ func TestConcurrentCreateSelfSignedIstioCA(t *testing.T) {
 			defer cancel0()
 			caOpts, err := NewSelfSignedIstioCAOptions(ctx0, 0,
 				caCertTTL, defaultCertTTL, rootCertCheckInverval, maxCertTTL, org, false, false,
-				caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize)
+				caNamespace, client.CoreV1(), rootCertFile, false, rsaKeySize, false)
 			if err != nil {
 				t.Errorf("NewSelfSignedIstioCAOptions got unexpected error: %v", err)
 			}
@@ -859,6 +859,7 @@ func createCA(maxTTL time.Duration, ecSigAlg util.SupportedECSignatureAlgorithms
 
 	intermediateCAOpts := util.CertOptions{
 		IsCA:         true,
+		IsCRLSigner:  true,
 		IsSelfSigned: false,
 		TTL:          time.Hour,
 		Org:          "Intermediate CA",
@@ -0,0 +1,387 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	// RevocationsConfigMap is the name of the ConfigMap in the Istio namespace storing the certificate revocations.
+	RevocationsConfigMap = "istio-ca-revocations"
+	// RevocationRequestsKey is the key of the revocation requests, a JSON list of RevocationFilter.
+	RevocationRequestsKey = "requests.json"
+	// RevokedKeyPrefix is the prefix of the keys storing the certificates revoked by the requests, as a
+	// JSON list of RevokedCert. Each CA replica stores the certificates it issued under its own key.
+	RevokedKeyPrefix = "revoked-"
+
+	// IssuedCertsConfigMapPrefix is the prefix of the ConfigMaps in the Istio namespace persisting the workload
+	// certificates issued by the CA replicas, so they can still be revoked by identity or node after a restart.
+	IssuedCertsConfigMapPrefix = "istio-ca-issued-"
+	// IssuedCertsLabel is the label of the ConfigMaps persisting the issued certificates.
+	IssuedCertsLabel = "istio.io/ca-issued-certs"
+	// IssuedCertsKey is the key of the issued certificates in their ConfigMaps, a JSON list of IssuedCert without
+	// their issuance time.
+	IssuedCertsKey = "issued.json"
+)
+
+// crlValidity is the validity of the CRLs published by the CA. The CRL is re-signed when half of it has elapsed.
+var crlValidity = 24 * time.Hour
+
+// RevocationFilter selects the issued workload certificates to revoke. Exactly one of the serial, subject ID and
+// node must be set.
+type RevocationFilter struct {
+	// Serial is the serial number of the certificate, in hexadecimal.
+	Serial string `json:"serial,omitempty"`
+	// SubjectID selects the certificates issued for the identity, e.g. spiffe://cluster.local/ns/foo/sa/bar.
+	SubjectID string `json:"subjectID,omitempty"`
+	// Node selects the certificates issued to the workloads running on the node.
+	Node string `json:"node,omitempty"`
+	// RevokedAt is the time of the revocation. Only the certificates issued before are revoked.
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+// Validate checks that the filter selects certificates.
+func (f RevocationFilter) Validate() error {
+	set := 0
+	for _, v := range []string{f.Serial, f.SubjectID, f.Node} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of serial, identity or node must be set")
+	}
+	if f.Serial != "" {
+		if _, err := parseSerial(f.Serial); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokedCert is a revoked workload certificate.
+type RevokedCert struct {
+	// Serial is the serial number of the certificate, in lower case hexadecimal.
+	Serial     string    `json:"serial"`
+	SubjectIDs []string  `json:"subjectIDs,omitempty"`
+	Node       string    `json:"node,omitempty"`
+	NotAfter   time.Time `json:"notAfter"`
+	RevokedAt  time.Time `json:"revokedAt"`
+}
+
+// IssuedCert is a workload certificate issued by the CA.
+type IssuedCert struct {
+	// Serial is the serial number of the certificate, in lower case hexadecimal.
+	Serial     string    `json:"serial"`
+	SubjectIDs []string  `json:"subjectIDs,omitempty"`
+	Node       string    `json:"node,omitempty"`
+	IssuedAt   time.Time `json:"issuedAt"`
+	NotAfter   time.Time `json:"notAfter"`
+}
+
+type issuedCert struct {
+	IssuedCert
+	// seq orders the certificates issued by the CA.
+	seq uint64
+}
+
+// revocationStore keeps track of the last issued workload certificates and of the revoked ones.
+type revocationStore struct {
+	mu       sync.Mutex
+	capacity int
+	issued   []issuedCert
+	seq      uint64
+	// recovered are the certificates issued by the previous and other CA replicas, see SetRecovered.
+	recovered []IssuedCert
+	revoked   map[string]RevokedCert
+
+	// The last CRL, with the signing certificate it is signed by and the time it must be re-signed at.
+	crl       []byte
+	crlSigner []byte
+	crlRenew  time.Time
+}
+
+func newRevocationStore(capacity int) *revocationStore {
+	return &revocationStore{
+		capacity: capacity,
+		revoked:  map[string]RevokedCert{},
+	}
+}
+
+// parseSerial parses a hexadecimal serial number, with or without colons, and returns it in canonical form.
+func parseSerial(serial string) (string, error) {
+	s := strings.TrimPrefix(strings.ToLower(strings.ReplaceAll(serial, ":", "")), "0x")
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok || n.Sign() <= 0 {
+		return "", fmt.Errorf("invalid serial number %q", serial)
+	}
+	return n.Text(16), nil
+}
+
+// recordIssued records a workload certificate issued by the CA, evicting the oldest one if the store is full.
+func (ca *IstioCA) recordIssued(certPEM []byte, opts CertOpts) {
+	if ca.revocations == nil || opts.ForCA {
+		return
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		pkiCaLog.Warnf("failed to record issued certificate: %v", err)
+		return
+	}
+	s := ca.revocations
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.issued) >= s.capacity {
+		s.issued = slices.FilterInPlace(s.issued, func(c issuedCert) bool {
+			return c.NotAfter.After(time.Now())
+		})
+		if len(s.issued) >= s.capacity {
+			s.issued = s.issued[len(s.issued)-s.capacity+1:]
+		}
+	}
+	s.seq++
+	s.issued = append(s.issued, issuedCert{
+		IssuedCert: IssuedCert{
+			Serial:     cert.SerialNumber.Text(16),
+			SubjectIDs: opts.SubjectIDs,
+			Node:       opts.NodeName,
+			IssuedAt:   time.Now(),
+			NotAfter:   cert.NotAfter,
+		},
+		seq: s.seq,
+	})
+}
+
+// IssuedSince returns the tracked workload certificates issued after the ones returned by the previous call,
+// identified by seq, and the seq to pass to the next call. It returns all of them if seq is 0.
+func (ca *IstioCA) IssuedSince(seq uint64) ([]IssuedCert, uint64) {
+	if ca.revocations == nil {
+		return nil, seq
+	}
+	s := ca.revocations
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []IssuedCert
+	for _, c := range s.issued {
+		if c.seq > seq {
+			out = append(out, c.IssuedCert)
+		}
+	}
+	return out, s.seq
+}
+
+// SetRecovered sets the workload certificates issued by the previous instances of the CA and by the other
+// replicas, which are revoked by identity or node in addition to the ones issued by this CA. Only the most
+// recent ones are kept, up to the capacity of the store.
+func (ca *IstioCA) SetRecovered(certs []IssuedCert) {
+	if ca.revocations == nil {
+		return
+	}
+	now := time.Now()
+	recovered := slices.Filter(certs, func(c IssuedCert) bool {
+		return c.NotAfter.After(now)
+	})
+	s := ca.revocations
+	if len(recovered) > s.capacity {
+		slices.SortFunc(recovered, func(a, b IssuedCert) int {
+			return a.IssuedAt.Compare(b.IssuedAt)
+		})
+		recovered = recovered[len(recovered)-s.capacity:]
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recovered = recovered
+}
+
+// ResolveRevocation returns the unexpired workload certificates revoked by the filter. The certificates selected
+// by identity or node are the ones tracked by this CA, and the recovered ones. A serial number is revoked even if
+// the certificate is not tracked, e.g. if it was issued by another replica.
+func (ca *IstioCA) ResolveRevocation(filter RevocationFilter) ([]RevokedCert, error) {
+	if ca.revocations == nil {
+		return nil, fmt.Errorf("certificate revocation is not enabled")
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	serial := ""
+	if filter.Serial != "" {
+		serial, _ = parseSerial(filter.Serial)
+	}
+
+	s := ca.revocations
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var out []RevokedCert
+	seen := sets.New[string]()
+	match := func(c IssuedCert) {
+		if !c.NotAfter.After(now) || c.IssuedAt.After(filter.RevokedAt) || seen.InsertContains(c.Serial) {
+			return
+		}
+		if (serial != "" && c.Serial == serial) || (filter.Node != "" && c.Node == filter.Node) ||
+			(filter.SubjectID != "" && slices.Contains(c.SubjectIDs, filter.SubjectID)) {
+			out = append(out, RevokedCert{Serial: c.Serial, SubjectIDs: c.SubjectIDs, Node: c.Node, NotAfter: c.NotAfter, RevokedAt: filter.RevokedAt})
+		}
+	}
+	for _, c := range s.issued {
+		match(c.IssuedCert)
+	}
+	for _, c := range s.recovered {
+		match(c)
+	}
+	if serial != "" && len(out) == 0 {
+		// The certificate was not issued by this CA recently, so its expiry is bounded by the max TTL.
+		notAfter := filter.RevokedAt.Add(ca.maxCertTTL)
+		if notAfter.After(now) {
+			out = append(out, RevokedCert{Serial: serial, NotAfter: notAfter, RevokedAt: filter.RevokedAt})
+		}
+	}
+	return out, nil
+}
+
+// SetRevoked sets the revoked certificates listed in the CRL.
+func (ca *IstioCA) SetRevoked(revoked []RevokedCert) {
+	if ca.revocations == nil {
+		return
+	}
+	s := ca.revocations
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated := make(map[string]RevokedCert, len(revoked))
+	for _, r := range revoked {
+		serial, err := parseSerial(r.Serial)
+		if err != nil {
+			pkiCaLog.Warnf("ignoring revoked certificate: %v", err)
+			continue
+		}
+		r.Serial = serial
+		updated[serial] = r
+	}
+	if !maps.EqualFunc(s.revoked, updated, func(a, b RevokedCert) bool { return a.RevokedAt.Equal(b.RevokedAt) }) {
+		// The CRL only needs to be re-signed if the revoked certificates changed.
+		s.crl = nil
+	}
+	s.revoked = updated
+}
+
+// Revoked returns the revoked certificates which have not expired yet.
+func (ca *IstioCA) Revoked() []RevokedCert {
+	if ca.revocations == nil {
+		return nil
+	}
+	s := ca.revocations
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneRevoked(time.Now())
+	out := make([]RevokedCert, 0, len(s.revoked))
+	for _, r := range s.revoked {
+		out = append(out, r)
+	}
+	return slices.SortBy(out, func(r RevokedCert) string {
+		return r.Serial
+	})
+}
+
+// pruneRevoked removes the expired certificates, which do not need to be listed in the CRL.
+func (s *revocationStore) pruneRevoked(now time.Time) {
+	for serial, r := range s.revoked {
+		if !r.NotAfter.After(now) {
+			delete(s.revoked, serial)
+			s.crl = nil
+		}
+	}
+}
+
+// CRL returns the PEM encoded CRL of the revoked certificates, signed by the CA signing certificate.
+// It returns nil if no unexpired certificate is revoked. The CRL is re-signed when the revoked
+// certificates or the signing certificate change, and before it expires.
+func (ca *IstioCA) CRL() ([]byte, error) {
+	if ca.revocations == nil {
+		return nil, nil
+	}
+	signingCert, signingKey, _, _ := ca.keyCertBundle.GetAll()
+	if signingCert == nil || signingKey == nil {
+		return nil, fmt.Errorf("istio CA is not ready")
+	}
+	s := ca.revocations
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.pruneRevoked(now)
+	if len(s.revoked) == 0 {
+		return nil, nil
+	}
+	if s.crl != nil && bytes.Equal(s.crlSigner, signingCert.Raw) && now.Before(s.crlRenew) {
+		return s.crl, nil
+	}
+
+	if err := checkCRLSigner(signingCert); err != nil {
+		return nil, err
+	}
+	signer, ok := (*signingKey).(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("the CA signing key does not support signing")
+	}
+	entries := make([]x509.RevocationListEntry, 0, len(s.revoked))
+	for _, r := range s.revoked {
+		serial, _ := new(big.Int).SetString(r.Serial, 16)
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: r.RevokedAt})
+	}
+	slices.SortFunc(entries, func(a, b x509.RevocationListEntry) int {
+		return a.SerialNumber.Cmp(b.SerialNumber)
+	})
+	template := &x509.RevocationList{
+		RevokedCertificateEntries: entries,
+		// The CRL number must increase with every CRL, including the ones of the other replicas.
+		Number:     big.NewInt(now.UnixNano()),
+		ThisUpdate: now,
+		NextUpdate: now.Add(crlValidity),
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, signingCert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CRL: %v", err)
+	}
+	s.crl = pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+	s.crlSigner = signingCert.Raw
+	s.crlRenew = now.Add(crlValidity / 2)
+	return s.crl, nil
+}
+
+// checkCRLSigner checks that the CA signing certificate has the cRLSign key usage, required to sign the CRLs.
+func checkCRLSigner(cert *x509.Certificate) error {
+	if cert.KeyUsage&x509.KeyUsageCRLSign == 0 {
+		return fmt.Errorf("certificate revocation requires the CA signing certificate %q to have the cRLSign key usage; "+
+			"reissue it with keyUsage cRLSign, or disable ENABLE_CA_CRL", cert.Subject)
+	}
+	return nil
+}
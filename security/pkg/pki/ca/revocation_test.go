@@ -0,0 +1,150 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestRevocation(t *testing.T) {
+	ca, err := createCA(time.Hour, "")
+	assert.NoError(t, err)
+
+	crl, err := ca.CRL()
+	assert.NoError(t, err)
+	assert.Equal(t, crl, nil)
+	_, err = ca.ResolveRevocation(RevocationFilter{Node: "node-1", RevokedAt: time.Now()})
+	assert.Error(t, err)
+
+	ca.revocations = newRevocationStore(2)
+	sign := func(id, node string) string {
+		csrPEM, _, err := util.GenCSR(util.CertOptions{Host: id, RSAKeySize: 2048})
+		assert.NoError(t, err)
+		certPEM, err := ca.Sign(csrPEM, CertOpts{SubjectIDs: []string{id}, TTL: time.Hour, NodeName: node})
+		assert.NoError(t, err)
+		cert, err := util.ParsePemEncodedCertificate(certPEM)
+		assert.NoError(t, err)
+		return cert.SerialNumber.Text(16)
+	}
+	evicted := sign("spiffe://cluster.local/ns/default/sa/a", "node-1")
+	a := sign("spiffe://cluster.local/ns/default/sa/a", "node-1")
+	b := sign("spiffe://cluster.local/ns/default/sa/b", "node-2")
+	revokedAt := time.Now()
+
+	serials := func(revoked []RevokedCert) []string {
+		return slices.Map(revoked, func(r RevokedCert) string {
+			return r.Serial
+		})
+	}
+	resolve := func(f RevocationFilter) []RevokedCert {
+		t.Helper()
+		f.RevokedAt = revokedAt
+		revoked, err := ca.ResolveRevocation(f)
+		assert.NoError(t, err)
+		return revoked
+	}
+	for _, f := range []RevocationFilter{{}, {Node: "node-1", Serial: "1"}, {Serial: "xyz"}} {
+		_, err := ca.ResolveRevocation(f)
+		assert.Error(t, err)
+	}
+	revoked := resolve(RevocationFilter{Node: "node-1"})
+	assert.Equal(t, serials(revoked), []string{a})
+	assert.Equal(t, revoked[0].SubjectIDs, []string{"spiffe://cluster.local/ns/default/sa/a"})
+	assert.Equal(t, serials(resolve(RevocationFilter{SubjectID: "spiffe://cluster.local/ns/default/sa/b"})), []string{b})
+	assert.Equal(t, len(resolve(RevocationFilter{SubjectID: "spiffe://cluster.local/ns/default/sa/c"})), 0)
+
+	// Serial numbers are revoked even if the certificate is not tracked.
+	untracked := resolve(RevocationFilter{Serial: "0x" + evicted})
+	assert.Equal(t, serials(untracked), []string{evicted})
+	assert.Equal(t, untracked[0].NotAfter.After(time.Now()), true)
+
+	// The certificates issued after the revocation are not revoked.
+	sign("spiffe://cluster.local/ns/default/sa/b", "node-2")
+	assert.Equal(t, serials(resolve(RevocationFilter{Node: "node-2"})), []string{b})
+
+	// The certificates recovered from the previous and other replicas are revoked by identity or node too, such as
+	// the evicted one.
+	issued, seq := ca.IssuedSince(0)
+	assert.Equal(t, len(issued), 2)
+	again, _ := ca.IssuedSince(seq)
+	assert.Equal(t, len(again), 0)
+	ca.SetRecovered([]IssuedCert{
+		{Serial: "abc", Node: "node-1", IssuedAt: revokedAt.Add(-time.Minute), NotAfter: time.Now().Add(time.Hour)},
+		{Serial: "def", Node: "node-1", IssuedAt: revokedAt.Add(-time.Minute), NotAfter: time.Now().Add(-time.Minute)},
+		{Serial: a, Node: "node-1", IssuedAt: revokedAt.Add(-time.Minute), NotAfter: time.Now().Add(time.Hour)},
+	})
+	assert.Equal(t, serials(resolve(RevocationFilter{Node: "node-1"})), []string{"abc", a})
+	ca.SetRecovered(nil)
+
+	ca.SetRevoked(append(revoked, untracked...))
+	assert.Equal(t, len(ca.Revoked()), 2)
+
+	crl, err = ca.CRL()
+	assert.NoError(t, err)
+	block, _ := pem.Decode(crl)
+	list, err := x509.ParseRevocationList(block.Bytes)
+	assert.NoError(t, err)
+	signingCert, _, _, _ := ca.keyCertBundle.GetAll()
+	assert.NoError(t, list.CheckSignatureFrom(signingCert))
+	assert.Equal(t, len(list.RevokedCertificateEntries), 2)
+	assert.Equal(t, list.NextUpdate.After(time.Now()), true)
+	resigned, err := ca.CRL()
+	assert.NoError(t, err)
+	assert.Equal(t, resigned, crl)
+
+	// The revoked certificates are replaced, and the expired ones are dropped.
+	ca.SetRevoked([]RevokedCert{
+		{Serial: "AB:CD", NotAfter: time.Now().Add(time.Hour), RevokedAt: time.Now()},
+		{Serial: a, NotAfter: time.Now().Add(-time.Minute), RevokedAt: time.Now()},
+	})
+	assert.Equal(t, serials(ca.Revoked()), []string{"abcd"})
+	crl, err = ca.CRL()
+	assert.NoError(t, err)
+	block, _ = pem.Decode(crl)
+	list, err = x509.ParseRevocationList(block.Bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, list.RevokedCertificateEntries[0].SerialNumber.Text(16), "abcd")
+
+	ca.SetRevoked(nil)
+	crl, err = ca.CRL()
+	assert.NoError(t, err)
+	assert.Equal(t, crl, nil)
+}
+
+func TestRevocationRequiresCRLSigner(t *testing.T) {
+	// The signing certificate of the test PKI lacks the cRLSign key usage.
+	caopts, err := NewPluggedCertIstioCAOptions(SigningCAFileBundle{
+		"../testdata/multilevelpki/root-cert.pem",
+		[]string{"../testdata/multilevelpki/int-cert-chain.pem"},
+		"../testdata/multilevelpki/int-cert.pem",
+		"../testdata/multilevelpki/int-key.pem",
+	}, time.Hour, time.Hour, 2048)
+	assert.NoError(t, err)
+	_, err = NewIstioCA(caopts)
+	assert.NoError(t, err)
+	caopts.IssuedCertsCapacity = 10
+	_, err = NewIstioCA(caopts)
+	if err == nil || !strings.Contains(err.Error(), "cRLSign") {
+		t.Fatalf("expected a cRLSign error, got %v", err)
+	}
+}
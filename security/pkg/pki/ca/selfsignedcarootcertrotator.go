@@ -44,6 +44,7 @@ type SelfSignedCARootCertRotatorConfig struct {
 	retryMax           time.Duration
 	dualUse            bool
 	enableJitter       bool
+	crlSigner          bool
 }
 
 // SelfSignedCARootCertRotator automatically checks self-signed signing root
@@ -181,6 +182,7 @@ func (rotator *SelfSignedCARootCertRotator) checkAndRotateRootCertForSigningCert
 		SignerPrivPem: caSecret.Data[CAPrivateKeyFile],
 		Org:           rotator.config.org,
 		IsCA:          true,
+		IsCRLSigner:   rotator.config.crlSigner,
 		IsSelfSigned:  true,
 		RSAKeySize:    rotator.ca.caRSAKeySize,
 		IsDualUse:     rotator.config.dualUse,
@@ -62,12 +62,19 @@ type IstioRAOptions struct {
 	TrustDomain string
 	// CertSignerDomain info
 	CertSignerDomain string
+	// SignerAddress : Unix domain socket of the external signer
+	SignerAddress string
+	// SignerTimeout : Timeout of the requests to the external signer
+	SignerTimeout time.Duration
 }
 
 const (
 	// ExtCAK8s : Integrate with external CA using k8s CSR API
 	ExtCAK8s CaExternalType = "ISTIOD_RA_KUBERNETES_API"
 
+	// ExtCASigner : Integrate with an external signer process over a Unix domain socket
+	ExtCASigner CaExternalType = "ISTIOD_RA_EXTERNAL_SIGNER"
+
 	// DefaultExtCACertDir : Location of external CA certificate
 	DefaultExtCACertDir string = "./etc/external-ca-cert"
 )
@@ -173,6 +180,13 @@ func NewIstioRA(opts *IstioRAOptions) (RegistrationAuthority, error) {
 		}
 		return istioRA, err
 	}
+	if opts.ExternalCAType == ExtCASigner {
+		istioRA, err := NewExternalSignerRA(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create an external signer RA: %v", err)
+		}
+		return istioRA, err
+	}
 	return nil, fmt.Errorf("invalid CA Name %s", opts.ExternalCAType)
 }
 
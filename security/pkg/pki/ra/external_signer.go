@@ -0,0 +1,266 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/security/pkg/pki/ca"
+	raerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/signer"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+var (
+	// signerCheckInterval is the interval at which the health and the root certificates of the external
+	// signer are checked.
+	signerCheckInterval = 10 * time.Second
+	// signerRetryInterval is the interval at which the root certificates of the external signer are
+	// requested until they are fetched.
+	signerRetryInterval = time.Second
+)
+
+// ExternalSignerRA is a RA delegating the signing of the certificates to an external signer process over
+// the gRPC protocol of the signer package, on a Unix domain socket.
+type ExternalSignerRA struct {
+	raOpts *IstioRAOptions
+	conn   *grpc.ClientConn
+	client signer.SignerClient
+	health healthpb.HealthClient
+
+	// keyCertBundle holds the root certificates of the signer.
+	keyCertBundle *atomic.Pointer[util.KeyCertBundle]
+	healthy       *atomic.Bool
+
+	// mutex protects rootsHandlers.
+	mutex         sync.Mutex
+	rootsHandlers []func()
+}
+
+// NewExternalSignerRA creates a RA for the external signer. The signer is connected to lazily: the RA is not
+// healthy until Run fetched the root certificates of the signer.
+func NewExternalSignerRA(raOpts *IstioRAOptions) (*ExternalSignerRA, error) {
+	address := raOpts.SignerAddress
+	if !strings.HasPrefix(address, "unix:") {
+		address = "unix://" + address
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("failed to connect to the external signer %s: %v", raOpts.SignerAddress, err))
+	}
+	r := &ExternalSignerRA{
+		raOpts:        raOpts,
+		conn:          conn,
+		client:        signer.NewSignerClient(conn),
+		health:        healthpb.NewHealthClient(conn),
+		keyCertBundle: atomic.NewPointer(util.NewKeyCertBundleFromPem(nil, nil, nil, nil)),
+		healthy:       atomic.NewBool(false),
+	}
+	return r, nil
+}
+
+// Run gets the root certificates of the signer, retrying until they are fetched, then periodically checks
+// the health and the root certificates of the signer, until stop is closed.
+func (r *ExternalSignerRA) Run(stop <-chan struct{}) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	defer r.conn.Close()
+	for {
+		select {
+		case <-timer.C:
+			r.check()
+			if len(r.GetCAKeyCertBundle().GetRootCertPem()) == 0 {
+				timer.Reset(signerRetryInterval)
+			} else {
+				timer.Reset(signerCheckInterval)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (r *ExternalSignerRA) check() {
+	defer r.checkHealth()
+	changed, err := r.refreshRoots()
+	if err != nil {
+		if len(r.GetCAKeyCertBundle().GetRootCertPem()) == 0 {
+			pkiRaLog.Infof("waiting for the root certificates of the external signer %s: %v", r.raOpts.SignerAddress, err)
+		} else {
+			pkiRaLog.Warnf("failed to get the root certificates of the external signer: %v", err)
+		}
+		return
+	}
+	if changed {
+		pkiRaLog.Info("root certificates of the external signer have changed")
+		r.mutex.Lock()
+		handlers := slices.Clone(r.rootsHandlers)
+		r.mutex.Unlock()
+		for _, h := range handlers {
+			h()
+		}
+	}
+}
+
+// AddRootsHandler adds a handler called when the root certificates of the signer change.
+func (r *ExternalSignerRA) AddRootsHandler(h func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rootsHandlers = append(r.rootsHandlers, h)
+}
+
+// Healthy returns whether the root certificates of the signer were fetched, and the signer reported to be
+// serving on the last health check.
+func (r *ExternalSignerRA) Healthy() bool {
+	return r.healthy.Load() && len(r.GetCAKeyCertBundle().GetRootCertPem()) > 0
+}
+
+func (r *ExternalSignerRA) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.raOpts.SignerTimeout)
+	defer cancel()
+	resp, err := r.health.Check(ctx, &healthpb.HealthCheckRequest{Service: signer.ServiceName})
+	healthy := err == nil && resp.Status == healthpb.HealthCheckResponse_SERVING
+	if healthy != r.healthy.Swap(healthy) {
+		if healthy {
+			pkiRaLog.Infof("external signer %s is serving", r.raOpts.SignerAddress)
+		} else {
+			pkiRaLog.Warnf("external signer %s is not serving (status %v, error %v)", r.raOpts.SignerAddress, resp.GetStatus(), err)
+		}
+	}
+}
+
+// refreshRoots gets the root certificates of the signer and returns true if they changed.
+func (r *ExternalSignerRA) refreshRoots() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.raOpts.SignerTimeout)
+	defer cancel()
+	resp, err := r.client.GetRootBundle(ctx, &signer.GetRootBundleRequest{})
+	if err != nil {
+		return false, err
+	}
+	roots := joinPem(resp.RootCerts)
+	if len(roots) == 0 {
+		return false, fmt.Errorf("no root certificate")
+	}
+	for _, root := range resp.RootCerts {
+		if _, err := util.ParsePemEncodedCertificate([]byte(root)); err != nil {
+			return false, fmt.Errorf("invalid root certificate: %v", err)
+		}
+	}
+	if bytes.Equal(roots, r.GetCAKeyCertBundle().GetRootCertPem()) {
+		return false, nil
+	}
+	r.keyCertBundle.Store(util.NewKeyCertBundleFromPem(nil, nil, nil, roots))
+	return true, nil
+}
+
+// Sign takes a PEM-encoded CSR and cert opts, and returns the certificate chain signed by the external signer,
+// up to but excluding the root certificate.
+func (r *ExternalSignerRA) Sign(csrPEM []byte, certOpts ca.CertOpts) ([]byte, error) {
+	lifetime, err := preSign(r.raOpts, csrPEM, certOpts.SubjectIDs, certOpts.TTL, certOpts.ForCA)
+	if err != nil {
+		return nil, err
+	}
+	if !r.Healthy() {
+		return nil, raerror.NewError(raerror.CANotReady, fmt.Errorf("external signer %s is not serving", r.raOpts.SignerAddress))
+	}
+	req := &signer.SignRequest{
+		Csr:              string(csrPEM),
+		SubjectIds:       certOpts.SubjectIDs,
+		ValidityDuration: int64(lifetime.Seconds()),
+		CertSigner:       certOpts.CertSigner,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.raOpts.SignerTimeout)
+	defer cancel()
+	resp, err := r.client.Sign(ctx, req)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CertGenError, fmt.Errorf("external signer failed to sign the CSR: %v", err))
+	}
+	certChain := joinPem(resp.CertChain)
+	if err := r.verify(certChain, certOpts.SubjectIDs); err != nil {
+		return nil, raerror.NewError(raerror.CertGenError, fmt.Errorf("invalid certificate from the external signer: %v", err))
+	}
+	return certChain, nil
+}
+
+// verify checks that the certificate chain is issued by the roots of the signer, for the subject IDs.
+func (r *ExternalSignerRA) verify(certChain []byte, subjectIDs []string) error {
+	if len(certChain) == 0 {
+		return fmt.Errorf("empty certificate chain")
+	}
+	if err := util.VerifyCertificate(nil, certChain, r.GetCAKeyCertBundle().GetRootCertPem(), nil); err != nil {
+		return err
+	}
+	cert, err := util.ParsePemEncodedCertificate(certChain)
+	if err != nil {
+		return err
+	}
+	ids, err := util.ExtractIDs(cert.Extensions)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(slices.Sort(ids), slices.Sort(slices.Clone(subjectIDs))) {
+		return fmt.Errorf("certificate identities %v do not match the requested ones %v", ids, subjectIDs)
+	}
+	return nil
+}
+
+// SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain.
+func (r *ExternalSignerRA) SignWithCertChain(csrPEM []byte, certOpts ca.CertOpts) ([]string, error) {
+	certChain, err := r.Sign(csrPEM, certOpts)
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(certChain)}, nil
+}
+
+// GetCAKeyCertBundle returns the KeyCertBundle holding the root certificates of the signer.
+func (r *ExternalSignerRA) GetCAKeyCertBundle() *util.KeyCertBundle {
+	return r.keyCertBundle.Load()
+}
+
+// SetCACertificatesFromMeshConfig is a noop: the root certificates are fetched from the signer.
+func (r *ExternalSignerRA) SetCACertificatesFromMeshConfig([]*meshconfig.MeshConfig_CertificateData) {
+}
+
+// GetRootCertFromMeshConfig always fails: the root certificates are fetched from the signer.
+func (r *ExternalSignerRA) GetRootCertFromMeshConfig(signerName string) ([]byte, error) {
+	return nil, fmt.Errorf("root certificates of signer %s are not defined in mesh config with an external signer", signerName)
+}
+
+// joinPem concatenates PEM encoded certificates.
+func joinPem(certs []string) []byte {
+	var b bytes.Buffer
+	for _, c := range certs {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		b.WriteString(c)
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
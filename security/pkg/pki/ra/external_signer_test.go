@@ -0,0 +1,130 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/ca"
+	raerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/signer/mock"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+func newExternalSigner(t *testing.T) (*mock.Signer, *ExternalSignerRA) {
+	socket := filepath.Join(t.TempDir(), "signer.sock")
+	signer, err := mock.NewSigner(socket)
+	assert.NoError(t, err)
+	t.Cleanup(signer.Close)
+	r, err := NewIstioRA(&IstioRAOptions{
+		ExternalCAType: ExtCASigner,
+		DefaultCertTTL: time.Hour,
+		MaxCertTTL:     2 * time.Hour,
+		SignerAddress:  socket,
+		SignerTimeout:  time.Second,
+	})
+	assert.NoError(t, err)
+	go r.(*ExternalSignerRA).Run(test.NewStop(t))
+	assert.EventuallyEqual(t, r.(*ExternalSignerRA).Healthy, true)
+	return signer, r.(*ExternalSignerRA)
+}
+
+func errorType(err error) string {
+	return err.(*raerror.Error).ErrorType()
+}
+
+func TestExternalSignerSign(t *testing.T) {
+	signer, r := newExternalSigner(t)
+	assert.Equal(t, r.Healthy(), true)
+	assert.Equal(t, r.GetCAKeyCertBundle().GetRootCertPem(), signer.RootCertPem())
+
+	csr := createFakeCsr(t, "")
+	certChain, err := r.SignWithCertChain(csr, ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, len(certChain), 1)
+	assert.NoError(t, pkiutil.VerifyCertificate(nil, []byte(certChain[0]), signer.RootCertPem(), nil))
+	cert, err := pkiutil.ParsePemEncodedCertificate([]byte(certChain[0]))
+	assert.NoError(t, err)
+	ids, err := pkiutil.ExtractIDs(cert.Extensions)
+	assert.NoError(t, err)
+	assert.Equal(t, ids, []string{testCsrHostName})
+
+	// Requests are validated before being forwarded to the signer.
+	_, err = r.Sign(csr, ca.CertOpts{SubjectIDs: []string{"spiffe://cluster.local/ns/default/sa/other"}, TTL: time.Hour})
+	assert.Equal(t, errorType(err), "CSR_ERROR")
+	_, err = r.Sign(csr, ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: 3 * time.Hour})
+	assert.Equal(t, errorType(err), "TTL_ERROR")
+}
+
+func TestExternalSignerTimeout(t *testing.T) {
+	signer, r := newExternalSigner(t)
+	r.raOpts.SignerTimeout = 50 * time.Millisecond
+	signer.SetDelay(time.Second)
+	_, err := r.Sign(createFakeCsr(t, ""), ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Hour})
+	assert.Error(t, err)
+	assert.Equal(t, errorType(err), "CERT_GEN_ERROR")
+}
+
+func TestExternalSignerHealth(t *testing.T) {
+	test.SetForTest(t, &signerCheckInterval, 10*time.Millisecond)
+	signer, r := newExternalSigner(t)
+
+	signer.SetServing(false)
+	assert.EventuallyEqual(t, r.Healthy, false)
+	_, err := r.Sign(createFakeCsr(t, ""), ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Hour})
+	assert.Equal(t, errorType(err), "CA_NOT_READY")
+
+	signer.SetServing(true)
+	assert.EventuallyEqual(t, r.Healthy, true)
+	_, err = r.Sign(createFakeCsr(t, ""), ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Hour})
+	assert.NoError(t, err)
+}
+
+func TestExternalSignerUnavailable(t *testing.T) {
+	test.SetForTest(t, &signerRetryInterval, 10*time.Millisecond)
+	socket := filepath.Join(t.TempDir(), "signer.sock")
+	r, err := NewExternalSignerRA(&IstioRAOptions{
+		DefaultCertTTL: time.Hour,
+		MaxCertTTL:     2 * time.Hour,
+		SignerAddress:  socket,
+		SignerTimeout:  100 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	roots := atomic.NewInt32(0)
+	r.AddRootsHandler(func() {
+		roots.Inc()
+	})
+	go r.Run(test.NewStop(t))
+
+	// The RA is not healthy until the root certificates of the signer are fetched.
+	assert.Equal(t, r.Healthy(), false)
+	_, err = r.Sign(createFakeCsr(t, ""), ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Hour})
+	assert.Equal(t, errorType(err), "CA_NOT_READY")
+
+	signer, err := mock.NewSigner(socket)
+	assert.NoError(t, err)
+	t.Cleanup(signer.Close)
+	assert.EventuallyEqual(t, r.Healthy, true)
+	assert.Equal(t, r.GetCAKeyCertBundle().GetRootCertPem(), signer.RootCertPem())
+	assert.Equal(t, roots.Load(), int32(1))
+	_, err = r.Sign(createFakeCsr(t, ""), ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Hour})
+	assert.NoError(t, err)
+}
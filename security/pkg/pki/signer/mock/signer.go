@@ -0,0 +1,132 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/signer"
+)
+
+// Signer is a reference external signer, signing the certificates with an in-memory self-signed CA.
+type Signer struct {
+	signer.UnimplementedSignerServer
+
+	ca       *ca.IstioCA
+	server   *grpc.Server
+	health   *health.Server
+	listener net.Listener
+
+	mu    sync.Mutex
+	delay time.Duration
+}
+
+// NewSigner creates a signer listening on the Unix domain socket.
+func NewSigner(socket string) (*Signer, error) {
+	opts, err := ca.NewSelfSignedDebugIstioCAOptions("", time.Hour, time.Hour, 24*time.Hour, "cluster.local", 2048, false)
+	if err != nil {
+		return nil, err
+	}
+	istioCA, err := ca.NewIstioCA(opts)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signer{
+		ca:       istioCA,
+		server:   grpc.NewServer(),
+		health:   health.NewServer(),
+		listener: listener,
+	}
+	signer.RegisterSignerServer(s.server, s)
+	healthpb.RegisterHealthServer(s.server, s.health)
+	s.health.SetServingStatus(signer.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+	return s, nil
+}
+
+// Sign implements signer.SignerServer.
+func (s *Signer) Sign(ctx context.Context, req *signer.SignRequest) (*signer.SignResponse, error) {
+	s.mu.Lock()
+	delay := s.delay
+	s.mu.Unlock()
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if len(req.SubjectIds) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no subject IDs in the request")
+	}
+	cert, err := s.ca.Sign([]byte(req.Csr), ca.CertOpts{
+		SubjectIDs: req.SubjectIds,
+		TTL:        time.Duration(req.ValidityDuration) * time.Second,
+		CertSigner: req.CertSigner,
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	chain := []string{string(cert)}
+	if intermediates := s.ca.GetCAKeyCertBundle().GetCertChainPem(); len(intermediates) > 0 {
+		chain = append(chain, string(intermediates))
+	}
+	return &signer.SignResponse{CertChain: chain}, nil
+}
+
+// GetRootBundle implements signer.SignerServer.
+func (s *Signer) GetRootBundle(context.Context, *signer.GetRootBundleRequest) (*signer.GetRootBundleResponse, error) {
+	return &signer.GetRootBundleResponse{RootCerts: []string{string(s.RootCertPem())}}, nil
+}
+
+// RootCertPem returns the root certificate of the signer.
+func (s *Signer) RootCertPem() []byte {
+	return s.ca.GetCAKeyCertBundle().GetRootCertPem()
+}
+
+// SetServing sets the status reported by the health service.
+func (s *Signer) SetServing(serving bool) {
+	st := healthpb.HealthCheckResponse_SERVING
+	if !serving {
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.health.SetServingStatus(signer.ServiceName, st)
+}
+
+// SetDelay delays the signing of the certificates.
+func (s *Signer) SetDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = delay
+}
+
+// Close stops the signer.
+func (s *Signer) Close() {
+	s.server.Stop()
+}
@@ -0,0 +1,25 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer defines the gRPC protocol istiod uses to delegate the signing of workload certificates
+// to an external signer process, such as an HSM backed signer, listening on a Unix domain socket.
+// istiod keeps authenticating and authorizing the CSRs; the signer only signs them.
+//
+// Version 1 of the protocol is the istio.security.signer.v1.Signer service of signer.proto. The signer
+// must also implement the grpc.health.v1.Health service, reporting the status of ServiceName.
+package signer
+
+// ServiceName is the name of the gRPC service of the external signer, which is also the service
+// reported by its health service.
+const ServiceName = "istio.security.signer.v1.Signer"
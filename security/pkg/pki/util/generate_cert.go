@@ -86,6 +86,9 @@ type CertOptions struct {
 	// Whether this certificate is used as signing cert for CA.
 	IsCA bool
 
+	// Whether this CA certificate is also allowed to sign CRLs.
+	IsCRLSigner bool
+
 	// Whether this certificate is self-signed.
 	IsSelfSigned bool
 
@@ -348,6 +351,9 @@ func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error)
 	if options.IsCA {
 		// If the cert is a CA cert, the private key is allowed to sign other certificates.
 		keyUsage = x509.KeyUsageCertSign
+		if options.IsCRLSigner {
+			keyUsage |= x509.KeyUsageCRLSign
+		}
 	} else {
 		// Otherwise the private key is allowed for digital signature and key encipherment.
 		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
@@ -572,6 +572,29 @@ func TestGenCertKeyFromOptions(t *testing.T) {
 	}
 }
 
+func TestGenCertKeyFromOptionsCRLSigner(t *testing.T) {
+	for _, crlSigner := range []bool{false, true} {
+		certPem, _, err := GenCertKeyFromOptions(CertOptions{
+			TTL:          time.Hour,
+			Org:          "MyOrg",
+			IsCA:         true,
+			IsCRLSigner:  crlSigner,
+			IsSelfSigned: true,
+			RSAKeySize:   2048,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := ParsePemEncodedCertificate(certPem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cert.KeyUsage&x509.KeyUsageCRLSign != 0; got != crlSigner {
+			t.Errorf("got cRLSign key usage %v, want %v", got, crlSigner)
+		}
+	}
+}
+
 func TestGenCertFromCSR(t *testing.T) {
 	keyFile := "../testdata/key.pem"
 	certFile := "../testdata/cert.pem"
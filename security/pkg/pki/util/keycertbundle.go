@@ -221,36 +221,46 @@ func (b *KeyCertBundle) CertOptions() (*CertOptions, error) {
 
 // UpdateVerifiedKeyCertBundleFromFile Verifies and updates KeyCertBundle with new certs
 func (b *KeyCertBundle) UpdateVerifiedKeyCertBundleFromFile(certFile string, privKeyFile string, certChainFiles []string, rootCertFile string) error {
-	certBytes, err := os.ReadFile(certFile)
+	certBytes, privKeyBytes, certChainBytes, rootCertBytes, err := ReadKeyCertBundleFiles(certFile, privKeyFile, certChainFiles, rootCertFile)
 	if err != nil {
 		return err
 	}
-	privKeyBytes, err := os.ReadFile(privKeyFile)
+
+	err = b.VerifyAndSetAll(certBytes, privKeyBytes, certChainBytes, rootCertBytes)
 	if err != nil {
 		return err
 	}
-	certChainBytes := []byte{}
-	if len(certChainFiles) != 0 {
-		for _, f := range certChainFiles {
-			var b []byte
-			if b, err = os.ReadFile(f); err != nil {
-				return err
-			}
 
-			certChainBytes = append(certChainBytes, b...)
-		}
+	return nil
+}
+
+// ReadKeyCertBundleFiles reads the PEM encoded cert, private key, cert chain and root cert files of a KeyCertBundle.
+// The cert chain files are concatenated.
+func ReadKeyCertBundleFiles(certFile string, privKeyFile string, certChainFiles []string, rootCertFile string) (
+	certBytes, privKeyBytes, certChainBytes, rootCertBytes []byte, err error,
+) {
+	certBytes, err = os.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, nil, nil, err
 	}
-	rootCertBytes, err := os.ReadFile(rootCertFile)
+	privKeyBytes, err = os.ReadFile(privKeyFile)
 	if err != nil {
-		return err
+		return nil, nil, nil, nil, err
 	}
+	certChainBytes = []byte{}
+	for _, f := range certChainFiles {
+		var b []byte
+		if b, err = os.ReadFile(f); err != nil {
+			return nil, nil, nil, nil, err
+		}
 
-	err = b.VerifyAndSetAll(certBytes, privKeyBytes, certChainBytes, rootCertBytes)
+		certChainBytes = append(certChainBytes, b...)
+	}
+	rootCertBytes, err = os.ReadFile(rootCertFile)
 	if err != nil {
-		return err
+		return nil, nil, nil, nil, err
 	}
-
-	return nil
+	return certBytes, privKeyBytes, certChainBytes, rootCertBytes, nil
 }
 
 // ExtractRootCertExpiryTimestamp returns the expiration of the first root cert
@@ -0,0 +1,330 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/slices"
+)
+
+var auditLog = log.RegisterScope("caaudit", "Istio CA certificate signing audit log")
+
+const (
+	// AuditResultIssued is the result of a certificate signing request that was granted.
+	AuditResultIssued = "issued"
+	// AuditResultRejected is the result of a certificate signing request that was rejected.
+	AuditResultRejected = "rejected"
+
+	// ImpersonationAllowed and ImpersonationDenied are the node impersonation decisions.
+	ImpersonationAllowed = "allowed"
+	ImpersonationDenied  = "denied"
+)
+
+// AuditRecord is the record of a certificate signing decision of the CA server.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Client is the remote address of the caller.
+	Client string `json:"client"`
+	// Authenticator is the type of the authenticator that authenticated the caller.
+	Authenticator    string   `json:"authenticator,omitempty"`
+	CallerIdentities []string `json:"callerIdentities,omitempty"`
+	// CallerPod is the namespace/name of the caller pod, if known.
+	CallerPod string `json:"callerPod,omitempty"`
+	Node      string `json:"node,omitempty"`
+	// ImpersonatedIdentity is the identity requested by a node proxy on behalf of a workload, and
+	// Impersonation is the node authorization decision for it.
+	ImpersonatedIdentity string `json:"impersonatedIdentity,omitempty"`
+	Impersonation        string `json:"impersonation,omitempty"`
+	// SANs are the identities of the requested certificate.
+	SANs         []string `json:"sans,omitempty"`
+	CertSigner   string   `json:"certSigner,omitempty"`
+	RequestedTTL string   `json:"requestedTTL,omitempty"`
+	// GrantedTTL, Serial and NotAfter describe the issued certificate.
+	GrantedTTL string    `json:"grantedTTL,omitempty"`
+	Serial     string    `json:"serial,omitempty"`
+	NotAfter   time.Time `json:"notAfter,omitempty"`
+	Result     string    `json:"result"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// AuditOptions configures the audit log of the CA server.
+type AuditOptions struct {
+	// File is the file the records are written to as JSON lines, in addition to the caaudit scope.
+	File string
+	// MaxSize is the size in bytes at which the file is rotated, keeping MaxBackups rotated files.
+	MaxSize    int64
+	MaxBackups int
+	// Recent is the number of most recent records kept in memory.
+	Recent int
+	// QueueSize is the number of records queued to be written to the file. The records are dropped
+	// when the queue is full. It defaults to defaultAuditQueueSize.
+	QueueSize int
+}
+
+const defaultAuditQueueSize = 1024
+
+// Auditor records the signing decisions of the CA server.
+type Auditor struct {
+	mu sync.Mutex
+	// recent is a ring buffer of the most recent records; next is the index of the next record.
+	recent []AuditRecord
+	next   int
+	full   bool
+
+	// The records are written to the file by a separate goroutine, off the signing requests path.
+	file    *rotatingFile
+	queue   chan AuditRecord
+	closed  bool
+	written chan struct{}
+}
+
+// NewAuditor creates an Auditor from the options.
+func NewAuditor(opts AuditOptions) (*Auditor, error) {
+	a := &Auditor{}
+	if opts.Recent > 0 {
+		a.recent = make([]AuditRecord, opts.Recent)
+	}
+	if opts.File != "" {
+		f, err := openRotatingFile(opts.File, opts.MaxSize, opts.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open CA audit log %s: %v", opts.File, err)
+		}
+		a.file = f
+		size := opts.QueueSize
+		if size <= 0 {
+			size = defaultAuditQueueSize
+		}
+		a.queue = make(chan AuditRecord, size)
+		a.written = make(chan struct{})
+		go a.writeFile()
+	}
+	return a, nil
+}
+
+// Record records a signing decision. It is a noop on a nil Auditor.
+func (a *Auditor) Record(r AuditRecord) {
+	if a == nil {
+		return
+	}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	if auditLog.DebugEnabled() {
+		auditLog.WithLabels(r.labels()...).Debug("certificate " + r.Result)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.recent) > 0 {
+		a.recent[a.next] = r
+		a.next = (a.next + 1) % len(a.recent)
+		if a.next == 0 {
+			a.full = true
+		}
+	}
+	if a.queue != nil && !a.closed {
+		select {
+		case a.queue <- r:
+		default:
+			auditDroppedCounts.Increment()
+		}
+	}
+}
+
+// writeFile writes the queued records to the file, until the queue is closed.
+func (a *Auditor) writeFile() {
+	defer close(a.written)
+	for r := range a.queue {
+		b, err := json.Marshal(r)
+		if err == nil {
+			_, err = a.file.Write(append(b, '\n'))
+		}
+		if err != nil {
+			auditLog.Errorf("failed to write CA audit record: %v", err)
+		}
+	}
+}
+
+func (r AuditRecord) labels() []any {
+	labels := []any{"client", r.Client}
+	add := func(k, v string) {
+		if v != "" {
+			labels = append(labels, k, v)
+		}
+	}
+	add("authenticator", r.Authenticator)
+	add("caller", strings.Join(r.CallerIdentities, ","))
+	add("pod", r.CallerPod)
+	add("node", r.Node)
+	add("impersonated", r.ImpersonatedIdentity)
+	add("impersonation", r.Impersonation)
+	add("sans", strings.Join(r.SANs, ","))
+	add("signer", r.CertSigner)
+	add("requestedTTL", r.RequestedTTL)
+	add("grantedTTL", r.GrantedTTL)
+	add("serial", r.Serial)
+	add("reason", r.Reason)
+	return labels
+}
+
+// Recent returns up to limit of the most recent records, newest first. All the records kept in memory
+// are returned if limit is not positive.
+func (a *Auditor) Recent(limit int) []AuditRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := a.next
+	if a.full {
+		n = len(a.recent)
+	}
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	out := make([]AuditRecord, 0, limit)
+	for i := 1; i <= limit; i++ {
+		out = append(out, a.recent[(a.next-i+len(a.recent))%len(a.recent)])
+	}
+	return out
+}
+
+// ServeHTTP serves the most recent records. The limit query parameter bounds the number of records,
+// the identity one filters them by caller or requested identity, and the result one by result.
+func (a *Auditor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	limit := 0
+	if l := req.URL.Query().Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			http.Error(w, fmt.Sprintf("invalid limit %q", l), http.StatusBadRequest)
+			return
+		}
+	}
+	identity := req.URL.Query().Get("identity")
+	result := req.URL.Query().Get("result")
+	records := slices.FilterInPlace(a.Recent(0), func(r AuditRecord) bool {
+		if identity != "" && !slices.Contains(r.SANs, identity) && !slices.Contains(r.CallerIdentities, identity) {
+			return false
+		}
+		return result == "" || r.Result == result
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// Close writes the queued records and closes the audit log file, if any.
+func (a *Auditor) Close() error {
+	a.mu.Lock()
+	if a.file == nil || a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+	<-a.written
+	return a.file.Close()
+}
+
+// rotatingFile is a file that is rotated once it reaches its maximum size: the file is renamed with
+// a .1 suffix, the previous .1 file with a .2 suffix and so on, dropping the oldest one.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			// The record is appended to the current file, which is rotated again on the next write.
+			auditLog.Warnf("failed to rotate CA audit log %s: %v", f.path, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the file and its backups, and opens a new file. The current file is only closed once the new
+// one is open, so the records are still appended to it if the rotation fails.
+func (f *rotatingFile) rotate() error {
+	if f.maxBackups <= 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else {
+		for i := f.maxBackups - 1; i > 0; i-- {
+			if err := os.Rename(f.backup(i), f.backup(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(f.path, f.backup(1)); err != nil {
+			return err
+		}
+	}
+	current := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	return current.Close()
+}
+
+func (f *rotatingFile) backup(i int) string {
+	return f.path + "." + strconv.Itoa(i)
+}
+
+func (f *rotatingFile) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,142 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func serials(records []AuditRecord) []string {
+	return slices.Map(records, func(r AuditRecord) string {
+		return r.Serial
+	})
+}
+
+func TestAuditorRecent(t *testing.T) {
+	a, err := NewAuditor(AuditOptions{Recent: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, len(a.Recent(0)), 0)
+
+	a.Record(AuditRecord{Serial: "1", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}, Result: AuditResultIssued})
+	a.Record(AuditRecord{Serial: "2", Result: AuditResultRejected})
+	assert.Equal(t, serials(a.Recent(0)), []string{"2", "1"})
+	a.Record(AuditRecord{Serial: "3", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}, Result: AuditResultIssued})
+	a.Record(AuditRecord{Serial: "4", CallerIdentities: []string{"spiffe://cluster.local/ns/a/sa/a"}, Result: AuditResultIssued})
+	assert.Equal(t, serials(a.Recent(0)), []string{"4", "3", "2"})
+	assert.Equal(t, serials(a.Recent(2)), []string{"4", "3"})
+
+	get := func(query string) []AuditRecord {
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/ca_auditz"+query, nil))
+		assert.Equal(t, w.Code, http.StatusOK)
+		var records []AuditRecord
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &records))
+		return records
+	}
+	assert.Equal(t, serials(get("")), []string{"4", "3", "2"})
+	assert.Equal(t, serials(get("?limit=1")), []string{"4"})
+	assert.Equal(t, serials(get("?identity=spiffe://cluster.local/ns/a/sa/a")), []string{"4", "3"})
+	assert.Equal(t, serials(get("?result=rejected")), []string{"2"})
+	assert.Equal(t, serials(get("?identity=spiffe://cluster.local/ns/a/sa/a&limit=1")), []string{"4"})
+
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/ca_auditz?limit=all", nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
+
+func TestAuditorFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	record := AuditRecord{Client: "10.0.0.1:1234", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}, Result: AuditResultIssued}
+	line, err := json.Marshal(record)
+	assert.NoError(t, err)
+	// Each file holds two records.
+	a, err := NewAuditor(AuditOptions{File: path, MaxSize: int64(2*len(line) + 100), MaxBackups: 2})
+	assert.NoError(t, err)
+	for i := 0; i < 7; i++ {
+		a.Record(record)
+	}
+	assert.NoError(t, a.Close())
+
+	lines := func(path string) int {
+		f, err := os.Open(path)
+		assert.NoError(t, err)
+		defer f.Close()
+		n := 0
+		for s := bufio.NewScanner(f); s.Scan(); n++ {
+			var r AuditRecord
+			assert.NoError(t, json.Unmarshal(s.Bytes(), &r))
+			assert.Equal(t, r.SANs, record.SANs)
+		}
+		return n
+	}
+	assert.Equal(t, lines(path), 1)
+	assert.Equal(t, lines(path+".1"), 2)
+	assert.Equal(t, lines(path+".2"), 2)
+	_, err = os.Stat(path + ".3")
+	assert.Equal(t, os.IsNotExist(err), true)
+
+	// Records are appended to an existing file.
+	a, err = NewAuditor(AuditOptions{File: path, MaxSize: int64(2*len(line) + 100), MaxBackups: 2})
+	assert.NoError(t, err)
+	a.Record(record)
+	assert.NoError(t, a.Close())
+	assert.Equal(t, lines(path), 2)
+
+	// The records are no longer written once the file is closed.
+	a.Record(record)
+	assert.NoError(t, a.Close())
+	assert.Equal(t, lines(path), 2)
+}
+
+func TestAuditorFileRotationFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	record := AuditRecord{Client: "10.0.0.1:1234", SANs: []string{"spiffe://cluster.local/ns/a/sa/a"}, Result: AuditResultIssued}
+	line, err := json.Marshal(record)
+	assert.NoError(t, err)
+	lines := func(path string) int {
+		b, _ := os.ReadFile(path)
+		return bytes.Count(b, []byte("\n"))
+	}
+	// The file cannot be renamed to its backup, which is a non-empty directory.
+	assert.NoError(t, os.MkdirAll(filepath.Join(path+".1", "dir"), 0o700))
+	a, err := NewAuditor(AuditOptions{File: path, MaxSize: int64(2*len(line) + 100), MaxBackups: 1})
+	assert.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		a.Record(record)
+	}
+	// The records are still appended to the file.
+	retry.UntilOrFail(t, func() bool {
+		return lines(path) == 4
+	}, retry.Timeout(time.Second))
+
+	// Once the rename succeeds, the file is rotated.
+	assert.NoError(t, os.RemoveAll(path+".1"))
+	a.Record(record)
+	assert.NoError(t, a.Close())
+	assert.Equal(t, lines(path+".1"), 4)
+	assert.Equal(t, lines(path), 1)
+}
@@ -55,6 +55,11 @@ var (
 		"The number of certificates issuances that have succeeded.",
 	)
 
+	auditDroppedCounts = monitoring.NewSum(
+		"citadel_server_audit_dropped_count",
+		"The number of CA audit records not written to the audit log file because its queue was full.",
+	)
+
 	rootCertExpiryTimestamp = monitoring.NewGauge(
 		"citadel_server_root_cert_expiry_timestamp",
 		"The unix timestamp, in seconds, when the root cert will expire.",
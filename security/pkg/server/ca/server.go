@@ -55,6 +55,7 @@ type Server struct {
 	serverCertTTL  time.Duration
 
 	nodeAuthorizer *MulticlusterNodeAuthorizor
+	audit          *Auditor
 }
 
 type SaNode struct {
@@ -76,11 +77,26 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	*pb.IstioCertificateResponse, error,
 ) {
 	s.monitoring.CSR.Increment()
+	rec := AuditRecord{
+		Client:       security.GetConnectionAddress(ctx),
+		RequestedTTL: (time.Duration(request.ValidityDuration) * time.Second).String(),
+		Result:       AuditResultRejected,
+	}
+	defer func() {
+		s.audit.Record(rec)
+	}()
 	caller, err := security.Authenticate(ctx, s.Authenticators)
 	if caller == nil || err != nil {
 		s.monitoring.AuthnError.Increment()
+		rec.Reason = "authentication failure"
 		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
 	}
+	rec.Authenticator = caller.Authenticator
+	rec.CallerIdentities = caller.Identities
+	if caller.KubernetesInfo.PodName != "" {
+		rec.CallerPod = caller.KubernetesInfo.PodNamespace + "/" + caller.KubernetesInfo.PodName
+	}
+	rec.Node = caller.KubernetesInfo.NodeName
 
 	serverCaLog := serverCaLog.WithLabels("client", security.GetConnectionAddress(ctx))
 	// By default, we will use the callers identity for the certificate
@@ -89,12 +105,15 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	impersonatedIdentity := crMetadata[security.ImpersonatedIdentity].GetStringValue()
 	if impersonatedIdentity != "" {
 		serverCaLog.Debugf("impersonated identity: %s", impersonatedIdentity)
+		rec.ImpersonatedIdentity = impersonatedIdentity
+		rec.Impersonation = ImpersonationDenied
 		// If there is an impersonated identity, we will override to use that identity (only single value
 		// supported), if the real caller is authorized.
 		if s.nodeAuthorizer == nil {
 			s.monitoring.AuthnError.Increment()
 			// Return an opaque error (for security purposes) but log the full reason
 			serverCaLog.Warnf("impersonation not allowed, as node authorizer (CA_TRUSTED_NODE_ACCOUNTS) is not configured")
+			rec.Reason = "impersonation not allowed: node authorizer is not configured"
 			return nil, status.Error(codes.Unauthenticated, "request impersonation authentication failure")
 
 		}
@@ -102,19 +121,24 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 			s.monitoring.AuthnError.Increment()
 			// Return an opaque error (for security purposes) but log the full reason
 			serverCaLog.Warnf("impersonation failed for identity %s, error: %v", impersonatedIdentity, err)
+			rec.Reason = "impersonation failed: " + err.Error()
 			return nil, status.Error(codes.Unauthenticated, "request impersonation authentication failure")
 		}
 		// Node is authorized to impersonate; overwrite the SAN to the impersonated identity.
 		sans = []string{impersonatedIdentity}
+		rec.Impersonation = ImpersonationAllowed
 	}
+	rec.SANs = sans
 	serverCaLog.Debugf("generating a certificate, sans: %v, requested ttl: %s", sans, time.Duration(request.ValidityDuration*int64(time.Second)))
 	certSigner := crMetadata[security.CertSigner].GetStringValue()
+	rec.CertSigner = certSigner
 	_, _, certChainBytes, rootCertBytes := s.ca.GetCAKeyCertBundle().GetAll()
 	certOpts := ca.CertOpts{
 		SubjectIDs: sans,
 		TTL:        time.Duration(request.ValidityDuration) * time.Second,
 		ForCA:      false,
 		CertSigner: certSigner,
+		NodeName:   caller.KubernetesInfo.NodeName,
 	}
 	var signErr error
 	var cert []byte
@@ -127,6 +151,7 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	}
 	if signErr != nil {
 		serverCaLog.Errorf("CSR signing error: %v", signErr.Error())
+		rec.Reason = "CSR signing error: " + signErr.Error()
 		s.monitoring.GetCertSignError(signErr.(*caerror.Error).ErrorType()).Increment()
 		return nil, status.Errorf(signErr.(*caerror.Error).HTTPErrorCode(), "CSR signing error (%v)", signErr.(*caerror.Error))
 	}
@@ -145,9 +170,22 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	}
 	s.monitoring.Success.Increment()
 	serverCaLog.Debugf("CSR successfully signed, sans %v.", caller.Identities)
+	rec.Result = AuditResultIssued
+	if s.audit != nil {
+		if leaf, err := util.ParsePemEncodedCertificate([]byte(respCertChain[0])); err == nil {
+			rec.Serial = leaf.SerialNumber.Text(16)
+			rec.NotAfter = leaf.NotAfter
+			rec.GrantedTTL = time.Until(leaf.NotAfter).Round(time.Second).String()
+		}
+	}
 	return response, nil
 }
 
+// Audit returns the audit log of the signing decisions, or nil if it is disabled.
+func (s *Server) Audit() *Auditor {
+	return s.audit
+}
+
 // RecordCertsExpiry updates the certificate-expiration related metrics given a new keycertbundle
 func RecordCertsExpiry(keyCertBundle *util.KeyCertBundle) {
 	// Expiry of the first root cert in trust bundle
@@ -196,6 +234,19 @@ func New(
 		monitoring:     newMonitoringMetrics(),
 	}
 
+	if features.EnableCAAuditLog {
+		audit, err := NewAuditor(AuditOptions{
+			File:       features.CAAuditLogFile,
+			MaxSize:    int64(features.CAAuditLogMaxSizeMB) * 1024 * 1024,
+			MaxBackups: features.CAAuditLogMaxBackups,
+			Recent:     features.CAAuditRecentRecords,
+		})
+		if err != nil {
+			return nil, err
+		}
+		server.audit = audit
+	}
+
 	if len(features.CATrustedNodeAccounts) > 0 {
 		// TODO: do we need some way to delayed readiness until this is synced? Probably
 		// Worst case is we deny some requests though which are retried
@@ -42,6 +42,7 @@ import (
 	"istio.io/istio/pkg/monitoring/monitortest"
 	"istio.io/istio/pkg/security"
 	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/pkg/util/sets"
 	mockca "istio.io/istio/security/pkg/pki/ca/mock"
 	caerror "istio.io/istio/security/pkg/pki/error"
@@ -611,3 +612,80 @@ func TestRecordCertsExipryMetrics(t *testing.T) {
 		mt.Assert(certChainExpirySeconds.Name(), nil, monitortest.AlmostEquals(certTTL.Seconds(), eps))
 	})
 }
+
+func TestCreateCertificateAudit(t *testing.T) {
+	certPEM, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:         "spiffe://cluster.local/ns/default/sa/app",
+		TTL:          time.Hour,
+		IsSelfSigned: true,
+		RSAKeySize:   2048,
+	})
+	assert.NoError(t, err)
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	assert.NoError(t, err)
+	audit, err := NewAuditor(AuditOptions{Recent: 10})
+	assert.NoError(t, err)
+	caller := &mockAuthenticator{
+		identities: []string{"spiffe://cluster.local/ns/default/sa/app"},
+		kubernetesInfo: security.KubernetesInfo{
+			PodName:      "app",
+			PodNamespace: "default",
+			NodeName:     "node-1",
+		},
+	}
+	server := &Server{
+		ca: &mockca.FakeCA{
+			SignedCert:    certPEM,
+			KeyCertBundle: util.NewKeyCertBundleFromPem(nil, nil, nil, []byte("root_cert")),
+		},
+		monitoring: newMonitoringMetrics(),
+		audit:      audit,
+	}
+	p := &peer.Peer{Addr: &net.IPAddr{IP: net.IPv4(192, 168, 1, 1)}, AuthInfo: credentials.TLSInfo{}}
+	ctx := peer.NewContext(context.Background(), p)
+	impersonate, _ := structpb.NewStruct(map[string]any{
+		security.ImpersonatedIdentity: "spiffe://cluster.local/ns/default/sa/other",
+	})
+
+	server.Authenticators = []security.Authenticator{&mockAuthenticator{errMsg: "not authorized"}}
+	_, err = server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: "dumb CSR", ValidityDuration: 3600})
+	assert.Error(t, err)
+	server.Authenticators = []security.Authenticator{caller}
+	_, err = server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: "dumb CSR", Metadata: impersonate})
+	assert.Error(t, err)
+	_, err = server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: "dumb CSR", ValidityDuration: 3600})
+	assert.NoError(t, err)
+	server.ca = &mockca.FakeCA{SignErr: caerror.NewError(caerror.TTLError, fmt.Errorf("requested TTL 48h0m0s is greater than the max allowed TTL"))}
+	_, err = server.CreateCertificate(ctx, &pb.IstioCertificateRequest{Csr: "dumb CSR", ValidityDuration: 48 * 3600})
+	assert.Error(t, err)
+
+	records := audit.Recent(0)
+	assert.Equal(t, len(records), 4)
+	for _, r := range records {
+		assert.Equal(t, r.Client, "192.168.1.1")
+	}
+	assert.Equal(t, records[3].Result, AuditResultRejected)
+	assert.Equal(t, records[3].Reason, "authentication failure")
+	assert.Equal(t, records[3].RequestedTTL, "1h0m0s")
+
+	assert.Equal(t, records[2].Result, AuditResultRejected)
+	assert.Equal(t, records[2].Authenticator, "mockAuthenticator")
+	assert.Equal(t, records[2].ImpersonatedIdentity, "spiffe://cluster.local/ns/default/sa/other")
+	assert.Equal(t, records[2].Impersonation, ImpersonationDenied)
+	assert.Equal(t, records[2].Reason, "impersonation not allowed: node authorizer is not configured")
+
+	issued := records[1]
+	assert.Equal(t, issued.Result, AuditResultIssued)
+	assert.Equal(t, issued.Authenticator, "mockAuthenticator")
+	assert.Equal(t, issued.CallerIdentities, []string{"spiffe://cluster.local/ns/default/sa/app"})
+	assert.Equal(t, issued.CallerPod, "default/app")
+	assert.Equal(t, issued.Node, "node-1")
+	assert.Equal(t, issued.SANs, []string{"spiffe://cluster.local/ns/default/sa/app"})
+	assert.Equal(t, issued.Serial, cert.SerialNumber.Text(16))
+	assert.Equal(t, issued.NotAfter.Equal(cert.NotAfter), true)
+	assert.Equal(t, issued.Reason, "")
+
+	assert.Equal(t, records[0].Result, AuditResultRejected)
+	assert.Equal(t, records[0].RequestedTTL, "48h0m0s")
+	assert.Equal(t, strings.HasPrefix(records[0].Reason, "CSR signing error: "), true)
+}
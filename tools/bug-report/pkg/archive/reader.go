@@ -0,0 +1,127 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// clusterResourceFiles are the files of the cluster info dir holding resources in YAML.
+	clusterResourceFiles = []string{"k8s-resources", "crs", "secrets"}
+	// configDumpFiles are the files of a proxy dir holding its config dump, the preferred one first.
+	configDumpFiles = []string{"config_dump?include_eds", "config_dump"}
+)
+
+// Archive is an extracted bug report archive.
+type Archive struct {
+	root string
+}
+
+// Open opens the extracted bug report archive in dir. dir is either the root of the archive, or the directory
+// it was extracted to.
+func Open(dir string) (*Archive, error) {
+	for _, root := range []string{dir, filepath.Join(dir, bugReportSubdir)} {
+		if fi, err := os.Stat(filepath.Join(root, clusterInfoSubdir)); err == nil && fi.IsDir() {
+			return &Archive{root: root}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not an extracted bug report archive: %s directory not found", dir, clusterInfoSubdir)
+}
+
+// Root returns the root dir of the archive.
+func (a *Archive) Root() string {
+	return a.root
+}
+
+// ClusterResourceFiles returns the paths of the files of the archive holding cluster resources in YAML. Secrets are
+// only included if their contents were collected.
+func (a *Archive) ClusterResourceFiles() ([]string, error) {
+	var out []string
+	for _, f := range clusterResourceFiles {
+		path := filepath.Join(ClusterInfoPath(a.root), f)
+		b, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Without --full-secrets, the secrets are a table rather than YAML.
+		if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("apiVersion:")) {
+			continue
+		}
+		out = append(out, path)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no cluster resources found in %s", ClusterInfoPath(a.root))
+	}
+	return out, nil
+}
+
+// Proxies returns the proxies of the archive, as `<pod>.<namespace>`.
+func (a *Archive) Proxies() ([]string, error) {
+	namespaces, err := os.ReadDir(filepath.Join(a.root, proxyLogsPathSubdir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, ns := range namespaces {
+		if !ns.IsDir() {
+			continue
+		}
+		pods, err := os.ReadDir(filepath.Join(a.root, proxyLogsPathSubdir, ns.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods {
+			if pod.IsDir() {
+				out = append(out, pod.Name()+"."+ns.Name())
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// ProxyConfigDump returns the Envoy config dump of the proxy of the pod.
+func (a *Archive) ProxyConfigDump(namespace, pod string) ([]byte, error) {
+	dir := ProxyOutputPath(a.root, namespace, pod)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("proxy %s.%s not found in the bug report", pod, namespace)
+	}
+	for _, f := range configDumpFiles {
+		b, err := os.ReadFile(filepath.Join(dir, f))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("no config dump of proxy %s.%s found in the bug report", pod, namespace)
+}
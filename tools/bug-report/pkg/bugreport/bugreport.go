@@ -165,6 +165,7 @@ func runBugReportCommand(ctx cli.Context, _ *cobra.Command, logOpts *log.Options
 	}
 
 	// TODO: sort by importance and discard any over the size limit.
+	proxyLogs := make(map[string]string, len(logs))
 	for path, text := range logs {
 		namespace, _, pod, _, err := cluster2.ParsePath(path)
 		if err != nil {
@@ -172,7 +173,9 @@ func runBugReportCommand(ctx cli.Context, _ *cobra.Command, logOpts *log.Options
 			continue
 		}
 		writeFile(filepath.Join(archive.ProxyOutputPath(tempDir, namespace, pod), common.ProxyContainerName+".log"), text, config.DryRun)
+		proxyLogs[namespace+"/"+pod] = text
 	}
+	writeLogSummary(config, proxyLogs)
 
 	logRuntime(curTime, "Done with bug-report command before generating the archive file")
 
@@ -209,6 +212,19 @@ func runBugReportCommand(ctx cli.Context, _ *cobra.Command, logOpts *log.Options
 	return nil
 }
 
+// writeLogSummary writes the summary of the proxy logs, keyed by namespace/pod, at the root of the archive.
+func writeLogSummary(config *config.BugReportConfig, proxyLogs map[string]string) {
+	defer logRuntime(time.Now(), "Done summarizing proxy logs")
+	summary := processlog.Summarize(config, proxyLogs)
+	out, err := summary.JSON()
+	if err != nil {
+		log.Errorf("failed to summarize proxy logs: %v", err)
+		return
+	}
+	writeFile(filepath.Join(archive.OutputRootDir(tempDir), "summary.json"), out, config.DryRun)
+	writeFile(filepath.Join(archive.OutputRootDir(tempDir), "summary.md"), summary.Markdown(), config.DryRun)
+}
+
 func dumpRevisionsAndVersions(ctx cli.Context, resources *cluster2.Resources, istioNamespace string, dryRun bool) {
 	defer logRuntime(time.Now(), "Done getting control plane revisions/versions")
 
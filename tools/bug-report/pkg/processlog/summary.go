@@ -0,0 +1,440 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/tools/bug-report/pkg/config"
+	"istio.io/istio/tools/bug-report/pkg/util/match"
+)
+
+const (
+	// maxSignatures is the maximum number of log signatures in a summary.
+	maxSignatures = 50
+	// maxPatternLength is the maximum length of a log signature pattern.
+	maxPatternLength = 300
+	// correlationWindow is the time around a log line in which the response flags of the same proxy are
+	// correlated with it.
+	correlationWindow = time.Minute
+)
+
+// normalizers replace the variable parts of log lines, in order, so that similar lines have the same signature.
+var normalizers = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})?`), "<time>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`\[?\b[0-9a-fA-F]{0,4}(?:::?[0-9a-fA-F]{1,4}){2,7}\b\]?(?::\d+)?`), "<ip>"},
+	// Pods of deployments, like reviews-v1-5f8d7c6b9d-x2x7q.
+	{regexp.MustCompile(`\b[a-z0-9](?:[-a-z0-9]*[a-z0-9])?-[a-z0-9]{8,10}-[a-z0-9]{5}\b`), "<pod>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{8,}\b`), "<id>"},
+	{regexp.MustCompile(`\b\d+(?:\.\d+)?(?:ns|us|µs|ms|s|m|h)?\b`), "<num>"},
+}
+
+// responseFlagDescriptions describes the Envoy response flags.
+var responseFlagDescriptions = map[string]string{
+	"DC":    "Downstream connection termination",
+	"DF":    "DNS resolution failed",
+	"DI":    "Request delayed by fault injection",
+	"DPE":   "Downstream request had an HTTP protocol error",
+	"FI":    "Request aborted by fault injection",
+	"IH":    "Request rejected because of an invalid header value",
+	"LH":    "Local service failed health check",
+	"LR":    "Connection local reset",
+	"NC":    "Upstream cluster not found",
+	"NR":    "No route configured",
+	"OM":    "Overload manager terminated the request",
+	"RL":    "Request rate limited locally",
+	"RLSE":  "Request rejected because of an error in the rate limit service",
+	"SI":    "Stream idle timeout",
+	"UAEX":  "Request denied by the external authorization service",
+	"UC":    "Upstream connection termination",
+	"UF":    "Upstream connection failure",
+	"UH":    "No healthy upstream host",
+	"UMSDR": "Upstream request reached max stream duration",
+	"UO":    "Upstream overflow (circuit breaking)",
+	"UPE":   "Upstream response had an HTTP protocol error",
+	"UR":    "Upstream remote reset",
+	"URX":   "Upstream retry limit or maximum connect attempts exceeded",
+	"UT":    "Upstream request timeout",
+}
+
+// Summary summarizes the logs of several proxies: the recurring warning and error signatures, and the response
+// flags of their access logs.
+type Summary struct {
+	// Proxies is the number of proxies whose logs are summarized.
+	Proxies int `json:"proxies"`
+	// Signatures are the most important log signatures, the most severe and frequent first.
+	Signatures []*Signature `json:"signatures"`
+	// ResponseFlags are the response flags of the access logs, the most frequent first.
+	ResponseFlags []*ResponseFlagStats `json:"responseFlags"`
+}
+
+// Signature is a group of similar log lines, once their variable parts like IPs, pod names and IDs are normalized.
+type Signature struct {
+	Level   string `json:"level"`
+	Pattern string `json:"pattern"`
+	// Example is the first log line with the signature.
+	Example   string     `json:"example"`
+	Count     int        `json:"count"`
+	Proxies   []string   `json:"proxies"`
+	FirstSeen *time.Time `json:"firstSeen,omitempty"`
+	LastSeen  *time.Time `json:"lastSeen,omitempty"`
+	// ResponseFlags are the numbers of requests of the same proxies with each response flag, around the lines
+	// with the signature.
+	ResponseFlags map[string]int `json:"responseFlags,omitempty"`
+
+	proxies     sets.String
+	occurrences []occurrence
+}
+
+// ResponseFlagStats are the occurrences of an Envoy response flag in the access logs.
+type ResponseFlagStats struct {
+	Flag        string   `json:"flag"`
+	Description string   `json:"description,omitempty"`
+	Count       int      `json:"count"`
+	Proxies     []string `json:"proxies"`
+	// UpstreamClusters are the numbers of requests with the flag for each upstream cluster.
+	UpstreamClusters map[string]int `json:"upstreamClusters,omitempty"`
+
+	proxies sets.String
+}
+
+type occurrence struct {
+	proxy string
+	time  time.Time
+}
+
+// accessLogEntry is a request of an access log with response flags.
+type accessLogEntry struct {
+	time            time.Time
+	flags           []string
+	upstreamCluster string
+}
+
+// Summarize summarizes the logs of the proxies, keyed by proxy name.
+func Summarize(config *config.BugReportConfig, logs map[string]string) *Summary {
+	signatures := map[string]*Signature{}
+	flags := map[string]*ResponseFlagStats{}
+	// Times of the requests with each response flag, by proxy.
+	flagTimes := map[string]map[string][]time.Time{}
+
+	for _, proxy := range slices.Sort(maps.Keys(logs)) {
+		for _, l := range strings.Split(logs[proxy], "\n") {
+			if e, ok := parseAccessLog(l); ok {
+				addResponseFlags(flags, flagTimes, proxy, e)
+				continue
+			}
+			ts, level, text, valid := parseLog(l)
+			if !valid {
+				continue
+			}
+			switch strings.ToLower(level) {
+			case levelFatal, levelError, levelWarn:
+			default:
+				continue
+			}
+			if match.MatchesGlobs(text, config.IgnoredErrors) {
+				continue
+			}
+			addSignature(signatures, proxy, strings.ToLower(level), text, l, *ts)
+		}
+	}
+
+	for _, times := range flagTimes {
+		for _, t := range times {
+			sort.Slice(t, func(i, j int) bool { return t[i].Before(t[j]) })
+		}
+	}
+	out := &Summary{Proxies: len(logs)}
+	for _, s := range signatures {
+		s.Proxies = sets.SortedList(s.proxies)
+		s.ResponseFlags = correlate(s.occurrences, flagTimes)
+		out.Signatures = append(out.Signatures, s)
+	}
+	sort.Slice(out.Signatures, func(i, j int) bool {
+		a, b := out.Signatures[i], out.Signatures[j]
+		if levelRank(a.Level) != levelRank(b.Level) {
+			return levelRank(a.Level) > levelRank(b.Level)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Pattern < b.Pattern
+	})
+	if len(out.Signatures) > maxSignatures {
+		out.Signatures = out.Signatures[:maxSignatures]
+	}
+	for _, f := range flags {
+		f.Proxies = sets.SortedList(f.proxies)
+		out.ResponseFlags = append(out.ResponseFlags, f)
+	}
+	sort.Slice(out.ResponseFlags, func(i, j int) bool {
+		a, b := out.ResponseFlags[i], out.ResponseFlags[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Flag < b.Flag
+	})
+	return out
+}
+
+func addSignature(signatures map[string]*Signature, proxy, level, text, line string, ts time.Time) {
+	pattern := normalize(text)
+	key := level + "\t" + pattern
+	s, f := signatures[key]
+	if !f {
+		s = &Signature{Level: level, Pattern: pattern, Example: line, proxies: sets.New[string]()}
+		signatures[key] = s
+	}
+	s.Count++
+	s.proxies.Insert(proxy)
+	s.occurrences = append(s.occurrences, occurrence{proxy: proxy, time: ts})
+	if s.FirstSeen == nil || ts.Before(*s.FirstSeen) {
+		s.FirstSeen = &ts
+	}
+	if s.LastSeen == nil || ts.After(*s.LastSeen) {
+		s.LastSeen = &ts
+	}
+}
+
+func addResponseFlags(flags map[string]*ResponseFlagStats, flagTimes map[string]map[string][]time.Time, proxy string, e accessLogEntry) {
+	for _, flag := range e.flags {
+		f, ok := flags[flag]
+		if !ok {
+			f = &ResponseFlagStats{
+				Flag:             flag,
+				Description:      responseFlagDescriptions[flag],
+				UpstreamClusters: map[string]int{},
+				proxies:          sets.New[string](),
+			}
+			flags[flag] = f
+		}
+		f.Count++
+		f.proxies.Insert(proxy)
+		if e.upstreamCluster != "" {
+			f.UpstreamClusters[e.upstreamCluster]++
+		}
+		if flagTimes[proxy] == nil {
+			flagTimes[proxy] = map[string][]time.Time{}
+		}
+		flagTimes[proxy][flag] = append(flagTimes[proxy][flag], e.time)
+	}
+}
+
+// correlate counts the requests with each response flag of the same proxy within correlationWindow of the
+// occurrences. The times of flagTimes must be sorted.
+func correlate(occurrences []occurrence, flagTimes map[string]map[string][]time.Time) map[string]int {
+	out := map[string]int{}
+	// Each request is only counted once, even if it is close to several occurrences.
+	counted := map[string]map[string]int{}
+	for _, o := range occurrences {
+		for flag, times := range flagTimes[o.proxy] {
+			if counted[o.proxy] == nil {
+				counted[o.proxy] = map[string]int{}
+			}
+			start := sort.Search(len(times), func(i int) bool { return !times[i].Before(o.time.Add(-correlationWindow)) })
+			if start < counted[o.proxy][flag] {
+				start = counted[o.proxy][flag]
+			}
+			end := sort.Search(len(times), func(i int) bool { return times[i].After(o.time.Add(correlationWindow)) })
+			if end > start {
+				out[flag] += end - start
+				counted[o.proxy][flag] = end
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// normalize replaces the variable parts of the log text.
+func normalize(text string) string {
+	for _, n := range normalizers {
+		text = n.pattern.ReplaceAllString(text, n.replacement)
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > maxPatternLength {
+		text = text[:maxPatternLength] + "..."
+	}
+	return text
+}
+
+func levelRank(level string) int {
+	switch level {
+	case levelFatal:
+		return 3
+	case levelError:
+		return 2
+	case levelWarn:
+		return 1
+	}
+	return 0
+}
+
+// Fields of the default Istio text access log format, once split by splitAccessLog.
+const (
+	accessLogStartTime       = 0
+	accessLogResponseFlags   = 3
+	accessLogUpstreamCluster = 16
+)
+
+// parseAccessLog parses an access log line in the default Istio text or JSON format, returning it only if it
+// has response flags.
+func parseAccessLog(line string) (accessLogEntry, bool) {
+	var startTime, flags, cluster string
+	if isJSONLog(line) {
+		entry := struct {
+			StartTime       string `json:"start_time"`
+			ResponseFlags   string `json:"response_flags"`
+			UpstreamCluster string `json:"upstream_cluster"`
+		}{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return accessLogEntry{}, false
+		}
+		startTime, flags, cluster = entry.StartTime, entry.ResponseFlags, entry.UpstreamCluster
+	} else {
+		if !strings.HasPrefix(line, "[") {
+			return accessLogEntry{}, false
+		}
+		fields := splitAccessLog(line)
+		if len(fields) <= accessLogResponseFlags {
+			return accessLogEntry{}, false
+		}
+		startTime, flags = fields[accessLogStartTime], fields[accessLogResponseFlags]
+		if len(fields) > accessLogUpstreamCluster {
+			cluster = fields[accessLogUpstreamCluster]
+		}
+	}
+	ts, err := time.Parse(time.RFC3339Nano, startTime)
+	if err != nil || flags == "" || flags == "-" {
+		return accessLogEntry{}, false
+	}
+	if cluster == "-" {
+		cluster = ""
+	}
+	return accessLogEntry{time: ts, flags: strings.Split(flags, ","), upstreamCluster: cluster}, true
+}
+
+// splitAccessLog splits an access log line on spaces, keeping the fields in quotes or brackets together
+// and removing the quotes and brackets.
+func splitAccessLog(line string) []string {
+	var fields []string
+	var cur strings.Builder
+	var closing rune
+	inField := false
+	for _, r := range line {
+		switch {
+		case closing != 0:
+			if r == closing {
+				closing = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' && !inField:
+			closing, inField = '"', true
+		case r == '[' && !inField:
+			closing, inField = ']', true
+		case r == ' ':
+			if inField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				inField = false
+			}
+		default:
+			cur.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
+
+// JSON returns the summary in JSON.
+func (s *Summary) JSON() (string, error) {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// Markdown returns the summary in Markdown.
+func (s *Summary) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Proxy log summary\n\n")
+	fmt.Fprintf(&sb, "Logs of %d proxies.\n\n", s.Proxies)
+
+	sb.WriteString("## Recurring log signatures\n\n")
+	if len(s.Signatures) == 0 {
+		sb.WriteString("No warning or error found.\n\n")
+	} else {
+		sb.WriteString("| Level | Count | Proxies | Correlated response flags | Signature |\n")
+		sb.WriteString("|---|---|---|---|---|\n")
+		for _, sig := range s.Signatures {
+			fmt.Fprintf(&sb, "| %s | %d | %d | %s | `%s` |\n", sig.Level, sig.Count, len(sig.Proxies),
+				formatCounts(sig.ResponseFlags), markdownEscape(sig.Pattern))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Response flags\n\n")
+	if len(s.ResponseFlags) == 0 {
+		sb.WriteString("No request with response flags found.\n")
+	} else {
+		sb.WriteString("| Flag | Description | Count | Proxies | Upstream clusters |\n")
+		sb.WriteString("|---|---|---|---|---|\n")
+		for _, f := range s.ResponseFlags {
+			fmt.Fprintf(&sb, "| %s | %s | %d | %d | %s |\n", f.Flag, f.Description, f.Count, len(f.Proxies),
+				markdownEscape(formatCounts(f.UpstreamClusters)))
+		}
+	}
+	return sb.String()
+}
+
+// formatCounts formats the counts, the largest first.
+func formatCounts(counts map[string]int) string {
+	keys := maps.Keys(counts)
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s (%d)", k, counts[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "`", "'").Replace(s)
+}
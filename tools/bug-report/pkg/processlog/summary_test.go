@@ -0,0 +1,114 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processlog
+
+import (
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/tools/bug-report/pkg/config"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{
+			in:   "upstream connect error to 10.244.0.12:8080 after 250ms",
+			want: "upstream connect error to <ip> after <num>",
+		},
+		{
+			in:   "pod reviews-v1-5f8d7c6b9d-x2x7q not ready",
+			want: "pod <pod> not ready",
+		},
+		{
+			in:   "request 0b5ee2e5-8f07-4d6a-9bc3-1f2e8c1a2b3c to [fd00:10:244::5]:80 failed",
+			want: "request <uuid> to <ip> failed",
+		},
+		{
+			in:   "config version 5c1a7d2e9f rejected at 2024-01-02T03:04:05.123Z",
+			want: "config version <id> rejected at <time>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, normalize(tt.in), tt.want)
+		})
+	}
+}
+
+func TestParseAccessLog(t *testing.T) {
+	line := `[2024-01-02T03:04:05.000Z] "GET /ratings HTTP/1.1" 503 UF,URX upstream_reset_before_response_started{connection_failure} - ` +
+		`"-" 0 91 30 - "-" "curl/8.5.0" "0b5ee2e5-8f07-4d6a-9bc3-1f2e8c1a2b3c" "ratings:9080" "10.244.0.12:9080" ` +
+		`outbound|9080||ratings.default.svc.cluster.local - 10.96.1.2:9080 10.244.0.5:51234 - default`
+	e, ok := parseAccessLog(line)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, e.flags, []string{"UF", "URX"})
+	assert.Equal(t, e.upstreamCluster, "outbound|9080||ratings.default.svc.cluster.local")
+
+	_, ok = parseAccessLog(`[2024-01-02T03:04:05.000Z] "GET / HTTP/1.1" 200 - via_upstream - "-" 0 91 3 2 "-" "curl" "id" "a" "b" c`)
+	assert.Equal(t, ok, false)
+
+	e, ok = parseAccessLog(`{"start_time":"2024-01-02T03:04:05.000Z","response_flags":"NR","upstream_cluster":"-"}`)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, e.flags, []string{"NR"})
+	assert.Equal(t, e.upstreamCluster, "")
+}
+
+func TestSummarize(t *testing.T) {
+	logs := map[string]string{
+		"default/productpage-v1-5f8d7c6b9d-x2x7q": strings.Join([]string{
+			"2024-01-02T03:04:00.000000Z\twarning\tenvoy config\tignored",
+			"2024-01-02T03:04:01.000000Z\terror\tupstream connect error to 10.244.0.12:9080",
+			"2024-01-02T03:04:02.000000Z\terror\tupstream connect error to 10.244.0.13:9080",
+			"2024-01-02T03:04:03.000000Z\twarn\tcertificate for reviews-v1-5f8d7c6b9d-x2x7q expires soon",
+			`[2024-01-02T03:04:01.500Z] "GET /ratings HTTP/1.1" 503 UF,URX - - "-" 0 91 30 - "-" "curl" "id" "ratings:9080" ` +
+				`"10.244.0.12:9080" outbound|9080||ratings.default.svc.cluster.local - 10.96.1.2:9080 10.244.0.5:51234 - default`,
+			`[2024-01-02T03:10:00.000Z] "GET /ratings HTTP/1.1" 503 UH - - "-" 0 91 30 - "-" "curl" "id" "ratings:9080" ` +
+				`"-" outbound|9080||ratings.default.svc.cluster.local - 10.96.1.2:9080 10.244.0.5:51234 - default`,
+		}, "\n"),
+		"default/reviews-v1-7d9c6b5f8d-abcde": strings.Join([]string{
+			"2024-01-02T03:04:05.000000Z\terror\tupstream connect error to 10.244.0.14:9080",
+			"2024-01-02T03:04:06.000000Z\terror\tignored error",
+			"2024-01-02T03:04:07.000000Z\tinfo\tall good",
+		}, "\n"),
+	}
+	s := Summarize(&config.BugReportConfig{IgnoredErrors: []string{"ignored*"}}, logs)
+
+	assert.Equal(t, s.Proxies, 2)
+	assert.Equal(t, len(s.Signatures), 2)
+	connect := s.Signatures[0]
+	assert.Equal(t, connect.Level, levelError)
+	assert.Equal(t, connect.Pattern, "upstream connect error to <ip>")
+	assert.Equal(t, connect.Count, 3)
+	assert.Equal(t, connect.Proxies, []string{"default/productpage-v1-5f8d7c6b9d-x2x7q", "default/reviews-v1-7d9c6b5f8d-abcde"})
+	// The UH request is out of the correlation window, and the UF,URX request is only counted once.
+	assert.Equal(t, connect.ResponseFlags, map[string]int{"UF": 1, "URX": 1})
+	assert.Equal(t, s.Signatures[1].Pattern, "certificate for <pod> expires soon")
+
+	assert.Equal(t, len(s.ResponseFlags), 3)
+	assert.Equal(t, s.ResponseFlags[0].Flag, "UF")
+	assert.Equal(t, s.ResponseFlags[0].UpstreamClusters, map[string]int{"outbound|9080||ratings.default.svc.cluster.local": 1})
+
+	md := s.Markdown()
+	if !strings.Contains(md, "| UF | Upstream connection failure | 1 | 1 |") {
+		t.Fatalf("unexpected markdown:\n%s", md)
+	}
+	if _, err := s.JSON(); err != nil {
+		t.Fatal(err)
+	}
+}